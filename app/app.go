@@ -0,0 +1,102 @@
+package app
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/marouane-ach/todo-go/config"
+	"github.com/marouane-ach/todo-go/db"
+	"github.com/marouane-ach/todo-go/mailer"
+	"github.com/marouane-ach/todo-go/store"
+	"github.com/uptrace/bun"
+)
+
+// App owns everything a running instance needs. Instances share no state,
+// so several of them can live in the same process.
+type App struct {
+	Config config.Config
+	// DB is nil when the App was built around another store with NewWithStore.
+	DB     *bun.DB
+	Store  *store.Store
+	Logger *slog.Logger
+	Mailer mailer.Mailer
+	// Now returns the current time. Tests can replace it to control the clock, and give the same function to
+	// store.NewMemory so that records are created at that time too.
+	Now func() time.Time
+
+	// ctx is cancelled when the App is closed, which tells background workers to stop.
+	ctx     context.Context
+	stop    context.CancelFunc
+	workers sync.WaitGroup
+}
+
+// New opens the database described by cfg, migrates it and seeds the colors.
+func New(cfg config.Config) (*App, error) {
+	bunDB, err := db.Open(cfg.DatabaseURL)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := context.Background()
+
+	if err := db.Migrate(ctx, bunDB); err != nil {
+		bunDB.Close()
+		return nil, err
+	}
+
+	if err := db.SeedColorsTable(ctx, bunDB); err != nil {
+		bunDB.Close()
+		return nil, err
+	}
+
+	a := NewWithStore(cfg, store.NewBun(bunDB))
+	a.DB = bunDB
+	return a, nil
+}
+
+// NewWithStore returns an App using st instead of opening a database, e.g. with store.NewMemory in tests.
+func NewWithStore(cfg config.Config, st *store.Store) *App {
+	ctx, stop := context.WithCancel(context.Background())
+
+	a := &App{
+		Config: cfg,
+		Store:  st,
+		Logger: slog.New(slog.NewTextHandler(os.Stderr, nil)),
+		Now:    time.Now,
+		ctx:    ctx,
+		stop:   stop,
+	}
+
+	if cfg.SMTPAddr != "" {
+		a.Mailer = &mailer.SMTP{Addr: cfg.SMTPAddr, Username: cfg.SMTPUsername, Password: cfg.SMTPPassword, From: cfg.MailFrom}
+	} else {
+		a.Mailer = &mailer.Log{Logger: a.Logger}
+	}
+
+	return a
+}
+
+// Go runs fn in the background. The context passed to fn is cancelled when the App is closed,
+// and Close waits for fn to return.
+func (a *App) Go(fn func(ctx context.Context)) {
+	a.workers.Add(1)
+	go func() {
+		defer a.workers.Done()
+		fn(a.ctx)
+	}()
+}
+
+// Close stops the background workers, waits for them to return and then closes the database.
+func (a *App) Close() error {
+	a.stop()
+	a.workers.Wait()
+
+	if a.DB == nil {
+		return nil
+	}
+
+	return a.DB.Close()
+}
@@ -0,0 +1,94 @@
+package app
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/marouane-ach/todo-go/config"
+	"github.com/marouane-ach/todo-go/store"
+)
+
+func TestCloseWaitsForWorkers(t *testing.T) {
+	t.Parallel()
+
+	a := NewWithStore(config.Default(), store.NewMemory(nil))
+
+	done := false
+	a.Go(func(ctx context.Context) {
+		<-ctx.Done()
+		done = true
+	})
+
+	if err := a.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if !done {
+		t.Error("Close returned before the worker")
+	}
+}
+
+func TestServeFinishesInFlightRequests(t *testing.T) {
+	t.Parallel()
+
+	cfg := config.Default()
+	cfg.Addr = "127.0.0.1:0"
+	a := NewWithStore(cfg, store.NewMemory(nil))
+	a.Logger = slog.New(slog.DiscardHandler)
+	defer a.Close()
+
+	started, release := make(chan struct{}), make(chan struct{})
+	e := echo.New()
+	e.HideBanner, e.HidePort = true, true
+	e.GET("/slow", func(c echo.Context) error {
+		close(started)
+		<-release
+		return c.String(http.StatusOK, "done")
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() { served <- a.Serve(ctx, e) }()
+
+	var addr string
+	for addr == "" {
+		if l := e.ListenerAddr(); l != nil {
+			addr = l.String()
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	type response struct {
+		body string
+		err  error
+	}
+	responses := make(chan response, 1)
+	go func() {
+		resp, err := http.Get("http://" + addr + "/slow")
+		if err != nil {
+			responses <- response{err: err}
+			return
+		}
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		responses <- response{string(body), err}
+	}()
+
+	<-started
+	cancel()
+	// Give the shutdown time to start before the request completes.
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+
+	if r := <-responses; r.err != nil || r.body != "done" {
+		t.Errorf("in-flight request = %q, %v, want done", r.body, r.err)
+	}
+	if err := <-served; err != nil {
+		t.Errorf("Serve = %v", err)
+	}
+}
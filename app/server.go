@@ -0,0 +1,52 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Serve starts e on the configured address and blocks until ctx is cancelled or the server fails.
+// Once ctx is cancelled the listener stops accepting connections and in-flight requests get
+// Config.ShutdownTimeout to complete before their connections are closed.
+func (a *App) Serve(ctx context.Context, e *echo.Echo) error {
+	if a.Config.H2C && !a.Config.TLS() {
+		protocols := new(http.Protocols)
+		protocols.SetHTTP1(true)
+		protocols.SetUnencryptedHTTP2(true)
+		e.Server.Protocols = protocols
+	}
+
+	errs := make(chan error, 1)
+	go func() {
+		if a.Config.TLS() {
+			errs <- e.StartTLS(a.Config.Addr, a.Config.TLSCertFile, a.Config.TLSKeyFile)
+		} else {
+			errs <- e.Start(a.Config.Addr)
+		}
+	}()
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+	}
+
+	a.Logger.Info("shutting down", "timeout", a.Config.ShutdownTimeout)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), a.Config.ShutdownTimeout)
+	defer cancel()
+
+	if err := e.Shutdown(shutdownCtx); err != nil {
+		a.Logger.Warn("in-flight requests did not finish in time", "error", err)
+		e.Close()
+	}
+
+	if err := <-errs; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return nil
+}
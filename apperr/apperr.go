@@ -0,0 +1,320 @@
+// Package apperr defines the errors the API reports to its clients.
+// Every error carries a Code from a single registry, so that a code means the same thing
+// on every endpoint and never changes once published.
+package apperr
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Code identifies a kind of failure. Codes are stable: they may be added but never renumbered or reused.
+type Code int
+
+const (
+	EmailTaken                Code = 4
+	SignupFailed              Code = 5
+	AccountNotFound           Code = 6
+	WrongPassword             Code = 7
+	LoginFailed               Code = 8
+	InvalidToken              Code = 9
+	UserFetchFailed           Code = 10
+	InvalidColor              Code = 11
+	TodoListCreateFailed      Code = 12
+	TodoListNotFound          Code = 13
+	Forbidden                 Code = 14
+	TodoCreateFailed          Code = 15
+	TodoNotFound              Code = 16
+	TodoUpdateFailed          Code = 19
+	RequestCancelled          Code = 20
+	RequestTimeout            Code = 21
+	LogoutFailed              Code = 22
+	TodoListDeleteFailed      Code = 23
+	InvalidRequest            Code = 24
+	RouteNotFound             Code = 25
+	MethodNotAllowed          Code = 26
+	Internal                  Code = 27
+	UnsupportedMediaType      Code = 28
+	RequestEntityTooLarge     Code = 29
+	ValidationFailed          Code = 30
+	InvalidID                 Code = 31
+	TodoListFetchFailed       Code = 32
+	TodoFetchFailed           Code = 33
+	InvalidCursor             Code = 34
+	SearchFailed              Code = 35
+	UserUpdateFailed          Code = 36
+	ReminderCreateFailed      Code = 37
+	ReminderNotFound          Code = 38
+	ReminderFetchFailed       Code = 39
+	ReminderDeleteFailed      Code = 40
+	NotificationFetchFailed   Code = 41
+	NotificationNotFound      Code = 42
+	NotificationUpdateFailed  Code = 43
+	RecurrenceUpdateFailed    Code = 44
+	RecurrenceNotFound        Code = 45
+	CompletionFetchFailed     Code = 46
+	RecurrenceDeleteFailed    Code = 47
+	ChecklistItemCreateFailed Code = 48
+	ChecklistItemNotFound     Code = 49
+	ChecklistItemFetchFailed  Code = 50
+	ChecklistItemUpdateFailed Code = 51
+	ChecklistItemDeleteFailed Code = 52
+	TodoDeleteFailed          Code = 53
+	TagCreateFailed           Code = 54
+	TagNotFound               Code = 55
+	TagFetchFailed            Code = 56
+	TagUpdateFailed           Code = 57
+	TagDeleteFailed           Code = 58
+	TagNameTaken              Code = 59
+	StatusCreateFailed        Code = 60
+	StatusNotFound            Code = 61
+	StatusFetchFailed         Code = 62
+	StatusUpdateFailed        Code = 63
+	StatusDeleteFailed        Code = 64
+	StatusRequired            Code = 65
+	TodoListUpdateFailed      Code = 66
+	TodoDuplicate             Code = 67
+	MemberNotFound            Code = 68
+	MemberFetchFailed         Code = 69
+	MemberUpdateFailed        Code = 70
+	MemberDeleteFailed        Code = 71
+	AlreadyMember             Code = 72
+	InvitationCreateFailed    Code = 73
+	InvitationNotFound        Code = 74
+	InvitationFetchFailed     Code = 75
+	InvitationDeleteFailed    Code = 76
+	AlreadyInvited            Code = 77
+	InvitationAcceptFailed    Code = 78
+	ShareLinkCreateFailed     Code = 79
+	ShareLinkNotFound         Code = 80
+	ShareLinkFetchFailed      Code = 81
+	ShareLinkDeleteFailed     Code = 82
+	ShareLinkExpired          Code = 83
+	SharePasswordRequired     Code = 84
+	CommentCreateFailed       Code = 85
+	CommentNotFound           Code = 86
+	CommentFetchFailed        Code = 87
+	CommentUpdateFailed       Code = 88
+	CommentDeleteFailed       Code = 89
+	OrganizationCreateFailed  Code = 90
+	OrganizationNotFound      Code = 91
+	OrganizationFetchFailed   Code = 92
+	OrganizationUpdateFailed  Code = 93
+	OrganizationDeleteFailed  Code = 94
+	OrganizationNotEmpty      Code = 95
+	AlreadyOrgMember          Code = 96
+	LastOrgAdmin              Code = 97
+	QuotaExceeded             Code = 98
+	FolderCreateFailed        Code = 99
+	FolderNotFound            Code = 100
+	FolderFetchFailed         Code = 101
+	FolderUpdateFailed        Code = 102
+	FolderDeleteFailed        Code = 103
+)
+
+// StatusClientClosedRequest is the non-standard status used when the client went away before the response was ready.
+const StatusClientClosedRequest = 499
+
+type definition struct {
+	status      int
+	description string
+}
+
+// registry maps each code to its HTTP status and default description.
+// Being a map literal, the compiler rejects two entries for the same code.
+// Retired codes: 1 and 2 (invalid email and password) became ValidationFailed field errors,
+// 3 was never used, 17 duplicated TodoNotFound and 18 duplicated Forbidden.
+var registry = map[Code]definition{
+	EmailTaken:                {http.StatusConflict, "An account with this email already exists."},
+	SignupFailed:              {http.StatusInternalServerError, "We encoutered a problem while creating your account."},
+	AccountNotFound:           {http.StatusNotFound, "An account with this email does not exist."},
+	WrongPassword:             {http.StatusUnauthorized, "Wrong password."},
+	LoginFailed:               {http.StatusInternalServerError, "We encoutered a problem while logging you in."},
+	InvalidToken:              {http.StatusUnauthorized, "Invalid token."},
+	UserFetchFailed:           {http.StatusInternalServerError, "Could not fetch user data."},
+	InvalidColor:              {http.StatusBadRequest, "Invalid color ID."},
+	TodoListCreateFailed:      {http.StatusInternalServerError, "Could not create todo list."},
+	TodoListNotFound:          {http.StatusNotFound, "Todo list does not exist."},
+	Forbidden:                 {http.StatusForbidden, "You are not allowed to do this."},
+	TodoCreateFailed:          {http.StatusInternalServerError, "Could not create todo."},
+	TodoNotFound:              {http.StatusNotFound, "Todo does not exist."},
+	TodoUpdateFailed:          {http.StatusInternalServerError, "We encoutered a problem while updating the todo."},
+	RequestCancelled:          {StatusClientClosedRequest, "The request was cancelled."},
+	RequestTimeout:            {http.StatusGatewayTimeout, "The request timed out."},
+	LogoutFailed:              {http.StatusInternalServerError, "We encoutered a problem while logging you out."},
+	TodoListDeleteFailed:      {http.StatusInternalServerError, "Could not delete todo list."},
+	InvalidRequest:            {http.StatusBadRequest, "The request is malformed."},
+	RouteNotFound:             {http.StatusNotFound, "This route does not exist."},
+	MethodNotAllowed:          {http.StatusMethodNotAllowed, "This method is not allowed on this route."},
+	Internal:                  {http.StatusInternalServerError, "An unexpected error occurred."},
+	UnsupportedMediaType:      {http.StatusUnsupportedMediaType, "This content type is not supported."},
+	RequestEntityTooLarge:     {http.StatusRequestEntityTooLarge, "The request body is too large."},
+	ValidationFailed:          {http.StatusUnprocessableEntity, "Some fields are invalid."},
+	InvalidID:                 {http.StatusBadRequest, "IDs must be positive integers."},
+	TodoListFetchFailed:       {http.StatusInternalServerError, "Could not fetch todo lists."},
+	TodoFetchFailed:           {http.StatusInternalServerError, "Could not fetch todos."},
+	InvalidCursor:             {http.StatusBadRequest, "The cursor is invalid or was made for another sort order."},
+	SearchFailed:              {http.StatusInternalServerError, "Failed to search the todos and todo lists."},
+	UserUpdateFailed:          {http.StatusInternalServerError, "Failed to update the account."},
+	ReminderCreateFailed:      {http.StatusInternalServerError, "Failed to create the reminder."},
+	ReminderNotFound:          {http.StatusNotFound, "Reminder not found."},
+	ReminderFetchFailed:       {http.StatusInternalServerError, "Failed to fetch the reminders."},
+	ReminderDeleteFailed:      {http.StatusInternalServerError, "Failed to delete the reminder."},
+	NotificationFetchFailed:   {http.StatusInternalServerError, "Failed to fetch the notifications."},
+	NotificationNotFound:      {http.StatusNotFound, "Notification not found."},
+	NotificationUpdateFailed:  {http.StatusInternalServerError, "Failed to update the notification."},
+	RecurrenceUpdateFailed:    {http.StatusInternalServerError, "Failed to update the recurrence."},
+	RecurrenceNotFound:        {http.StatusNotFound, "Recurrence not found."},
+	CompletionFetchFailed:     {http.StatusInternalServerError, "Failed to fetch the completions."},
+	RecurrenceDeleteFailed:    {http.StatusInternalServerError, "Failed to delete the recurrence."},
+	ChecklistItemCreateFailed: {http.StatusInternalServerError, "Failed to create the checklist item."},
+	ChecklistItemNotFound:     {http.StatusNotFound, "Checklist item not found."},
+	ChecklistItemFetchFailed:  {http.StatusInternalServerError, "Failed to fetch the checklist items."},
+	ChecklistItemUpdateFailed: {http.StatusInternalServerError, "Failed to update the checklist item."},
+	ChecklistItemDeleteFailed: {http.StatusInternalServerError, "Failed to delete the checklist item."},
+	TodoDeleteFailed:          {http.StatusInternalServerError, "Failed to delete the todo."},
+	TagCreateFailed:           {http.StatusInternalServerError, "Failed to create the tag."},
+	TagNotFound:               {http.StatusNotFound, "Tag not found."},
+	TagFetchFailed:            {http.StatusInternalServerError, "Failed to fetch the tags."},
+	TagUpdateFailed:           {http.StatusInternalServerError, "Failed to update the tag."},
+	TagDeleteFailed:           {http.StatusInternalServerError, "Failed to delete the tag."},
+	TagNameTaken:              {http.StatusConflict, "You already have a tag with this name."},
+	StatusCreateFailed:        {http.StatusInternalServerError, "Failed to create the status."},
+	StatusNotFound:            {http.StatusNotFound, "Status not found."},
+	StatusFetchFailed:         {http.StatusInternalServerError, "Failed to fetch the statuses."},
+	StatusUpdateFailed:        {http.StatusInternalServerError, "Failed to update the status."},
+	StatusDeleteFailed:        {http.StatusInternalServerError, "Failed to delete the status."},
+	StatusRequired:            {http.StatusConflict, "A todo list needs at least one open and one terminal status."},
+	TodoListUpdateFailed:      {http.StatusInternalServerError, "Could not update todo list."},
+	TodoDuplicate:             {http.StatusConflict, "The todo list already has a todo with this text."},
+	MemberNotFound:            {http.StatusNotFound, "Member not found."},
+	MemberFetchFailed:         {http.StatusInternalServerError, "Failed to fetch the members."},
+	MemberUpdateFailed:        {http.StatusInternalServerError, "Failed to update the member."},
+	MemberDeleteFailed:        {http.StatusInternalServerError, "Failed to remove the member."},
+	AlreadyMember:             {http.StatusConflict, "This user already has access to the todo list."},
+	InvitationCreateFailed:    {http.StatusInternalServerError, "Failed to create the invitation."},
+	InvitationNotFound:        {http.StatusNotFound, "Invitation not found."},
+	InvitationFetchFailed:     {http.StatusInternalServerError, "Failed to fetch the invitations."},
+	InvitationDeleteFailed:    {http.StatusInternalServerError, "Failed to delete the invitation."},
+	AlreadyInvited:            {http.StatusConflict, "This email address is already invited to the todo list."},
+	InvitationAcceptFailed:    {http.StatusInternalServerError, "Failed to accept the invitation."},
+	ShareLinkCreateFailed:     {http.StatusInternalServerError, "Failed to create the share link."},
+	ShareLinkNotFound:         {http.StatusNotFound, "Share link not found."},
+	ShareLinkFetchFailed:      {http.StatusInternalServerError, "Failed to fetch the shared todo list."},
+	ShareLinkDeleteFailed:     {http.StatusInternalServerError, "Failed to revoke the share link."},
+	ShareLinkExpired:          {http.StatusGone, "This share link has expired."},
+	SharePasswordRequired:     {http.StatusUnauthorized, "This share link needs a password, sent in the X-Share-Password header."},
+	CommentCreateFailed:       {http.StatusInternalServerError, "Failed to create the comment."},
+	CommentNotFound:           {http.StatusNotFound, "Comment not found."},
+	CommentFetchFailed:        {http.StatusInternalServerError, "Failed to fetch the comments."},
+	CommentUpdateFailed:       {http.StatusInternalServerError, "Failed to update the comment."},
+	CommentDeleteFailed:       {http.StatusInternalServerError, "Failed to delete the comment."},
+	OrganizationCreateFailed:  {http.StatusInternalServerError, "Failed to create the organization."},
+	OrganizationNotFound:      {http.StatusNotFound, "Organization not found."},
+	OrganizationFetchFailed:   {http.StatusInternalServerError, "Failed to fetch the organizations."},
+	OrganizationUpdateFailed:  {http.StatusInternalServerError, "Failed to update the organization."},
+	OrganizationDeleteFailed:  {http.StatusInternalServerError, "Failed to delete the organization."},
+	OrganizationNotEmpty:      {http.StatusConflict, "The organization still has todo lists."},
+	AlreadyOrgMember:          {http.StatusConflict, "This user is already a member of the organization."},
+	LastOrgAdmin:              {http.StatusConflict, "An organization needs at least one admin."},
+	QuotaExceeded:             {http.StatusForbidden, "The organization has reached its quota."},
+	FolderCreateFailed:        {http.StatusInternalServerError, "Failed to create the folder."},
+	FolderNotFound:            {http.StatusNotFound, "Folder not found."},
+	FolderFetchFailed:         {http.StatusInternalServerError, "Failed to fetch the folders."},
+	FolderUpdateFailed:        {http.StatusInternalServerError, "Failed to update the folder."},
+	FolderDeleteFailed:        {http.StatusInternalServerError, "Failed to delete the folder."},
+}
+
+// Status returns the HTTP status the code is reported with.
+func (c Code) Status() int {
+	if d, ok := registry[c]; ok {
+		return d.status
+	}
+	return http.StatusInternalServerError
+}
+
+// Description returns the default human readable description of the code.
+func (c Code) Description() string {
+	return registry[c].description
+}
+
+// Error is an error that can be shown to the client.
+type Error struct {
+	Code Code
+	// Description overrides the default description of Code when set.
+	Description string
+	// Fields lists the invalid fields of a ValidationFailed error.
+	Fields []FieldError
+	// ExistingID is the ID of the resource a conflict is with, when the client can use it.
+	ExistingID int
+	// Err is the underlying cause. It is logged but never shown to the client.
+	Err error
+}
+
+// FieldError describes why the value of one request field was rejected.
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+// New returns an Error with the default description of code.
+func New(code Code) *Error {
+	return &Error{Code: code}
+}
+
+// Wrap returns an Error for code caused by err. If err already is, or wraps, an *Error,
+// that one is returned instead so that errors raised deeper down (e.g. inside a transaction) keep their code.
+func Wrap(code Code, err error) *Error {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+
+	return &Error{Code: code, Err: err}
+}
+
+// WithDescription returns a copy of e with a custom description.
+func (e *Error) WithDescription(format string, args ...any) *Error {
+	cp := *e
+	cp.Description = fmt.Sprintf(format, args...)
+	return &cp
+}
+
+// WithFields returns a copy of e listing the given invalid fields.
+func (e *Error) WithFields(fields []FieldError) *Error {
+	cp := *e
+	cp.Fields = fields
+	return &cp
+}
+
+// WithExistingID returns a copy of e pointing at the resource with the given ID.
+func (e *Error) WithExistingID(id int) *Error {
+	cp := *e
+	cp.ExistingID = id
+	return &cp
+}
+
+// Status returns the HTTP status of the error.
+func (e *Error) Status() int {
+	return e.Code.Status()
+}
+
+// Message returns the description shown to the client.
+func (e *Error) Message() string {
+	if e.Description != "" {
+		return e.Description
+	}
+	return e.Code.Description()
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%d %s: %v", e.Code, e.Message(), e.Err)
+	}
+	return fmt.Sprintf("%d %s", e.Code, e.Message())
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,41 @@
+package apperr
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestRegistry(t *testing.T) {
+	t.Parallel()
+
+	for code, d := range registry {
+		if d.description == "" || (http.StatusText(d.status) == "" && d.status != StatusClientClosedRequest) {
+			t.Errorf("code %d has status %d and description %q", code, d.status, d.description)
+		}
+	}
+
+	if got := Code(3).Status(); got != http.StatusInternalServerError {
+		t.Errorf("status of a retired code = %d, want %d", got, http.StatusInternalServerError)
+	}
+}
+
+func TestWrap(t *testing.T) {
+	t.Parallel()
+
+	cause := errors.New("disk full")
+	err := Wrap(TodoUpdateFailed, cause)
+	if err.Code != TodoUpdateFailed || !errors.Is(err, cause) {
+		t.Errorf("Wrap = %v, want code %d wrapping %v", err, TodoUpdateFailed, cause)
+	}
+
+	// An Error raised deeper down keeps its code.
+	inner := New(TodoNotFound).WithDescription("Todo %d does not exist.", 7)
+	if got := Wrap(Internal, fmt.Errorf("in tx: %w", inner)); got != inner {
+		t.Errorf("Wrap of a wrapped Error = %v, want %v", got, inner)
+	}
+	if got := inner.Message(); got != "Todo 7 does not exist." {
+		t.Errorf("Message = %q", got)
+	}
+}
@@ -0,0 +1,164 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Config holds the settings the application is started with.
+type Config struct {
+	// Addr is the address the HTTP server listens on.
+	Addr string
+	// DatabaseURL is either a SQLite DSN or a postgres:// URL.
+	DatabaseURL string
+	// RequestTimeout bounds the time a handler may spend on the database. Zero disables it.
+	RequestTimeout time.Duration
+	// ShutdownTimeout is how long in-flight requests get to finish once a shutdown starts.
+	ShutdownTimeout time.Duration
+	// TLSCertFile and TLSKeyFile enable HTTPS when both are set.
+	TLSCertFile string
+	TLSKeyFile  string
+	// H2C serves HTTP/2 over cleartext connections in addition to HTTP/1.1. It is ignored when TLS is enabled.
+	H2C bool
+	// ProblemJSON renders every error as RFC 7807 problem details instead of only when the client asks for them.
+	ProblemJSON bool
+	// SMTPAddr is the host:port of the SMTP server mails are sent through. Mails are only logged when it is empty.
+	SMTPAddr     string
+	SMTPUsername string
+	SMTPPassword string
+	// MailFrom is the sender address of the mails.
+	MailFrom string
+	// WebhookSecret signs the body of webhook notifications when it is set.
+	WebhookSecret string
+	// SchedulerInterval is how often due reminders are looked for. Zero disables the scheduler on this instance.
+	SchedulerInterval time.Duration
+	// OrgMaxMembers and OrgMaxTodoLists are the quotas new organizations get. Zero means unlimited.
+	OrgMaxMembers   int
+	OrgMaxTodoLists int
+}
+
+// Default returns the configuration used when no environment variable is set.
+func Default() Config {
+	return Config{
+		Addr:              ":1323",
+		DatabaseURL:       "file:dev.db?cache=shared&mode=rwc",
+		RequestTimeout:    5 * time.Second,
+		ShutdownTimeout:   10 * time.Second,
+		MailFrom:          "todo@localhost",
+		SchedulerInterval: 10 * time.Second,
+		OrgMaxMembers:     20,
+		OrgMaxTodoLists:   100,
+	}
+}
+
+// Load reads the configuration from the environment, falling back to Default for unset variables.
+func Load() (Config, error) {
+	cfg := Default()
+
+	if v, ok := os.LookupEnv("TODO_ADDR"); ok {
+		cfg.Addr = v
+	}
+
+	if v, ok := os.LookupEnv("TODO_DATABASE_URL"); ok {
+		cfg.DatabaseURL = v
+	}
+
+	if err := lookupDuration("TODO_REQUEST_TIMEOUT", &cfg.RequestTimeout); err != nil {
+		return cfg, err
+	}
+
+	if err := lookupDuration("TODO_SHUTDOWN_TIMEOUT", &cfg.ShutdownTimeout); err != nil {
+		return cfg, err
+	}
+
+	cfg.TLSCertFile = os.Getenv("TODO_TLS_CERT_FILE")
+	cfg.TLSKeyFile = os.Getenv("TODO_TLS_KEY_FILE")
+	if (cfg.TLSCertFile == "") != (cfg.TLSKeyFile == "") {
+		return cfg, errors.New("config: TODO_TLS_CERT_FILE and TODO_TLS_KEY_FILE must be set together")
+	}
+
+	if err := lookupBool("TODO_H2C", &cfg.H2C); err != nil {
+		return cfg, err
+	}
+
+	if err := lookupBool("TODO_PROBLEM_JSON", &cfg.ProblemJSON); err != nil {
+		return cfg, err
+	}
+
+	cfg.SMTPAddr = os.Getenv("TODO_SMTP_ADDR")
+	cfg.SMTPUsername = os.Getenv("TODO_SMTP_USERNAME")
+	cfg.SMTPPassword = os.Getenv("TODO_SMTP_PASSWORD")
+
+	if v, ok := os.LookupEnv("TODO_MAIL_FROM"); ok {
+		cfg.MailFrom = v
+	}
+
+	cfg.WebhookSecret = os.Getenv("TODO_WEBHOOK_SECRET")
+
+	if err := lookupDuration("TODO_SCHEDULER_INTERVAL", &cfg.SchedulerInterval); err != nil {
+		return cfg, err
+	}
+
+	if err := lookupInt("TODO_ORG_MAX_MEMBERS", &cfg.OrgMaxMembers); err != nil {
+		return cfg, err
+	}
+
+	if err := lookupInt("TODO_ORG_MAX_TODO_LISTS", &cfg.OrgMaxTodoLists); err != nil {
+		return cfg, err
+	}
+
+	return cfg, nil
+}
+
+// TLS reports whether the server should listen with HTTPS.
+func (c Config) TLS() bool {
+	return c.TLSCertFile != "" && c.TLSKeyFile != ""
+}
+
+func lookupDuration(key string, dst *time.Duration) error {
+	v, ok := os.LookupEnv(key)
+	if !ok {
+		return nil
+	}
+
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return fmt.Errorf("config: %s: %w", key, err)
+	}
+
+	*dst = d
+	return nil
+}
+
+func lookupBool(key string, dst *bool) error {
+	v, ok := os.LookupEnv(key)
+	if !ok {
+		return nil
+	}
+
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return fmt.Errorf("config: %s: %w", key, err)
+	}
+
+	*dst = b
+	return nil
+}
+
+func lookupInt(key string, dst *int) error {
+	v, ok := os.LookupEnv(key)
+	if !ok {
+		return nil
+	}
+
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		return fmt.Errorf("config: %s: must be a non-negative integer", key)
+	}
+
+	*dst = n
+	return nil
+}
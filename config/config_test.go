@@ -0,0 +1,23 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLoadRequestTimeout(t *testing.T) {
+	t.Setenv("TODO_REQUEST_TIMEOUT", "250ms")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.RequestTimeout != 250*time.Millisecond {
+		t.Errorf("RequestTimeout = %v, want 250ms", cfg.RequestTimeout)
+	}
+
+	t.Setenv("TODO_REQUEST_TIMEOUT", "soon")
+	if _, err := Load(); err == nil {
+		t.Error("Load with an invalid duration succeeded")
+	}
+}
@@ -0,0 +1,175 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/marouane-ach/todo-go/apperr"
+	"github.com/marouane-ach/todo-go/models"
+	"github.com/marouane-ach/todo-go/store"
+)
+
+// Access policy: resources the user cannot see are reported as missing (404) so that their IDs
+// don't leak, resources the user can see but not act on are forbidden (403), malformed IDs are
+// bad requests (400) and store failures are internal errors (500).
+
+// authenticate returns the user owning the bearer token of the request along with the token itself.
+func (ctl *Controller) authenticate(c echo.Context, ctx context.Context) (*models.User, *models.Token, error) {
+	headerToken, ok := strings.CutPrefix(c.Request().Header.Get("Authorization"), "Bearer ")
+	if !ok || len(headerToken) != 64 {
+		return nil, nil, apperr.New(apperr.InvalidToken)
+	}
+
+	token, err := ctl.app.Store.Tokens.Get(ctx, headerToken)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, nil, apperr.New(apperr.InvalidToken)
+	} else if err != nil {
+		return nil, nil, apperr.Wrap(apperr.UserFetchFailed, err)
+	}
+
+	user, err := ctl.app.Store.Users.GetByID(ctx, token.OwnerID)
+	if err != nil {
+		return nil, nil, apperr.Wrap(apperr.UserFetchFailed, err)
+	}
+
+	if header := c.Request().Header.Get("X-Org-ID"); header != "" {
+		organizationID, err := parseID(header)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		// Organizations the user isn't a member of are invisible to them, like todo lists.
+		user.Org, err = ctl.app.Store.OrgMemberships.Get(ctx, organizationID, user.ID)
+		if errors.Is(err, store.ErrNotFound) {
+			return nil, nil, apperr.New(apperr.OrganizationNotFound)
+		} else if err != nil {
+			return nil, nil, apperr.Wrap(apperr.OrganizationFetchFailed, err)
+		}
+	}
+
+	return user, token, nil
+}
+
+// orgID returns the ID of the organization the request of user is scoped to, or zero outside of organizations.
+func orgID(user *models.User) int {
+	if user.Org == nil {
+		return 0
+	}
+	return user.Org.OrganizationID
+}
+
+// parseID parses an ID taken from the URL.
+func parseID(id string) (int, error) {
+	n, err := strconv.Atoi(id)
+	if err != nil || n <= 0 {
+		return 0, apperr.New(apperr.InvalidID)
+	}
+
+	return n, nil
+}
+
+// roleAtLeast reports whether role grants everything minRole does. An empty role grants nothing.
+func roleAtLeast(role, minRole string) bool {
+	return slices.Index(models.Roles, role) >= slices.Index(models.Roles, minRole)
+}
+
+// roleOn returns the role user has on todoList, given the role of their membership of the list, empty when they have none.
+// The owner of a personal list and the creator of an organization's list own it, as do the organization's admins, and the other
+// members of the organization can edit its lists unless the list was shared with them with a higher role.
+func roleOn(user *models.User, todoList *models.TodoList, memberRole string) string {
+	if todoList.OrganizationID == 0 {
+		if todoList.OwnerID == user.ID {
+			return models.RoleOwner
+		}
+		return memberRole
+	}
+
+	if user.Org == nil || user.Org.OrganizationID != todoList.OrganizationID {
+		return ""
+	}
+
+	if todoList.OwnerID == user.ID || user.Org.Role == models.OrgRoleAdmin {
+		return models.RoleOwner
+	}
+
+	if roleAtLeast(memberRole, models.RoleEditor) {
+		return memberRole
+	}
+	return models.RoleEditor
+}
+
+// authorize makes sure user has at least the role minRole on todoList and sets its Role. The users the list
+// isn't shared with get notFound, for the list or the resource of the list they asked for, so that it stays
+// invisible to them, and the members whose role is too low get Forbidden. Lists outside of the organization the
+// request is scoped to, or organization lists when it isn't scoped to one, are invisible too.
+func authorize(ctx context.Context, st *store.Store, user *models.User, todoList *models.TodoList, minRole string, notFound apperr.Code) error {
+	if todoList == nil || todoList.OrganizationID != orgID(user) {
+		return apperr.New(notFound)
+	}
+
+	todoList.Role = roleOn(user, todoList, "")
+	if todoList.Role != models.RoleOwner {
+		membership, err := st.Memberships.Get(ctx, todoList.ID, user.ID)
+		if err != nil && !errors.Is(err, store.ErrNotFound) {
+			return apperr.Wrap(apperr.MemberFetchFailed, err)
+		}
+		if err == nil {
+			todoList.Role = roleOn(user, todoList, membership.Role)
+		}
+	}
+
+	if todoList.Role == "" {
+		return apperr.New(notFound)
+	}
+
+	if !roleAtLeast(todoList.Role, minRole) {
+		return apperr.New(apperr.Forbidden)
+	}
+	return nil
+}
+
+// authorizedTodoList loads the todo list with the given ID from st and makes sure user has at least the role minRole on it.
+func authorizedTodoList(ctx context.Context, st *store.Store, user *models.User, id string, minRole string) (*models.TodoList, error) {
+	todoListID, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	todoList, err := st.TodoLists.GetByID(ctx, todoListID)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, apperr.New(apperr.TodoListNotFound)
+	} else if err != nil {
+		return nil, apperr.Wrap(apperr.TodoListFetchFailed, err)
+	}
+
+	if err = authorize(ctx, st, user, todoList, minRole, apperr.TodoListNotFound); err != nil {
+		return nil, err
+	}
+
+	return todoList, nil
+}
+
+// authorizedTodo loads the todo with the given ID from st and makes sure user has at least the role minRole on its todo list.
+func authorizedTodo(ctx context.Context, st *store.Store, user *models.User, id string, minRole string) (*models.Todo, error) {
+	todoID, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	todo, err := st.Todos.GetByID(ctx, todoID)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, apperr.New(apperr.TodoNotFound)
+	} else if err != nil {
+		return nil, apperr.Wrap(apperr.TodoFetchFailed, err)
+	}
+
+	if err = authorize(ctx, st, user, todo.TodoList, minRole, apperr.TodoNotFound); err != nil {
+		return nil, err
+	}
+
+	return todo, nil
+}
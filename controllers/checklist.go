@@ -0,0 +1,339 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+
+	"github.com/labstack/echo/v4"
+	"github.com/marouane-ach/todo-go/apperr"
+	"github.com/marouane-ach/todo-go/dtos"
+	"github.com/marouane-ach/todo-go/models"
+	"github.com/marouane-ach/todo-go/store"
+)
+
+// Create Checklist Item godoc
+// @Summary      Add a checklist item to this todo
+// @Description  Accepts `text` and `done` as a JSON object and returns the created item, which goes after the other items of the todo.
+// @Description  The todo counts its items in `ItemsTotal` and `ItemsDone`.
+// @Tags         Checklists
+// @Param        item body dtos.ChecklistItemDTO true "The item's text and done status"
+// @Param        id path int true "Todo ID"
+// @Accept       json
+// @Produce      json
+// @Success      201  {object}	models.ChecklistItem
+// @Failure      400  {object}  dtos.ErrorDTO
+// @Failure      401  {object}  dtos.ErrorDTO
+// @Failure      403  {object}  dtos.ErrorDTO
+// @Failure      404  {object}  dtos.ErrorDTO
+// @Failure      422  {object}  dtos.ErrorDTO
+// @Failure      500  {object}  dtos.ErrorDTO
+// @Security	 BearerAuth
+// @Router       /todos/{id}/checklist [post]
+func (ctl *Controller) CreateChecklistItem(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	user, _, err := ctl.authenticate(c, ctx)
+	if err != nil {
+		return err
+	}
+
+	itemDTO := new(dtos.ChecklistItemDTO)
+	if err = bind(c, itemDTO); err != nil {
+		return err
+	}
+
+	todo, err := authorizedTodo(ctx, ctl.app.Store, user, c.Param("id"), models.RoleEditor)
+	if err != nil {
+		return err
+	}
+
+	item := &models.ChecklistItem{TodoID: todo.ID, Text: itemDTO.Text, Done: itemDTO.Done}
+	err = ctl.app.Store.InTx(ctx, func(tx *store.Store) error {
+		if err := tx.ChecklistItems.Create(ctx, item); err != nil {
+			return err
+		}
+		return ctl.updateProgress(ctx, tx, user, todo)
+	})
+	if err != nil {
+		return apperr.Wrap(apperr.ChecklistItemCreateFailed, err)
+	}
+
+	return c.JSON(http.StatusCreated, item)
+}
+
+// List Checklist Items godoc
+// @Summary      List the checklist items of this todo
+// @Description  Returns the items of the todo in order.
+// @Tags         Checklists
+// @Param        id path int true "Todo ID"
+// @Accept       json
+// @Produce      json
+// @Success      200  {array}	models.ChecklistItem
+// @Failure      400  {object}  dtos.ErrorDTO
+// @Failure      401  {object}  dtos.ErrorDTO
+// @Failure      404  {object}  dtos.ErrorDTO
+// @Failure      500  {object}  dtos.ErrorDTO
+// @Security	 BearerAuth
+// @Router       /todos/{id}/checklist [get]
+func (ctl *Controller) ListChecklistItems(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	user, _, err := ctl.authenticate(c, ctx)
+	if err != nil {
+		return err
+	}
+
+	todo, err := authorizedTodo(ctx, ctl.app.Store, user, c.Param("id"), models.RoleViewer)
+	if err != nil {
+		return err
+	}
+
+	items, err := ctl.app.Store.ChecklistItems.ListByTodo(ctx, todo.ID)
+	if err != nil {
+		return apperr.Wrap(apperr.ChecklistItemFetchFailed, err)
+	}
+
+	return c.JSON(http.StatusOK, items)
+}
+
+// Update Checklist Item godoc
+// @Summary      Update a checklist item
+// @Description  Accepts `text` and `done` as a JSON object and returns the updated item.
+// @Description  Checking the last item completes the todo if its `auto_complete` is set.
+// @Tags         Checklists
+// @Param        item body dtos.ChecklistItemDTO true "The item's text and done status"
+// @Param        id path int true "Checklist Item ID"
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}	models.ChecklistItem
+// @Failure      400  {object}  dtos.ErrorDTO
+// @Failure      401  {object}  dtos.ErrorDTO
+// @Failure      403  {object}  dtos.ErrorDTO
+// @Failure      404  {object}  dtos.ErrorDTO
+// @Failure      422  {object}  dtos.ErrorDTO
+// @Failure      500  {object}  dtos.ErrorDTO
+// @Security	 BearerAuth
+// @Router       /checklist/{id} [put]
+func (ctl *Controller) UpdateChecklistItem(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	user, _, err := ctl.authenticate(c, ctx)
+	if err != nil {
+		return err
+	}
+
+	itemDTO := new(dtos.ChecklistItemDTO)
+	if err = bind(c, itemDTO); err != nil {
+		return err
+	}
+
+	item, err := authorizedChecklistItem(ctx, ctl.app.Store, user, c.Param("id"), models.RoleEditor)
+	if err != nil {
+		return err
+	}
+
+	todo := item.Todo
+	item.Todo = nil
+	item.Text, item.Done = itemDTO.Text, itemDTO.Done
+	item.UpdatedAt = ctl.app.Now()
+
+	err = ctl.app.Store.InTx(ctx, func(tx *store.Store) error {
+		if err := tx.ChecklistItems.Update(ctx, item); err != nil {
+			return err
+		}
+		return ctl.updateProgress(ctx, tx, user, todo)
+	})
+	if errors.Is(err, store.ErrNotFound) {
+		return apperr.New(apperr.ChecklistItemNotFound)
+	} else if err != nil {
+		return apperr.Wrap(apperr.ChecklistItemUpdateFailed, err)
+	}
+
+	return c.JSON(http.StatusOK, item)
+}
+
+// Delete Checklist Item godoc
+// @Summary      Delete a checklist item
+// @Description  Deletes the item and returns it as a JSON object.
+// @Tags         Checklists
+// @Param        id path int true "Checklist Item ID"
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}	models.ChecklistItem
+// @Failure      400  {object}  dtos.ErrorDTO
+// @Failure      401  {object}  dtos.ErrorDTO
+// @Failure      403  {object}  dtos.ErrorDTO
+// @Failure      404  {object}  dtos.ErrorDTO
+// @Failure      500  {object}  dtos.ErrorDTO
+// @Security	 BearerAuth
+// @Router       /checklist/{id} [delete]
+func (ctl *Controller) DeleteChecklistItem(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	user, _, err := ctl.authenticate(c, ctx)
+	if err != nil {
+		return err
+	}
+
+	item, err := authorizedChecklistItem(ctx, ctl.app.Store, user, c.Param("id"), models.RoleEditor)
+	if err != nil {
+		return err
+	}
+
+	todo := item.Todo
+	item.Todo = nil
+
+	err = ctl.app.Store.InTx(ctx, func(tx *store.Store) error {
+		if err := tx.ChecklistItems.Delete(ctx, item.ID); err != nil {
+			return err
+		}
+		return ctl.updateProgress(ctx, tx, user, todo)
+	})
+	if errors.Is(err, store.ErrNotFound) {
+		return apperr.New(apperr.ChecklistItemNotFound)
+	} else if err != nil {
+		return apperr.Wrap(apperr.ChecklistItemDeleteFailed, err)
+	}
+
+	return c.JSON(http.StatusOK, item)
+}
+
+// Reorder Checklist godoc
+// @Summary      Reorder the checklist of this todo
+// @Description  Accepts the `ids` of all the items of the todo in their new order and returns the items in that order.
+// @Tags         Checklists
+// @Param        order body dtos.ChecklistOrderDTO true "The IDs of the items in order"
+// @Param        id path int true "Todo ID"
+// @Accept       json
+// @Produce      json
+// @Success      200  {array}	models.ChecklistItem
+// @Failure      400  {object}  dtos.ErrorDTO
+// @Failure      401  {object}  dtos.ErrorDTO
+// @Failure      403  {object}  dtos.ErrorDTO
+// @Failure      404  {object}  dtos.ErrorDTO
+// @Failure      422  {object}  dtos.ErrorDTO
+// @Failure      500  {object}  dtos.ErrorDTO
+// @Security	 BearerAuth
+// @Router       /todos/{id}/checklist/reorder [post]
+func (ctl *Controller) ReorderChecklist(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	user, _, err := ctl.authenticate(c, ctx)
+	if err != nil {
+		return err
+	}
+
+	orderDTO := new(dtos.ChecklistOrderDTO)
+	if err = bind(c, orderDTO); err != nil {
+		return err
+	}
+
+	todo, err := authorizedTodo(ctx, ctl.app.Store, user, c.Param("id"), models.RoleEditor)
+	if err != nil {
+		return err
+	}
+
+	var items []models.ChecklistItem
+	err = ctl.app.Store.InTx(ctx, func(tx *store.Store) error {
+		current, err := tx.ChecklistItems.ListByTodo(ctx, todo.ID)
+		if err != nil {
+			return err
+		}
+
+		ids := make([]int, len(current))
+		for i, item := range current {
+			ids[i] = item.ID
+		}
+		if !sameIDs(ids, orderDTO.IDs) {
+			return apperr.New(apperr.ValidationFailed).WithFields([]apperr.FieldError{
+				{Field: "ids", Message: "must list every item of the todo exactly once"},
+			})
+		}
+
+		if err := tx.ChecklistItems.Reorder(ctx, todo.ID, orderDTO.IDs); err != nil {
+			return err
+		}
+
+		items, err = tx.ChecklistItems.ListByTodo(ctx, todo.ID)
+		return err
+	})
+	if err != nil {
+		return apperr.Wrap(apperr.ChecklistItemUpdateFailed, err)
+	}
+
+	return c.JSON(http.StatusOK, items)
+}
+
+// authorizedChecklistItem loads the checklist item with the given ID from st and makes sure user has at least the role minRole
+// on the todo list of its todo.
+func authorizedChecklistItem(ctx context.Context, st *store.Store, user *models.User, id string, minRole string) (*models.ChecklistItem, error) {
+	itemID, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	item, err := st.ChecklistItems.GetByID(ctx, itemID)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, apperr.New(apperr.ChecklistItemNotFound)
+	} else if err != nil {
+		return nil, apperr.Wrap(apperr.ChecklistItemFetchFailed, err)
+	}
+
+	if item.Todo == nil {
+		return nil, apperr.New(apperr.ChecklistItemNotFound)
+	}
+
+	if err = authorize(ctx, st, user, item.Todo.TodoList, minRole, apperr.ChecklistItemNotFound); err != nil {
+		return nil, err
+	}
+
+	return item, nil
+}
+
+// updateProgress counts the checklist items of todo again after they changed and completes
+// the todo if it auto completes and all of them are done.
+func (ctl *Controller) updateProgress(ctx context.Context, st *store.Store, user *models.User, todo *models.Todo) error {
+	if err := st.Todos.UpdateProgress(ctx, todo); err != nil {
+		return err
+	}
+
+	if !todo.AutoComplete || todo.Completed || todo.ItemsTotal == 0 || todo.ItemsDone < todo.ItemsTotal {
+		return nil
+	}
+
+	// The todo of an item is loaded without its recurrence.
+	recurrence, err := st.Recurrences.GetByTodo(ctx, todo.ID)
+	if err == nil {
+		todo.Recurrence = recurrence
+	} else if !errors.Is(err, store.ErrNotFound) {
+		return err
+	}
+
+	now := ctl.app.Now()
+	recurred, err := completeTodo(ctx, st, user, todo, now)
+	if err != nil {
+		return err
+	}
+	todo.UpdatedAt = now
+
+	if err := st.Todos.Update(ctx, todo); err != nil {
+		return err
+	}
+
+	if !recurred {
+		return nil
+	}
+	return rescheduleReminders(ctx, st, todo, now)
+}
+
+// sameIDs reports whether a and b hold the same IDs, each once.
+func sameIDs(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	a, b = slices.Sorted(slices.Values(a)), slices.Sorted(slices.Values(b))
+	return slices.Equal(a, b) && len(slices.Compact(b)) == len(b)
+}
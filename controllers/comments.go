@@ -0,0 +1,335 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/marouane-ach/todo-go/apperr"
+	"github.com/marouane-ach/todo-go/dtos"
+	"github.com/marouane-ach/todo-go/models"
+	"github.com/marouane-ach/todo-go/notify"
+	"github.com/marouane-ach/todo-go/store"
+)
+
+// mentionPattern matches the mentions of a comment: a word made of @ followed by an email address.
+var mentionPattern = regexp.MustCompile(`(?:^|\s)@([^\s@]+@[^\s@]+)`)
+
+// Create Comment godoc
+// @Summary      Comment on this todo
+// @Description  Accepts a plain text `body` as a JSON object and returns the created comment. Editors of the todo list can comment.
+// @Description  Mentioning the owner or a member of the list, or of its organization, with @ followed by their email address, e.g. `@ana@example.com`,
+// @Description  sends them an in-app notification.
+// @Tags         Comments
+// @Param        comment body dtos.CommentDTO true "The comment's body"
+// @Param        id path int true "Todo ID"
+// @Accept       json
+// @Produce      json
+// @Success      201  {object}	models.Comment
+// @Failure      400  {object}  dtos.ErrorDTO
+// @Failure      401  {object}  dtos.ErrorDTO
+// @Failure      403  {object}  dtos.ErrorDTO
+// @Failure      404  {object}  dtos.ErrorDTO
+// @Failure      422  {object}  dtos.ErrorDTO
+// @Failure      500  {object}  dtos.ErrorDTO
+// @Security	 BearerAuth
+// @Router       /todos/{id}/comments [post]
+func (ctl *Controller) CreateComment(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	user, _, err := ctl.authenticate(c, ctx)
+	if err != nil {
+		return err
+	}
+
+	commentDTO := new(dtos.CommentDTO)
+	if err = bind(c, commentDTO); err != nil {
+		return err
+	}
+
+	todo, err := authorizedTodo(ctx, ctl.app.Store, user, c.Param("id"), models.RoleEditor)
+	if err != nil {
+		return err
+	}
+
+	comment := &models.Comment{TodoID: todo.ID, AuthorID: user.ID, Body: commentDTO.Body}
+	if err = ctl.app.Store.Comments.Create(ctx, comment); err != nil {
+		return apperr.Wrap(apperr.CommentCreateFailed, err)
+	}
+
+	ctl.app.Go(func(ctx context.Context) {
+		ctl.notifyMentions(ctx, comment, todo, user, "")
+	})
+
+	return c.JSON(http.StatusCreated, comment)
+}
+
+// List Comments godoc
+// @Summary      List the comments on this todo
+// @Description  Returns a page of the comments on the todo, oldest first.
+// @Tags         Comments
+// @Param        id     path  int    true  "Todo ID"
+// @Param        limit  query int    false "Number of comments per page (1-100, default 50)"
+// @Param        cursor query string false "Cursor returned as next_cursor by the previous page"
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}	dtos.PageDTO[models.Comment]
+// @Failure      400  {object}  dtos.ErrorDTO
+// @Failure      401  {object}  dtos.ErrorDTO
+// @Failure      404  {object}  dtos.ErrorDTO
+// @Failure      422  {object}  dtos.ErrorDTO
+// @Failure      500  {object}  dtos.ErrorDTO
+// @Security	 BearerAuth
+// @Router       /todos/{id}/comments [get]
+func (ctl *Controller) ListComments(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	user, _, err := ctl.authenticate(c, ctx)
+	if err != nil {
+		return err
+	}
+
+	queryDTO := new(dtos.CommentQueryDTO)
+	if err = bind(c, queryDTO); err != nil {
+		return err
+	}
+
+	todo, err := authorizedTodo(ctx, ctl.app.Store, user, c.Param("id"), models.RoleViewer)
+	if err != nil {
+		return err
+	}
+
+	comments, err := ctl.commentPage(ctx, todo.ID, queryDTO)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, comments)
+}
+
+// Update Comment godoc
+// @Summary      Edit this comment
+// @Description  Accepts the new `body` as a JSON object and returns the updated comment. Only its author can edit it, while they can edit the todo list.
+// @Description  Only the users the edit mentions for the first time are notified.
+// @Tags         Comments
+// @Param        comment body dtos.CommentDTO true "The comment's body"
+// @Param        id path int true "Comment ID"
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}	models.Comment
+// @Failure      400  {object}  dtos.ErrorDTO
+// @Failure      401  {object}  dtos.ErrorDTO
+// @Failure      403  {object}  dtos.ErrorDTO
+// @Failure      404  {object}  dtos.ErrorDTO
+// @Failure      422  {object}  dtos.ErrorDTO
+// @Failure      500  {object}  dtos.ErrorDTO
+// @Security	 BearerAuth
+// @Router       /comments/{id} [put]
+func (ctl *Controller) UpdateComment(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	user, _, err := ctl.authenticate(c, ctx)
+	if err != nil {
+		return err
+	}
+
+	commentDTO := new(dtos.CommentDTO)
+	if err = bind(c, commentDTO); err != nil {
+		return err
+	}
+
+	comment, err := authoredComment(ctx, ctl.app.Store, user, c.Param("id"), models.RoleEditor)
+	if err != nil {
+		return err
+	}
+
+	todo, previous := comment.Todo, comment.Body
+	comment.Todo = nil
+	comment.Body = commentDTO.Body
+	comment.UpdatedAt = ctl.app.Now()
+
+	if err = ctl.app.Store.Comments.Update(ctx, comment); errors.Is(err, store.ErrNotFound) {
+		return apperr.New(apperr.CommentNotFound)
+	} else if err != nil {
+		return apperr.Wrap(apperr.CommentUpdateFailed, err)
+	}
+
+	ctl.app.Go(func(ctx context.Context) {
+		ctl.notifyMentions(ctx, comment, todo, user, previous)
+	})
+
+	return c.JSON(http.StatusOK, comment)
+}
+
+// Delete Comment godoc
+// @Summary      Delete this comment
+// @Description  Deletes the comment and returns it. Only its author can delete it, as long as they can see the todo list.
+// @Tags         Comments
+// @Param        id path int true "Comment ID"
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}	models.Comment
+// @Failure      400  {object}  dtos.ErrorDTO
+// @Failure      401  {object}  dtos.ErrorDTO
+// @Failure      403  {object}  dtos.ErrorDTO
+// @Failure      404  {object}  dtos.ErrorDTO
+// @Failure      500  {object}  dtos.ErrorDTO
+// @Security	 BearerAuth
+// @Router       /comments/{id} [delete]
+func (ctl *Controller) DeleteComment(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	user, _, err := ctl.authenticate(c, ctx)
+	if err != nil {
+		return err
+	}
+
+	comment, err := authoredComment(ctx, ctl.app.Store, user, c.Param("id"), models.RoleViewer)
+	if err != nil {
+		return err
+	}
+
+	if err = ctl.app.Store.Comments.Delete(ctx, comment.ID); errors.Is(err, store.ErrNotFound) {
+		return apperr.New(apperr.CommentNotFound)
+	} else if err != nil {
+		return apperr.Wrap(apperr.CommentDeleteFailed, err)
+	}
+
+	comment.Todo = nil
+	return c.JSON(http.StatusOK, comment)
+}
+
+// authoredComment loads the comment with the given ID from st and makes sure user wrote it and has at least the role minRole
+// on its todo list.
+func authoredComment(ctx context.Context, st *store.Store, user *models.User, id string, minRole string) (*models.Comment, error) {
+	commentID, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	comment, err := st.Comments.GetByID(ctx, commentID)
+	if errors.Is(err, store.ErrNotFound) || (err == nil && comment.Todo == nil) {
+		return nil, apperr.New(apperr.CommentNotFound)
+	} else if err != nil {
+		return nil, apperr.Wrap(apperr.CommentFetchFailed, err)
+	}
+
+	if err = authorize(ctx, st, user, comment.Todo.TodoList, minRole, apperr.CommentNotFound); err != nil {
+		return nil, err
+	}
+
+	if comment.AuthorID != user.ID {
+		return nil, apperr.New(apperr.Forbidden).WithDescription("Only the author of a comment can change it.")
+	}
+
+	return comment, nil
+}
+
+// commentPage returns the page of the comments of a todo described by queryDTO.
+func (ctl *Controller) commentPage(ctx context.Context, todoID int, queryDTO *dtos.CommentQueryDTO) (*dtos.PageDTO[models.Comment], error) {
+	query := store.CommentQuery{TodoID: todoID, Limit: queryDTO.Limit}
+	if query.Limit == 0 {
+		query.Limit = defaultPageLimit
+	}
+
+	var err error
+	query.After, err = decodeCursor(queryDTO.Cursor, store.CommentSort, true)
+	if err != nil {
+		return nil, err
+	}
+
+	// Fetch one more comment than asked to know whether there is a next page.
+	limit := query.Limit
+	query.Limit++
+
+	comments, err := ctl.app.Store.Comments.List(ctx, query)
+	if err != nil {
+		return nil, apperr.Wrap(apperr.CommentFetchFailed, err)
+	}
+
+	return page(comments, limit, store.CommentSort, func(c *models.Comment) store.Cursor {
+		return store.Cursor{Value: c.CreatedAt, ID: c.ID}
+	}), nil
+}
+
+// mentions returns the email addresses body mentions, lowercased.
+func mentions(body string) map[string]bool {
+	emails := map[string]bool{}
+	for _, match := range mentionPattern.FindAllStringSubmatch(body, -1) {
+		// Punctuation right after a mention ends the sentence, not the address.
+		emails[strings.ToLower(strings.TrimRight(match[1], ".,;:!?)'\""))] = true
+	}
+	return emails
+}
+
+// notifyMentions notifies the users with access to the todo list of todo whom comment mentions, except its author and the users
+// previous, the body of the comment before an edit, already mentioned. It runs in the background, so failures are only logged.
+func (ctl *Controller) notifyMentions(ctx context.Context, comment *models.Comment, todo *models.Todo, author *models.User, previous string) {
+	mentioned := mentions(comment.Body)
+	for email := range mentions(previous) {
+		delete(mentioned, email)
+	}
+	delete(mentioned, strings.ToLower(author.Email))
+
+	if len(mentioned) == 0 || todo.TodoList == nil {
+		return
+	}
+
+	users, err := ctl.listUsers(ctx, todo.TodoList)
+	if err != nil {
+		ctl.app.Logger.Warn("could not find the users a comment mentions", "comment", comment.ID, "error", err)
+		return
+	}
+
+	title := fmt.Sprintf("%s mentioned you on %s", author.Email, todo.Text)
+	inApp := &notify.InApp{Store: ctl.app.Store}
+	for _, u := range users {
+		if !mentioned[strings.ToLower(u.Email)] {
+			continue
+		}
+
+		if err := inApp.Notify(ctx, notify.Message{User: u, Kind: "mention", Title: title, Body: comment.Body, TodoID: todo.ID}); err != nil {
+			ctl.app.Logger.Warn("could not notify a mention", "comment", comment.ID, "user", u.ID, "error", err)
+		}
+	}
+}
+
+// listUsers returns the users with access to todoList: its owner and members, or the members of its organization.
+func (ctl *Controller) listUsers(ctx context.Context, todoList *models.TodoList) ([]*models.User, error) {
+	var users []*models.User
+	if todoList.OrganizationID != 0 {
+		memberships, err := ctl.app.Store.OrgMemberships.ListByOrganization(ctx, todoList.OrganizationID)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, membership := range memberships {
+			if membership.User != nil {
+				users = append(users, membership.User)
+			}
+		}
+		return users, nil
+	}
+
+	owner, err := ctl.app.Store.Users.GetByID(ctx, todoList.OwnerID)
+	if err != nil {
+		return nil, err
+	}
+
+	memberships, err := ctl.app.Store.Memberships.ListByTodoList(ctx, todoList.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	users = append(users, owner)
+	for _, membership := range memberships {
+		if membership.User != nil {
+			users = append(users, membership.User)
+		}
+	}
+	return users, nil
+}
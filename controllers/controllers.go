@@ -0,0 +1,185 @@
+package controllers
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/marouane-ach/todo-go/app"
+	"github.com/marouane-ach/todo-go/validation"
+	echoSwagger "github.com/swaggo/echo-swagger"
+)
+
+// Controller holds the HTTP handlers. Each handler reads its dependencies from the App
+// it was created with instead of package-level state.
+type Controller struct {
+	app *app.App
+}
+
+func New(a *app.App) *Controller {
+	return &Controller{app: a}
+}
+
+// RegisterRoutes mounts every handler on e and installs the error handler and the validator.
+func (ctl *Controller) RegisterRoutes(e *echo.Echo) {
+	e.HTTPErrorHandler = ctl.handleError
+	e.Validator = validation.New()
+	e.Use(ctl.withRequestContext)
+
+	e.GET("/swagger/*", echoSwagger.WrapHandler)
+
+	e.POST("/signup", ctl.Signup)
+
+	e.POST("/login", ctl.Login)
+
+	e.POST("/logout", ctl.Logout)
+
+	e.GET("/me", ctl.GetProfile)
+
+	e.PATCH("/me", ctl.UpdateProfile)
+
+	e.POST("/todolists", ctl.CreateTodoList)
+
+	e.GET("/todolists", ctl.GetUserTodoLists)
+
+	e.GET("/todolists/:id", ctl.GetTodoListByID)
+
+	e.PUT("/todolists/:id", ctl.UpdateTodoList)
+
+	e.DELETE("/todolists/:id", ctl.DeleteTodoList)
+
+	e.POST("/todolists/:id/reorder", ctl.ReorderTodoList)
+
+	e.GET("/todolists/:id/export", ctl.ExportTodoList)
+
+	e.POST("/folders", ctl.CreateFolder)
+
+	e.GET("/folders", ctl.ListFolders)
+
+	e.GET("/folders/:id", ctl.GetFolder)
+
+	e.PUT("/folders/:id", ctl.UpdateFolder)
+
+	e.DELETE("/folders/:id", ctl.DeleteFolder)
+
+	e.POST("/todolists/:id/todos", ctl.CreateTodo)
+
+	e.GET("/todolists/:id/todos", ctl.ListTodoListTodos)
+
+	e.GET("/todos", ctl.ListTodos)
+
+	e.GET("/todos/:id", ctl.GetTodo)
+
+	e.PUT("/todos/:id", ctl.UpdateTodo)
+
+	e.DELETE("/todos/:id", ctl.DeleteTodo)
+
+	e.POST("/todos/:id/move", ctl.MoveTodo)
+
+	e.POST("/todos/:id/reorder", ctl.ReorderTodo)
+
+	e.POST("/todos/:id/checklist", ctl.CreateChecklistItem)
+
+	e.GET("/todos/:id/checklist", ctl.ListChecklistItems)
+
+	e.POST("/todos/:id/checklist/reorder", ctl.ReorderChecklist)
+
+	e.PUT("/checklist/:id", ctl.UpdateChecklistItem)
+
+	e.DELETE("/checklist/:id", ctl.DeleteChecklistItem)
+
+	e.GET("/todolists/:id/statuses", ctl.ListStatuses)
+
+	e.POST("/todolists/:id/statuses", ctl.CreateStatus)
+
+	e.POST("/todolists/:id/statuses/reorder", ctl.ReorderStatuses)
+
+	e.PUT("/statuses/:id", ctl.UpdateStatus)
+
+	e.DELETE("/statuses/:id", ctl.DeleteStatus)
+
+	e.GET("/todolists/:id/board", ctl.GetBoard)
+
+	e.PUT("/todos/:id/status", ctl.SetTodoStatus)
+
+	e.POST("/tags", ctl.CreateTag)
+
+	e.GET("/tags", ctl.ListTags)
+
+	e.GET("/tags/:id", ctl.GetTag)
+
+	e.PUT("/tags/:id", ctl.UpdateTag)
+
+	e.DELETE("/tags/:id", ctl.DeleteTag)
+
+	e.POST("/todos/:id/tags/:tag_id", ctl.AttachTag)
+
+	e.DELETE("/todos/:id/tags/:tag_id", ctl.DetachTag)
+
+	e.POST("/todos/:id/reminders", ctl.CreateReminder)
+
+	e.GET("/todos/:id/reminders", ctl.ListReminders)
+
+	e.DELETE("/reminders/:id", ctl.DeleteReminder)
+
+	e.PUT("/todos/:id/recurrence", ctl.SetRecurrence)
+
+	e.DELETE("/todos/:id/recurrence", ctl.DeleteRecurrence)
+
+	e.GET("/todos/:id/completions", ctl.ListCompletions)
+
+	e.GET("/todolists/:id/members", ctl.ListMembers)
+
+	e.PUT("/todolists/:id/members/:user_id", ctl.UpdateMember)
+
+	e.DELETE("/todolists/:id/members/:user_id", ctl.RemoveMember)
+
+	e.POST("/todolists/:id/invitations", ctl.CreateInvitation)
+
+	e.GET("/todolists/:id/invitations", ctl.ListTodoListInvitations)
+
+	e.GET("/invitations", ctl.ListInvitations)
+
+	e.DELETE("/invitations/:id", ctl.DeleteInvitation)
+
+	e.POST("/invitations/:id/accept", ctl.AcceptInvitation)
+
+	e.POST("/invitations/:id/decline", ctl.DeclineInvitation)
+
+	e.POST("/todolists/:id/sharelinks", ctl.CreateShareLink)
+
+	e.GET("/todolists/:id/sharelinks", ctl.ListShareLinks)
+
+	e.DELETE("/sharelinks/:id", ctl.DeleteShareLink)
+
+	e.GET("/shared/:slug", ctl.GetSharedTodoList)
+
+	e.POST("/todos/:id/comments", ctl.CreateComment)
+
+	e.GET("/todos/:id/comments", ctl.ListComments)
+
+	e.PUT("/comments/:id", ctl.UpdateComment)
+
+	e.DELETE("/comments/:id", ctl.DeleteComment)
+
+	e.POST("/organizations", ctl.CreateOrganization)
+
+	e.GET("/organizations", ctl.ListOrganizations)
+
+	e.GET("/organizations/:id", ctl.GetOrganization)
+
+	e.PUT("/organizations/:id", ctl.UpdateOrganization)
+
+	e.DELETE("/organizations/:id", ctl.DeleteOrganization)
+
+	e.GET("/organizations/:id/members", ctl.ListOrgMembers)
+
+	e.POST("/organizations/:id/members", ctl.AddOrgMember)
+
+	e.PUT("/organizations/:id/members/:user_id", ctl.UpdateOrgMember)
+
+	e.DELETE("/organizations/:id/members/:user_id", ctl.RemoveOrgMember)
+
+	e.GET("/notifications", ctl.ListNotifications)
+
+	e.POST("/notifications/:id/read", ctl.ReadNotification)
+
+	e.GET("/search", ctl.Search)
+}
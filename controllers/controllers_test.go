@@ -0,0 +1,803 @@
+package controllers_test
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+	_ "time/tzdata"
+
+	"github.com/labstack/echo/v4"
+	"github.com/marouane-ach/todo-go/app"
+	"github.com/marouane-ach/todo-go/config"
+	"github.com/marouane-ach/todo-go/controllers"
+	"github.com/marouane-ach/todo-go/store"
+)
+
+// backends build a new App per test, each with its own data, for every store the handlers run against.
+// Both read the time from clock, so the same steps give the same responses on either.
+var backends = map[string]func(t *testing.T, clock func() time.Time) *app.App{
+	"memory": func(t *testing.T, clock func() time.Time) *app.App {
+		a := app.NewWithStore(testConfig(), store.NewMemory(clock))
+		a.Now = clock
+		return a
+	},
+	"sqlite": func(t *testing.T, clock func() time.Time) *app.App {
+		cfg := testConfig()
+		cfg.DatabaseURL = "file:" + filepath.Join(t.TempDir(), "todo.db") + "?cache=shared&mode=rwc"
+
+		a, err := app.New(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		a.Now = clock
+		return a
+	},
+}
+
+// testConfig is the configuration of the tests, with organization quotas small enough to reach.
+func testConfig() config.Config {
+	cfg := config.Default()
+	cfg.OrgMaxMembers, cfg.OrgMaxTodoLists = 2, 2
+	return cfg
+}
+
+// now is the time every test starts at.
+var now = time.Date(2026, time.January, 2, 3, 4, 5, 0, time.UTC)
+
+// step is a request sent by one of the users of a test, or anonymously if user is empty.
+type step struct {
+	user   string
+	method string
+	path   string
+	body   string
+	// headers are set on the request in addition to the content type and the token.
+	headers map[string]string
+	status  int
+	// want holds the values some fields of the JSON object returned must have.
+	want map[string]any
+	// count is the number of elements of the data array returned, or of the array itself, when not zero.
+	count int
+	// ids are the IDs of the elements of the data array returned, or of the array itself, in order.
+	ids []int
+	// advance moves the clock of the test forward before the request is sent.
+	advance time.Duration
+}
+
+// The path, body and headers of a step can refer to a string field of an earlier response as {Field}, e.g. {Slug},
+// and get its value in the latest response that had it.
+var placeholder = regexp.MustCompile(`\{[A-Z]\w*\}`)
+
+var tests = []struct {
+	name  string
+	steps []step
+}{
+	{
+		name: "signup",
+		steps: []step{
+			{method: http.MethodPost, path: "/signup", body: `{"email":"e@b.com","password":"short"}`, status: http.StatusUnprocessableEntity,
+				want: map[string]any{"error_code": 30, "errors": []map[string]any{{"field": "password", "message": "must contain at least 8 characters"}}}},
+			{method: http.MethodPost, path: "/signup", body: `{"email":"not an email","password":"password1"}`, status: http.StatusUnprocessableEntity,
+				want: map[string]any{"errors": []map[string]any{{"field": "email", "message": "must be a valid email address"}}}},
+			{method: http.MethodPost, path: "/signup", body: `{"email":"  a@b.com ","password":"password1"}`, status: http.StatusConflict,
+				want: map[string]any{"error_code": 4}},
+			{method: http.MethodPost, path: "/signup", body: `{"email":"e@b.com","password":"password1"}`, status: http.StatusCreated},
+		},
+	},
+	{
+		name: "validation",
+		steps: []step{
+			{user: "a", method: http.MethodPost, path: "/todolists", body: `{"name":"   "}`, status: http.StatusUnprocessableEntity,
+				want: map[string]any{"errors": []map[string]any{
+					{"field": "name", "message": "is required"},
+					{"field": "color_id", "message": "is required"},
+				}}},
+			{user: "a", method: http.MethodPost, path: "/todolists", body: `{"name":" Home ","color_id":1}`, status: http.StatusCreated,
+				want: map[string]any{"Name": "Home"}},
+			{user: "a", method: http.MethodPost, path: "/todolists/1/todos", body: `{"text":"` + strings.Repeat("a", 501) + `"}`,
+				status: http.StatusUnprocessableEntity,
+				want:   map[string]any{"errors": []map[string]any{{"field": "text", "message": "must contain at most 500 characters"}}}},
+			{user: "a", method: http.MethodPost, path: "/todolists", body: `{}`, headers: map[string]string{echo.HeaderAccept: "application/problem+json"},
+				status: http.StatusUnprocessableEntity, want: map[string]any{"status": 422, "error_code": 30, "errors": []map[string]any{
+					{"field": "name", "message": "is required"},
+					{"field": "color_id", "message": "is required"},
+				}}},
+		},
+	},
+	{
+		name: "login",
+		steps: []step{
+			{method: http.MethodPost, path: "/login", body: `{"email":"a@b.com","password":"password1"}`, status: http.StatusOK},
+			{method: http.MethodPost, path: "/login", body: `{"email":"a@b.com","password":"password2"}`, status: http.StatusUnauthorized,
+				want: map[string]any{"error_code": 7}},
+			{method: http.MethodPost, path: "/login", body: `{"email":"e@b.com","password":"password1"}`, status: http.StatusNotFound,
+				want: map[string]any{"error_code": 6}},
+		},
+	},
+	{
+		name: "todo lists",
+		steps: []step{
+			{user: "a", method: http.MethodPost, path: "/todolists", body: `{"name":"Home","color_id":1}`, status: http.StatusCreated,
+				want: map[string]any{"ID": 1, "Name": "Home", "OwnerID": 1}},
+			{user: "a", method: http.MethodPost, path: "/todolists", body: `{"name":"Work","color_id":99}`, status: http.StatusBadRequest,
+				want: map[string]any{"error_code": 11}},
+			{user: "a", method: http.MethodGet, path: "/todolists/1", status: http.StatusOK, want: map[string]any{"Name": "Home", "ColorID": 1}},
+			{user: "a", method: http.MethodGet, path: "/todolists/2", status: http.StatusNotFound, want: map[string]any{"error_code": 13}},
+			{user: "a", method: http.MethodDelete, path: "/todolists/1", status: http.StatusOK, want: map[string]any{"ID": 1}},
+			{user: "a", method: http.MethodGet, path: "/todolists/1", status: http.StatusNotFound},
+		},
+	},
+	{
+		name: "todos",
+		steps: []step{
+			{user: "a", method: http.MethodPost, path: "/todolists", body: `{"name":"Home","color_id":1}`, status: http.StatusCreated},
+			{user: "a", method: http.MethodPost, path: "/todolists/1/todos", body: `{"text":"Buy milk"}`, status: http.StatusCreated,
+				want: map[string]any{"ID": 1, "Text": "Buy milk", "Completed": false, "TodoListID": 1}},
+			{user: "a", method: http.MethodPut, path: "/todos/1", body: `{"text":"Buy oat milk","completed":true}`, status: http.StatusOK,
+				want: map[string]any{"Text": "Buy oat milk", "Completed": true}},
+			{user: "a", method: http.MethodPut, path: "/todos/2", body: `{"text":"Buy milk"}`, status: http.StatusNotFound},
+		},
+	},
+	{
+		name: "deleting a todo list deletes its todos",
+		steps: []step{
+			{user: "a", method: http.MethodPost, path: "/todolists", body: `{"name":"Home","color_id":1}`, status: http.StatusCreated},
+			{user: "a", method: http.MethodPost, path: "/todolists/1/todos", body: `{"text":"Buy milk"}`, status: http.StatusCreated},
+			{user: "a", method: http.MethodDelete, path: "/todolists/1", status: http.StatusOK},
+			{user: "a", method: http.MethodPut, path: "/todos/1", body: `{"text":"Buy milk"}`, status: http.StatusNotFound},
+		},
+	},
+	{
+		name: "todo list of another user",
+		steps: []step{
+			{user: "a", method: http.MethodPost, path: "/todolists", body: `{"name":"Home","color_id":1}`, status: http.StatusCreated},
+			{user: "a", method: http.MethodPost, path: "/todolists/1/todos", body: `{"text":"Buy milk"}`, status: http.StatusCreated},
+			{user: "c", method: http.MethodGet, path: "/todolists/1", status: http.StatusNotFound, want: map[string]any{"error_code": 13}},
+			{user: "c", method: http.MethodDelete, path: "/todolists/1", status: http.StatusNotFound},
+			{user: "c", method: http.MethodPost, path: "/todolists/1/todos", body: `{"text":"Buy milk"}`, status: http.StatusNotFound},
+			{user: "c", method: http.MethodPut, path: "/todos/1", body: `{"text":"Buy milk"}`, status: http.StatusNotFound,
+				want: map[string]any{"error_code": 16}},
+			{user: "a", method: http.MethodGet, path: "/todolists/1", status: http.StatusOK},
+		},
+	},
+	{
+		name: "malformed IDs",
+		steps: []step{
+			{user: "a", method: http.MethodGet, path: "/todolists/abc", status: http.StatusBadRequest, want: map[string]any{"error_code": 31}},
+			{user: "a", method: http.MethodDelete, path: "/todolists/0", status: http.StatusBadRequest, want: map[string]any{"error_code": 31}},
+			{user: "a", method: http.MethodPut, path: "/todos/-1", body: `{"text":"Buy milk"}`, status: http.StatusBadRequest,
+				want: map[string]any{"error_code": 31}},
+		},
+	},
+	{
+		name: "logout",
+		steps: []step{
+			{method: http.MethodGet, path: "/todolists", status: http.StatusUnauthorized, want: map[string]any{"error_code": 9}},
+			{user: "a", method: http.MethodPost, path: "/logout", status: http.StatusOK},
+			{user: "a", method: http.MethodGet, path: "/todolists", status: http.StatusUnauthorized, want: map[string]any{"error_code": 9}},
+		},
+	},
+	{
+		name: "errors",
+		steps: []step{
+			{user: "a", method: http.MethodGet, path: "/nowhere", status: http.StatusNotFound,
+				want: map[string]any{"error_code": 25, "description": "This route does not exist."}},
+			{user: "a", method: http.MethodPatch, path: "/todolists", status: http.StatusMethodNotAllowed, want: map[string]any{"error_code": 26}},
+			{user: "a", method: http.MethodPost, path: "/todolists", body: `{"name":`, status: http.StatusBadRequest, want: map[string]any{"error_code": 24}},
+			{user: "a", method: http.MethodGet, path: "/todolists/1", headers: map[string]string{echo.HeaderAccept: "application/problem+json"},
+				status: http.StatusNotFound, want: map[string]any{
+					"type": "urn:todo-app:error:13", "title": "Not Found", "status": 404, "detail": "Todo list does not exist.",
+					"instance": "/todolists/1", "error_code": 13,
+				}},
+		},
+	},
+	{
+		name: "pages of todo lists",
+		steps: []step{
+			{user: "a", method: http.MethodPost, path: "/todolists", body: `{"name":"Home","color_id":1}`, status: http.StatusCreated},
+			{user: "a", method: http.MethodPost, path: "/todolists", body: `{"name":"Work","color_id":2}`, status: http.StatusCreated},
+			{user: "a", method: http.MethodPost, path: "/todolists", body: `{"name":"Garden","color_id":1}`, status: http.StatusCreated},
+			{user: "c", method: http.MethodPost, path: "/todolists", body: `{"name":"Attic","color_id":1}`, status: http.StatusCreated},
+			{user: "a", method: http.MethodGet, path: "/todolists?sort=name&order=asc&limit=2", status: http.StatusOK, count: 2,
+				want: map[string]any{"pagination": map[string]any{
+					"limit": 2, "has_more": true, "next_cursor": cursor(`{"s":"name","d":false,"v":"Home","id":1}`),
+				}}},
+			{user: "a", method: http.MethodGet, path: "/todolists?sort=name&order=asc&limit=2&cursor=" + cursor(`{"s":"name","d":false,"v":"Home","id":1}`),
+				status: http.StatusOK, count: 1, want: map[string]any{"pagination": map[string]any{"limit": 2, "has_more": false}}},
+			{user: "a", method: http.MethodGet, path: "/todolists?sort=name&order=desc&cursor=" + cursor(`{"s":"name","d":false,"v":"Home","id":1}`),
+				status: http.StatusBadRequest, want: map[string]any{"error_code": 34}},
+			{user: "a", method: http.MethodGet, path: "/todolists?color_id=1", status: http.StatusOK, count: 2},
+			{user: "a", method: http.MethodPost, path: "/todolists/2/todos", body: `{"text":"Send invoices"}`, status: http.StatusCreated},
+			{user: "a", method: http.MethodGet, path: "/todolists?has_incomplete=true", status: http.StatusOK, count: 1},
+			{user: "a", method: http.MethodGet, path: "/todolists?has_incomplete=false", status: http.StatusOK, count: 2},
+			{user: "a", method: http.MethodGet, path: "/todolists?limit=101", status: http.StatusUnprocessableEntity},
+		},
+	},
+	{
+		name: "todo listings",
+		steps: []step{
+			{user: "a", method: http.MethodPost, path: "/todolists", body: `{"name":"Home","color_id":1}`, status: http.StatusCreated},
+			{user: "a", method: http.MethodPost, path: "/todolists", body: `{"name":"Work","color_id":2}`, status: http.StatusCreated},
+			{user: "c", method: http.MethodPost, path: "/todolists", body: `{"name":"Attic","color_id":1}`, status: http.StatusCreated},
+			{user: "a", method: http.MethodPost, path: "/todolists/1/todos", body: `{"text":"Buy milk"}`, status: http.StatusCreated},
+			{user: "a", method: http.MethodPost, path: "/todolists/1/todos", body: `{"text":"Buy bread"}`, status: http.StatusCreated},
+			{user: "a", method: http.MethodPost, path: "/todolists/2/todos", body: `{"text":"Send invoices"}`, status: http.StatusCreated},
+			{user: "c", method: http.MethodPost, path: "/todolists/3/todos", body: `{"text":"Buy a lamp"}`, status: http.StatusCreated},
+			{user: "a", method: http.MethodPut, path: "/todos/2", body: `{"text":"Buy bread","completed":true}`, status: http.StatusOK},
+			{user: "a", method: http.MethodGet, path: "/todos", status: http.StatusOK, count: 3},
+			{user: "a", method: http.MethodGet, path: "/todos?completed=true", status: http.StatusOK, count: 1},
+			{user: "a", method: http.MethodGet, path: "/todos?text=Buy", status: http.StatusOK, count: 2},
+			{user: "a", method: http.MethodGet, path: "/todos?todo_list_id=2", status: http.StatusOK, count: 1},
+			{user: "a", method: http.MethodGet, path: "/todos?todo_list_id=3", status: http.StatusOK, want: map[string]any{"data": []any{}}},
+			{user: "a", method: http.MethodGet, path: "/todolists/1/todos?sort=text&order=asc&limit=1", status: http.StatusOK, count: 1,
+				want: map[string]any{"pagination": map[string]any{
+					"limit": 1, "has_more": true, "next_cursor": cursor(`{"s":"text","d":false,"v":"Buy bread","id":2}`),
+				}}},
+			{user: "a", method: http.MethodGet, path: "/todolists/3/todos", status: http.StatusNotFound},
+			{user: "a", method: http.MethodGet, path: "/todos?completed=maybe", status: http.StatusUnprocessableEntity},
+		},
+	},
+	{
+		name: "search with operators",
+		steps: []step{
+			{user: "a", method: http.MethodPost, path: "/todolists", body: `{"name":"Home","color_id":1}`, status: http.StatusCreated},
+			{user: "a", method: http.MethodPost, path: "/todolists/1/todos", body: `{"text":"Buy milk near the door"}`, status: http.StatusCreated},
+			{user: "a", method: http.MethodPost, path: "/todolists/1/todos", body: `{"text":"Call the plumber and not the electrician"}`, status: http.StatusCreated},
+			{user: "a", method: http.MethodGet, path: "/search?q=" + url.QueryEscape(`NEAR(the door) mil*`), status: http.StatusOK, count: 1},
+			{user: "a", method: http.MethodGet, path: "/search?q=" + url.QueryEscape(`AND NOT`), status: http.StatusOK, count: 1},
+			{user: "a", method: http.MethodGet, path: "/search?q=" + url.QueryEscape(`"the door`), status: http.StatusOK, count: 1},
+			{user: "a", method: http.MethodGet, path: "/search?q=" + url.QueryEscape(`the OR`), status: http.StatusOK, want: map[string]any{"data": []any{}}},
+			{user: "a", method: http.MethodGet, path: "/search?q=" + url.QueryEscape(`plumber ^call -the`), status: http.StatusOK, count: 1},
+			{user: "a", method: http.MethodGet, path: "/search?q=" + url.QueryEscape(`text:plumber`), status: http.StatusOK, want: map[string]any{"data": []any{}}},
+			{user: "a", method: http.MethodGet, path: "/search?q=" + url.QueryEscape(`'; DROP TABLE todos; -- "*`), status: http.StatusOK, want: map[string]any{"data": []any{}}},
+			{user: "a", method: http.MethodGet, path: "/search?q=home", status: http.StatusOK, count: 1},
+			{user: "c", method: http.MethodGet, path: "/search?q=milk", status: http.StatusOK, want: map[string]any{"data": []any{}}},
+		},
+	},
+	{
+		name: "due dates",
+		steps: []step{
+			{user: "a", method: http.MethodGet, path: "/me", status: http.StatusOK, want: map[string]any{"email": "a@b.com", "timezone": "UTC"}},
+			{user: "a", method: http.MethodPatch, path: "/me", body: `{"timezone":"Mars/Olympus_Mons"}`, status: http.StatusUnprocessableEntity},
+			// It is Thursday 1 January 2026 at 19:04 in Los Angeles.
+			{user: "a", method: http.MethodPatch, path: "/me", body: `{"timezone":"America/Los_Angeles"}`, status: http.StatusOK,
+				want: map[string]any{"timezone": "America/Los_Angeles"}},
+			{user: "a", method: http.MethodPost, path: "/todolists", body: `{"name":"Home","color_id":1}`, status: http.StatusCreated},
+			{user: "a", method: http.MethodPost, path: "/todolists/1/todos", body: `{"text":"Water plants","due_at":"2026-01-01T10:00:00Z"}`, status: http.StatusCreated,
+				want: map[string]any{"DueAt": "2026-01-01T10:00:00Z", "AllDay": false}},
+			{user: "a", method: http.MethodPost, path: "/todolists/1/todos", body: `{"text":"Pay rent","due_at":"2026-01-01T23:30:00-08:00","all_day":true}`,
+				status: http.StatusCreated, want: map[string]any{"DueAt": "2026-01-01T00:00:00Z", "AllDay": true}},
+			{user: "a", method: http.MethodPost, path: "/todolists/1/todos", body: `{"text":"Call mom","due_at":"2026-01-02T06:00:00Z"}`, status: http.StatusCreated},
+			{user: "a", method: http.MethodPost, path: "/todolists/1/todos", body: `{"text":"Go hiking","due_at":"2026-01-04T12:00:00Z"}`, status: http.StatusCreated},
+			{user: "a", method: http.MethodPost, path: "/todolists/1/todos", body: `{"text":"Read a book"}`, status: http.StatusCreated},
+			{user: "a", method: http.MethodGet, path: "/todos?due=overdue", status: http.StatusOK, count: 1},
+			{user: "a", method: http.MethodGet, path: "/todos?due=today", status: http.StatusOK, count: 3},
+			{user: "a", method: http.MethodGet, path: "/todos?due=week", status: http.StatusOK, count: 4},
+			{user: "a", method: http.MethodGet, path: "/todos?due=none", status: http.StatusOK, count: 1},
+			{user: "a", method: http.MethodPut, path: "/todos/1", body: `{"text":"Water plants","completed":true,"due_at":"2026-01-01T10:00:00Z"}`, status: http.StatusOK},
+			{user: "a", method: http.MethodGet, path: "/todos?due=overdue", status: http.StatusOK, want: map[string]any{"data": []any{}}},
+			{user: "a", method: http.MethodPut, path: "/todos/4", body: `{"text":"Go hiking"}`, status: http.StatusOK, want: map[string]any{"DueAt": nil}},
+			{user: "a", method: http.MethodGet, path: "/todos?due=none", status: http.StatusOK, count: 2},
+		},
+	},
+	{
+		name: "reminders",
+		steps: []step{
+			{user: "a", method: http.MethodPost, path: "/todolists", body: `{"name":"Home","color_id":1}`, status: http.StatusCreated},
+			{user: "a", method: http.MethodPost, path: "/todolists/1/todos", body: `{"text":"Water plants"}`, status: http.StatusCreated},
+			{user: "a", method: http.MethodPost, path: "/todos/1/reminders", body: `{"remind_at":"2026-01-02T05:00:00+01:00"}`, status: http.StatusCreated,
+				want: map[string]any{"ID": 1, "UserID": 1, "FireAt": "2026-01-02T04:00:00Z", "Channel": "in_app", "Status": "pending"}},
+			{user: "a", method: http.MethodPost, path: "/todos/1/reminders", body: `{"offset_minutes":30,"channel":"email"}`, status: http.StatusCreated,
+				want: map[string]any{"ID": 2, "FireAt": nil, "OffsetMinutes": 30}},
+			{user: "a", method: http.MethodPost, path: "/todos/1/reminders", body: `{"remind_at":"2026-01-02T05:00:00Z","offset_minutes":30}`,
+				status: http.StatusUnprocessableEntity},
+			{user: "a", method: http.MethodPost, path: "/todos/1/reminders", body: `{"channel":"webhook","remind_at":"2026-01-02T05:00:00Z"}`,
+				status: http.StatusUnprocessableEntity, want: map[string]any{"errors": []map[string]any{
+					{"field": "channel", "message": "requires a webhook_url, set with PATCH /me"},
+				}}},
+			{user: "a", method: http.MethodPut, path: "/todos/1", body: `{"text":"Water plants","due_at":"2026-01-03T10:00:00Z"}`, status: http.StatusOK},
+			{user: "a", method: http.MethodGet, path: "/todos/1/reminders", status: http.StatusOK, count: 2},
+			{user: "c", method: http.MethodGet, path: "/todos/1/reminders", status: http.StatusNotFound},
+			{user: "c", method: http.MethodPost, path: "/todos/1/reminders", body: `{"offset_minutes":30}`, status: http.StatusNotFound},
+			{user: "c", method: http.MethodDelete, path: "/reminders/1", status: http.StatusNotFound},
+			{user: "a", method: http.MethodDelete, path: "/reminders/1", status: http.StatusOK, want: map[string]any{"ID": 1}},
+			{user: "a", method: http.MethodGet, path: "/todos/1/reminders", status: http.StatusOK, count: 1},
+			{user: "a", method: http.MethodGet, path: "/notifications", status: http.StatusOK, want: map[string]any{"data": []any{}}},
+			{user: "a", method: http.MethodPost, path: "/notifications/1/read", status: http.StatusNotFound},
+		},
+	},
+	{
+		name: "recurring todos",
+		steps: []step{
+			{user: "a", method: http.MethodPost, path: "/todolists", body: `{"name":"Home","color_id":1}`, status: http.StatusCreated},
+			{user: "a", method: http.MethodPost, path: "/todolists/1/todos", body: `{"text":"Take out trash","due_at":"2026-01-05T09:00:00Z"}`, status: http.StatusCreated},
+			{user: "a", method: http.MethodPost, path: "/todolists/1/todos", body: `{"text":"Stretch"}`, status: http.StatusCreated},
+			{user: "a", method: http.MethodPut, path: "/todos/1/recurrence", body: `{"rule":"FREQ=FORTNIGHTLY"}`, status: http.StatusUnprocessableEntity},
+			{user: "a", method: http.MethodPut, path: "/todos/2/recurrence", body: `{"rule":"FREQ=DAILY"}`, status: http.StatusUnprocessableEntity,
+				want: map[string]any{"errors": []map[string]any{{"field": "mode", "message": "fixed recurrences require the todo to have a due date"}}}},
+			{user: "a", method: http.MethodPut, path: "/todos/1/recurrence", body: `{"rule":"FREQ=WEEKLY;COUNT=2"}`, status: http.StatusOK,
+				want: map[string]any{"TodoID": 1, "Mode": "fixed", "Start": "2026-01-05T09:00:00Z", "Text": "Take out trash"}},
+			{user: "c", method: http.MethodPut, path: "/todos/1/recurrence", body: `{"rule":"FREQ=DAILY"}`, status: http.StatusNotFound},
+			{user: "a", method: http.MethodPut, path: "/todos/1", body: `{"text":"Take out trash","completed":true,"due_at":"2026-01-05T09:00:00Z"}`, status: http.StatusOK,
+				want: map[string]any{"Completed": false, "DueAt": "2026-01-12T09:00:00Z"}},
+			{user: "a", method: http.MethodPut, path: "/todos/1?scope=future", body: `{"text":"Take out recycling","due_at":"2026-01-12T09:00:00Z"}`, status: http.StatusOK},
+			{user: "a", method: http.MethodPut, path: "/todos/1", body: `{"text":"Take out recycling","completed":true,"due_at":"2026-01-12T09:00:00Z"}`, status: http.StatusOK,
+				want: map[string]any{"Completed": true, "DueAt": "2026-01-12T09:00:00Z"}},
+			{user: "a", method: http.MethodGet, path: "/todos/1/completions", status: http.StatusOK, count: 2},
+			{user: "c", method: http.MethodGet, path: "/todos/1/completions", status: http.StatusNotFound},
+			{user: "a", method: http.MethodPut, path: "/todos/2/recurrence", body: `{"rule":"FREQ=DAILY;INTERVAL=3","mode":"after_completion"}`, status: http.StatusOK},
+			{user: "a", method: http.MethodPut, path: "/todos/2", body: `{"text":"Stretch","completed":true}`, status: http.StatusOK,
+				want: map[string]any{"Completed": false, "DueAt": "2026-01-05T03:04:05Z"}},
+			{user: "a", method: http.MethodDelete, path: "/todos/2/recurrence", status: http.StatusOK, want: map[string]any{"Mode": "after_completion"}},
+			{user: "a", method: http.MethodDelete, path: "/todos/2/recurrence", status: http.StatusNotFound},
+			{user: "a", method: http.MethodGet, path: "/todos/2/completions", status: http.StatusOK, count: 1},
+		},
+	},
+	{
+		name: "checklists",
+		steps: []step{
+			{user: "a", method: http.MethodPost, path: "/todolists", body: `{"name":"Home","color_id":1}`, status: http.StatusCreated},
+			{user: "a", method: http.MethodPost, path: "/todolists/1/todos", body: `{"text":"Pack for the trip"}`, status: http.StatusCreated},
+			{user: "a", method: http.MethodPost, path: "/todos/1/checklist", body: `{"text":"Passport"}`, status: http.StatusCreated,
+				want: map[string]any{"ID": 1, "TodoID": 1, "Done": false, "Position": 1}},
+			{user: "a", method: http.MethodPost, path: "/todos/1/checklist", body: `{"text":"Charger","done":true}`, status: http.StatusCreated,
+				want: map[string]any{"Position": 2}},
+			{user: "a", method: http.MethodPost, path: "/todos/1/checklist", body: `{"text":"   "}`, status: http.StatusUnprocessableEntity},
+			{user: "c", method: http.MethodPost, path: "/todos/1/checklist", body: `{"text":"Sunscreen"}`, status: http.StatusNotFound},
+			{user: "a", method: http.MethodPut, path: "/todos/1", body: `{"text":"Pack for the trip","auto_complete":true}`, status: http.StatusOK,
+				want: map[string]any{"ItemsTotal": 2, "ItemsDone": 1, "Completed": false}},
+			{user: "a", method: http.MethodPost, path: "/todos/1/checklist/reorder", body: `{"ids":[2]}`, status: http.StatusUnprocessableEntity,
+				want: map[string]any{"errors": []map[string]any{{"field": "ids", "message": "must list every item of the todo exactly once"}}}},
+			{user: "a", method: http.MethodPost, path: "/todos/1/checklist/reorder", body: `{"ids":[2,1]}`, status: http.StatusOK, count: 2},
+			{user: "a", method: http.MethodGet, path: "/todos/1/checklist", status: http.StatusOK, count: 2},
+			{user: "c", method: http.MethodPut, path: "/checklist/1", body: `{"text":"Passport","done":true}`, status: http.StatusNotFound},
+			{user: "a", method: http.MethodPut, path: "/checklist/1", body: `{"text":"Passport","done":true}`, status: http.StatusOK,
+				want: map[string]any{"Done": true, "Position": 2}},
+			{user: "a", method: http.MethodGet, path: "/todos?completed=true", status: http.StatusOK, count: 1},
+			{user: "a", method: http.MethodDelete, path: "/checklist/2", status: http.StatusOK, want: map[string]any{"Text": "Charger"}},
+			{user: "a", method: http.MethodDelete, path: "/checklist/2", status: http.StatusNotFound},
+			{user: "a", method: http.MethodGet, path: "/todos/1/checklist", status: http.StatusOK, count: 1},
+		},
+	},
+	{
+		name: "notes",
+		steps: []step{
+			{user: "a", method: http.MethodPost, path: "/todolists", body: `{"name":"Home","color_id":1}`, status: http.StatusCreated},
+			{user: "a", method: http.MethodPost, path: "/todolists/1/todos", body: `{"text":"Buy milk","notes":"**skimmed** <script>alert(1)</script>"}`,
+				status: http.StatusCreated, want: map[string]any{"Notes": "**skimmed** <script>alert(1)</script>", "NotesHTML": nil}},
+			{user: "a", method: http.MethodPut, path: "/todos/1?render=html", body: `{"text":"Buy milk","notes":"**skimmed** [shop](javascript:alert(1))"}`,
+				status: http.StatusOK, want: map[string]any{"NotesHTML": "<p><strong>skimmed</strong> shop</p>\n"}},
+			{user: "a", method: http.MethodGet, path: "/todos?render=pdf", status: http.StatusUnprocessableEntity},
+			{user: "a", method: http.MethodGet, path: "/search?q=skimmed", status: http.StatusOK, count: 1},
+		},
+	},
+	{
+		name: "export",
+		steps: []step{
+			{user: "a", method: http.MethodPost, path: "/todolists", body: `{"name":"Home","color_id":1}`, status: http.StatusCreated},
+			{user: "a", method: http.MethodPost, path: "/todolists/1/todos", body: `{"text":"Buy milk","notes":"*skimmed*","priority":2}`, status: http.StatusCreated},
+			{user: "a", method: http.MethodPost, path: "/tags", body: `{"name":"errands"}`, status: http.StatusCreated},
+			{user: "a", method: http.MethodPost, path: "/todos/1/tags/1", status: http.StatusOK},
+			{user: "a", method: http.MethodGet, path: "/todolists/1/export", status: http.StatusOK, want: map[string]any{
+				"name": "Home",
+				"todos": []map[string]any{{
+					"text": "Buy milk", "completed": false, "status": "To do", "priority": 2, "tags": []string{"errands"}, "notes": "*skimmed*",
+					"due_at": nil, "all_day": false, "completed_at": nil, "items_total": 0, "items_done": 0,
+				}},
+			}},
+			{user: "a", method: http.MethodGet, path: "/todolists/1/export?format=csv", status: http.StatusOK},
+			{user: "a", method: http.MethodGet, path: "/todolists/1/export?format=xml", status: http.StatusUnprocessableEntity},
+			{user: "c", method: http.MethodGet, path: "/todolists/1/export", status: http.StatusNotFound},
+		},
+	},
+	{
+		name: "tags",
+		steps: []step{
+			{user: "a", method: http.MethodPost, path: "/todolists", body: `{"name":"Home","color_id":1}`, status: http.StatusCreated},
+			{user: "a", method: http.MethodPost, path: "/tags", body: `{"name":"errands","color_id":2}`, status: http.StatusCreated,
+				want: map[string]any{"ID": 1, "Name": "errands", "ColorID": 2, "OwnerID": 1}},
+			{user: "a", method: http.MethodPost, path: "/tags", body: `{"name":"Errands"}`, status: http.StatusConflict},
+			{user: "c", method: http.MethodPost, path: "/tags", body: `{"name":"errands"}`, status: http.StatusCreated},
+			{user: "a", method: http.MethodPost, path: "/tags", body: `{"name":"urgent"}`, status: http.StatusCreated},
+			{user: "a", method: http.MethodPost, path: "/todolists/1/todos", body: `{"text":"Buy milk","tag_ids":[1,3]}`, status: http.StatusCreated},
+			{user: "a", method: http.MethodPost, path: "/todolists/1/todos", body: `{"text":"Post letter","tag_ids":[1]}`, status: http.StatusCreated},
+			{user: "a", method: http.MethodPost, path: "/todolists/1/todos", body: `{"text":"Fix the sink","tag_ids":[2]}`, status: http.StatusUnprocessableEntity},
+			{user: "a", method: http.MethodPost, path: "/todolists/1/todos", body: `{"text":"Fix the sink"}`, status: http.StatusCreated},
+			{user: "a", method: http.MethodPost, path: "/todos/3/tags/3", status: http.StatusOK},
+			{user: "c", method: http.MethodPost, path: "/todos/3/tags/2", status: http.StatusNotFound},
+			{user: "a", method: http.MethodGet, path: "/todos?tag_id=1&tag_id=3", status: http.StatusOK, count: 3},
+			{user: "a", method: http.MethodGet, path: "/todos?tag_id=1&tag_id=3&tag_match=all", status: http.StatusOK, count: 1},
+			{user: "a", method: http.MethodGet, path: "/tags/1", status: http.StatusOK, want: map[string]any{"TodoCount": 2}},
+			{user: "a", method: http.MethodGet, path: "/tags", status: http.StatusOK, count: 2},
+			{user: "a", method: http.MethodDelete, path: "/todos/1/tags/1", status: http.StatusOK},
+			{user: "a", method: http.MethodDelete, path: "/tags/3", status: http.StatusOK, want: map[string]any{"Name": "urgent"}},
+			{user: "a", method: http.MethodGet, path: "/todos?tag_id=1", status: http.StatusOK, count: 1},
+			{user: "a", method: http.MethodGet, path: "/todos", status: http.StatusOK, count: 3},
+			{user: "c", method: http.MethodGet, path: "/tags/1", status: http.StatusNotFound},
+		},
+	},
+	{
+		name: "statuses and board",
+		steps: []step{
+			{user: "a", method: http.MethodPost, path: "/todolists", body: `{"name":"Home","color_id":1}`, status: http.StatusCreated},
+			{user: "a", method: http.MethodGet, path: "/todolists/1/statuses", status: http.StatusOK, count: 4},
+			{user: "a", method: http.MethodPost, path: "/todolists/1/todos", body: `{"text":"Fix the sink","priority":3}`, status: http.StatusCreated,
+				want: map[string]any{"StatusID": 1, "Priority": 3}},
+			{user: "a", method: http.MethodPost, path: "/todolists/1/todos", body: `{"text":"Paint the fence","priority":4}`, status: http.StatusUnprocessableEntity},
+			{user: "a", method: http.MethodPost, path: "/todolists/1/todos", body: `{"text":"Paint the fence","status_id":2}`, status: http.StatusCreated},
+			{user: "a", method: http.MethodPut, path: "/todos/1/status", body: `{"status_id":4}`, status: http.StatusOK,
+				want: map[string]any{"StatusID": 4, "Completed": true}},
+			{user: "a", method: http.MethodGet, path: "/todos?status_id=4", status: http.StatusOK, count: 1},
+			{user: "a", method: http.MethodGet, path: "/todos?priority=3&priority=2", status: http.StatusOK, count: 1},
+			{user: "a", method: http.MethodPut, path: "/todos/1", body: `{"text":"Fix the sink","priority":3}`, status: http.StatusOK,
+				want: map[string]any{"StatusID": 1, "Completed": false}},
+			{user: "a", method: http.MethodPost, path: "/todolists/1/statuses", body: `{"name":"Waiting"}`, status: http.StatusCreated,
+				want: map[string]any{"Name": "Waiting", "Position": 5, "Terminal": false}},
+			{user: "a", method: http.MethodPut, path: "/statuses/4", body: `{"name":"Done"}`, status: http.StatusConflict, want: map[string]any{"error_code": 65}},
+			{user: "a", method: http.MethodPut, path: "/statuses/2", body: `{"name":"Shipped","terminal":true}`, status: http.StatusOK},
+			{user: "a", method: http.MethodGet, path: "/todos?completed=true", status: http.StatusOK, count: 1},
+			{user: "a", method: http.MethodDelete, path: "/statuses/2", status: http.StatusOK},
+			{user: "a", method: http.MethodGet, path: "/todos?status_id=4", status: http.StatusOK, count: 1},
+			{user: "a", method: http.MethodPost, path: "/todolists/1/statuses/reorder", body: `{"ids":[5,1,3,4]}`, status: http.StatusOK, count: 4},
+			{user: "a", method: http.MethodGet, path: "/todolists/1/board", status: http.StatusOK},
+			{user: "c", method: http.MethodGet, path: "/todolists/1/board", status: http.StatusNotFound},
+			{user: "c", method: http.MethodPut, path: "/todos/1/status", body: `{"status_id":4}`, status: http.StatusNotFound},
+		},
+	},
+	{
+		name: "manual order",
+		steps: []step{
+			{user: "a", method: http.MethodPost, path: "/todolists", body: `{"name":"Home","color_id":1}`, status: http.StatusCreated},
+			{user: "a", method: http.MethodPost, path: "/todolists", body: `{"name":"Work","color_id":2}`, status: http.StatusCreated},
+			{user: "a", method: http.MethodPost, path: "/todolists", body: `{"name":"Travel","color_id":3}`, status: http.StatusCreated},
+			{user: "a", method: http.MethodGet, path: "/todolists?todos=omit", status: http.StatusOK, ids: []int{1, 2, 3}},
+			{user: "a", method: http.MethodPost, path: "/todolists/3/reorder", body: `{"before_id":1}`, status: http.StatusOK},
+			{user: "a", method: http.MethodPost, path: "/todolists/1/reorder", body: `{"after_id":2}`, status: http.StatusOK},
+			{user: "a", method: http.MethodGet, path: "/todolists?todos=omit", status: http.StatusOK, ids: []int{3, 2, 1}},
+			{user: "a", method: http.MethodPost, path: "/todolists/1/reorder", body: `{"before_id":2,"after_id":3}`, status: http.StatusUnprocessableEntity},
+			{user: "a", method: http.MethodPost, path: "/todolists/1/reorder", body: `{"before_id":1}`, status: http.StatusUnprocessableEntity},
+			{user: "c", method: http.MethodPost, path: "/todolists/1/reorder", body: `{"before_id":3}`, status: http.StatusNotFound},
+			{user: "a", method: http.MethodPost, path: "/todolists/1/todos", body: `{"text":"Buy milk"}`, status: http.StatusCreated},
+			{user: "a", method: http.MethodPost, path: "/todolists/1/todos", body: `{"text":"Water plants"}`, status: http.StatusCreated},
+			{user: "a", method: http.MethodPost, path: "/todolists/1/todos", body: `{"text":"Fix the sink"}`, status: http.StatusCreated},
+			{user: "a", method: http.MethodPost, path: "/todolists/2/todos", body: `{"text":"File the report"}`, status: http.StatusCreated},
+			{user: "a", method: http.MethodPost, path: "/todos/3/reorder", body: `{"before_id":1}`, status: http.StatusOK},
+			{user: "a", method: http.MethodPost, path: "/todos/3/reorder", body: `{"after_id":4}`, status: http.StatusUnprocessableEntity},
+			{user: "a", method: http.MethodGet, path: "/todolists/1/todos", status: http.StatusOK, ids: []int{3, 1, 2}},
+			{user: "a", method: http.MethodGet, path: "/todos?sort=rank&order=asc&todo_list_id=1", status: http.StatusOK, ids: []int{3, 1, 2}},
+		},
+	},
+	{
+		name: "duplicate todos",
+		steps: []step{
+			{user: "a", method: http.MethodPost, path: "/todolists", body: `{"name":"Home","color_id":1,"reject_duplicates":true}`, status: http.StatusCreated},
+			{user: "a", method: http.MethodPost, path: "/todolists", body: `{"name":"Work","color_id":2}`, status: http.StatusCreated},
+			{user: "c", method: http.MethodPost, path: "/todolists", body: `{"name":"Home","color_id":1}`, status: http.StatusCreated},
+			{user: "a", method: http.MethodPost, path: "/todolists/1/todos", body: `{"text":"Buy milk"}`, status: http.StatusCreated},
+			{user: "a", method: http.MethodPost, path: "/todolists/1/todos", body: `{"text":"buy MILK"}`, status: http.StatusConflict,
+				want: map[string]any{"existing_id": 1}},
+			{user: "c", method: http.MethodPost, path: "/todolists/3/todos", body: `{"text":"Buy milk"}`, status: http.StatusCreated},
+			{user: "a", method: http.MethodPost, path: "/todolists/2/todos", body: `{"text":"Buy milk"}`, status: http.StatusCreated},
+			{user: "a", method: http.MethodPost, path: "/todolists/2/todos", body: `{"text":"Buy milk"}`, status: http.StatusCreated},
+			{user: "a", method: http.MethodPost, path: "/todos/4/move", body: `{"todo_list_id":1}`, status: http.StatusConflict, want: map[string]any{"existing_id": 1}},
+			{user: "a", method: http.MethodPost, path: "/todolists/1/todos", body: `{"text":"Water plants"}`, status: http.StatusCreated},
+			{user: "a", method: http.MethodPut, path: "/todos/5", body: `{"text":"Buy Milk"}`, status: http.StatusConflict, want: map[string]any{"existing_id": 1}},
+			{user: "a", method: http.MethodPut, path: "/todolists/1", body: `{"name":"Home","color_id":1}`, status: http.StatusOK,
+				want: map[string]any{"RejectDuplicates": false}},
+			{user: "a", method: http.MethodPut, path: "/todos/5", body: `{"text":"Buy Milk"}`, status: http.StatusOK},
+			{user: "a", method: http.MethodGet, path: "/todos?text=milk", status: http.StatusOK, count: 4},
+		},
+	},
+	{
+		name: "sharing",
+		steps: []step{
+			{user: "a", method: http.MethodPost, path: "/todolists", body: `{"name":"Home","color_id":1}`, status: http.StatusCreated},
+			{user: "a", method: http.MethodPost, path: "/todolists/1/todos", body: `{"text":"Buy milk"}`, status: http.StatusCreated},
+			{user: "c", method: http.MethodPost, path: "/todolists/1/invitations", body: `{"email":"d@b.com","role":"viewer"}`, status: http.StatusNotFound},
+			{user: "a", method: http.MethodPost, path: "/todolists/1/invitations", body: `{"email":"c@b.com","role":"viewer"}`, status: http.StatusCreated},
+			{user: "c", method: http.MethodGet, path: "/invitations", status: http.StatusOK, count: 1},
+			{user: "d", method: http.MethodPost, path: "/invitations/1/accept", status: http.StatusNotFound},
+			{user: "c", method: http.MethodPost, path: "/invitations/1/accept", status: http.StatusOK, want: map[string]any{"ID": 1, "Role": "viewer"}},
+			{user: "a", method: http.MethodPost, path: "/todolists/1/invitations", body: `{"email":"c@b.com","role":"editor"}`, status: http.StatusConflict},
+			{user: "c", method: http.MethodGet, path: "/todolists/1", status: http.StatusOK, want: map[string]any{"Role": "viewer"}},
+			{user: "c", method: http.MethodGet, path: "/todos", status: http.StatusOK, count: 1},
+			{user: "c", method: http.MethodPut, path: "/todos/1", body: `{"text":"Buy oat milk"}`, status: http.StatusForbidden, want: map[string]any{"error_code": 14}},
+			{user: "d", method: http.MethodPut, path: "/todos/1", body: `{"text":"Buy oat milk"}`, status: http.StatusNotFound, want: map[string]any{"error_code": 16}},
+			{user: "d", method: http.MethodGet, path: "/todolists/1", status: http.StatusNotFound},
+			// Reminders are personal, and viewers can set them.
+			{user: "c", method: http.MethodPost, path: "/todos/1/reminders", body: `{"remind_at":"2026-01-03T09:00:00Z"}`, status: http.StatusCreated,
+				want: map[string]any{"ID": 1, "UserID": 2}},
+			{user: "a", method: http.MethodPost, path: "/todos/1/reminders", body: `{"remind_at":"2026-01-03T09:00:00Z"}`, status: http.StatusCreated,
+				want: map[string]any{"ID": 2, "UserID": 1}},
+			{user: "d", method: http.MethodPost, path: "/todos/1/reminders", body: `{"remind_at":"2026-01-03T09:00:00Z"}`, status: http.StatusNotFound},
+			{user: "c", method: http.MethodGet, path: "/todos/1/reminders", status: http.StatusOK, ids: []int{1}},
+			{user: "a", method: http.MethodGet, path: "/todos/1/reminders", status: http.StatusOK, ids: []int{2}},
+			{user: "a", method: http.MethodDelete, path: "/reminders/1", status: http.StatusNotFound},
+			{user: "c", method: http.MethodDelete, path: "/reminders/2", status: http.StatusNotFound},
+			{user: "c", method: http.MethodDelete, path: "/reminders/1", status: http.StatusOK},
+			{user: "c", method: http.MethodPut, path: "/todolists/1/members/2", body: `{"role":"admin"}`, status: http.StatusForbidden},
+			{user: "a", method: http.MethodPut, path: "/todolists/1/members/2", body: `{"role":"editor"}`, status: http.StatusOK},
+			{user: "c", method: http.MethodPut, path: "/todos/1", body: `{"text":"Buy oat milk"}`, status: http.StatusOK},
+			{user: "c", method: http.MethodDelete, path: "/todolists/1", status: http.StatusForbidden},
+			{user: "a", method: http.MethodGet, path: "/todolists/1/members", status: http.StatusOK, count: 2},
+			{user: "c", method: http.MethodDelete, path: "/todolists/1/members/2", status: http.StatusOK},
+			{user: "c", method: http.MethodGet, path: "/todolists/1", status: http.StatusNotFound},
+		},
+	},
+	{
+		name: "share links",
+		steps: []step{
+			{user: "a", method: http.MethodPost, path: "/todolists", body: `{"name":"Home","color_id":1}`, status: http.StatusCreated},
+			{user: "a", method: http.MethodPost, path: "/todolists/1/todos", body: `{"text":"Buy milk","notes":"*skimmed*"}`, status: http.StatusCreated},
+			{user: "a", method: http.MethodPost, path: "/todolists/1/sharelinks", body: `{"expires_at":"2026-01-02T03:00:00Z"}`, status: http.StatusUnprocessableEntity},
+			{user: "c", method: http.MethodPost, path: "/todolists/1/sharelinks", body: `{}`, status: http.StatusNotFound},
+			{user: "a", method: http.MethodPost, path: "/todolists/1/sharelinks", body: `{"expires_at":"2026-01-02T04:00:00Z"}`, status: http.StatusCreated,
+				want: map[string]any{"ExpiresAt": "2026-01-02T04:00:00Z", "HasPassword": false}},
+			{method: http.MethodGet, path: "/shared/{Slug}", status: http.StatusOK, want: map[string]any{
+				"name": "Home",
+				"todos": []map[string]any{{
+					"text": "Buy milk", "completed": false, "status": "To do", "priority": 0, "notes": "*skimmed*",
+					"due_at": nil, "all_day": false, "completed_at": nil, "items_total": 0, "items_done": 0,
+				}},
+			}},
+			{method: http.MethodGet, path: "/shared/{Slug}", advance: time.Hour, status: http.StatusGone, want: map[string]any{"error_code": 83}},
+			{method: http.MethodGet, path: "/shared/nope", status: http.StatusNotFound},
+			{user: "a", method: http.MethodPost, path: "/todolists/1/sharelinks", body: `{"password":"hunter22"}`, status: http.StatusCreated,
+				want: map[string]any{"ExpiresAt": nil, "HasPassword": true}},
+			{method: http.MethodGet, path: "/shared/{Slug}", status: http.StatusUnauthorized, want: map[string]any{"error_code": 84}},
+			{method: http.MethodGet, path: "/shared/{Slug}", headers: map[string]string{"X-Share-Password": "hunter2"}, status: http.StatusUnauthorized,
+				want: map[string]any{"error_code": 7}},
+			{method: http.MethodGet, path: "/shared/{Slug}", headers: map[string]string{"X-Share-Password": "hunter22"}, status: http.StatusOK,
+				want: map[string]any{"name": "Home"}},
+			{user: "a", method: http.MethodGet, path: "/todolists/1/sharelinks", status: http.StatusOK, ids: []int{1, 2}},
+			{user: "c", method: http.MethodDelete, path: "/sharelinks/2", status: http.StatusNotFound},
+			{user: "a", method: http.MethodDelete, path: "/sharelinks/2", status: http.StatusOK, want: map[string]any{"Views": 1}},
+			{method: http.MethodGet, path: "/shared/{Slug}", headers: map[string]string{"X-Share-Password": "hunter22"}, status: http.StatusNotFound},
+		},
+	},
+	{
+		name: "assignees and comments",
+		steps: []step{
+			{user: "a", method: http.MethodPost, path: "/todolists", body: `{"name":"Home","color_id":1}`, status: http.StatusCreated},
+			{user: "a", method: http.MethodPost, path: "/todolists/1/todos", body: `{"text":"Buy milk"}`, status: http.StatusCreated},
+			{user: "a", method: http.MethodPost, path: "/todolists/1/todos", body: `{"text":"Water plants","assignee_id":1}`, status: http.StatusCreated,
+				want: map[string]any{"AssigneeID": 1}},
+			{user: "a", method: http.MethodPost, path: "/todolists/1/invitations", body: `{"email":"c@b.com","role":"editor"}`, status: http.StatusCreated},
+			{user: "c", method: http.MethodPost, path: "/invitations/1/accept", status: http.StatusOK},
+			{user: "a", method: http.MethodPut, path: "/todos/1", body: `{"text":"Buy milk","assignee_id":3}`, status: http.StatusUnprocessableEntity,
+				want: map[string]any{"errors": []map[string]any{{"field": "assignee_id", "message": "must be the owner or a member of the todo list"}}}},
+			{user: "a", method: http.MethodPut, path: "/todos/1", body: `{"text":"Buy milk","assignee_id":2}`, status: http.StatusOK, want: map[string]any{"AssigneeID": 2}},
+			{user: "c", method: http.MethodGet, path: "/todos?assignee=me", status: http.StatusOK, ids: []int{1}},
+			{user: "a", method: http.MethodGet, path: "/todos?assignee=none", status: http.StatusOK, want: map[string]any{"data": []any{}}},
+			{user: "a", method: http.MethodGet, path: "/todos?assignee=1", status: http.StatusOK, ids: []int{2}},
+			{user: "c", method: http.MethodPost, path: "/todos/1/comments", body: `{"body":"On it, @a@b.com"}`, status: http.StatusCreated,
+				want: map[string]any{"ID": 1, "TodoID": 1, "AuthorID": 2, "Body": "On it, @a@b.com"}},
+			{user: "d", method: http.MethodPost, path: "/todos/1/comments", body: `{"body":"Me too"}`, status: http.StatusNotFound},
+			{user: "a", method: http.MethodPost, path: "/todos/1/comments", body: `{"body":"Thanks"}`, status: http.StatusCreated},
+			{user: "a", method: http.MethodGet, path: "/todos/1/comments", status: http.StatusOK, ids: []int{1, 2}},
+			{user: "a", method: http.MethodPut, path: "/comments/1", body: `{"body":"Done"}`, status: http.StatusForbidden},
+			{user: "c", method: http.MethodPut, path: "/comments/1", body: `{"body":"Done"}`, status: http.StatusOK, want: map[string]any{"Body": "Done"}},
+			{user: "c", method: http.MethodDelete, path: "/comments/1", status: http.StatusOK},
+			{user: "c", method: http.MethodGet, path: "/todos/1/comments", status: http.StatusOK, ids: []int{2}},
+		},
+	},
+	{
+		name: "organizations",
+		steps: []step{
+			{user: "a", method: http.MethodPost, path: "/organizations", body: `{"name":"Acme"}`, status: http.StatusCreated, want: map[string]any{"ID": 1}},
+			{user: "c", method: http.MethodPost, path: "/organizations", body: `{"name":"Globex"}`, status: http.StatusCreated, want: map[string]any{"ID": 2}},
+			{user: "a", method: http.MethodPost, path: "/todolists", body: `{"name":"Home","color_id":1}`, status: http.StatusCreated},
+			{user: "c", method: http.MethodPost, path: "/todolists", headers: map[string]string{"X-Org-ID": "2"}, body: `{"name":"Plans","color_id":1}`,
+				status: http.StatusCreated},
+			{user: "a", method: http.MethodPost, path: "/todolists", headers: map[string]string{"X-Org-ID": "1"}, body: `{"name":"Roadmap","color_id":1}`,
+				status: http.StatusCreated, want: map[string]any{"ID": 3, "OrganizationID": 1}},
+			{user: "a", method: http.MethodPost, path: "/todolists/3/todos", headers: map[string]string{"X-Org-ID": "1"}, body: `{"text":"Ship it"}`,
+				status: http.StatusCreated},
+			{user: "a", method: http.MethodGet, path: "/todolists?todos=omit", headers: map[string]string{"X-Org-ID": "1"}, status: http.StatusOK, ids: []int{3}},
+			{user: "a", method: http.MethodGet, path: "/todolists?todos=omit", status: http.StatusOK, ids: []int{1}},
+			{user: "a", method: http.MethodGet, path: "/todos", headers: map[string]string{"X-Org-ID": "1"}, status: http.StatusOK, ids: []int{1}},
+			{user: "a", method: http.MethodGet, path: "/todos", status: http.StatusOK, want: map[string]any{"data": []any{}}},
+			// Personal lists and the lists of other organizations are invisible under X-Org-ID, and organization lists without it.
+			{user: "a", method: http.MethodGet, path: "/todolists/1", headers: map[string]string{"X-Org-ID": "1"}, status: http.StatusNotFound},
+			{user: "a", method: http.MethodGet, path: "/todolists/2", headers: map[string]string{"X-Org-ID": "1"}, status: http.StatusNotFound},
+			{user: "a", method: http.MethodGet, path: "/todolists/3", status: http.StatusNotFound},
+			{user: "a", method: http.MethodPut, path: "/todos/1", body: `{"text":"Ship it now"}`, status: http.StatusNotFound},
+			{user: "a", method: http.MethodGet, path: "/todolists", headers: map[string]string{"X-Org-ID": "2"}, status: http.StatusNotFound},
+			{user: "a", method: http.MethodPost, path: "/organizations/1/members", body: `{"email":"c@b.com","role":"member"}`, status: http.StatusCreated},
+			{user: "a", method: http.MethodPost, path: "/organizations/1/members", body: `{"email":"d@b.com","role":"member"}`, status: http.StatusForbidden,
+				want: map[string]any{"error_code": 98}},
+			{user: "c", method: http.MethodGet, path: "/todolists/3", headers: map[string]string{"X-Org-ID": "1"}, status: http.StatusOK,
+				want: map[string]any{"Role": "editor"}},
+			{user: "c", method: http.MethodGet, path: "/todolists/2", headers: map[string]string{"X-Org-ID": "1"}, status: http.StatusNotFound},
+			{user: "c", method: http.MethodPost, path: "/todolists", headers: map[string]string{"X-Org-ID": "1"}, body: `{"name":"Hiring","color_id":2}`,
+				status: http.StatusCreated},
+			{user: "c", method: http.MethodPost, path: "/todolists", headers: map[string]string{"X-Org-ID": "1"}, body: `{"name":"Budget","color_id":3}`,
+				status: http.StatusForbidden, want: map[string]any{"error_code": 98}},
+			{user: "c", method: http.MethodPost, path: "/todolists", body: `{"name":"Budget","color_id":3}`, status: http.StatusCreated},
+			{user: "a", method: http.MethodDelete, path: "/organizations/1/members/2", status: http.StatusOK},
+			{user: "c", method: http.MethodGet, path: "/todolists/3", headers: map[string]string{"X-Org-ID": "1"}, status: http.StatusNotFound},
+			{user: "a", method: http.MethodPost, path: "/organizations/1/members", body: `{"email":"d@b.com","role":"member"}`, status: http.StatusCreated},
+		},
+	},
+	{
+		name: "folders",
+		steps: []step{
+			{user: "a", method: http.MethodPost, path: "/folders", body: `{"name":"Work"}`, status: http.StatusCreated, want: map[string]any{"ID": 1, "ParentID": 0}},
+			{user: "a", method: http.MethodPost, path: "/folders", body: `{"name":"Projects","parent_id":1}`, status: http.StatusCreated, want: map[string]any{"ParentID": 1}},
+			{user: "a", method: http.MethodPost, path: "/folders", body: `{"name":"Old","parent_id":9}`, status: http.StatusUnprocessableEntity},
+			{user: "c", method: http.MethodPost, path: "/folders", body: `{"name":"Mine","parent_id":1}`, status: http.StatusUnprocessableEntity},
+			{user: "c", method: http.MethodGet, path: "/folders/1", status: http.StatusNotFound},
+			{user: "a", method: http.MethodPut, path: "/folders/1", body: `{"name":"Work","parent_id":2}`, status: http.StatusUnprocessableEntity},
+			{user: "a", method: http.MethodPost, path: "/todolists", body: `{"name":"Launch","color_id":1,"folder_id":2}`, status: http.StatusCreated,
+				want: map[string]any{"FolderID": 2}},
+			{user: "a", method: http.MethodPost, path: "/todolists", body: `{"name":"Home","color_id":1}`, status: http.StatusCreated},
+			{user: "a", method: http.MethodGet, path: "/todolists?view=tree", status: http.StatusOK},
+			{user: "a", method: http.MethodGet, path: "/folders", status: http.StatusOK, ids: []int{2, 1}},
+			{user: "a", method: http.MethodDelete, path: "/folders/1", status: http.StatusOK},
+			{user: "a", method: http.MethodGet, path: "/folders/2", status: http.StatusOK, want: map[string]any{"ParentID": 0}},
+			{user: "a", method: http.MethodDelete, path: "/folders/2?cascade=true", status: http.StatusOK},
+			{user: "a", method: http.MethodGet, path: "/todolists?todos=omit", status: http.StatusOK, ids: []int{2}},
+		},
+	},
+	{
+		name: "webhook URL",
+		steps: []step{
+			{user: "a", method: http.MethodPatch, path: "/me", body: `{"webhook_url":"http://127.0.0.1:8080/hook"}`, status: http.StatusUnprocessableEntity,
+				want: map[string]any{"errors": []map[string]any{{"field": "webhook_url", "message": "must point to a public address"}}}},
+			{user: "a", method: http.MethodPatch, path: "/me", body: `{"webhook_url":"http://[::ffff:10.0.0.1]/hook"}`, status: http.StatusUnprocessableEntity},
+			{user: "a", method: http.MethodPatch, path: "/me", body: `{"webhook_url":"ftp://93.184.215.14/hook"}`, status: http.StatusUnprocessableEntity},
+			{user: "a", method: http.MethodPatch, path: "/me", body: `{"webhook_url":"https://93.184.215.14/hook"}`, status: http.StatusOK},
+			{user: "a", method: http.MethodPatch, path: "/me", body: `{"webhook_url":""}`, status: http.StatusOK},
+		},
+	},
+}
+
+// TestHandlers runs every test against every backend, in parallel and each on a new App, so the results
+// also show that Apps share no state.
+func TestHandlers(t *testing.T) {
+	t.Parallel()
+
+	for name, newApp := range backends {
+		for _, tt := range tests {
+			t.Run(name+"/"+tt.name, func(t *testing.T) {
+				t.Parallel()
+
+				var elapsed atomic.Int64
+				a := newApp(t, func() time.Time { return now.Add(time.Duration(elapsed.Load())) })
+				t.Cleanup(func() { a.Close() })
+
+				e := echo.New()
+				controllers.New(a).RegisterRoutes(e)
+
+				tokens := map[string]string{
+					"a": signup(t, e, "a@b.com"),
+					"c": signup(t, e, "c@b.com"),
+					"d": signup(t, e, "d@b.com"),
+				}
+
+				fields := map[string]string{}
+				fill := func(s string) string {
+					return placeholder.ReplaceAllStringFunc(s, func(name string) string {
+						if value, ok := fields[name[1:len(name)-1]]; ok {
+							return value
+						}
+						return name
+					})
+				}
+
+				for i, s := range tt.steps {
+					elapsed.Add(int64(s.advance))
+
+					headers := make(map[string]string, len(s.headers))
+					for key, value := range s.headers {
+						headers[key] = fill(value)
+					}
+
+					rec := serve(e, s.method, fill(s.path), fill(s.body), tokens[s.user], headers)
+					if rec.Code != s.status {
+						t.Fatalf("step %d: %s %s = %d, want %d: %s", i, s.method, s.path, rec.Code, s.status, rec.Body)
+					}
+
+					var body any
+					if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil && (s.want != nil || s.count != 0 || s.ids != nil) {
+						t.Fatalf("step %d: %s %s: %v", i, s.method, s.path, err)
+					}
+
+					got, _ := body.(map[string]any)
+					for field, value := range got {
+						if value, ok := value.(string); ok {
+							fields[field] = value
+						}
+					}
+
+					data, _ := got["data"].([]any)
+					if array, ok := body.([]any); ok {
+						data = array
+					}
+
+					if s.count != 0 && len(data) != s.count {
+						t.Errorf("step %d: %s %s: got %d elements, want %d: %s", i, s.method, s.path, len(data), s.count, rec.Body)
+					}
+
+					if s.ids != nil {
+						ids := make([]int, len(data))
+						for j, element := range data {
+							id, _ := element.(map[string]any)["ID"].(float64)
+							ids[j] = int(id)
+						}
+						if !slices.Equal(ids, s.ids) {
+							t.Errorf("step %d: %s %s: got IDs %v, want %v", i, s.method, s.path, ids, s.ids)
+						}
+					}
+
+					for field, value := range s.want {
+						if want := jsonValue(t, value); !reflect.DeepEqual(got[field], want) {
+							t.Errorf("step %d: %s %s: %s = %v, want %v", i, s.method, s.path, field, got[field], want)
+						}
+					}
+				}
+			})
+		}
+	}
+}
+
+// signup creates a user with email and returns their token.
+func signup(t *testing.T, e *echo.Echo, email string) string {
+	t.Helper()
+
+	rec := serve(e, http.MethodPost, "/signup", `{"email":"`+email+`","password":"password1"}`, "", nil)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("signup %s = %d: %s", email, rec.Code, rec.Body)
+	}
+
+	var token string
+	if err := json.Unmarshal(rec.Body.Bytes(), &token); err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func serve(e *echo.Echo, method, path, body, token string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	if token != "" {
+		req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+	}
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+// cursor encodes a pagination cursor the way the API does.
+func cursor(json string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(json))
+}
+
+// jsonValue returns v as it reads once encoded to JSON and decoded, e.g. a float64 for an int.
+func jsonValue(t *testing.T, v any) any {
+	t.Helper()
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded any
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	return decoded
+}
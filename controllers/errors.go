@@ -0,0 +1,149 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/marouane-ach/todo-go/apperr"
+	"github.com/marouane-ach/todo-go/dtos"
+)
+
+// MIMEApplicationProblemJSON is the media type of RFC 7807 problem details.
+const MIMEApplicationProblemJSON = "application/problem+json"
+
+// handleError is the Echo HTTPErrorHandler. Every failure is rendered as an ErrorDTO, or as
+// RFC 7807 problem details when the config asks for it or the client accepts application/problem+json.
+func (ctl *Controller) handleError(err error, c echo.Context) {
+	if c.Response().Committed {
+		return
+	}
+
+	appErr := toAppError(err)
+	if appErr.Status() >= http.StatusInternalServerError {
+		ctl.app.Logger.Error("request failed",
+			"method", c.Request().Method, "path", c.Request().URL.Path, "error", err)
+	}
+
+	if c.Request().Method == http.MethodHead {
+		err = c.NoContent(appErr.Status())
+	} else if ctl.app.Config.ProblemJSON || strings.Contains(c.Request().Header.Get(echo.HeaderAccept), MIMEApplicationProblemJSON) {
+		c.Response().Header().Set(echo.HeaderContentType, MIMEApplicationProblemJSON)
+		err = c.JSON(appErr.Status(), &dtos.ProblemDTO{
+			Type:       fmt.Sprintf("urn:todo-app:error:%d", appErr.Code),
+			Title:      http.StatusText(appErr.Status()),
+			Status:     appErr.Status(),
+			Detail:     appErr.Message(),
+			Instance:   c.Request().URL.Path,
+			ErrorCode:  int(appErr.Code),
+			Errors:     fieldErrorDTOs(appErr.Fields),
+			ExistingID: appErr.ExistingID,
+		})
+	} else {
+		err = c.JSON(appErr.Status(), &dtos.ErrorDTO{
+			ErrorCode:   int(appErr.Code),
+			Description: appErr.Message(),
+			Errors:      fieldErrorDTOs(appErr.Fields),
+			ExistingID:  appErr.ExistingID,
+		})
+	}
+
+	if err != nil {
+		ctl.app.Logger.Error("could not write error response", "error", err)
+	}
+}
+
+func fieldErrorDTOs(fields []apperr.FieldError) []dtos.FieldErrorDTO {
+	if len(fields) == 0 {
+		return nil
+	}
+
+	dtoFields := make([]dtos.FieldErrorDTO, len(fields))
+	for i, f := range fields {
+		dtoFields[i] = dtos.FieldErrorDTO{Field: f.Field, Message: f.Message}
+	}
+	return dtoFields
+}
+
+// toAppError converts any error returned by a handler or by Echo itself into an *apperr.Error.
+func toAppError(err error) *apperr.Error {
+	var appErr *apperr.Error
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+
+	var httpErr *echo.HTTPError
+	if errors.As(err, &httpErr) {
+		switch httpErr.Code {
+		case http.StatusNotFound:
+			return apperr.Wrap(apperr.RouteNotFound, err)
+		case http.StatusMethodNotAllowed:
+			return apperr.Wrap(apperr.MethodNotAllowed, err)
+		case http.StatusUnsupportedMediaType:
+			return apperr.Wrap(apperr.UnsupportedMediaType, err)
+		case http.StatusRequestEntityTooLarge:
+			return apperr.Wrap(apperr.RequestEntityTooLarge, err)
+		}
+
+		if httpErr.Code < http.StatusInternalServerError {
+			return apperr.Wrap(apperr.InvalidRequest, err).WithDescription("%v", httpErr.Message)
+		}
+	}
+
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return apperr.Wrap(apperr.RequestTimeout, err)
+	case errors.Is(err, context.Canceled):
+		return apperr.Wrap(apperr.RequestCancelled, err)
+	}
+
+	return apperr.Wrap(apperr.Internal, err)
+}
+
+// withRequestContext bounds the request context by the configured request timeout. A handler
+// failing after its context ended is reported as a timeout or a cancellation, whatever error it
+// returned, because drivers don't always wrap the context error.
+func (ctl *Controller) withRequestContext(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var ctx context.Context
+		var cancel context.CancelFunc
+		if ctl.app.Config.RequestTimeout > 0 {
+			ctx, cancel = context.WithTimeout(c.Request().Context(), ctl.app.Config.RequestTimeout)
+		} else {
+			ctx, cancel = context.WithCancel(c.Request().Context())
+		}
+		defer cancel()
+
+		c.SetRequest(c.Request().WithContext(ctx))
+
+		err := next(c)
+		if err == nil {
+			return nil
+		}
+
+		switch ctx.Err() {
+		case context.DeadlineExceeded:
+			return &apperr.Error{Code: apperr.RequestTimeout, Err: err}
+		case context.Canceled:
+			return &apperr.Error{Code: apperr.RequestCancelled, Err: err}
+		}
+
+		return err
+	}
+}
+
+// bind decodes the request into dto and validates it.
+func bind(c echo.Context, dto any) error {
+	if err := c.Bind(dto); err != nil {
+		appErr := toAppError(err)
+		if appErr.Code == apperr.Internal {
+			return apperr.Wrap(apperr.InvalidRequest, err)
+		}
+		return appErr
+	}
+
+	return c.Validate(dto)
+}
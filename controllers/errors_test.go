@@ -0,0 +1,63 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/marouane-ach/todo-go/app"
+	"github.com/marouane-ach/todo-go/apperr"
+	"github.com/marouane-ach/todo-go/config"
+	"github.com/marouane-ach/todo-go/store"
+)
+
+func TestToAppError(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		err  error
+		code apperr.Code
+	}{
+		{apperr.New(apperr.TodoNotFound), apperr.TodoNotFound},
+		{fmt.Errorf("in tx: %w", apperr.New(apperr.Forbidden)), apperr.Forbidden},
+		{echo.ErrNotFound, apperr.RouteNotFound},
+		{echo.ErrMethodNotAllowed, apperr.MethodNotAllowed},
+		{echo.ErrUnsupportedMediaType, apperr.UnsupportedMediaType},
+		{echo.ErrStatusRequestEntityTooLarge, apperr.RequestEntityTooLarge},
+		{echo.NewHTTPError(http.StatusBadRequest, "bad"), apperr.InvalidRequest},
+		{echo.ErrBadGateway, apperr.Internal},
+		{fmt.Errorf("query: %w", context.DeadlineExceeded), apperr.RequestTimeout},
+		{fmt.Errorf("query: %w", context.Canceled), apperr.RequestCancelled},
+		{errors.New("driver: bad connection"), apperr.Internal},
+	}
+
+	for _, tt := range tests {
+		if got := toAppError(tt.err).Code; got != tt.code {
+			t.Errorf("toAppError(%v) = %d, want %d", tt.err, got, tt.code)
+		}
+	}
+}
+
+func TestWithRequestContext(t *testing.T) {
+	t.Parallel()
+
+	cfg := config.Default()
+	cfg.RequestTimeout = 10 * time.Millisecond
+	ctl := New(app.NewWithStore(cfg, store.NewMemory(nil)))
+
+	// Drivers don't always wrap the context error, so the handler returns an unrelated one.
+	handler := ctl.withRequestContext(func(c echo.Context) error {
+		<-c.Request().Context().Done()
+		return errors.New("driver: bad connection")
+	})
+
+	err := handler(echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/", nil), httptest.NewRecorder()))
+	if got := toAppError(err); got.Code != apperr.RequestTimeout || got.Status() != http.StatusGatewayTimeout {
+		t.Errorf("error = %v, want %d with status %d", err, apperr.RequestTimeout, http.StatusGatewayTimeout)
+	}
+}
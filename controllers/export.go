@@ -0,0 +1,158 @@
+package controllers
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/marouane-ach/todo-go/apperr"
+	"github.com/marouane-ach/todo-go/dtos"
+	"github.com/marouane-ach/todo-go/models"
+)
+
+// exportColumns are the columns of CSV exports, in order.
+var exportColumns = []string{
+	"text", "completed", "status", "priority", "tags", "notes", "due_at", "all_day", "completed_at", "items_total", "items_done",
+}
+
+// Export Todo List godoc
+// @Summary      Export a todo list
+// @Description  Returns the todo list with all of its todos in order, as a JSON file or, with `format=csv`, a CSV file with one row per todo.
+// @Description  Todos include their status, tags and the Markdown source of their notes. Any member of the list can export it.
+// @Tags         Todo Lists
+// @Param        id     path  int    true  "Todo List ID"
+// @Param        format query string false "Format of the file, json by default" Enums(json, csv)
+// @Accept       json
+// @Produce      json
+// @Produce      text/csv
+// @Success      200  {object}	dtos.ExportedTodoListDTO
+// @Failure      400  {object}  dtos.ErrorDTO
+// @Failure      401  {object}  dtos.ErrorDTO
+// @Failure      404  {object}  dtos.ErrorDTO
+// @Failure      422  {object}  dtos.ErrorDTO
+// @Failure      500  {object}  dtos.ErrorDTO
+// @Security	 BearerAuth
+// @Router       /todolists/{id}/export [get]
+func (ctl *Controller) ExportTodoList(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	user, _, err := ctl.authenticate(c, ctx)
+	if err != nil {
+		return err
+	}
+
+	var query dtos.ExportQueryDTO
+	if err := bind(c, &query); err != nil {
+		return err
+	}
+
+	todoList, err := authorizedTodoList(ctx, ctl.app.Store, user, c.Param("id"), models.RoleViewer)
+	if err != nil {
+		return err
+	}
+
+	color, err := ctl.app.Store.Colors.GetByID(ctx, todoList.ColorID)
+	if err != nil {
+		return apperr.Wrap(apperr.TodoListFetchFailed, err)
+	}
+
+	statuses, err := ctl.app.Store.Statuses.ListByTodoList(ctx, todoList.ID)
+	if err != nil {
+		return apperr.Wrap(apperr.TodoListFetchFailed, err)
+	}
+
+	statusNames := make(map[int]string, len(statuses))
+	for _, status := range statuses {
+		statusNames[status.ID] = status.Name
+	}
+
+	exported := dtos.ExportedTodoListDTO{Name: todoList.Name, Color: color.ColorHex, Todos: []dtos.ExportedTodoDTO{}}
+	for _, todo := range todoList.Todos {
+		tags := make([]string, len(todo.Tags))
+		for i, tag := range todo.Tags {
+			tags[i] = tag.Name
+		}
+
+		exported.Todos = append(exported.Todos, dtos.ExportedTodoDTO{
+			Text:        todo.Text,
+			Completed:   todo.Completed,
+			Status:      statusNames[todo.StatusID],
+			Priority:    todo.Priority,
+			Tags:        tags,
+			Notes:       todo.Notes,
+			DueAt:       todo.DueAt,
+			AllDay:      todo.AllDay,
+			CompletedAt: todo.CompletedAt,
+			ItemsTotal:  todo.ItemsTotal,
+			ItemsDone:   todo.ItemsDone,
+		})
+	}
+
+	filename := fmt.Sprintf("todo-list-%d.%s", todoList.ID, cmp.Or(query.Format, "json"))
+	c.Response().Header().Set(echo.HeaderContentDisposition, mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+
+	if query.Format != "csv" {
+		return c.JSON(http.StatusOK, exported)
+	}
+
+	var b bytes.Buffer
+	if err := writeExportCSV(&b, exported.Todos); err != nil {
+		return apperr.Wrap(apperr.Internal, err)
+	}
+	return c.Blob(http.StatusOK, "text/csv; charset=utf-8", b.Bytes())
+}
+
+// writeExportCSV writes todos as the rows of a CSV file with a header.
+func writeExportCSV(out io.Writer, todos []dtos.ExportedTodoDTO) error {
+	w := csv.NewWriter(out)
+	if err := w.Write(exportColumns); err != nil {
+		return err
+	}
+
+	for _, todo := range todos {
+		record := []string{
+			csvCell(todo.Text),
+			strconv.FormatBool(todo.Completed),
+			csvCell(todo.Status),
+			strconv.Itoa(todo.Priority),
+			csvCell(strings.Join(todo.Tags, ", ")),
+			csvCell(todo.Notes),
+			csvTime(todo.DueAt),
+			strconv.FormatBool(todo.AllDay),
+			csvTime(todo.CompletedAt),
+			strconv.Itoa(todo.ItemsTotal),
+			strconv.Itoa(todo.ItemsDone),
+		}
+		if err := w.Write(record); err != nil {
+			return err
+		}
+	}
+
+	w.Flush()
+	return w.Error()
+}
+
+// csvCell returns s as a CSV cell. Text starting like a formula gets a leading quote so that spreadsheets
+// opening the file show it rather than evaluate it.
+func csvCell(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+// csvTime returns t as an RFC 3339 time in UTC, or an empty cell.
+func csvTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,34 @@
+package controllers
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/marouane-ach/todo-go/dtos"
+)
+
+func TestWriteExportCSV(t *testing.T) {
+	t.Parallel()
+
+	due := time.Date(2026, time.March, 29, 9, 30, 0, 0, time.FixedZone("CEST", 2*3600))
+	todos := []dtos.ExportedTodoDTO{
+		{
+			Text: "Buy milk", Status: "To do", Priority: 2, Tags: []string{"errands", "home"},
+			Notes: "- skimmed\n- \"organic\", if any", DueAt: &due, ItemsTotal: 2, ItemsDone: 1,
+		},
+		{Text: "=HYPERLINK(\"http://example.com\")", Completed: true, Status: "Done", Tags: []string{}, CompletedAt: &due},
+	}
+
+	var b strings.Builder
+	if err := writeExportCSV(&b, todos); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "text,completed,status,priority,tags,notes,due_at,all_day,completed_at,items_total,items_done\n" +
+		"Buy milk,false,To do,2,\"errands, home\",\"'- skimmed\n- \"\"organic\"\", if any\",2026-03-29T07:30:00Z,false,,2,1\n" +
+		"\"'=HYPERLINK(\"\"http://example.com\"\")\",true,Done,0,,,,false,2026-03-29T07:30:00Z,0,0\n"
+	if got := b.String(); got != want {
+		t.Errorf("writeExportCSV wrote\n%s\nwant\n%s", got, want)
+	}
+}
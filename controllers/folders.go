@@ -0,0 +1,411 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/marouane-ach/todo-go/apperr"
+	"github.com/marouane-ach/todo-go/dtos"
+	"github.com/marouane-ach/todo-go/models"
+	"github.com/marouane-ach/todo-go/store"
+)
+
+// Create Folder godoc
+// @Summary      Create a folder
+// @Description  Accepts a `name` and the `parent_id` of the folder to nest it in, 0 for the root, as a JSON object and returns the created folder.
+// @Description  With `X-Org-ID`, the folder belongs to the organization and its members share it.
+// @Tags         Folders
+// @Param        folder body dtos.FolderDTO true "The folder's name and parent"
+// @Param        X-Org-ID header int false "ID of the organization to work in"
+// @Accept       json
+// @Produce      json
+// @Success      201  {object}	models.Folder
+// @Failure      400  {object}  dtos.ErrorDTO
+// @Failure      401  {object}  dtos.ErrorDTO
+// @Failure      404  {object}  dtos.ErrorDTO
+// @Failure      422  {object}  dtos.ErrorDTO
+// @Failure      500  {object}  dtos.ErrorDTO
+// @Security	 BearerAuth
+// @Router       /folders [post]
+func (ctl *Controller) CreateFolder(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	user, _, err := ctl.authenticate(c, ctx)
+	if err != nil {
+		return err
+	}
+
+	folderDTO := new(dtos.FolderDTO)
+	if err = bind(c, folderDTO); err != nil {
+		return err
+	}
+
+	folder := &models.Folder{Name: folderDTO.Name, ParentID: folderDTO.ParentID, OwnerID: user.ID, OrganizationID: orgID(user)}
+	err = ctl.app.Store.InTx(ctx, func(tx *store.Store) error {
+		if err := checkParent(ctx, tx, user, folder); err != nil {
+			return err
+		}
+		return tx.Folders.Create(ctx, folder)
+	})
+	if err != nil {
+		return apperr.Wrap(apperr.FolderCreateFailed, err)
+	}
+
+	return c.JSON(http.StatusCreated, folder)
+}
+
+// List Folders godoc
+// @Summary      List the user's folders
+// @Description  Returns the folders of the user, or of the organization with `X-Org-ID`, by name. `GET /todolists?view=tree` arranges
+// @Description  them in a tree along with the todo lists.
+// @Tags         Folders
+// @Param        X-Org-ID header int false "ID of the organization to work in"
+// @Accept       json
+// @Produce      json
+// @Success      200  {array}	models.Folder
+// @Failure      400  {object}  dtos.ErrorDTO
+// @Failure      401  {object}  dtos.ErrorDTO
+// @Failure      404  {object}  dtos.ErrorDTO
+// @Failure      500  {object}  dtos.ErrorDTO
+// @Security	 BearerAuth
+// @Router       /folders [get]
+func (ctl *Controller) ListFolders(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	user, _, err := ctl.authenticate(c, ctx)
+	if err != nil {
+		return err
+	}
+
+	folders, err := ctl.app.Store.Folders.List(ctx, store.FolderQuery{UserID: user.ID, OrganizationID: orgID(user)})
+	if err != nil {
+		return apperr.Wrap(apperr.FolderFetchFailed, err)
+	}
+
+	return c.JSON(http.StatusOK, folders)
+}
+
+// Get Folder godoc
+// @Summary      Get a folder by ID
+// @Description  Returns the folder.
+// @Tags         Folders
+// @Param        id path int true "Folder ID"
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}	models.Folder
+// @Failure      400  {object}  dtos.ErrorDTO
+// @Failure      401  {object}  dtos.ErrorDTO
+// @Failure      404  {object}  dtos.ErrorDTO
+// @Failure      500  {object}  dtos.ErrorDTO
+// @Security	 BearerAuth
+// @Router       /folders/{id} [get]
+func (ctl *Controller) GetFolder(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	user, _, err := ctl.authenticate(c, ctx)
+	if err != nil {
+		return err
+	}
+
+	folder, err := authorizedFolder(ctx, ctl.app.Store, user, c.Param("id"))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, folder)
+}
+
+// Update Folder godoc
+// @Summary      Rename or move a folder
+// @Description  Accepts the `name` and the `parent_id` of the folder, 0 for the root, as a JSON object and returns the folder.
+// @Description  The folders and todo lists in it move along with it. A folder can't be nested in itself or in one of its subfolders.
+// @Tags         Folders
+// @Param        folder body dtos.FolderDTO true "The folder's name and parent"
+// @Param        id path int true "Folder ID"
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}	models.Folder
+// @Failure      400  {object}  dtos.ErrorDTO
+// @Failure      401  {object}  dtos.ErrorDTO
+// @Failure      404  {object}  dtos.ErrorDTO
+// @Failure      422  {object}  dtos.ErrorDTO
+// @Failure      500  {object}  dtos.ErrorDTO
+// @Security	 BearerAuth
+// @Router       /folders/{id} [put]
+func (ctl *Controller) UpdateFolder(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	user, _, err := ctl.authenticate(c, ctx)
+	if err != nil {
+		return err
+	}
+
+	folderDTO := new(dtos.FolderDTO)
+	if err = bind(c, folderDTO); err != nil {
+		return err
+	}
+
+	var folder *models.Folder
+	err = ctl.app.Store.InTx(ctx, func(tx *store.Store) error {
+		var err error
+		if folder, err = authorizedFolder(ctx, tx, user, c.Param("id")); err != nil {
+			return err
+		}
+
+		folder.Name, folder.ParentID = folderDTO.Name, folderDTO.ParentID
+		folder.UpdatedAt = ctl.app.Now()
+
+		if err := checkParent(ctx, tx, user, folder); err != nil {
+			return err
+		}
+		return tx.Folders.Update(ctx, folder)
+	})
+	if errors.Is(err, store.ErrNotFound) {
+		return apperr.New(apperr.FolderNotFound)
+	} else if err != nil {
+		return apperr.Wrap(apperr.FolderUpdateFailed, err)
+	}
+
+	return c.JSON(http.StatusOK, folder)
+}
+
+// Delete Folder godoc
+// @Summary      Delete a folder
+// @Description  Deletes the folder and returns it. Its todo lists and the folders nested in it move to the root, unless `cascade` is true:
+// @Description  then the nested folders and the todo lists of all of them are deleted too, which takes owning every one of those lists.
+// @Tags         Folders
+// @Param        id      path  int    true  "Folder ID"
+// @Param        cascade query string false "Whether to delete the content of the folder rather than move it to the root" Enums(true, false) default(false)
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}	models.Folder
+// @Failure      400  {object}  dtos.ErrorDTO
+// @Failure      401  {object}  dtos.ErrorDTO
+// @Failure      403  {object}  dtos.ErrorDTO
+// @Failure      404  {object}  dtos.ErrorDTO
+// @Failure      422  {object}  dtos.ErrorDTO
+// @Failure      500  {object}  dtos.ErrorDTO
+// @Security	 BearerAuth
+// @Router       /folders/{id} [delete]
+func (ctl *Controller) DeleteFolder(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	user, _, err := ctl.authenticate(c, ctx)
+	if err != nil {
+		return err
+	}
+
+	deleteDTO := new(dtos.FolderDeleteDTO)
+	if err = bind(c, deleteDTO); err != nil {
+		return err
+	}
+
+	var folder *models.Folder
+	err = ctl.app.Store.InTx(ctx, func(tx *store.Store) error {
+		var err error
+		if folder, err = authorizedFolder(ctx, tx, user, c.Param("id")); err != nil {
+			return err
+		}
+
+		if deleteDTO.Cascade == "true" {
+			return deleteFolderTree(ctx, tx, user, folder)
+		}
+
+		if err := tx.TodoLists.ClearFolder(ctx, folder.ID); err != nil {
+			return err
+		}
+
+		if err := tx.Folders.ClearParent(ctx, folder.ID); err != nil {
+			return err
+		}
+		return tx.Folders.Delete(ctx, folder.ID)
+	})
+	if errors.Is(err, store.ErrNotFound) {
+		return apperr.New(apperr.FolderNotFound)
+	} else if err != nil {
+		return apperr.Wrap(apperr.FolderDeleteFailed, err)
+	}
+
+	return c.JSON(http.StatusOK, folder)
+}
+
+// deleteFolderTree deletes folder from st along with the folders nested in it and the todo lists of all of them, making sure
+// user owns every one of those lists.
+func deleteFolderTree(ctx context.Context, st *store.Store, user *models.User, folder *models.Folder) error {
+	folders, err := st.Folders.List(ctx, store.FolderQuery{UserID: user.ID, OrganizationID: folder.OrganizationID})
+	if err != nil {
+		return err
+	}
+
+	ids := []int{folder.ID}
+	for i := 0; i < len(ids); i++ {
+		for _, f := range folders {
+			if f.ParentID == ids[i] {
+				ids = append(ids, f.ID)
+			}
+		}
+	}
+
+	todoLists, err := st.TodoLists.List(ctx, store.TodoListQuery{
+		UserID:         user.ID,
+		OrganizationID: folder.OrganizationID,
+		FolderIDs:      ids,
+		Sort:           store.Sort{Field: "rank"},
+		OmitTodos:      true,
+	})
+	if err != nil {
+		return err
+	}
+
+	for i := range todoLists {
+		if err := authorize(ctx, st, user, &todoLists[i], models.RoleOwner, apperr.TodoListNotFound); err != nil {
+			return err
+		}
+
+		if err := deleteTodoList(ctx, st, todoLists[i].ID); err != nil {
+			return err
+		}
+	}
+
+	for _, id := range ids {
+		if err := st.Folders.Delete(ctx, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// authorizedFolder loads the folder with the given ID from st and makes sure it is one of the folders the request of user
+// is scoped to: a folder of the organization, or a folder of the user outside of organizations.
+func authorizedFolder(ctx context.Context, st *store.Store, user *models.User, id string) (*models.Folder, error) {
+	folderID, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	folder, err := st.Folders.GetByID(ctx, folderID)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, apperr.New(apperr.FolderNotFound)
+	} else if err != nil {
+		return nil, apperr.Wrap(apperr.FolderFetchFailed, err)
+	}
+
+	if !inScope(user, folder) {
+		return nil, apperr.New(apperr.FolderNotFound)
+	}
+
+	return folder, nil
+}
+
+// inScope reports whether folder is one of the folders the request of user is scoped to.
+func inScope(user *models.User, folder *models.Folder) bool {
+	return folder.OrganizationID == orgID(user) && (folder.OrganizationID != 0 || folder.OwnerID == user.ID)
+}
+
+// checkParent makes sure the parent of folder, if it has one, is a folder the request of user is scoped to and isn't folder
+// itself or one of its subfolders.
+func checkParent(ctx context.Context, st *store.Store, user *models.User, folder *models.Folder) error {
+	if folder.ParentID == 0 {
+		return nil
+	}
+
+	folders, err := st.Folders.List(ctx, store.FolderQuery{UserID: user.ID, OrganizationID: orgID(user)})
+	if err != nil {
+		return err
+	}
+
+	parents := make(map[int]int, len(folders))
+	for _, f := range folders {
+		parents[f.ID] = f.ParentID
+	}
+
+	if _, ok := parents[folder.ParentID]; !ok {
+		return apperr.New(apperr.ValidationFailed).WithFields([]apperr.FieldError{
+			{Field: "parent_id", Message: "must be the ID of one of your folders"},
+		})
+	}
+
+	// Walk up from the new parent: reaching the folder means it would be nested in itself. New folders have no ID yet.
+	for id, seen := folder.ParentID, 0; id != 0 && seen <= len(folders); id, seen = parents[id], seen+1 {
+		if id == folder.ID {
+			return apperr.New(apperr.ValidationFailed).WithFields([]apperr.FieldError{
+				{Field: "parent_id", Message: "can't be the folder itself or one of its subfolders"},
+			})
+		}
+	}
+	return nil
+}
+
+// checkFolder makes sure the folder with the given ID, if any, is a folder the request of user is scoped to, for a todo list
+// to be filed in it.
+func checkFolder(ctx context.Context, st *store.Store, user *models.User, folderID int) error {
+	if folderID == 0 {
+		return nil
+	}
+
+	folder, err := st.Folders.GetByID(ctx, folderID)
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		return apperr.Wrap(apperr.FolderFetchFailed, err)
+	}
+
+	if err != nil || !inScope(user, folder) {
+		return apperr.New(apperr.ValidationFailed).WithFields([]apperr.FieldError{
+			{Field: "folder_id", Message: "must be the ID of one of your folders"},
+		})
+	}
+	return nil
+}
+
+// folderTree arranges todoLists in folders. The folders and todo lists whose parent isn't one of folders are at the root,
+// and so is one folder of every cycle that concurrent moves could have left, so that no folder goes missing.
+func folderTree(folders []models.Folder, todoLists []models.TodoList) *dtos.TodoListTreeDTO {
+	known := make(map[int]bool, len(folders))
+	for _, f := range folders {
+		known[f.ID] = true
+	}
+
+	subfolders := map[int][]models.Folder{}
+	for _, f := range folders {
+		parentID := f.ParentID
+		if !known[parentID] {
+			parentID = 0
+		}
+		subfolders[parentID] = append(subfolders[parentID], f)
+	}
+
+	lists := map[int][]models.TodoList{}
+	for _, l := range todoLists {
+		folderID := l.FolderID
+		if !known[folderID] {
+			folderID = 0
+		}
+		lists[folderID] = append(lists[folderID], l)
+	}
+
+	visited := map[int]bool{}
+	var nodes func(parentID int) []dtos.FolderNodeDTO
+	nodes = func(parentID int) []dtos.FolderNodeDTO {
+		result := []dtos.FolderNodeDTO{}
+		for _, f := range subfolders[parentID] {
+			if visited[f.ID] {
+				continue
+			}
+			visited[f.ID] = true
+			result = append(result, dtos.FolderNodeDTO{Folder: f, Folders: nodes(f.ID), TodoLists: append([]models.TodoList{}, lists[f.ID]...)})
+		}
+		return result
+	}
+
+	root := nodes(0)
+	for _, f := range folders {
+		if visited[f.ID] {
+			continue
+		}
+		visited[f.ID] = true
+		root = append(root, dtos.FolderNodeDTO{Folder: f, Folders: nodes(f.ID), TodoLists: append([]models.TodoList{}, lists[f.ID]...)})
+	}
+
+	return &dtos.TodoListTreeDTO{Folders: root, TodoLists: append([]models.TodoList{}, lists[0]...)}
+}
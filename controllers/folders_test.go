@@ -0,0 +1,77 @@
+package controllers
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/marouane-ach/todo-go/dtos"
+	"github.com/marouane-ach/todo-go/models"
+)
+
+func TestFolderTree(t *testing.T) {
+	t.Parallel()
+
+	folder := func(id, parentID int) models.Folder {
+		f := models.Folder{ParentID: parentID}
+		f.ID = id
+		return f
+	}
+	todoList := func(id, folderID int) models.TodoList {
+		l := models.TodoList{FolderID: folderID}
+		l.ID = id
+		return l
+	}
+
+	tests := []struct {
+		name      string
+		folders   []models.Folder
+		todoLists []models.TodoList
+		want      string
+	}{
+		{
+			name:      "nested",
+			folders:   []models.Folder{folder(1, 0), folder(2, 1), folder(3, 2), folder(4, 0)},
+			todoLists: []models.TodoList{todoList(1, 3), todoList(2, 0), todoList(3, 1)},
+			want:      "1(2(3(;1););3) 4(;);2",
+		},
+		{
+			name:      "unknown parents",
+			folders:   []models.Folder{folder(1, 7), folder(2, 1)},
+			todoLists: []models.TodoList{todoList(1, 9), todoList(2, 2)},
+			want:      "1(2(;2););1",
+		},
+		{
+			name:      "cycle",
+			folders:   []models.Folder{folder(1, 0), folder(2, 3), folder(3, 2), folder(4, 4)},
+			todoLists: []models.TodoList{todoList(1, 3), todoList(2, 4)},
+			want:      "1(;) 2(3(;1);) 4(;2);",
+		},
+	}
+
+	for _, tt := range tests {
+		if got := outline(folderTree(tt.folders, tt.todoLists)); got != tt.want {
+			t.Errorf("%s: folderTree = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+// outline describes tree as its folders, each with its nested folders and the IDs of its todo lists in parentheses,
+// followed by the IDs of the todo lists at the root.
+func outline(tree *dtos.TodoListTreeDTO) string {
+	var describe func(nodes []dtos.FolderNodeDTO, todoLists []models.TodoList) string
+	describe = func(nodes []dtos.FolderNodeDTO, todoLists []models.TodoList) string {
+		folders := make([]string, len(nodes))
+		for i, node := range nodes {
+			folders[i] = fmt.Sprintf("%d(%s)", node.Folder.ID, describe(node.Folders, node.TodoLists))
+		}
+
+		ids := make([]string, len(todoLists))
+		for i, l := range todoLists {
+			ids[i] = fmt.Sprint(l.ID)
+		}
+		return strings.Join(folders, " ") + ";" + strings.Join(ids, " ")
+	}
+
+	return describe(tree.Folders, tree.TodoLists)
+}
@@ -0,0 +1,560 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/marouane-ach/todo-go/apperr"
+	"github.com/marouane-ach/todo-go/dtos"
+	"github.com/marouane-ach/todo-go/mailer"
+	"github.com/marouane-ach/todo-go/models"
+	"github.com/marouane-ach/todo-go/notify"
+	"github.com/marouane-ach/todo-go/store"
+)
+
+// List Members godoc
+// @Summary      List the users with access to this todo list
+// @Description  Returns the owner of the todo list followed by its members, oldest first, with their roles.
+// @Tags         Sharing
+// @Param        id path int true "Todo List ID"
+// @Accept       json
+// @Produce      json
+// @Success      200  {array}	dtos.MemberDTO
+// @Failure      400  {object}  dtos.ErrorDTO
+// @Failure      401  {object}  dtos.ErrorDTO
+// @Failure      404  {object}  dtos.ErrorDTO
+// @Failure      500  {object}  dtos.ErrorDTO
+// @Security	 BearerAuth
+// @Router       /todolists/{id}/members [get]
+func (ctl *Controller) ListMembers(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	user, _, err := ctl.authenticate(c, ctx)
+	if err != nil {
+		return err
+	}
+
+	todoList, err := authorizedTodoList(ctx, ctl.app.Store, user, c.Param("id"), models.RoleViewer)
+	if err != nil {
+		return err
+	}
+
+	owner, err := ctl.app.Store.Users.GetByID(ctx, todoList.OwnerID)
+	if err != nil {
+		return apperr.Wrap(apperr.MemberFetchFailed, err)
+	}
+
+	memberships, err := ctl.app.Store.Memberships.ListByTodoList(ctx, todoList.ID)
+	if err != nil {
+		return apperr.Wrap(apperr.MemberFetchFailed, err)
+	}
+
+	members := []dtos.MemberDTO{member(owner, models.RoleOwner)}
+	for _, membership := range memberships {
+		if membership.User != nil {
+			members = append(members, member(membership.User, membership.Role))
+		}
+	}
+
+	return c.JSON(http.StatusOK, members)
+}
+
+// Update Member godoc
+// @Summary      Change the role of a member of this todo list
+// @Description  Accepts a `role`, one of `viewer`, `editor` and `admin`, as a JSON object and returns the member. Only admins can change roles,
+// @Description  and the owner keeps theirs.
+// @Tags         Sharing
+// @Param        role body dtos.MemberRoleDTO true "The new role"
+// @Param        id path int true "Todo List ID"
+// @Param        user_id path int true "User ID of the member"
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}	dtos.MemberDTO
+// @Failure      400  {object}  dtos.ErrorDTO
+// @Failure      401  {object}  dtos.ErrorDTO
+// @Failure      403  {object}  dtos.ErrorDTO
+// @Failure      404  {object}  dtos.ErrorDTO
+// @Failure      422  {object}  dtos.ErrorDTO
+// @Failure      500  {object}  dtos.ErrorDTO
+// @Security	 BearerAuth
+// @Router       /todolists/{id}/members/{user_id} [put]
+func (ctl *Controller) UpdateMember(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	user, _, err := ctl.authenticate(c, ctx)
+	if err != nil {
+		return err
+	}
+
+	roleDTO := new(dtos.MemberRoleDTO)
+	if err = bind(c, roleDTO); err != nil {
+		return err
+	}
+
+	memberID, err := parseID(c.Param("user_id"))
+	if err != nil {
+		return err
+	}
+
+	todoList, err := authorizedTodoList(ctx, ctl.app.Store, user, c.Param("id"), models.RoleAdmin)
+	if err != nil {
+		return err
+	}
+
+	if memberID == todoList.OwnerID {
+		return apperr.New(apperr.Forbidden).WithDescription("The owner of a todo list keeps their role.")
+	}
+
+	membership, memberUser, err := ctl.membership(ctx, todoList.ID, memberID)
+	if err != nil {
+		return err
+	}
+
+	membership.Role = roleDTO.Role
+	membership.UpdatedAt = ctl.app.Now()
+	if err = ctl.app.Store.Memberships.Update(ctx, membership); errors.Is(err, store.ErrNotFound) {
+		return apperr.New(apperr.MemberNotFound)
+	} else if err != nil {
+		return apperr.Wrap(apperr.MemberUpdateFailed, err)
+	}
+
+	return c.JSON(http.StatusOK, member(memberUser, membership.Role))
+}
+
+// Remove Member godoc
+// @Summary      Remove a member from this todo list, or leave it
+// @Description  Admins can remove any member. Any member can remove themselves to leave the list, but the owner can't.
+// @Description  The todos of the list assigned to the member are unassigned. Returns the removed member.
+// @Tags         Sharing
+// @Param        id path int true "Todo List ID"
+// @Param        user_id path int true "User ID of the member"
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}	dtos.MemberDTO
+// @Failure      400  {object}  dtos.ErrorDTO
+// @Failure      401  {object}  dtos.ErrorDTO
+// @Failure      403  {object}  dtos.ErrorDTO
+// @Failure      404  {object}  dtos.ErrorDTO
+// @Failure      500  {object}  dtos.ErrorDTO
+// @Security	 BearerAuth
+// @Router       /todolists/{id}/members/{user_id} [delete]
+func (ctl *Controller) RemoveMember(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	user, _, err := ctl.authenticate(c, ctx)
+	if err != nil {
+		return err
+	}
+
+	memberID, err := parseID(c.Param("user_id"))
+	if err != nil {
+		return err
+	}
+
+	// Leaving only takes access to the list.
+	minRole := models.RoleAdmin
+	if memberID == user.ID {
+		minRole = models.RoleViewer
+	}
+
+	todoList, err := authorizedTodoList(ctx, ctl.app.Store, user, c.Param("id"), minRole)
+	if err != nil {
+		return err
+	}
+
+	if memberID == todoList.OwnerID {
+		return apperr.New(apperr.Forbidden).WithDescription("The owner of a todo list can't leave it.")
+	}
+
+	membership, memberUser, err := ctl.membership(ctx, todoList.ID, memberID)
+	if err != nil {
+		return err
+	}
+
+	err = ctl.app.Store.InTx(ctx, func(tx *store.Store) error {
+		if err := tx.Memberships.Delete(ctx, membership.ID); err != nil {
+			return err
+		}
+		return tx.Todos.Unassign(ctx, todoList.ID, memberID)
+	})
+	if errors.Is(err, store.ErrNotFound) {
+		return apperr.New(apperr.MemberNotFound)
+	} else if err != nil {
+		return apperr.Wrap(apperr.MemberDeleteFailed, err)
+	}
+
+	return c.JSON(http.StatusOK, member(memberUser, membership.Role))
+}
+
+// Create Invitation godoc
+// @Summary      Invite someone to this todo list
+// @Description  Accepts an `email` address and a `role`, one of `viewer`, `editor` and `admin`, as a JSON object and returns the invitation.
+// @Description  Only admins can invite. The address gets an email, and its user, if it has signed up, an in-app notification.
+// @Description  They accept or decline the invitation once logged in with that address. The lists of an organization are only shared with its members.
+// @Tags         Sharing
+// @Param        invitation body dtos.InvitationDTO true "The email address to invite and the role to give"
+// @Param        id path int true "Todo List ID"
+// @Accept       json
+// @Produce      json
+// @Success      201  {object}	models.Invitation
+// @Failure      400  {object}  dtos.ErrorDTO
+// @Failure      401  {object}  dtos.ErrorDTO
+// @Failure      403  {object}  dtos.ErrorDTO
+// @Failure      404  {object}  dtos.ErrorDTO
+// @Failure      409  {object}  dtos.ErrorDTO
+// @Failure      422  {object}  dtos.ErrorDTO
+// @Failure      500  {object}  dtos.ErrorDTO
+// @Security	 BearerAuth
+// @Router       /todolists/{id}/invitations [post]
+func (ctl *Controller) CreateInvitation(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	user, _, err := ctl.authenticate(c, ctx)
+	if err != nil {
+		return err
+	}
+
+	invitationDTO := new(dtos.InvitationDTO)
+	if err = bind(c, invitationDTO); err != nil {
+		return err
+	}
+
+	todoList, err := authorizedTodoList(ctx, ctl.app.Store, user, c.Param("id"), models.RoleAdmin)
+	if err != nil {
+		return err
+	}
+
+	invitee, err := ctl.app.Store.Users.GetByEmail(ctx, invitationDTO.Email)
+	if errors.Is(err, store.ErrNotFound) {
+		invitee = nil
+	} else if err != nil {
+		return apperr.Wrap(apperr.InvitationCreateFailed, err)
+	}
+
+	if todoList.OrganizationID != 0 {
+		// The lists of an organization are only shared within it.
+		member := invitee != nil
+		if member {
+			if member, err = ctl.app.Store.HasAccess(ctx, todoList, invitee.ID); err != nil {
+				return apperr.Wrap(apperr.InvitationCreateFailed, err)
+			}
+		}
+
+		if !member {
+			return apperr.New(apperr.ValidationFailed).WithFields([]apperr.FieldError{
+				{Field: "email", Message: "must be the email address of a member of the organization"},
+			})
+		}
+	}
+
+	if invitee != nil {
+		if invitee.ID == todoList.OwnerID {
+			return apperr.New(apperr.AlreadyMember)
+		}
+
+		_, err = ctl.app.Store.Memberships.Get(ctx, todoList.ID, invitee.ID)
+		if err == nil {
+			return apperr.New(apperr.AlreadyMember)
+		} else if !errors.Is(err, store.ErrNotFound) {
+			return apperr.Wrap(apperr.InvitationCreateFailed, err)
+		}
+	}
+
+	invitation := &models.Invitation{TodoListID: todoList.ID, InviterID: user.ID, Email: invitationDTO.Email, Role: invitationDTO.Role}
+	if err = ctl.app.Store.Invitations.Create(ctx, invitation); errors.Is(err, store.ErrConflict) {
+		return apperr.New(apperr.AlreadyInvited)
+	} else if err != nil {
+		return apperr.Wrap(apperr.InvitationCreateFailed, err)
+	}
+
+	ctl.app.Go(func(ctx context.Context) {
+		ctl.sendInvitation(ctx, invitation, todoList, user, invitee)
+	})
+
+	return c.JSON(http.StatusCreated, invitation)
+}
+
+// List Todo List Invitations godoc
+// @Summary      List the pending invitations to this todo list
+// @Description  Returns the invitations to the todo list nobody accepted or declined yet, oldest first. Only admins can see them.
+// @Tags         Sharing
+// @Param        id path int true "Todo List ID"
+// @Accept       json
+// @Produce      json
+// @Success      200  {array}	models.Invitation
+// @Failure      400  {object}  dtos.ErrorDTO
+// @Failure      401  {object}  dtos.ErrorDTO
+// @Failure      403  {object}  dtos.ErrorDTO
+// @Failure      404  {object}  dtos.ErrorDTO
+// @Failure      500  {object}  dtos.ErrorDTO
+// @Security	 BearerAuth
+// @Router       /todolists/{id}/invitations [get]
+func (ctl *Controller) ListTodoListInvitations(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	user, _, err := ctl.authenticate(c, ctx)
+	if err != nil {
+		return err
+	}
+
+	todoList, err := authorizedTodoList(ctx, ctl.app.Store, user, c.Param("id"), models.RoleAdmin)
+	if err != nil {
+		return err
+	}
+
+	invitations, err := ctl.app.Store.Invitations.ListByTodoList(ctx, todoList.ID)
+	if err != nil {
+		return apperr.Wrap(apperr.InvitationFetchFailed, err)
+	}
+
+	return c.JSON(http.StatusOK, invitations)
+}
+
+// List Invitations godoc
+// @Summary      List the user's invitations
+// @Description  Returns the pending invitations sent to the email address of the user, oldest first, with the todo lists they are to, without their todos.
+// @Tags         Sharing
+// @Accept       json
+// @Produce      json
+// @Success      200  {array}	models.Invitation
+// @Failure      401  {object}  dtos.ErrorDTO
+// @Failure      500  {object}  dtos.ErrorDTO
+// @Security	 BearerAuth
+// @Router       /invitations [get]
+func (ctl *Controller) ListInvitations(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	user, _, err := ctl.authenticate(c, ctx)
+	if err != nil {
+		return err
+	}
+
+	invitations, err := ctl.app.Store.Invitations.ListByEmail(ctx, user.Email)
+	if err != nil {
+		return apperr.Wrap(apperr.InvitationFetchFailed, err)
+	}
+
+	return c.JSON(http.StatusOK, invitations)
+}
+
+// Accept Invitation godoc
+// @Summary      Accept an invitation
+// @Description  Makes the user a member of the todo list of the invitation with its role and returns the todo list.
+// @Tags         Sharing
+// @Param        id path int true "Invitation ID"
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}	models.TodoList
+// @Failure      400  {object}  dtos.ErrorDTO
+// @Failure      401  {object}  dtos.ErrorDTO
+// @Failure      403  {object}  dtos.ErrorDTO
+// @Failure      404  {object}  dtos.ErrorDTO
+// @Failure      409  {object}  dtos.ErrorDTO
+// @Failure      500  {object}  dtos.ErrorDTO
+// @Security	 BearerAuth
+// @Router       /invitations/{id}/accept [post]
+func (ctl *Controller) AcceptInvitation(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	user, _, err := ctl.authenticate(c, ctx)
+	if err != nil {
+		return err
+	}
+
+	var todoList *models.TodoList
+	err = ctl.app.Store.InTx(ctx, func(tx *store.Store) error {
+		invitation, err := receivedInvitation(ctx, tx, user, c.Param("id"))
+		if err != nil {
+			return err
+		}
+
+		if invitation.TodoList.OwnerID == user.ID {
+			return apperr.New(apperr.AlreadyMember)
+		}
+
+		if invitation.TodoList.OrganizationID != 0 {
+			// The user may have left the organization since they were invited.
+			if ok, err := tx.HasAccess(ctx, invitation.TodoList, user.ID); err != nil {
+				return err
+			} else if !ok {
+				return apperr.New(apperr.Forbidden).WithDescription("Only members of the organization can join its todo lists.")
+			}
+		}
+
+		membership := &models.Membership{TodoListID: invitation.TodoListID, UserID: user.ID, Role: invitation.Role}
+		if err := tx.Memberships.Create(ctx, membership); errors.Is(err, store.ErrConflict) {
+			return apperr.New(apperr.AlreadyMember)
+		} else if err != nil {
+			return err
+		}
+
+		if err := tx.Invitations.Delete(ctx, invitation.ID); err != nil {
+			return err
+		}
+
+		todoList, err = tx.TodoLists.GetByID(ctx, invitation.TodoListID)
+		if err != nil {
+			return err
+		}
+		todoList.Role = membership.Role
+		return nil
+	})
+	if err != nil {
+		return apperr.Wrap(apperr.InvitationAcceptFailed, err)
+	}
+
+	return c.JSON(http.StatusOK, todoList)
+}
+
+// Decline Invitation godoc
+// @Summary      Decline an invitation
+// @Description  Deletes the invitation and returns it.
+// @Tags         Sharing
+// @Param        id path int true "Invitation ID"
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}	models.Invitation
+// @Failure      400  {object}  dtos.ErrorDTO
+// @Failure      401  {object}  dtos.ErrorDTO
+// @Failure      404  {object}  dtos.ErrorDTO
+// @Failure      500  {object}  dtos.ErrorDTO
+// @Security	 BearerAuth
+// @Router       /invitations/{id}/decline [post]
+func (ctl *Controller) DeclineInvitation(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	user, _, err := ctl.authenticate(c, ctx)
+	if err != nil {
+		return err
+	}
+
+	invitation, err := receivedInvitation(ctx, ctl.app.Store, user, c.Param("id"))
+	if err != nil {
+		return err
+	}
+
+	return ctl.deleteInvitation(c, invitation)
+}
+
+// Delete Invitation godoc
+// @Summary      Withdraw an invitation
+// @Description  Deletes an invitation to a todo list and returns it. Only the admins of the list can withdraw its invitations.
+// @Tags         Sharing
+// @Param        id path int true "Invitation ID"
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}	models.Invitation
+// @Failure      400  {object}  dtos.ErrorDTO
+// @Failure      401  {object}  dtos.ErrorDTO
+// @Failure      403  {object}  dtos.ErrorDTO
+// @Failure      404  {object}  dtos.ErrorDTO
+// @Failure      500  {object}  dtos.ErrorDTO
+// @Security	 BearerAuth
+// @Router       /invitations/{id} [delete]
+func (ctl *Controller) DeleteInvitation(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	user, _, err := ctl.authenticate(c, ctx)
+	if err != nil {
+		return err
+	}
+
+	invitationID, err := parseID(c.Param("id"))
+	if err != nil {
+		return err
+	}
+
+	invitation, err := ctl.app.Store.Invitations.GetByID(ctx, invitationID)
+	if errors.Is(err, store.ErrNotFound) {
+		return apperr.New(apperr.InvitationNotFound)
+	} else if err != nil {
+		return apperr.Wrap(apperr.InvitationFetchFailed, err)
+	}
+
+	if err = authorize(ctx, ctl.app.Store, user, invitation.TodoList, models.RoleAdmin, apperr.InvitationNotFound); err != nil {
+		return err
+	}
+
+	return ctl.deleteInvitation(c, invitation)
+}
+
+// deleteInvitation deletes invitation and responds with it.
+func (ctl *Controller) deleteInvitation(c echo.Context, invitation *models.Invitation) error {
+	err := ctl.app.Store.Invitations.Delete(c.Request().Context(), invitation.ID)
+	if errors.Is(err, store.ErrNotFound) {
+		return apperr.New(apperr.InvitationNotFound)
+	} else if err != nil {
+		return apperr.Wrap(apperr.InvitationDeleteFailed, err)
+	}
+
+	invitation.TodoList = nil
+	return c.JSON(http.StatusOK, invitation)
+}
+
+// receivedInvitation loads the invitation with the given ID from st and makes sure it was sent to the email address of user.
+func receivedInvitation(ctx context.Context, st *store.Store, user *models.User, id string) (*models.Invitation, error) {
+	invitationID, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	invitation, err := st.Invitations.GetByID(ctx, invitationID)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, apperr.New(apperr.InvitationNotFound)
+	} else if err != nil {
+		return nil, apperr.Wrap(apperr.InvitationFetchFailed, err)
+	}
+
+	if invitation.TodoList == nil || !strings.EqualFold(invitation.Email, user.Email) {
+		return nil, apperr.New(apperr.InvitationNotFound)
+	}
+
+	return invitation, nil
+}
+
+// membership returns the membership of the user with the given ID in a todo list, along with the user.
+func (ctl *Controller) membership(ctx context.Context, todoListID, userID int) (*models.Membership, *models.User, error) {
+	membership, err := ctl.app.Store.Memberships.Get(ctx, todoListID, userID)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, nil, apperr.New(apperr.MemberNotFound)
+	} else if err != nil {
+		return nil, nil, apperr.Wrap(apperr.MemberFetchFailed, err)
+	}
+
+	memberUser, err := ctl.app.Store.Users.GetByID(ctx, userID)
+	if err != nil {
+		return nil, nil, apperr.Wrap(apperr.MemberFetchFailed, err)
+	}
+
+	return membership, memberUser, nil
+}
+
+// member describes a user with the given role on a todo list.
+func member(user *models.User, role string) dtos.MemberDTO {
+	return dtos.MemberDTO{UserID: user.ID, Email: user.Email, Role: role}
+}
+
+// sendInvitation emails invitation to its address and notifies invitee in the app, if they have signed up.
+// It runs in the background, so failures are only logged.
+func (ctl *Controller) sendInvitation(ctx context.Context, invitation *models.Invitation, todoList *models.TodoList, inviter, invitee *models.User) {
+	title := fmt.Sprintf("%s invited you to %s", inviter.Email, todoList.Name)
+	body := fmt.Sprintf("You are invited to join the todo list %s as %s. Log in with %s to accept or decline the invitation.",
+		todoList.Name, invitation.Role, invitation.Email)
+
+	if err := ctl.app.Mailer.Send(ctx, mailer.Mail{To: invitation.Email, Subject: title, Body: body}); err != nil {
+		ctl.app.Logger.Warn("could not email an invitation", "invitation", invitation.ID, "error", err)
+	}
+
+	if invitee == nil {
+		return
+	}
+
+	inApp := &notify.InApp{Store: ctl.app.Store}
+	if err := inApp.Notify(ctx, notify.Message{User: invitee, Kind: "invitation", Title: title, Body: body}); err != nil {
+		ctl.app.Logger.Warn("could not notify an invitation", "invitation", invitation.ID, "error", err)
+	}
+}
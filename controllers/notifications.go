@@ -0,0 +1,111 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/marouane-ach/todo-go/apperr"
+	"github.com/marouane-ach/todo-go/dtos"
+	"github.com/marouane-ach/todo-go/models"
+	"github.com/marouane-ach/todo-go/store"
+)
+
+// List Notifications godoc
+// @Summary      List the user's in-app notifications
+// @Description  Returns a page of notifications, newest first.
+// @Tags         Notifications
+// @Param        limit  query int    false "Number of notifications per page (1-100, default 50)"
+// @Param        cursor query string false "Cursor returned as next_cursor by the previous page"
+// @Param        unread query bool   false "Only the notifications that weren't read yet"
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}	dtos.PageDTO[models.Notification]
+// @Failure      400  {object}  dtos.ErrorDTO
+// @Failure      401  {object}  dtos.ErrorDTO
+// @Failure      422  {object}  dtos.ErrorDTO
+// @Failure      500  {object}  dtos.ErrorDTO
+// @Security	 BearerAuth
+// @Router       /notifications [get]
+func (ctl *Controller) ListNotifications(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	user, _, err := ctl.authenticate(c, ctx)
+	if err != nil {
+		return err
+	}
+
+	queryDTO := new(dtos.NotificationQueryDTO)
+	if err = bind(c, queryDTO); err != nil {
+		return err
+	}
+
+	query := store.NotificationQuery{UserID: user.ID, Unread: queryDTO.Unread == "true", Limit: queryDTO.Limit}
+	if query.Limit == 0 {
+		query.Limit = defaultPageLimit
+	}
+
+	query.After, err = decodeCursor(queryDTO.Cursor, store.NotificationSort, true)
+	if err != nil {
+		return err
+	}
+
+	// Fetch one more notification than asked to know whether there is a next page.
+	limit := query.Limit
+	query.Limit++
+
+	notifications, err := ctl.app.Store.Notifications.List(ctx, query)
+	if err != nil {
+		return apperr.Wrap(apperr.NotificationFetchFailed, err)
+	}
+
+	return c.JSON(http.StatusOK, page(notifications, limit, store.NotificationSort, func(n *models.Notification) store.Cursor {
+		return store.Cursor{Value: n.CreatedAt, ID: n.ID}
+	}))
+}
+
+// Read Notification godoc
+// @Summary      Mark a notification as read
+// @Description  Returns the notification as a JSON object.
+// @Tags         Notifications
+// @Param        id path int true "Notification ID"
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}	models.Notification
+// @Failure      400  {object}  dtos.ErrorDTO
+// @Failure      401  {object}  dtos.ErrorDTO
+// @Failure      404  {object}  dtos.ErrorDTO
+// @Failure      500  {object}  dtos.ErrorDTO
+// @Security	 BearerAuth
+// @Router       /notifications/{id}/read [post]
+func (ctl *Controller) ReadNotification(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	user, _, err := ctl.authenticate(c, ctx)
+	if err != nil {
+		return err
+	}
+
+	notificationID, err := parseID(c.Param("id"))
+	if err != nil {
+		return err
+	}
+
+	notification, err := ctl.app.Store.Notifications.GetByID(ctx, notificationID)
+	if errors.Is(err, store.ErrNotFound) || (err == nil && notification.UserID != user.ID) {
+		return apperr.New(apperr.NotificationNotFound)
+	} else if err != nil {
+		return apperr.Wrap(apperr.NotificationFetchFailed, err)
+	}
+
+	if notification.ReadAt == nil {
+		now := ctl.app.Now()
+		notification.ReadAt, notification.UpdatedAt = &now, now
+
+		if err = ctl.app.Store.Notifications.Update(ctx, notification); err != nil {
+			return apperr.Wrap(apperr.NotificationUpdateFailed, err)
+		}
+	}
+
+	return c.JSON(http.StatusOK, notification)
+}
@@ -0,0 +1,576 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/marouane-ach/todo-go/apperr"
+	"github.com/marouane-ach/todo-go/dtos"
+	"github.com/marouane-ach/todo-go/models"
+	"github.com/marouane-ach/todo-go/store"
+)
+
+// Create Organization godoc
+// @Summary      Create an organization
+// @Description  Accepts a `name` as a JSON object and returns the created organization, whose admin is the user.
+// @Description  New organizations get the quotas of the server on their members and todo lists. Zero means unlimited.
+// @Tags         Organizations
+// @Param        organization body dtos.OrganizationDTO true "The organization's name"
+// @Accept       json
+// @Produce      json
+// @Success      201  {object}	models.Organization
+// @Failure      400  {object}  dtos.ErrorDTO
+// @Failure      401  {object}  dtos.ErrorDTO
+// @Failure      422  {object}  dtos.ErrorDTO
+// @Failure      500  {object}  dtos.ErrorDTO
+// @Security	 BearerAuth
+// @Router       /organizations [post]
+func (ctl *Controller) CreateOrganization(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	user, _, err := ctl.authenticate(c, ctx)
+	if err != nil {
+		return err
+	}
+
+	organizationDTO := new(dtos.OrganizationDTO)
+	if err = bind(c, organizationDTO); err != nil {
+		return err
+	}
+
+	organization := &models.Organization{
+		Name:         organizationDTO.Name,
+		MaxMembers:   ctl.app.Config.OrgMaxMembers,
+		MaxTodoLists: ctl.app.Config.OrgMaxTodoLists,
+	}
+	err = ctl.app.Store.InTx(ctx, func(tx *store.Store) error {
+		if err := tx.Organizations.Create(ctx, organization); err != nil {
+			return err
+		}
+		return tx.OrgMemberships.Create(ctx, &models.OrgMembership{OrganizationID: organization.ID, UserID: user.ID, Role: models.OrgRoleAdmin})
+	})
+	if err != nil {
+		return apperr.Wrap(apperr.OrganizationCreateFailed, err)
+	}
+
+	organization.Role = models.OrgRoleAdmin
+	return c.JSON(http.StatusCreated, organization)
+}
+
+// List Organizations godoc
+// @Summary      List the user's organizations
+// @Description  Returns the organizations the user is a member of, oldest membership first, each with the `Role` of the user in it.
+// @Description  Send the ID of one of them in the `X-Org-ID` header to work with its todo lists.
+// @Tags         Organizations
+// @Accept       json
+// @Produce      json
+// @Success      200  {array}	models.Organization
+// @Failure      401  {object}  dtos.ErrorDTO
+// @Failure      500  {object}  dtos.ErrorDTO
+// @Security	 BearerAuth
+// @Router       /organizations [get]
+func (ctl *Controller) ListOrganizations(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	user, _, err := ctl.authenticate(c, ctx)
+	if err != nil {
+		return err
+	}
+
+	memberships, err := ctl.app.Store.OrgMemberships.ListByUser(ctx, user.ID)
+	if err != nil {
+		return apperr.Wrap(apperr.OrganizationFetchFailed, err)
+	}
+
+	organizations := []models.Organization{}
+	for _, membership := range memberships {
+		if membership.Organization != nil {
+			membership.Organization.Role = membership.Role
+			organizations = append(organizations, *membership.Organization)
+		}
+	}
+
+	return c.JSON(http.StatusOK, organizations)
+}
+
+// Get Organization godoc
+// @Summary      Get an organization by ID
+// @Description  Returns the organization with the `Role` of the user in it. Only its members can see it.
+// @Tags         Organizations
+// @Param        id path int true "Organization ID"
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}	models.Organization
+// @Failure      400  {object}  dtos.ErrorDTO
+// @Failure      401  {object}  dtos.ErrorDTO
+// @Failure      404  {object}  dtos.ErrorDTO
+// @Failure      500  {object}  dtos.ErrorDTO
+// @Security	 BearerAuth
+// @Router       /organizations/{id} [get]
+func (ctl *Controller) GetOrganization(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	user, _, err := ctl.authenticate(c, ctx)
+	if err != nil {
+		return err
+	}
+
+	organization, err := authorizedOrganization(ctx, ctl.app.Store, user, c.Param("id"), models.OrgRoleMember)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, organization)
+}
+
+// Update Organization godoc
+// @Summary      Rename an organization
+// @Description  Accepts the new `name` as a JSON object and returns the organization. Only admins can rename it.
+// @Tags         Organizations
+// @Param        organization body dtos.OrganizationDTO true "The organization's new name"
+// @Param        id path int true "Organization ID"
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}	models.Organization
+// @Failure      400  {object}  dtos.ErrorDTO
+// @Failure      401  {object}  dtos.ErrorDTO
+// @Failure      403  {object}  dtos.ErrorDTO
+// @Failure      404  {object}  dtos.ErrorDTO
+// @Failure      422  {object}  dtos.ErrorDTO
+// @Failure      500  {object}  dtos.ErrorDTO
+// @Security	 BearerAuth
+// @Router       /organizations/{id} [put]
+func (ctl *Controller) UpdateOrganization(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	user, _, err := ctl.authenticate(c, ctx)
+	if err != nil {
+		return err
+	}
+
+	organizationDTO := new(dtos.OrganizationDTO)
+	if err = bind(c, organizationDTO); err != nil {
+		return err
+	}
+
+	organization, err := authorizedOrganization(ctx, ctl.app.Store, user, c.Param("id"), models.OrgRoleAdmin)
+	if err != nil {
+		return err
+	}
+
+	organization.Name = organizationDTO.Name
+	organization.UpdatedAt = ctl.app.Now()
+
+	if err = ctl.app.Store.Organizations.Update(ctx, organization); errors.Is(err, store.ErrNotFound) {
+		return apperr.New(apperr.OrganizationNotFound)
+	} else if err != nil {
+		return apperr.Wrap(apperr.OrganizationUpdateFailed, err)
+	}
+
+	return c.JSON(http.StatusOK, organization)
+}
+
+// Delete Organization godoc
+// @Summary      Delete an organization
+// @Description  Deletes the organization, its folders and its memberships and returns it. Only admins can delete it, once it has no todo lists left.
+// @Tags         Organizations
+// @Param        id path int true "Organization ID"
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}	models.Organization
+// @Failure      400  {object}  dtos.ErrorDTO
+// @Failure      401  {object}  dtos.ErrorDTO
+// @Failure      403  {object}  dtos.ErrorDTO
+// @Failure      404  {object}  dtos.ErrorDTO
+// @Failure      409  {object}  dtos.ErrorDTO
+// @Failure      500  {object}  dtos.ErrorDTO
+// @Security	 BearerAuth
+// @Router       /organizations/{id} [delete]
+func (ctl *Controller) DeleteOrganization(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	user, _, err := ctl.authenticate(c, ctx)
+	if err != nil {
+		return err
+	}
+
+	organization, err := authorizedOrganization(ctx, ctl.app.Store, user, c.Param("id"), models.OrgRoleAdmin)
+	if err != nil {
+		return err
+	}
+
+	err = ctl.app.Store.InTx(ctx, func(tx *store.Store) error {
+		// The lists are counted in the transaction so that none is created in the meantime.
+		n, err := tx.TodoLists.CountByOrganization(ctx, organization.ID)
+		if err != nil {
+			return err
+		} else if n > 0 {
+			return apperr.New(apperr.OrganizationNotEmpty)
+		}
+
+		if err := tx.Folders.DeleteByOrganization(ctx, organization.ID); err != nil {
+			return err
+		}
+
+		if err := tx.OrgMemberships.DeleteByOrganization(ctx, organization.ID); err != nil {
+			return err
+		}
+		return tx.Organizations.Delete(ctx, organization.ID)
+	})
+	if errors.Is(err, store.ErrNotFound) {
+		return apperr.New(apperr.OrganizationNotFound)
+	} else if err != nil {
+		return apperr.Wrap(apperr.OrganizationDeleteFailed, err)
+	}
+
+	return c.JSON(http.StatusOK, organization)
+}
+
+// List Organization Members godoc
+// @Summary      List the members of an organization
+// @Description  Returns the members of the organization, oldest first, with their roles. Only its members can see them.
+// @Tags         Organizations
+// @Param        id path int true "Organization ID"
+// @Accept       json
+// @Produce      json
+// @Success      200  {array}	dtos.MemberDTO
+// @Failure      400  {object}  dtos.ErrorDTO
+// @Failure      401  {object}  dtos.ErrorDTO
+// @Failure      404  {object}  dtos.ErrorDTO
+// @Failure      500  {object}  dtos.ErrorDTO
+// @Security	 BearerAuth
+// @Router       /organizations/{id}/members [get]
+func (ctl *Controller) ListOrgMembers(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	user, _, err := ctl.authenticate(c, ctx)
+	if err != nil {
+		return err
+	}
+
+	organization, err := authorizedOrganization(ctx, ctl.app.Store, user, c.Param("id"), models.OrgRoleMember)
+	if err != nil {
+		return err
+	}
+
+	memberships, err := ctl.app.Store.OrgMemberships.ListByOrganization(ctx, organization.ID)
+	if err != nil {
+		return apperr.Wrap(apperr.MemberFetchFailed, err)
+	}
+
+	members := []dtos.MemberDTO{}
+	for _, membership := range memberships {
+		if membership.User != nil {
+			members = append(members, member(membership.User, membership.Role))
+		}
+	}
+
+	return c.JSON(http.StatusOK, members)
+}
+
+// Add Organization Member godoc
+// @Summary      Add a user to an organization
+// @Description  Accepts the `email` address of a user who signed up and a `role`, `member` or `admin`, as a JSON object and returns the new member.
+// @Description  Only admins can add members, as long as the organization is below its quota of members.
+// @Tags         Organizations
+// @Param        member body dtos.OrgMemberDTO true "The email address of the user and their role"
+// @Param        id path int true "Organization ID"
+// @Accept       json
+// @Produce      json
+// @Success      201  {object}	dtos.MemberDTO
+// @Failure      400  {object}  dtos.ErrorDTO
+// @Failure      401  {object}  dtos.ErrorDTO
+// @Failure      403  {object}  dtos.ErrorDTO
+// @Failure      404  {object}  dtos.ErrorDTO
+// @Failure      409  {object}  dtos.ErrorDTO
+// @Failure      422  {object}  dtos.ErrorDTO
+// @Failure      500  {object}  dtos.ErrorDTO
+// @Security	 BearerAuth
+// @Router       /organizations/{id}/members [post]
+func (ctl *Controller) AddOrgMember(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	user, _, err := ctl.authenticate(c, ctx)
+	if err != nil {
+		return err
+	}
+
+	memberDTO := new(dtos.OrgMemberDTO)
+	if err = bind(c, memberDTO); err != nil {
+		return err
+	}
+
+	organization, err := authorizedOrganization(ctx, ctl.app.Store, user, c.Param("id"), models.OrgRoleAdmin)
+	if err != nil {
+		return err
+	}
+
+	memberUser, err := ctl.app.Store.Users.GetByEmail(ctx, memberDTO.Email)
+	if errors.Is(err, store.ErrNotFound) {
+		return apperr.New(apperr.ValidationFailed).WithFields([]apperr.FieldError{
+			{Field: "email", Message: "must be the email address of a user who signed up"},
+		})
+	} else if err != nil {
+		return apperr.Wrap(apperr.MemberUpdateFailed, err)
+	}
+
+	membership := &models.OrgMembership{OrganizationID: organization.ID, UserID: memberUser.ID, Role: memberDTO.Role}
+	err = ctl.app.Store.InTx(ctx, func(tx *store.Store) error {
+		// The members are counted in the transaction so that concurrent requests can't exceed the quota together.
+		memberships, err := tx.OrgMemberships.ListByOrganization(ctx, organization.ID)
+		if err != nil {
+			return err
+		}
+
+		if organization.MaxMembers > 0 && len(memberships) >= organization.MaxMembers {
+			return apperr.New(apperr.QuotaExceeded).WithDescription("The organization can't have more than %d members.", organization.MaxMembers)
+		}
+
+		if err := tx.OrgMemberships.Create(ctx, membership); errors.Is(err, store.ErrConflict) {
+			return apperr.New(apperr.AlreadyOrgMember)
+		} else if err != nil {
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return apperr.Wrap(apperr.MemberUpdateFailed, err)
+	}
+
+	return c.JSON(http.StatusCreated, member(memberUser, membership.Role))
+}
+
+// Update Organization Member godoc
+// @Summary      Change the role of a member of an organization
+// @Description  Accepts a `role`, `member` or `admin`, as a JSON object and returns the member. Only admins can change roles,
+// @Description  and the organization keeps at least one admin.
+// @Tags         Organizations
+// @Param        role body dtos.OrgMemberRoleDTO true "The new role"
+// @Param        id path int true "Organization ID"
+// @Param        user_id path int true "User ID of the member"
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}	dtos.MemberDTO
+// @Failure      400  {object}  dtos.ErrorDTO
+// @Failure      401  {object}  dtos.ErrorDTO
+// @Failure      403  {object}  dtos.ErrorDTO
+// @Failure      404  {object}  dtos.ErrorDTO
+// @Failure      409  {object}  dtos.ErrorDTO
+// @Failure      422  {object}  dtos.ErrorDTO
+// @Failure      500  {object}  dtos.ErrorDTO
+// @Security	 BearerAuth
+// @Router       /organizations/{id}/members/{user_id} [put]
+func (ctl *Controller) UpdateOrgMember(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	user, _, err := ctl.authenticate(c, ctx)
+	if err != nil {
+		return err
+	}
+
+	roleDTO := new(dtos.OrgMemberRoleDTO)
+	if err = bind(c, roleDTO); err != nil {
+		return err
+	}
+
+	memberID, err := parseID(c.Param("user_id"))
+	if err != nil {
+		return err
+	}
+
+	organization, err := authorizedOrganization(ctx, ctl.app.Store, user, c.Param("id"), models.OrgRoleAdmin)
+	if err != nil {
+		return err
+	}
+
+	var membership *models.OrgMembership
+	err = ctl.app.Store.InTx(ctx, func(tx *store.Store) error {
+		var err error
+		membership, err = orgMembership(ctx, tx, organization.ID, memberID)
+		if err != nil {
+			return err
+		}
+
+		if membership.Role == models.OrgRoleAdmin && roleDTO.Role != models.OrgRoleAdmin {
+			if err := keepAdmin(ctx, tx, organization.ID); err != nil {
+				return err
+			}
+		}
+
+		membership.Role = roleDTO.Role
+		membership.UpdatedAt = ctl.app.Now()
+		return tx.OrgMemberships.Update(ctx, membership)
+	})
+	if errors.Is(err, store.ErrNotFound) {
+		return apperr.New(apperr.MemberNotFound)
+	} else if err != nil {
+		return apperr.Wrap(apperr.MemberUpdateFailed, err)
+	}
+
+	return c.JSON(http.StatusOK, member(membership.User, membership.Role))
+}
+
+// Remove Organization Member godoc
+// @Summary      Remove a member from an organization, or leave it
+// @Description  Admins can remove any member, and any member can remove themselves to leave the organization, as long as it keeps an admin.
+// @Description  The member loses access to the todo lists of the organization, including those they created, and the todos of those lists
+// @Description  assigned to them are unassigned. Returns the removed member.
+// @Tags         Organizations
+// @Param        id path int true "Organization ID"
+// @Param        user_id path int true "User ID of the member"
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}	dtos.MemberDTO
+// @Failure      400  {object}  dtos.ErrorDTO
+// @Failure      401  {object}  dtos.ErrorDTO
+// @Failure      403  {object}  dtos.ErrorDTO
+// @Failure      404  {object}  dtos.ErrorDTO
+// @Failure      409  {object}  dtos.ErrorDTO
+// @Failure      500  {object}  dtos.ErrorDTO
+// @Security	 BearerAuth
+// @Router       /organizations/{id}/members/{user_id} [delete]
+func (ctl *Controller) RemoveOrgMember(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	user, _, err := ctl.authenticate(c, ctx)
+	if err != nil {
+		return err
+	}
+
+	memberID, err := parseID(c.Param("user_id"))
+	if err != nil {
+		return err
+	}
+
+	// Leaving only takes being a member.
+	minRole := models.OrgRoleAdmin
+	if memberID == user.ID {
+		minRole = models.OrgRoleMember
+	}
+
+	organization, err := authorizedOrganization(ctx, ctl.app.Store, user, c.Param("id"), minRole)
+	if err != nil {
+		return err
+	}
+
+	var membership *models.OrgMembership
+	err = ctl.app.Store.InTx(ctx, func(tx *store.Store) error {
+		var err error
+		membership, err = orgMembership(ctx, tx, organization.ID, memberID)
+		if err != nil {
+			return err
+		}
+
+		if membership.Role == models.OrgRoleAdmin {
+			if err := keepAdmin(ctx, tx, organization.ID); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.OrgMemberships.Delete(ctx, membership.ID); err != nil {
+			return err
+		}
+
+		if err := tx.Memberships.DeleteInOrganization(ctx, organization.ID, memberID); err != nil {
+			return err
+		}
+		return tx.Todos.UnassignInOrganization(ctx, organization.ID, memberID)
+	})
+	if errors.Is(err, store.ErrNotFound) {
+		return apperr.New(apperr.MemberNotFound)
+	} else if err != nil {
+		return apperr.Wrap(apperr.MemberDeleteFailed, err)
+	}
+
+	return c.JSON(http.StatusOK, member(membership.User, membership.Role))
+}
+
+// authorizedOrganization loads the organization with the given ID from st and makes sure user is a member of it, an admin if
+// minRole is models.OrgRoleAdmin, and sets its Role. Organizations the user isn't a member of are reported as missing.
+func authorizedOrganization(ctx context.Context, st *store.Store, user *models.User, id string, minRole string) (*models.Organization, error) {
+	organizationID, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	membership, err := st.OrgMemberships.Get(ctx, organizationID, user.ID)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, apperr.New(apperr.OrganizationNotFound)
+	} else if err != nil {
+		return nil, apperr.Wrap(apperr.OrganizationFetchFailed, err)
+	}
+
+	organization, err := st.Organizations.GetByID(ctx, organizationID)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, apperr.New(apperr.OrganizationNotFound)
+	} else if err != nil {
+		return nil, apperr.Wrap(apperr.OrganizationFetchFailed, err)
+	}
+
+	if minRole == models.OrgRoleAdmin && membership.Role != models.OrgRoleAdmin {
+		return nil, apperr.New(apperr.Forbidden)
+	}
+
+	organization.Role = membership.Role
+	return organization, nil
+}
+
+// orgMembership returns the membership of the user with the given ID in an organization, along with the user.
+func orgMembership(ctx context.Context, st *store.Store, organizationID, userID int) (*models.OrgMembership, error) {
+	membership, err := st.OrgMemberships.Get(ctx, organizationID, userID)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, apperr.New(apperr.MemberNotFound)
+	} else if err != nil {
+		return nil, err
+	}
+
+	membership.User, err = st.Users.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return membership, nil
+}
+
+// keepAdmin makes sure an organization has another admin than the one about to be demoted or removed.
+func keepAdmin(ctx context.Context, st *store.Store, organizationID int) error {
+	memberships, err := st.OrgMemberships.ListByOrganization(ctx, organizationID)
+	if err != nil {
+		return err
+	}
+
+	admins := 0
+	for _, membership := range memberships {
+		if membership.Role == models.OrgRoleAdmin {
+			admins++
+		}
+	}
+
+	if admins <= 1 {
+		return apperr.New(apperr.LastOrgAdmin)
+	}
+	return nil
+}
+
+// checkTodoListQuota makes sure an organization can have one more todo list.
+func checkTodoListQuota(ctx context.Context, st *store.Store, organizationID int) error {
+	organization, err := st.Organizations.GetByID(ctx, organizationID)
+	if err != nil {
+		return err
+	}
+
+	if organization.MaxTodoLists == 0 {
+		return nil
+	}
+
+	n, err := st.TodoLists.CountByOrganization(ctx, organizationID)
+	if err != nil {
+		return err
+	}
+
+	if n >= organization.MaxTodoLists {
+		return apperr.New(apperr.QuotaExceeded).WithDescription("The organization can't have more than %d todo lists.", organization.MaxTodoLists)
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package controllers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"time"
+
+	"github.com/marouane-ach/todo-go/apperr"
+	"github.com/marouane-ach/todo-go/dtos"
+	"github.com/marouane-ach/todo-go/store"
+)
+
+const (
+	defaultPageLimit = 50
+)
+
+// cursorJSON is what an opaque cursor decodes to. The sort is included so that a cursor
+// can't be replayed against a different order.
+type cursorJSON struct {
+	Sort  string `json:"s"`
+	Desc  bool   `json:"d"`
+	Value string `json:"v"`
+	ID    int    `json:"id"`
+}
+
+func encodeCursor(sort store.Sort, cursor store.Cursor) string {
+	c := cursorJSON{Sort: sort.Field, Desc: sort.Desc, ID: cursor.ID}
+	switch v := cursor.Value.(type) {
+	case string:
+		c.Value = v
+	case time.Time:
+		c.Value = v.Format(time.RFC3339Nano)
+	}
+
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// decodeCursor decodes a cursor made by encodeCursor for the same sort. timeValue tells whether the sort field is a time.
+func decodeCursor(s string, sort store.Sort, timeValue bool) (*store.Cursor, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, apperr.Wrap(apperr.InvalidCursor, err)
+	}
+
+	var c cursorJSON
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, apperr.Wrap(apperr.InvalidCursor, err)
+	}
+
+	if c.Sort != sort.Field || c.Desc != sort.Desc {
+		return nil, apperr.New(apperr.InvalidCursor)
+	}
+
+	cursor := &store.Cursor{Value: c.Value, ID: c.ID}
+	if timeValue {
+		t, err := time.Parse(time.RFC3339Nano, c.Value)
+		if err != nil {
+			return nil, apperr.Wrap(apperr.InvalidCursor, err)
+		}
+		cursor.Value = t
+	}
+
+	return cursor, nil
+}
+
+// timeSort reports whether the keys of sort are times, which all sort fields but names, texts and ranks are.
+func timeSort(sort store.Sort) bool {
+	switch sort.Field {
+	case "name", "text", "rank":
+		return false
+	}
+	return true
+}
+
+// parseSort returns the sort requested by the client, or the default one.
+func parseSort(field, order, defaultField string, defaultDesc bool) store.Sort {
+	sort := store.Sort{Field: field, Desc: order == "desc"}
+	if field == "" {
+		sort.Field = defaultField
+	}
+	if order == "" {
+		sort.Desc = defaultDesc
+	}
+	return sort
+}
+
+// page builds the response for a listing fetched with one row more than limit, which tells whether there is a next page.
+func page[T any](items []T, limit int, sort store.Sort, cursorOf func(*T) store.Cursor) *dtos.PageDTO[T] {
+	p := &dtos.PageDTO[T]{Data: items, Pagination: dtos.PaginationDTO{Limit: limit}}
+	if len(items) > limit {
+		p.Data = items[:limit]
+		p.Pagination.HasMore = true
+		p.Pagination.NextCursor = encodeCursor(sort, cursorOf(&p.Data[limit-1]))
+	}
+
+	if p.Data == nil {
+		p.Data = []T{}
+	}
+
+	return p
+}
@@ -0,0 +1,118 @@
+package controllers
+
+import (
+	"encoding/base64"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/marouane-ach/todo-go/apperr"
+	"github.com/marouane-ach/todo-go/store"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	createdAt := time.Date(2026, time.March, 29, 2, 30, 0, 123456789, time.FixedZone("CEST", 2*3600))
+
+	tests := []struct {
+		sort   store.Sort
+		cursor store.Cursor
+	}{
+		{store.Sort{Field: "name"}, store.Cursor{Value: "Groceries", ID: 3}},
+		{store.Sort{Field: "name", Desc: true}, store.Cursor{Value: `"quoted" ünicode`, ID: 1}},
+		{store.Sort{Field: "rank"}, store.Cursor{Value: "V0V", ID: 12}},
+		{store.Sort{Field: "created_at", Desc: true}, store.Cursor{Value: createdAt, ID: 7}},
+	}
+
+	for _, tt := range tests {
+		s := encodeCursor(tt.sort, tt.cursor)
+		_, isTime := tt.cursor.Value.(time.Time)
+
+		got, err := decodeCursor(s, tt.sort, isTime)
+		if err != nil {
+			t.Errorf("decodeCursor(%q, %v) = %v", s, tt.sort, err)
+			continue
+		}
+
+		if want, ok := tt.cursor.Value.(time.Time); ok {
+			if value, _ := got.Value.(time.Time); !value.Equal(want) || got.ID != tt.cursor.ID {
+				t.Errorf("decodeCursor(%q, %v) = %v, want %v", s, tt.sort, *got, tt.cursor)
+			}
+		} else if !reflect.DeepEqual(*got, tt.cursor) {
+			t.Errorf("decodeCursor(%q, %v) = %v, want %v", s, tt.sort, *got, tt.cursor)
+		}
+	}
+}
+
+func TestDecodeEmptyCursor(t *testing.T) {
+	t.Parallel()
+
+	if cursor, err := decodeCursor("", store.Sort{Field: "name"}, false); cursor != nil || err != nil {
+		t.Errorf("decodeCursor(\"\") = %v, %v, want no cursor", cursor, err)
+	}
+}
+
+func TestDecodeTamperedCursor(t *testing.T) {
+	t.Parallel()
+
+	sort := store.Sort{Field: "created_at", Desc: true}
+	valid := encodeCursor(sort, store.Cursor{Value: time.Date(2026, time.January, 2, 3, 4, 5, 0, time.UTC), ID: 7})
+	encode := func(s string) string { return base64.RawURLEncoding.EncodeToString([]byte(s)) }
+
+	tests := []struct {
+		name   string
+		cursor string
+	}{
+		{"not base64", "not a cursor!"},
+		{"padded base64", base64.URLEncoding.EncodeToString([]byte(`{"s":"created_at","d":true,"v":"2026-01-02T03:04:05Z","id":7}`))},
+		{"truncated", valid[:len(valid)-4]},
+		{"appended", valid + "AA"},
+		{"not JSON", encode("created_at:7")},
+		{"JSON array", encode(`["created_at",true,"2026-01-02T03:04:05Z",7]`)},
+		{"ID of the wrong type", encode(`{"s":"created_at","d":true,"v":"2026-01-02T03:04:05Z","id":"7"}`)},
+		{"other sort field", encode(`{"s":"updated_at","d":true,"v":"2026-01-02T03:04:05Z","id":7}`)},
+		{"other order", encode(`{"s":"created_at","d":false,"v":"2026-01-02T03:04:05Z","id":7}`)},
+		{"no sort", encode(`{"v":"2026-01-02T03:04:05Z","id":7}`)},
+		{"value not a time", encode(`{"s":"created_at","d":true,"v":"'; DROP TABLE todos; --","id":7}`)},
+		{"empty value", encode(`{"s":"created_at","d":true,"id":7}`)},
+		{"other sort's cursor", encodeCursor(store.Sort{Field: "name", Desc: true}, store.Cursor{Value: "Groceries", ID: 7})},
+	}
+
+	for _, tt := range tests {
+		cursor, err := decodeCursor(tt.cursor, sort, true)
+
+		var appErr *apperr.Error
+		if !errors.As(err, &appErr) || appErr.Code != apperr.InvalidCursor {
+			t.Errorf("%s: decodeCursor(%q) = %v, %v, want %v", tt.name, tt.cursor, cursor, err, apperr.InvalidCursor)
+		}
+	}
+}
+
+func TestPage(t *testing.T) {
+	t.Parallel()
+
+	sort := store.Sort{Field: "name"}
+	cursorOf := func(s *string) store.Cursor { return store.Cursor{Value: *s, ID: len(*s)} }
+
+	p := page([]string{"a", "bb", "ccc"}, 2, sort, cursorOf)
+	if !reflect.DeepEqual(p.Data, []string{"a", "bb"}) || !p.Pagination.HasMore || p.Pagination.Limit != 2 {
+		t.Fatalf("page = %+v, want the first 2 items and more", p)
+	}
+
+	cursor, err := decodeCursor(p.Pagination.NextCursor, sort, false)
+	if err != nil || !reflect.DeepEqual(*cursor, store.Cursor{Value: "bb", ID: 2}) {
+		t.Errorf("next cursor decodes to %v, %v, want the last item of the page", cursor, err)
+	}
+
+	p = page([]string{"a", "bb"}, 2, sort, cursorOf)
+	if len(p.Data) != 2 || p.Pagination.HasMore || p.Pagination.NextCursor != "" {
+		t.Errorf("page = %+v, want a last page", p)
+	}
+
+	p = page[string](nil, 2, sort, cursorOf)
+	if p.Data == nil || len(p.Data) != 0 || p.Pagination.HasMore {
+		t.Errorf("page = %+v, want an empty page", p)
+	}
+}
@@ -0,0 +1,93 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+
+	"github.com/marouane-ach/todo-go/apperr"
+	"github.com/marouane-ach/todo-go/dtos"
+	"github.com/marouane-ach/todo-go/models"
+	"github.com/marouane-ach/todo-go/rank"
+	"github.com/marouane-ach/todo-go/store"
+)
+
+// rankAttempts is how many times a transaction ranking a todo list or a todo runs when concurrent
+// requests keep taking the rank it picks.
+const rankAttempts = 3
+
+// adjacentRankFunc is the AdjacentRank method of the todo list or todo store.
+type adjacentRankFunc func(ctx context.Context, scopeID int, rank string, before bool) (string, error)
+
+// todoListRanks returns the AdjacentRank method ordering the todo lists the request of user is scoped to in st, and the scope
+// to pass it: the lists of the organization, or the personal lists of the user.
+func todoListRanks(st *store.Store, user *models.User) (adjacentRankFunc, int) {
+	if user.Org != nil {
+		return st.TodoLists.AdjacentOrganizationRank, user.Org.OrganizationID
+	}
+	return st.TodoLists.AdjacentRank, user.ID
+}
+
+// placement is where a reorder request puts a todo list or a todo: right before or right after the target.
+type placement struct {
+	targetID int
+	before   bool
+	// field is the field of the request holding targetID.
+	field string
+}
+
+// parsePlacement reads the placement of a reorder request for the todo list or todo with the given ID.
+func parsePlacement(reorderDTO *dtos.ReorderDTO, id int) (placement, error) {
+	if (reorderDTO.BeforeID == 0) == (reorderDTO.AfterID == 0) {
+		return placement{}, apperr.New(apperr.ValidationFailed).WithFields([]apperr.FieldError{
+			{Field: "before_id", Message: "exactly one of before_id and after_id is required"},
+		})
+	}
+
+	p := placement{targetID: reorderDTO.AfterID, field: "after_id"}
+	if reorderDTO.BeforeID != 0 {
+		p = placement{targetID: reorderDTO.BeforeID, before: true, field: "before_id"}
+	}
+
+	if p.targetID == id {
+		return placement{}, apperr.New(apperr.ValidationFailed).WithFields([]apperr.FieldError{
+			{Field: p.field, Message: "must be the ID of another item"},
+		})
+	}
+	return p, nil
+}
+
+// rankNextTo returns a rank right before target, or right after it if before is false, among the ranks
+// adjacent looks up in scopeID. Nothing else changes rank, so the ranks around target stay valid.
+func rankNextTo(ctx context.Context, adjacent adjacentRankFunc, scopeID int, target string, before bool) (string, error) {
+	neighbor, err := adjacent(ctx, scopeID, target, before)
+	if err != nil {
+		return "", err
+	}
+
+	if before {
+		return rank.Between(neighbor, target), nil
+	}
+	return rank.Between(target, neighbor), nil
+}
+
+// rankLast returns a rank after all the ranks adjacent looks up in scopeID.
+func rankLast(ctx context.Context, adjacent adjacentRankFunc, scopeID int) (string, error) {
+	last, err := adjacent(ctx, scopeID, "", true)
+	if err != nil {
+		return "", err
+	}
+	return rank.Between(last, ""), nil
+}
+
+// inTxRanked runs fn in a transaction, again when it fails with store.ErrConflict because a concurrent
+// request gave the rank fn picked to another todo list or todo first. fn computes the rank in the transaction,
+// so a new attempt sees the rank that was taken.
+func (ctl *Controller) inTxRanked(ctx context.Context, fn func(tx *store.Store) error) error {
+	var err error
+	for range rankAttempts {
+		if err = ctl.app.Store.InTx(ctx, fn); !errors.Is(err, store.ErrConflict) {
+			return err
+		}
+	}
+	return err
+}
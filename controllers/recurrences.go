@@ -0,0 +1,286 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/marouane-ach/todo-go/apperr"
+	"github.com/marouane-ach/todo-go/dtos"
+	"github.com/marouane-ach/todo-go/models"
+	"github.com/marouane-ach/todo-go/recurrence"
+	"github.com/marouane-ach/todo-go/store"
+)
+
+// Set Recurrence godoc
+// @Summary      Make this todo recurring
+// @Description  Accepts an RFC 5545 `rule`, e.g. `FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR` for every weekday or `FREQ=MONTHLY;BYDAY=1MO`
+// @Description  for the first Monday of each month, and a `mode`. Returns the recurrence.
+// @Description  In `fixed` mode, the default, the schedule starts at the due date of the todo, which is required.
+// @Description  In `after_completion` mode, `FREQ=DAILY;INTERVAL=3` brings the todo back three days after each completion.
+// @Description  Completing a recurring todo records the completion and moves the todo to its next occurrence;
+// @Description  it stays completed once the rule has no more occurrences. Replaces the current recurrence, if any.
+// @Tags         Recurrences
+// @Param        recurrence body dtos.RecurrenceDTO true "How the todo recurs"
+// @Param        id path int true "Todo ID"
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}	models.Recurrence
+// @Failure      400  {object}  dtos.ErrorDTO
+// @Failure      401  {object}  dtos.ErrorDTO
+// @Failure      403  {object}  dtos.ErrorDTO
+// @Failure      404  {object}  dtos.ErrorDTO
+// @Failure      422  {object}  dtos.ErrorDTO
+// @Failure      500  {object}  dtos.ErrorDTO
+// @Security	 BearerAuth
+// @Router       /todos/{id}/recurrence [put]
+func (ctl *Controller) SetRecurrence(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	user, _, err := ctl.authenticate(c, ctx)
+	if err != nil {
+		return err
+	}
+
+	recurrenceDTO := new(dtos.RecurrenceDTO)
+	if err = bind(c, recurrenceDTO); err != nil {
+		return err
+	}
+
+	rule, err := recurrence.Parse(recurrenceDTO.Rule)
+	if err != nil {
+		return apperr.New(apperr.ValidationFailed).WithFields([]apperr.FieldError{
+			{Field: "rule", Message: err.Error()},
+		})
+	}
+
+	if recurrenceDTO.Mode == "" {
+		recurrenceDTO.Mode = recurrence.ModeFixed
+	}
+
+	todo, err := authorizedTodo(ctx, ctl.app.Store, user, c.Param("id"), models.RoleEditor)
+	if err != nil {
+		return err
+	}
+
+	if recurrenceDTO.Mode == recurrence.ModeFixed && todo.DueAt == nil {
+		return apperr.New(apperr.ValidationFailed).WithFields([]apperr.FieldError{
+			{Field: "mode", Message: "fixed recurrences require the todo to have a due date"},
+		})
+	}
+
+	r := todo.Recurrence
+	if r == nil {
+		r = &models.Recurrence{TodoID: todo.ID}
+	}
+	r.Rule, r.Mode, r.Text = rule, recurrenceDTO.Mode, todo.Text
+	r.Start, r.Current = todo.DueAt, todo.DueAt
+	r.UpdatedAt = ctl.app.Now()
+
+	if r.ID == 0 {
+		err = ctl.app.Store.Recurrences.Create(ctx, r)
+	} else {
+		err = ctl.app.Store.Recurrences.Update(ctx, r)
+	}
+	if err != nil {
+		return apperr.Wrap(apperr.RecurrenceUpdateFailed, err)
+	}
+
+	return c.JSON(http.StatusOK, r)
+}
+
+// Delete Recurrence godoc
+// @Summary      Stop this todo from recurring
+// @Description  Deletes the recurrence of the todo and returns it as a JSON object. The todo and its completions are kept.
+// @Tags         Recurrences
+// @Param        id path int true "Todo ID"
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}	models.Recurrence
+// @Failure      400  {object}  dtos.ErrorDTO
+// @Failure      401  {object}  dtos.ErrorDTO
+// @Failure      403  {object}  dtos.ErrorDTO
+// @Failure      404  {object}  dtos.ErrorDTO
+// @Failure      500  {object}  dtos.ErrorDTO
+// @Security	 BearerAuth
+// @Router       /todos/{id}/recurrence [delete]
+func (ctl *Controller) DeleteRecurrence(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	user, _, err := ctl.authenticate(c, ctx)
+	if err != nil {
+		return err
+	}
+
+	todo, err := authorizedTodo(ctx, ctl.app.Store, user, c.Param("id"), models.RoleEditor)
+	if err != nil {
+		return err
+	}
+
+	if todo.Recurrence == nil {
+		return apperr.New(apperr.RecurrenceNotFound)
+	}
+
+	if err = ctl.app.Store.Recurrences.Delete(ctx, todo.Recurrence.ID); errors.Is(err, store.ErrNotFound) {
+		return apperr.New(apperr.RecurrenceNotFound)
+	} else if err != nil {
+		return apperr.Wrap(apperr.RecurrenceDeleteFailed, err)
+	}
+
+	return c.JSON(http.StatusOK, todo.Recurrence)
+}
+
+// List Completions godoc
+// @Summary      List the completions of this todo
+// @Description  Returns a page of the times the todo was completed, with the due date it had then, latest first.
+// @Description  Recurring todos have one per completed occurrence.
+// @Tags         Recurrences
+// @Param        id     path  int    true  "Todo ID"
+// @Param        limit  query int    false "Number of completions per page (1-100, default 50)"
+// @Param        cursor query string false "Cursor returned as next_cursor by the previous page"
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}	dtos.PageDTO[models.Completion]
+// @Failure      400  {object}  dtos.ErrorDTO
+// @Failure      401  {object}  dtos.ErrorDTO
+// @Failure      404  {object}  dtos.ErrorDTO
+// @Failure      422  {object}  dtos.ErrorDTO
+// @Failure      500  {object}  dtos.ErrorDTO
+// @Security	 BearerAuth
+// @Router       /todos/{id}/completions [get]
+func (ctl *Controller) ListCompletions(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	user, _, err := ctl.authenticate(c, ctx)
+	if err != nil {
+		return err
+	}
+
+	queryDTO := new(dtos.CompletionQueryDTO)
+	if err = bind(c, queryDTO); err != nil {
+		return err
+	}
+
+	todo, err := authorizedTodo(ctx, ctl.app.Store, user, c.Param("id"), models.RoleViewer)
+	if err != nil {
+		return err
+	}
+
+	query := store.CompletionQuery{TodoID: todo.ID, Limit: queryDTO.Limit}
+	if query.Limit == 0 {
+		query.Limit = defaultPageLimit
+	}
+
+	query.After, err = decodeCursor(queryDTO.Cursor, store.CompletionSort, true)
+	if err != nil {
+		return err
+	}
+
+	// Fetch one more completion than asked to know whether there is a next page.
+	limit := query.Limit
+	query.Limit++
+
+	completions, err := ctl.app.Store.Completions.List(ctx, query)
+	if err != nil {
+		return apperr.Wrap(apperr.CompletionFetchFailed, err)
+	}
+
+	return c.JSON(http.StatusOK, page(completions, limit, store.CompletionSort, func(c *models.Completion) store.Cursor {
+		return store.Cursor{Value: c.CompletedAt, ID: c.ID}
+	}))
+}
+
+// updateSeries applies an update of the current occurrence of a recurring todo to the next ones.
+// previousDue is the due date the todo had before the update.
+func updateSeries(todo *models.Todo, previousDue *time.Time) error {
+	r := todo.Recurrence
+	r.Text = todo.Text
+
+	if equalTimes(previousDue, todo.DueAt) {
+		return nil
+	}
+
+	if r.Mode == recurrence.ModeFixed {
+		if todo.DueAt == nil {
+			return apperr.New(apperr.ValidationFailed).WithFields([]apperr.FieldError{
+				{Field: "due_at", Message: "fixed recurrences require the todo to have a due date"},
+			})
+		}
+		// Moving the series restarts its schedule from the new due date.
+		r.Start = todo.DueAt
+	}
+	r.Current = todo.DueAt
+	return nil
+}
+
+// completeTodo records the completion of todo at now and, if it is recurring, moves it to its next
+// occurrence, which is returned as true. The todo ends up in a status matching whether it is completed.
+// The caller saves todo.
+func completeTodo(ctx context.Context, st *store.Store, user *models.User, todo *models.Todo, now time.Time) (bool, error) {
+	completion := &models.Completion{TodoID: todo.ID, DueAt: todo.DueAt, CompletedAt: now}
+	if err := st.Completions.Create(ctx, completion); err != nil {
+		return false, err
+	}
+
+	todo.Completed, todo.CompletedAt = true, &now
+
+	r := todo.Recurrence
+	if r == nil {
+		return false, syncStatus(ctx, st, todo)
+	}
+
+	next, ok, err := nextOccurrence(r, todo, location(user), now)
+	if err != nil {
+		return false, err
+	} else if !ok {
+		return false, syncStatus(ctx, st, todo)
+	}
+
+	todo.Completed, todo.CompletedAt = false, nil
+	todo.Text, todo.DueAt = r.Text, &next
+	r.Current = &next
+	r.UpdatedAt = now
+
+	if err := st.Recurrences.Update(ctx, r); err != nil {
+		return false, err
+	}
+
+	// The next occurrence starts with its checklist to do again.
+	if err := st.ChecklistItems.UncheckAll(ctx, todo.ID); err != nil {
+		return false, err
+	}
+	if err := st.Todos.UpdateProgress(ctx, todo); err != nil {
+		return false, err
+	}
+	return true, syncStatus(ctx, st, todo)
+}
+
+// nextOccurrence returns the due date of the occurrence following the current one of todo, completed at now.
+// Fixed recurrences skip the occurrences already past, so that an overdue todo comes back once.
+func nextOccurrence(r *models.Recurrence, todo *models.Todo, loc *time.Location, now time.Time) (time.Time, bool, error) {
+	// All-day due dates are dates at midnight UTC, so their rules are expanded in UTC.
+	ruleLoc := loc
+	if todo.AllDay {
+		ruleLoc = time.UTC
+	}
+
+	if r.Mode == recurrence.ModeAfterCompletion {
+		start := recurrence.StartAfterCompletion(now, r.Current, todo.AllDay, loc)
+		return recurrence.Next(r.Rule, start, start, ruleLoc)
+	}
+
+	if r.Start == nil || r.Current == nil {
+		return time.Time{}, false, nil
+	}
+
+	after := now
+	if todo.AllDay {
+		after = dateOf(now, loc)
+	}
+	if r.Current.After(after) {
+		after = *r.Current
+	}
+	return recurrence.Next(r.Rule, *r.Start, after, ruleLoc)
+}
@@ -0,0 +1,246 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/marouane-ach/todo-go/apperr"
+	"github.com/marouane-ach/todo-go/dtos"
+	"github.com/marouane-ach/todo-go/models"
+	"github.com/marouane-ach/todo-go/notify"
+	"github.com/marouane-ach/todo-go/store"
+)
+
+// Create Reminder godoc
+// @Summary      Add a reminder to this todo
+// @Description  Accepts either `remind_at`, an RFC 3339 time, or `offset_minutes`, a number of minutes before the due date of the todo,
+// @Description  and the `channel` to deliver the reminder through, `in_app` by default. Returns the created reminder.
+// @Description  Relative reminders follow the due date when it changes and don't fire while the todo has none.
+// @Description  A reminder notifies the user who created it, through their own email address or webhook URL.
+// @Description  Anyone who can see the todo list can create reminders, and delete their own.
+// @Tags         Reminders
+// @Param        reminder body dtos.ReminderDTO true "When and how to remind"
+// @Param        id path int true "Todo ID"
+// @Accept       json
+// @Produce      json
+// @Success      201  {object}	models.Reminder
+// @Failure      400  {object}  dtos.ErrorDTO
+// @Failure      401  {object}  dtos.ErrorDTO
+// @Failure      404  {object}  dtos.ErrorDTO
+// @Failure      422  {object}  dtos.ErrorDTO
+// @Failure      500  {object}  dtos.ErrorDTO
+// @Security	 BearerAuth
+// @Router       /todos/{id}/reminders [post]
+func (ctl *Controller) CreateReminder(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	user, _, err := ctl.authenticate(c, ctx)
+	if err != nil {
+		return err
+	}
+
+	reminderDTO := new(dtos.ReminderDTO)
+	if err = bind(c, reminderDTO); err != nil {
+		return err
+	}
+
+	if (reminderDTO.RemindAt == nil) == (reminderDTO.OffsetMinutes == nil) {
+		return apperr.New(apperr.ValidationFailed).WithFields([]apperr.FieldError{
+			{Field: "remind_at", Message: "exactly one of remind_at and offset_minutes is required"},
+		})
+	}
+
+	if reminderDTO.Channel == "" {
+		reminderDTO.Channel = notify.ChannelInApp
+	}
+
+	if reminderDTO.Channel == notify.ChannelWebhook && user.WebhookURL == "" {
+		return apperr.New(apperr.ValidationFailed).WithFields([]apperr.FieldError{
+			{Field: "channel", Message: "requires a webhook_url, set with PATCH /me"},
+		})
+	}
+
+	todo, err := authorizedTodo(ctx, ctl.app.Store, user, c.Param("id"), models.RoleViewer)
+	if err != nil {
+		return err
+	}
+
+	reminder := &models.Reminder{
+		TodoID:        todo.ID,
+		UserID:        user.ID,
+		OffsetMinutes: reminderDTO.OffsetMinutes,
+		Channel:       reminderDTO.Channel,
+		Status:        models.ReminderPending,
+	}
+	if reminderDTO.RemindAt != nil {
+		remindAt := reminderDTO.RemindAt.UTC()
+		reminder.RemindAt = &remindAt
+	}
+	reminder.FireAt = fireAt(reminder, todo)
+
+	if err = ctl.app.Store.Reminders.Create(ctx, reminder); err != nil {
+		return apperr.Wrap(apperr.ReminderCreateFailed, err)
+	}
+
+	return c.JSON(http.StatusCreated, reminder)
+}
+
+// List Reminders godoc
+// @Summary      List the reminders of this todo
+// @Description  Returns the reminders the user set on the todo, including the ones already sent, oldest first.
+// @Tags         Reminders
+// @Param        id path int true "Todo ID"
+// @Accept       json
+// @Produce      json
+// @Success      200  {array}	models.Reminder
+// @Failure      400  {object}  dtos.ErrorDTO
+// @Failure      401  {object}  dtos.ErrorDTO
+// @Failure      404  {object}  dtos.ErrorDTO
+// @Failure      500  {object}  dtos.ErrorDTO
+// @Security	 BearerAuth
+// @Router       /todos/{id}/reminders [get]
+func (ctl *Controller) ListReminders(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	user, _, err := ctl.authenticate(c, ctx)
+	if err != nil {
+		return err
+	}
+
+	todo, err := authorizedTodo(ctx, ctl.app.Store, user, c.Param("id"), models.RoleViewer)
+	if err != nil {
+		return err
+	}
+
+	reminders, err := ctl.app.Store.Reminders.ListByTodo(ctx, todo.ID)
+	if err != nil {
+		return apperr.Wrap(apperr.ReminderFetchFailed, err)
+	}
+
+	// Reminders are personal: each user only sees the ones they will receive.
+	reminders = slices.DeleteFunc(reminders, func(r models.Reminder) bool { return r.UserID != user.ID })
+
+	return c.JSON(http.StatusOK, reminders)
+}
+
+// Delete Reminder godoc
+// @Summary      Delete a reminder
+// @Description  Deletes the reminder and returns it as a JSON object. Users only delete the reminders they created.
+// @Tags         Reminders
+// @Param        id path int true "Reminder ID"
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}	models.Reminder
+// @Failure      400  {object}  dtos.ErrorDTO
+// @Failure      401  {object}  dtos.ErrorDTO
+// @Failure      404  {object}  dtos.ErrorDTO
+// @Failure      500  {object}  dtos.ErrorDTO
+// @Security	 BearerAuth
+// @Router       /reminders/{id} [delete]
+func (ctl *Controller) DeleteReminder(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	user, _, err := ctl.authenticate(c, ctx)
+	if err != nil {
+		return err
+	}
+
+	reminder, err := authorizedReminder(ctx, ctl.app.Store, user, c.Param("id"), models.RoleViewer)
+	if err != nil {
+		return err
+	}
+
+	// The reminders of other members are as invisible as they are in the list.
+	if reminder.UserID != user.ID {
+		return apperr.New(apperr.ReminderNotFound)
+	}
+
+	if err = ctl.app.Store.Reminders.Delete(ctx, reminder.ID); errors.Is(err, store.ErrNotFound) {
+		return apperr.New(apperr.ReminderNotFound)
+	} else if err != nil {
+		return apperr.Wrap(apperr.ReminderDeleteFailed, err)
+	}
+
+	reminder.Todo = nil
+	return c.JSON(http.StatusOK, reminder)
+}
+
+// authorizedReminder loads the reminder with the given ID from st and makes sure user has at least the role minRole
+// on the todo list of its todo.
+func authorizedReminder(ctx context.Context, st *store.Store, user *models.User, id string, minRole string) (*models.Reminder, error) {
+	reminderID, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	reminder, err := st.Reminders.GetByID(ctx, reminderID)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, apperr.New(apperr.ReminderNotFound)
+	} else if err != nil {
+		return nil, apperr.Wrap(apperr.ReminderFetchFailed, err)
+	}
+
+	if reminder.Todo == nil {
+		return nil, apperr.New(apperr.ReminderNotFound)
+	}
+
+	if err = authorize(ctx, st, user, reminder.Todo.TodoList, minRole, apperr.ReminderNotFound); err != nil {
+		return nil, err
+	}
+
+	return reminder, nil
+}
+
+// fireAt returns when reminder should fire for todo, or nil if it is relative and the todo has no due date.
+func fireAt(reminder *models.Reminder, todo *models.Todo) *time.Time {
+	if reminder.RemindAt != nil {
+		return reminder.RemindAt
+	}
+
+	if todo.DueAt == nil {
+		return nil
+	}
+
+	t := todo.DueAt.Add(-time.Duration(*reminder.OffsetMinutes) * time.Minute)
+	return &t
+}
+
+// rescheduleReminders moves the reminders relative to the due date of todo after it changed.
+// The ones already sent fire again if their new time is still to come.
+func rescheduleReminders(ctx context.Context, st *store.Store, todo *models.Todo, now time.Time) error {
+	reminders, err := st.Reminders.ListByTodo(ctx, todo.ID)
+	if err != nil {
+		return err
+	}
+
+	for i := range reminders {
+		reminder := &reminders[i]
+		if reminder.OffsetMinutes == nil {
+			continue
+		}
+
+		reminder.FireAt = fireAt(reminder, todo)
+		if reminder.FireAt != nil && reminder.FireAt.After(now) {
+			reminder.Status, reminder.Attempts, reminder.LastError, reminder.SentAt = models.ReminderPending, 0, "", nil
+		}
+		reminder.UpdatedAt = now
+
+		if err := st.Reminders.Update(ctx, reminder); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// equalTimes reports whether two optional times are both nil or the same instant.
+func equalTimes(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
@@ -0,0 +1,85 @@
+package controllers
+
+import (
+	"html"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/marouane-ach/todo-go/apperr"
+	"github.com/marouane-ach/todo-go/dtos"
+	"github.com/marouane-ach/todo-go/store"
+)
+
+const defaultSearchLimit = 20
+
+var highlighter = strings.NewReplacer(store.HighlightStart, "<mark>", store.HighlightEnd, "</mark>")
+
+// Search godoc
+// @Summary      Search todos and todo lists
+// @Description  Returns the todos and todo lists whose text, notes or name matches every word of `q`, best first.
+// @Description  Quoted words match as a phrase and a trailing `*` matches word prefixes.
+// @Tags         Search
+// @Param        q     query string true  "Search terms"
+// @Param        limit query int    false "Maximum number of results (1-100, default 20)"
+// @Param        render query string false "html to add the sanitized HTML rendering of the notes of the todos as NotesHTML" Enums(html)
+// @Param        X-Org-ID header int false "ID of the organization to work in"
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}	dtos.SearchResultsDTO
+// @Failure      401  {object}  dtos.ErrorDTO
+// @Failure      422  {object}  dtos.ErrorDTO
+// @Failure      500  {object}  dtos.ErrorDTO
+// @Security	 BearerAuth
+// @Router       /search [get]
+func (ctl *Controller) Search(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	user, _, err := ctl.authenticate(c, ctx)
+	if err != nil {
+		return err
+	}
+
+	queryDTO := new(dtos.SearchQueryDTO)
+	if err = bind(c, queryDTO); err != nil {
+		return err
+	}
+
+	renderHTML, err := renderParam(c)
+	if err != nil {
+		return err
+	}
+
+	query := store.SearchQuery{UserID: user.ID, OrganizationID: orgID(user), Terms: store.ParseSearch(queryDTO.Q), Limit: queryDTO.Limit}
+	if query.Limit == 0 {
+		query.Limit = defaultSearchLimit
+	}
+
+	hits, err := ctl.app.Store.Search.Search(ctx, query)
+	if err != nil {
+		return apperr.Wrap(apperr.SearchFailed, err)
+	}
+
+	results := make([]dtos.SearchResultDTO, len(hits))
+	for i, hit := range hits {
+		results[i] = dtos.SearchResultDTO{
+			Type:     "todo_list",
+			Rank:     hit.Rank,
+			Snippet:  highlighter.Replace(html.EscapeString(hit.Snippet)),
+			TodoList: hit.TodoList,
+		}
+		if hit.Todo != nil {
+			// The list is already in TodoList.
+			todo := *hit.Todo
+			todo.TodoList = nil
+			if renderHTML {
+				if err = renderNotes(&todo); err != nil {
+					return err
+				}
+			}
+			results[i].Type, results[i].Todo = "todo", &todo
+		}
+	}
+
+	return c.JSON(http.StatusOK, dtos.SearchResultsDTO{Data: results})
+}
@@ -0,0 +1,268 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/marouane-ach/todo-go/apperr"
+	"github.com/marouane-ach/todo-go/dtos"
+	"github.com/marouane-ach/todo-go/models"
+	"github.com/marouane-ach/todo-go/store"
+	"github.com/marouane-ach/todo-go/utils"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// sharePasswordHeader carries the password of a share link.
+const sharePasswordHeader = "X-Share-Password"
+
+// Create Share Link godoc
+// @Summary      Share this todo list through a public link
+// @Description  Accepts an optional `expires_at`, an RFC 3339 time, and an optional `password` as a JSON object and returns the created link.
+// @Description  Anyone with its `Slug` can read the list at `GET /shared/{slug}` without logging in, sending the password, if any, in the
+// @Description  X-Share-Password header. Only the owner of the list can share it.
+// @Tags         Share Links
+// @Param        share_link body dtos.ShareLinkDTO true "When the link expires and its password"
+// @Param        id path int true "Todo List ID"
+// @Accept       json
+// @Produce      json
+// @Success      201  {object}	models.ShareLink
+// @Failure      400  {object}  dtos.ErrorDTO
+// @Failure      401  {object}  dtos.ErrorDTO
+// @Failure      403  {object}  dtos.ErrorDTO
+// @Failure      404  {object}  dtos.ErrorDTO
+// @Failure      422  {object}  dtos.ErrorDTO
+// @Failure      500  {object}  dtos.ErrorDTO
+// @Security	 BearerAuth
+// @Router       /todolists/{id}/sharelinks [post]
+func (ctl *Controller) CreateShareLink(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	user, _, err := ctl.authenticate(c, ctx)
+	if err != nil {
+		return err
+	}
+
+	shareLinkDTO := new(dtos.ShareLinkDTO)
+	if err = bind(c, shareLinkDTO); err != nil {
+		return err
+	}
+
+	if shareLinkDTO.ExpiresAt != nil && !shareLinkDTO.ExpiresAt.After(ctl.app.Now()) {
+		return apperr.New(apperr.ValidationFailed).WithFields([]apperr.FieldError{
+			{Field: "expires_at", Message: "must be in the future"},
+		})
+	}
+
+	todoList, err := authorizedTodoList(ctx, ctl.app.Store, user, c.Param("id"), models.RoleOwner)
+	if err != nil {
+		return err
+	}
+
+	link := &models.ShareLink{TodoListID: todoList.ID, Slug: utils.GenerateSlug()}
+	if shareLinkDTO.ExpiresAt != nil {
+		expiresAt := shareLinkDTO.ExpiresAt.UTC()
+		link.ExpiresAt = &expiresAt
+	}
+	if shareLinkDTO.Password != "" {
+		link.HashedPassword = utils.HashPassword(shareLinkDTO.Password)
+	}
+
+	if err = ctl.app.Store.ShareLinks.Create(ctx, link); err != nil {
+		return apperr.Wrap(apperr.ShareLinkCreateFailed, err)
+	}
+
+	link.HasPassword = link.HashedPassword != ""
+	return c.JSON(http.StatusCreated, link)
+}
+
+// List Share Links godoc
+// @Summary      List the share links of this todo list
+// @Description  Returns the links sharing the todo list, oldest first, with the number of `Views` of each and when it was last accessed.
+// @Description  Only the owner of the list can see them.
+// @Tags         Share Links
+// @Param        id path int true "Todo List ID"
+// @Accept       json
+// @Produce      json
+// @Success      200  {array}	models.ShareLink
+// @Failure      400  {object}  dtos.ErrorDTO
+// @Failure      401  {object}  dtos.ErrorDTO
+// @Failure      403  {object}  dtos.ErrorDTO
+// @Failure      404  {object}  dtos.ErrorDTO
+// @Failure      500  {object}  dtos.ErrorDTO
+// @Security	 BearerAuth
+// @Router       /todolists/{id}/sharelinks [get]
+func (ctl *Controller) ListShareLinks(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	user, _, err := ctl.authenticate(c, ctx)
+	if err != nil {
+		return err
+	}
+
+	todoList, err := authorizedTodoList(ctx, ctl.app.Store, user, c.Param("id"), models.RoleOwner)
+	if err != nil {
+		return err
+	}
+
+	links, err := ctl.app.Store.ShareLinks.ListByTodoList(ctx, todoList.ID)
+	if err != nil {
+		return apperr.Wrap(apperr.ShareLinkFetchFailed, err)
+	}
+
+	for i := range links {
+		links[i].HasPassword = links[i].HashedPassword != ""
+	}
+
+	return c.JSON(http.StatusOK, links)
+}
+
+// Delete Share Link godoc
+// @Summary      Revoke a share link
+// @Description  Deletes the share link, which stops working right away, and returns it. Only the owner of the todo list can revoke it.
+// @Tags         Share Links
+// @Param        id path int true "Share Link ID"
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}	models.ShareLink
+// @Failure      400  {object}  dtos.ErrorDTO
+// @Failure      401  {object}  dtos.ErrorDTO
+// @Failure      403  {object}  dtos.ErrorDTO
+// @Failure      404  {object}  dtos.ErrorDTO
+// @Failure      500  {object}  dtos.ErrorDTO
+// @Security	 BearerAuth
+// @Router       /sharelinks/{id} [delete]
+func (ctl *Controller) DeleteShareLink(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	user, _, err := ctl.authenticate(c, ctx)
+	if err != nil {
+		return err
+	}
+
+	linkID, err := parseID(c.Param("id"))
+	if err != nil {
+		return err
+	}
+
+	link, err := ctl.app.Store.ShareLinks.GetByID(ctx, linkID)
+	if errors.Is(err, store.ErrNotFound) {
+		return apperr.New(apperr.ShareLinkNotFound)
+	} else if err != nil {
+		return apperr.Wrap(apperr.ShareLinkFetchFailed, err)
+	}
+
+	if err = authorize(ctx, ctl.app.Store, user, link.TodoList, models.RoleOwner, apperr.ShareLinkNotFound); err != nil {
+		return err
+	}
+
+	if err = ctl.app.Store.ShareLinks.Delete(ctx, link.ID); errors.Is(err, store.ErrNotFound) {
+		return apperr.New(apperr.ShareLinkNotFound)
+	} else if err != nil {
+		return apperr.Wrap(apperr.ShareLinkDeleteFailed, err)
+	}
+
+	link.TodoList = nil
+	link.HasPassword = link.HashedPassword != ""
+	return c.JSON(http.StatusOK, link)
+}
+
+// Get Shared Todo List godoc
+// @Summary      Read a todo list through a share link
+// @Description  Returns the name, color and todos of the shared todo list, in order, and nothing about its owner. No login is needed,
+// @Description  but links with a password need it in the X-Share-Password header. Every successful read counts as a view of the link.
+// @Tags         Share Links
+// @Param        slug path string true "Slug of the share link"
+// @Param        X-Share-Password header string false "Password of the link, if it has one"
+// @Param        render query string false "html to add the sanitized HTML rendering of the notes of the todos as notes_html" Enums(html)
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}	dtos.SharedTodoListDTO
+// @Failure      401  {object}  dtos.ErrorDTO
+// @Failure      404  {object}  dtos.ErrorDTO
+// @Failure      410  {object}  dtos.ErrorDTO
+// @Failure      422  {object}  dtos.ErrorDTO
+// @Failure      500  {object}  dtos.ErrorDTO
+// @Router       /shared/{slug} [get]
+func (ctl *Controller) GetSharedTodoList(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	html, err := renderParam(c)
+	if err != nil {
+		return err
+	}
+
+	link, err := ctl.app.Store.ShareLinks.GetBySlug(ctx, c.Param("slug"))
+	if errors.Is(err, store.ErrNotFound) {
+		return apperr.New(apperr.ShareLinkNotFound)
+	} else if err != nil {
+		return apperr.Wrap(apperr.ShareLinkFetchFailed, err)
+	}
+
+	now := ctl.app.Now()
+	if link.ExpiresAt != nil && !link.ExpiresAt.After(now) {
+		return apperr.New(apperr.ShareLinkExpired)
+	}
+
+	if link.HashedPassword != "" {
+		password := c.Request().Header.Get(sharePasswordHeader)
+		if password == "" {
+			return apperr.New(apperr.SharePasswordRequired)
+		}
+		if bcrypt.CompareHashAndPassword([]byte(link.HashedPassword), []byte(password)) != nil {
+			return apperr.New(apperr.WrongPassword)
+		}
+	}
+
+	todoList, err := ctl.app.Store.TodoLists.GetByID(ctx, link.TodoListID)
+	if errors.Is(err, store.ErrNotFound) {
+		return apperr.New(apperr.ShareLinkNotFound)
+	} else if err != nil {
+		return apperr.Wrap(apperr.ShareLinkFetchFailed, err)
+	}
+
+	color, err := ctl.app.Store.Colors.GetByID(ctx, todoList.ColorID)
+	if err != nil {
+		return apperr.Wrap(apperr.ShareLinkFetchFailed, err)
+	}
+
+	statuses, err := ctl.app.Store.Statuses.ListByTodoList(ctx, todoList.ID)
+	if err != nil {
+		return apperr.Wrap(apperr.ShareLinkFetchFailed, err)
+	}
+
+	statusNames := make(map[int]string, len(statuses))
+	for _, status := range statuses {
+		statusNames[status.ID] = status.Name
+	}
+
+	shared := dtos.SharedTodoListDTO{Name: todoList.Name, Color: color.ColorHex, Todos: []dtos.SharedTodoDTO{}}
+	for i := range todoList.Todos {
+		todo := &todoList.Todos[i]
+		if html {
+			if err = renderNotes(todo); err != nil {
+				return err
+			}
+		}
+
+		shared.Todos = append(shared.Todos, dtos.SharedTodoDTO{
+			Text:        todo.Text,
+			Completed:   todo.Completed,
+			Status:      statusNames[todo.StatusID],
+			Priority:    todo.Priority,
+			Notes:       todo.Notes,
+			NotesHTML:   todo.NotesHTML,
+			DueAt:       todo.DueAt,
+			AllDay:      todo.AllDay,
+			CompletedAt: todo.CompletedAt,
+			ItemsTotal:  todo.ItemsTotal,
+			ItemsDone:   todo.ItemsDone,
+		})
+	}
+
+	if err = ctl.app.Store.ShareLinks.RecordView(ctx, link.ID, now); err != nil && !errors.Is(err, store.ErrNotFound) {
+		return apperr.Wrap(apperr.ShareLinkFetchFailed, err)
+	}
+
+	return c.JSON(http.StatusOK, shared)
+}
@@ -0,0 +1,532 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/marouane-ach/todo-go/apperr"
+	"github.com/marouane-ach/todo-go/dtos"
+	"github.com/marouane-ach/todo-go/models"
+	"github.com/marouane-ach/todo-go/store"
+)
+
+// List Statuses godoc
+// @Summary      List the statuses of this todo list
+// @Description  Returns the statuses of the todo list in board order. New todo lists start with To do, In progress, Blocked and Done, the only terminal one.
+// @Tags         Statuses
+// @Param        id path int true "Todo List ID"
+// @Accept       json
+// @Produce      json
+// @Success      200  {array}	models.Status
+// @Failure      400  {object}  dtos.ErrorDTO
+// @Failure      401  {object}  dtos.ErrorDTO
+// @Failure      404  {object}  dtos.ErrorDTO
+// @Failure      500  {object}  dtos.ErrorDTO
+// @Security	 BearerAuth
+// @Router       /todolists/{id}/statuses [get]
+func (ctl *Controller) ListStatuses(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	user, _, err := ctl.authenticate(c, ctx)
+	if err != nil {
+		return err
+	}
+
+	todoList, err := authorizedTodoList(ctx, ctl.app.Store, user, c.Param("id"), models.RoleViewer)
+	if err != nil {
+		return err
+	}
+
+	statuses, err := ctl.app.Store.Statuses.ListByTodoList(ctx, todoList.ID)
+	if err != nil {
+		return apperr.Wrap(apperr.StatusFetchFailed, err)
+	}
+
+	return c.JSON(http.StatusOK, statuses)
+}
+
+// Create Status godoc
+// @Summary      Add a status to this todo list
+// @Description  Accepts a `name` and whether the status is `terminal` as a JSON object and returns the created status, which goes after the other statuses of the list.
+// @Description  Todos reaching a terminal status are completed.
+// @Tags         Statuses
+// @Param        status body dtos.StatusDTO true "The status' name and whether it is terminal"
+// @Param        id path int true "Todo List ID"
+// @Accept       json
+// @Produce      json
+// @Success      201  {object}	models.Status
+// @Failure      400  {object}  dtos.ErrorDTO
+// @Failure      401  {object}  dtos.ErrorDTO
+// @Failure      403  {object}  dtos.ErrorDTO
+// @Failure      404  {object}  dtos.ErrorDTO
+// @Failure      422  {object}  dtos.ErrorDTO
+// @Failure      500  {object}  dtos.ErrorDTO
+// @Security	 BearerAuth
+// @Router       /todolists/{id}/statuses [post]
+func (ctl *Controller) CreateStatus(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	user, _, err := ctl.authenticate(c, ctx)
+	if err != nil {
+		return err
+	}
+
+	statusDTO := new(dtos.StatusDTO)
+	if err = bind(c, statusDTO); err != nil {
+		return err
+	}
+
+	todoList, err := authorizedTodoList(ctx, ctl.app.Store, user, c.Param("id"), models.RoleAdmin)
+	if err != nil {
+		return err
+	}
+
+	status := &models.Status{TodoListID: todoList.ID, Name: statusDTO.Name, Terminal: statusDTO.Terminal}
+	if err = ctl.app.Store.Statuses.Create(ctx, status); err != nil {
+		return apperr.Wrap(apperr.StatusCreateFailed, err)
+	}
+
+	return c.JSON(http.StatusCreated, status)
+}
+
+// Update Status godoc
+// @Summary      Rename a status or change whether it is terminal
+// @Description  Accepts a `name` and whether the status is `terminal` as a JSON object and returns the updated status.
+// @Description  Making a status terminal completes its todos and making it open reopens them.
+// @Description  A todo list keeps at least one open and one terminal status.
+// @Tags         Statuses
+// @Param        status body dtos.StatusDTO true "The status' name and whether it is terminal"
+// @Param        id path int true "Status ID"
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}	models.Status
+// @Failure      400  {object}  dtos.ErrorDTO
+// @Failure      401  {object}  dtos.ErrorDTO
+// @Failure      403  {object}  dtos.ErrorDTO
+// @Failure      404  {object}  dtos.ErrorDTO
+// @Failure      409  {object}  dtos.ErrorDTO
+// @Failure      422  {object}  dtos.ErrorDTO
+// @Failure      500  {object}  dtos.ErrorDTO
+// @Security	 BearerAuth
+// @Router       /statuses/{id} [put]
+func (ctl *Controller) UpdateStatus(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	user, _, err := ctl.authenticate(c, ctx)
+	if err != nil {
+		return err
+	}
+
+	statusDTO := new(dtos.StatusDTO)
+	if err = bind(c, statusDTO); err != nil {
+		return err
+	}
+
+	var status *models.Status
+	err = ctl.app.Store.InTx(ctx, func(tx *store.Store) error {
+		status, err = authorizedStatus(ctx, tx, user, c.Param("id"), models.RoleAdmin)
+		if err != nil {
+			return err
+		}
+		status.TodoList = nil
+
+		terminalChanged := status.Terminal != statusDTO.Terminal
+		status.Name, status.Terminal = statusDTO.Name, statusDTO.Terminal
+		status.UpdatedAt = ctl.app.Now()
+
+		if terminalChanged {
+			statuses, err := tx.Statuses.ListByTodoList(ctx, status.TodoListID)
+			if err != nil {
+				return err
+			}
+			i := slices.IndexFunc(statuses, func(s models.Status) bool { return s.ID == status.ID })
+			statuses[i] = *status
+			if !openAndTerminal(statuses) {
+				return apperr.New(apperr.StatusRequired)
+			}
+		}
+
+		if err := tx.Statuses.Update(ctx, status); err != nil {
+			return err
+		}
+
+		if !terminalChanged {
+			return nil
+		}
+
+		var completedAt *time.Time
+		if status.Terminal {
+			completedAt = &status.UpdatedAt
+		}
+		return tx.Todos.CompleteStatus(ctx, status.ID, completedAt)
+	})
+	if errors.Is(err, store.ErrNotFound) {
+		return apperr.New(apperr.StatusNotFound)
+	} else if err != nil {
+		return apperr.Wrap(apperr.StatusUpdateFailed, err)
+	}
+
+	return c.JSON(http.StatusOK, status)
+}
+
+// Delete Status godoc
+// @Summary      Delete a status
+// @Description  Moves the todos of the status to the first other status of the todo list that is also open or terminal,
+// @Description  deletes the status and returns it as a JSON object. A todo list keeps at least one open and one terminal status.
+// @Tags         Statuses
+// @Param        id path int true "Status ID"
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}	models.Status
+// @Failure      400  {object}  dtos.ErrorDTO
+// @Failure      401  {object}  dtos.ErrorDTO
+// @Failure      403  {object}  dtos.ErrorDTO
+// @Failure      404  {object}  dtos.ErrorDTO
+// @Failure      409  {object}  dtos.ErrorDTO
+// @Failure      500  {object}  dtos.ErrorDTO
+// @Security	 BearerAuth
+// @Router       /statuses/{id} [delete]
+func (ctl *Controller) DeleteStatus(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	user, _, err := ctl.authenticate(c, ctx)
+	if err != nil {
+		return err
+	}
+
+	var status *models.Status
+	err = ctl.app.Store.InTx(ctx, func(tx *store.Store) error {
+		status, err = authorizedStatus(ctx, tx, user, c.Param("id"), models.RoleAdmin)
+		if err != nil {
+			return err
+		}
+		status.TodoList = nil
+
+		statuses, err := tx.Statuses.ListByTodoList(ctx, status.TodoListID)
+		if err != nil {
+			return err
+		}
+		statuses = slices.DeleteFunc(statuses, func(s models.Status) bool { return s.ID == status.ID })
+		if !openAndTerminal(statuses) {
+			return apperr.New(apperr.StatusRequired)
+		}
+
+		i := slices.IndexFunc(statuses, func(s models.Status) bool { return s.Terminal == status.Terminal })
+		if err := tx.Todos.MoveStatus(ctx, status.ID, statuses[i].ID); err != nil {
+			return err
+		}
+
+		return tx.Statuses.Delete(ctx, status.ID)
+	})
+	if errors.Is(err, store.ErrNotFound) {
+		return apperr.New(apperr.StatusNotFound)
+	} else if err != nil {
+		return apperr.Wrap(apperr.StatusDeleteFailed, err)
+	}
+
+	return c.JSON(http.StatusOK, status)
+}
+
+// Reorder Statuses godoc
+// @Summary      Reorder the statuses of this todo list
+// @Description  Accepts the `ids` of all the statuses of the todo list in their new order and returns the statuses in that order.
+// @Tags         Statuses
+// @Param        order body dtos.StatusOrderDTO true "The IDs of the statuses in order"
+// @Param        id path int true "Todo List ID"
+// @Accept       json
+// @Produce      json
+// @Success      200  {array}	models.Status
+// @Failure      400  {object}  dtos.ErrorDTO
+// @Failure      401  {object}  dtos.ErrorDTO
+// @Failure      403  {object}  dtos.ErrorDTO
+// @Failure      404  {object}  dtos.ErrorDTO
+// @Failure      422  {object}  dtos.ErrorDTO
+// @Failure      500  {object}  dtos.ErrorDTO
+// @Security	 BearerAuth
+// @Router       /todolists/{id}/statuses/reorder [post]
+func (ctl *Controller) ReorderStatuses(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	user, _, err := ctl.authenticate(c, ctx)
+	if err != nil {
+		return err
+	}
+
+	orderDTO := new(dtos.StatusOrderDTO)
+	if err = bind(c, orderDTO); err != nil {
+		return err
+	}
+
+	todoList, err := authorizedTodoList(ctx, ctl.app.Store, user, c.Param("id"), models.RoleAdmin)
+	if err != nil {
+		return err
+	}
+
+	var statuses []models.Status
+	err = ctl.app.Store.InTx(ctx, func(tx *store.Store) error {
+		current, err := tx.Statuses.ListByTodoList(ctx, todoList.ID)
+		if err != nil {
+			return err
+		}
+
+		ids := make([]int, len(current))
+		for i, status := range current {
+			ids[i] = status.ID
+		}
+		if !sameIDs(ids, orderDTO.IDs) {
+			return apperr.New(apperr.ValidationFailed).WithFields([]apperr.FieldError{
+				{Field: "ids", Message: "must list every status of the todo list exactly once"},
+			})
+		}
+
+		if err := tx.Statuses.Reorder(ctx, todoList.ID, orderDTO.IDs); err != nil {
+			return err
+		}
+
+		statuses, err = tx.Statuses.ListByTodoList(ctx, todoList.ID)
+		return err
+	})
+	if err != nil {
+		return apperr.Wrap(apperr.StatusUpdateFailed, err)
+	}
+
+	return c.JSON(http.StatusOK, statuses)
+}
+
+// Get Board godoc
+// @Summary      Get the board of this todo list
+// @Description  Returns the todos of the todo list grouped in one column per status, in the order of the statuses.
+// @Description  The todos of a column are in the order of the todo list.
+// @Tags         Statuses
+// @Param        id path int true "Todo List ID"
+// @Param        render query string false "html to add the sanitized HTML rendering of the notes of the todos as NotesHTML" Enums(html)
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}	dtos.BoardDTO
+// @Failure      400  {object}  dtos.ErrorDTO
+// @Failure      401  {object}  dtos.ErrorDTO
+// @Failure      404  {object}  dtos.ErrorDTO
+// @Failure      422  {object}  dtos.ErrorDTO
+// @Failure      500  {object}  dtos.ErrorDTO
+// @Security	 BearerAuth
+// @Router       /todolists/{id}/board [get]
+func (ctl *Controller) GetBoard(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	user, _, err := ctl.authenticate(c, ctx)
+	if err != nil {
+		return err
+	}
+
+	html, err := renderParam(c)
+	if err != nil {
+		return err
+	}
+
+	todoList, err := authorizedTodoList(ctx, ctl.app.Store, user, c.Param("id"), models.RoleViewer)
+	if err != nil {
+		return err
+	}
+
+	statuses, err := ctl.app.Store.Statuses.ListByTodoList(ctx, todoList.ID)
+	if err != nil {
+		return apperr.Wrap(apperr.StatusFetchFailed, err)
+	}
+
+	if html {
+		if err = renderListNotes(todoList); err != nil {
+			return err
+		}
+	}
+
+	board := dtos.BoardDTO{Columns: make([]dtos.BoardColumnDTO, len(statuses))}
+	columns := make(map[int]*dtos.BoardColumnDTO, len(statuses))
+	for i, status := range statuses {
+		board.Columns[i] = dtos.BoardColumnDTO{Status: status, Todos: []models.Todo{}}
+		columns[status.ID] = &board.Columns[i]
+	}
+
+	for _, todo := range todoList.Todos {
+		if column, ok := columns[statusFor(statuses, &todo)]; ok {
+			column.Todos = append(column.Todos, todo)
+		}
+	}
+
+	return c.JSON(http.StatusOK, board)
+}
+
+// Set Todo Status godoc
+// @Summary      Move this todo to another status
+// @Description  Accepts the `status_id` of one of the statuses of the todo list, moves the todo there and returns it.
+// @Description  Moving a todo to a terminal status completes it, like setting `completed`, and moving it out of one reopens it.
+// @Tags         Statuses
+// @Param        status body dtos.TodoStatusDTO true "The status to move the todo to"
+// @Param        id path int true "Todo ID"
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}	models.Todo
+// @Failure      400  {object}  dtos.ErrorDTO
+// @Failure      401  {object}  dtos.ErrorDTO
+// @Failure      403  {object}  dtos.ErrorDTO
+// @Failure      404  {object}  dtos.ErrorDTO
+// @Failure      422  {object}  dtos.ErrorDTO
+// @Failure      500  {object}  dtos.ErrorDTO
+// @Security	 BearerAuth
+// @Router       /todos/{id}/status [put]
+func (ctl *Controller) SetTodoStatus(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	user, _, err := ctl.authenticate(c, ctx)
+	if err != nil {
+		return err
+	}
+
+	statusDTO := new(dtos.TodoStatusDTO)
+	if err = bind(c, statusDTO); err != nil {
+		return err
+	}
+
+	// The todo is read and written in the same transaction so that concurrent moves don't mix.
+	var todo *models.Todo
+	err = ctl.app.Store.InTx(ctx, func(tx *store.Store) error {
+		todo, err = authorizedTodo(ctx, tx, user, c.Param("id"), models.RoleEditor)
+		if err != nil {
+			return err
+		}
+
+		status, err := todoListStatus(ctx, tx, todo.TodoListID, statusDTO.StatusID)
+		if err != nil {
+			return err
+		}
+
+		now := ctl.app.Now()
+		todo.UpdatedAt = now
+		recurred, err := applyStatus(ctx, tx, user, todo, status, now)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Todos.Update(ctx, todo); err != nil {
+			return err
+		}
+
+		if !recurred {
+			return nil
+		}
+		return rescheduleReminders(ctx, tx, todo, now)
+	})
+	if err != nil {
+		return apperr.Wrap(apperr.TodoUpdateFailed, err)
+	}
+
+	todo.TodoList = nil
+	return c.JSON(http.StatusOK, todo)
+}
+
+// authorizedStatus loads the status with the given ID from st and makes sure user has at least the role minRole on its todo list.
+func authorizedStatus(ctx context.Context, st *store.Store, user *models.User, id string, minRole string) (*models.Status, error) {
+	statusID, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	status, err := st.Statuses.GetByID(ctx, statusID)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, apperr.New(apperr.StatusNotFound)
+	} else if err != nil {
+		return nil, apperr.Wrap(apperr.StatusFetchFailed, err)
+	}
+
+	if err = authorize(ctx, st, user, status.TodoList, minRole, apperr.StatusNotFound); err != nil {
+		return nil, err
+	}
+
+	return status, nil
+}
+
+// todoListStatus loads the status with the given ID from st, which the client sent as status_id,
+// and makes sure it is a status of the todo list.
+func todoListStatus(ctx context.Context, st *store.Store, todoListID, statusID int) (*models.Status, error) {
+	status, err := st.Statuses.GetByID(ctx, statusID)
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		return nil, apperr.Wrap(apperr.StatusFetchFailed, err)
+	}
+
+	if err != nil || status.TodoListID != todoListID {
+		return nil, apperr.New(apperr.ValidationFailed).WithFields([]apperr.FieldError{
+			{Field: "status_id", Message: "must be a status of the todo list"},
+		})
+	}
+
+	status.TodoList = nil
+	return status, nil
+}
+
+// createStatuses gives a new todo list the default statuses.
+func createStatuses(ctx context.Context, st *store.Store, todoListID int) error {
+	for _, status := range models.DefaultStatuses {
+		status.TodoListID = todoListID
+		if err := st.Statuses.Create(ctx, &status); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyStatus moves todo to status, completing it if the status is terminal and reopening it if not.
+// It returns true if the todo recurred. The caller saves todo.
+func applyStatus(ctx context.Context, st *store.Store, user *models.User, todo *models.Todo, status *models.Status, now time.Time) (bool, error) {
+	todo.StatusID = status.ID
+
+	if status.Terminal && !todo.Completed {
+		return completeTodo(ctx, st, user, todo, now)
+	}
+
+	if !status.Terminal {
+		todo.Completed, todo.CompletedAt = false, nil
+	}
+	return false, nil
+}
+
+// syncStatus moves todo to the first status of its todo list matching whether it is completed,
+// unless its status already does.
+func syncStatus(ctx context.Context, st *store.Store, todo *models.Todo) error {
+	statuses, err := st.Statuses.ListByTodoList(ctx, todo.TodoListID)
+	if err != nil {
+		return err
+	}
+
+	todo.StatusID = statusFor(statuses, todo)
+	return nil
+}
+
+// statusFor returns the ID of the status todo belongs in among the statuses of its todo list:
+// its own if it matches whether the todo is completed, or else the first one that does.
+func statusFor(statuses []models.Status, todo *models.Todo) int {
+	first := 0
+	for _, status := range statuses {
+		if status.Terminal != todo.Completed {
+			continue
+		}
+		if status.ID == todo.StatusID {
+			return status.ID
+		}
+		if first == 0 {
+			first = status.ID
+		}
+	}
+	return first
+}
+
+// openAndTerminal reports whether statuses have at least one open and one terminal status.
+func openAndTerminal(statuses []models.Status) bool {
+	open, terminal := false, false
+	for _, status := range statuses {
+		open, terminal = open || !status.Terminal, terminal || status.Terminal
+	}
+	return open && terminal
+}
@@ -0,0 +1,368 @@
+package controllers
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/marouane-ach/todo-go/apperr"
+	"github.com/marouane-ach/todo-go/dtos"
+	"github.com/marouane-ach/todo-go/models"
+	"github.com/marouane-ach/todo-go/store"
+)
+
+// Create Tag godoc
+// @Summary      Create a new tag
+// @Description  Accepts a `name`, unique among the user's tags ignoring case, and an optional `color_id` as a JSON object and returns the created tag.
+// @Tags         Tags
+// @Param        tag body dtos.TagDTO true "The tag's name and color ID"
+// @Accept       json
+// @Produce      json
+// @Success      201  {object}	models.Tag
+// @Failure      400  {object}  dtos.ErrorDTO
+// @Failure      401  {object}  dtos.ErrorDTO
+// @Failure      409  {object}  dtos.ErrorDTO
+// @Failure      422  {object}  dtos.ErrorDTO
+// @Failure      500  {object}  dtos.ErrorDTO
+// @Security	 BearerAuth
+// @Router       /tags [post]
+func (ctl *Controller) CreateTag(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	user, _, err := ctl.authenticate(c, ctx)
+	if err != nil {
+		return err
+	}
+
+	tagDTO := new(dtos.TagDTO)
+	if err = bind(c, tagDTO); err != nil {
+		return err
+	}
+
+	tag := &models.Tag{Name: tagDTO.Name, OwnerID: user.ID}
+	if tag.Color, err = tagColor(ctx, ctl.app.Store, tagDTO.ColorID); err != nil {
+		return err
+	}
+	tag.ColorID = tagDTO.ColorID
+
+	if err = ctl.app.Store.Tags.Create(ctx, tag); errors.Is(err, store.ErrConflict) {
+		return apperr.New(apperr.TagNameTaken)
+	} else if err != nil {
+		return apperr.Wrap(apperr.TagCreateFailed, err)
+	}
+
+	return c.JSON(http.StatusCreated, tag)
+}
+
+// List Tags godoc
+// @Summary      List the user's tags
+// @Description  Returns the tags of the user ordered by name, each with the number of todos it labels as `TodoCount`.
+// @Tags         Tags
+// @Accept       json
+// @Produce      json
+// @Success      200  {array}	models.Tag
+// @Failure      401  {object}  dtos.ErrorDTO
+// @Failure      500  {object}  dtos.ErrorDTO
+// @Security	 BearerAuth
+// @Router       /tags [get]
+func (ctl *Controller) ListTags(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	user, _, err := ctl.authenticate(c, ctx)
+	if err != nil {
+		return err
+	}
+
+	tags, err := ctl.app.Store.Tags.List(ctx, store.TagQuery{OwnerID: user.ID})
+	if err != nil {
+		return apperr.Wrap(apperr.TagFetchFailed, err)
+	}
+
+	return c.JSON(http.StatusOK, tags)
+}
+
+// Get Tag godoc
+// @Summary      Get a tag by ID
+// @Description  Returns the tag with the number of todos it labels as `TodoCount`.
+// @Tags         Tags
+// @Param        id path int true "Tag ID"
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}	models.Tag
+// @Failure      400  {object}  dtos.ErrorDTO
+// @Failure      401  {object}  dtos.ErrorDTO
+// @Failure      404  {object}  dtos.ErrorDTO
+// @Failure      500  {object}  dtos.ErrorDTO
+// @Security	 BearerAuth
+// @Router       /tags/{id} [get]
+func (ctl *Controller) GetTag(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	user, _, err := ctl.authenticate(c, ctx)
+	if err != nil {
+		return err
+	}
+
+	tag, err := ownedTag(ctx, ctl.app.Store, user, c.Param("id"))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, tag)
+}
+
+// Update Tag godoc
+// @Summary      Rename or recolor a tag
+// @Description  Accepts a `name` and an optional `color_id` as a JSON object and returns the updated tag.
+// @Description  The todos with the tag show the new name and color. Leaving `color_id` out removes the color.
+// @Tags         Tags
+// @Param        tag body dtos.TagDTO true "The tag's name and color ID"
+// @Param        id path int true "Tag ID"
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}	models.Tag
+// @Failure      400  {object}  dtos.ErrorDTO
+// @Failure      401  {object}  dtos.ErrorDTO
+// @Failure      404  {object}  dtos.ErrorDTO
+// @Failure      409  {object}  dtos.ErrorDTO
+// @Failure      422  {object}  dtos.ErrorDTO
+// @Failure      500  {object}  dtos.ErrorDTO
+// @Security	 BearerAuth
+// @Router       /tags/{id} [put]
+func (ctl *Controller) UpdateTag(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	user, _, err := ctl.authenticate(c, ctx)
+	if err != nil {
+		return err
+	}
+
+	tagDTO := new(dtos.TagDTO)
+	if err = bind(c, tagDTO); err != nil {
+		return err
+	}
+
+	tag, err := ownedTag(ctx, ctl.app.Store, user, c.Param("id"))
+	if err != nil {
+		return err
+	}
+
+	if tag.Color, err = tagColor(ctx, ctl.app.Store, tagDTO.ColorID); err != nil {
+		return err
+	}
+	tag.Name, tag.ColorID = tagDTO.Name, tagDTO.ColorID
+	tag.UpdatedAt = ctl.app.Now()
+
+	if err = ctl.app.Store.Tags.Update(ctx, tag); errors.Is(err, store.ErrConflict) {
+		return apperr.New(apperr.TagNameTaken)
+	} else if errors.Is(err, store.ErrNotFound) {
+		return apperr.New(apperr.TagNotFound)
+	} else if err != nil {
+		return apperr.Wrap(apperr.TagUpdateFailed, err)
+	}
+
+	return c.JSON(http.StatusOK, tag)
+}
+
+// Delete Tag godoc
+// @Summary      Delete a tag
+// @Description  Removes the tag from its todos, deletes it and returns it as a JSON object. The todos are kept.
+// @Tags         Tags
+// @Param        id path int true "Tag ID"
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}	models.Tag
+// @Failure      400  {object}  dtos.ErrorDTO
+// @Failure      401  {object}  dtos.ErrorDTO
+// @Failure      404  {object}  dtos.ErrorDTO
+// @Failure      500  {object}  dtos.ErrorDTO
+// @Security	 BearerAuth
+// @Router       /tags/{id} [delete]
+func (ctl *Controller) DeleteTag(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	user, _, err := ctl.authenticate(c, ctx)
+	if err != nil {
+		return err
+	}
+
+	tag, err := ownedTag(ctx, ctl.app.Store, user, c.Param("id"))
+	if err != nil {
+		return err
+	}
+
+	err = ctl.app.Store.InTx(ctx, func(tx *store.Store) error {
+		return tx.Tags.Delete(ctx, tag.ID)
+	})
+	if errors.Is(err, store.ErrNotFound) {
+		return apperr.New(apperr.TagNotFound)
+	} else if err != nil {
+		return apperr.Wrap(apperr.TagDeleteFailed, err)
+	}
+
+	return c.JSON(http.StatusOK, tag)
+}
+
+// Attach Tag godoc
+// @Summary      Add a tag to this todo
+// @Description  Returns the todo with its tags. Adding a tag the todo already has does nothing.
+// @Tags         Tags
+// @Param        id path int true "Todo ID"
+// @Param        tag_id path int true "Tag ID"
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}	models.Todo
+// @Failure      400  {object}  dtos.ErrorDTO
+// @Failure      401  {object}  dtos.ErrorDTO
+// @Failure      403  {object}  dtos.ErrorDTO
+// @Failure      404  {object}  dtos.ErrorDTO
+// @Failure      500  {object}  dtos.ErrorDTO
+// @Security	 BearerAuth
+// @Router       /todos/{id}/tags/{tag_id} [post]
+func (ctl *Controller) AttachTag(c echo.Context) error {
+	return ctl.tagTodo(c, ctl.app.Store.Tags.Attach)
+}
+
+// Detach Tag godoc
+// @Summary      Remove a tag from this todo
+// @Description  Returns the todo with its remaining tags. Removing a tag the todo doesn't have does nothing.
+// @Tags         Tags
+// @Param        id path int true "Todo ID"
+// @Param        tag_id path int true "Tag ID"
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}	models.Todo
+// @Failure      400  {object}  dtos.ErrorDTO
+// @Failure      401  {object}  dtos.ErrorDTO
+// @Failure      403  {object}  dtos.ErrorDTO
+// @Failure      404  {object}  dtos.ErrorDTO
+// @Failure      500  {object}  dtos.ErrorDTO
+// @Security	 BearerAuth
+// @Router       /todos/{id}/tags/{tag_id} [delete]
+func (ctl *Controller) DetachTag(c echo.Context) error {
+	return ctl.tagTodo(c, ctl.app.Store.Tags.Detach)
+}
+
+// tagTodo applies change, which attaches or detaches a tag, to the todo and the tag of the request
+// and responds with the todo.
+func (ctl *Controller) tagTodo(c echo.Context, change func(ctx context.Context, todoID, tagID int) error) error {
+	ctx := c.Request().Context()
+
+	user, _, err := ctl.authenticate(c, ctx)
+	if err != nil {
+		return err
+	}
+
+	todo, err := authorizedTodo(ctx, ctl.app.Store, user, c.Param("id"), models.RoleEditor)
+	if err != nil {
+		return err
+	}
+
+	tag, err := ownedTag(ctx, ctl.app.Store, user, c.Param("tag_id"))
+	if err != nil {
+		return err
+	}
+
+	if err = change(ctx, todo.ID, tag.ID); err != nil {
+		return apperr.Wrap(apperr.TodoUpdateFailed, err)
+	}
+
+	todo, err = authorizedTodo(ctx, ctl.app.Store, user, c.Param("id"), models.RoleEditor)
+	if err != nil {
+		return err
+	}
+
+	todo.TodoList = nil
+	return c.JSON(http.StatusOK, todo)
+}
+
+// ownedTag loads the tag with the given ID from st and makes sure it belongs to user.
+func ownedTag(ctx context.Context, st *store.Store, user *models.User, id string) (*models.Tag, error) {
+	tagID, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	tag, err := st.Tags.GetByID(ctx, tagID)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, apperr.New(apperr.TagNotFound)
+	} else if err != nil {
+		return nil, apperr.Wrap(apperr.TagFetchFailed, err)
+	}
+
+	if tag.OwnerID != user.ID {
+		return nil, apperr.New(apperr.TagNotFound)
+	}
+
+	return tag, nil
+}
+
+// ownedTags loads the tags with the given IDs from st, in the order todos list their tags,
+// and makes sure they all belong to user.
+func ownedTags(ctx context.Context, st *store.Store, user *models.User, ids []int) ([]models.Tag, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	ids = slices.Compact(slices.Sorted(slices.Values(ids)))
+
+	tags, err := st.Tags.List(ctx, store.TagQuery{OwnerID: user.ID, IDs: ids})
+	if err != nil {
+		return nil, apperr.Wrap(apperr.TagFetchFailed, err)
+	}
+
+	if len(tags) != len(ids) {
+		return nil, apperr.New(apperr.ValidationFailed).WithFields([]apperr.FieldError{
+			{Field: "tag_ids", Message: "must be IDs of your tags"},
+		})
+	}
+
+	// The tags of a todo are loaded without their colors and counts.
+	for i := range tags {
+		tags[i].Color, tags[i].TodoCount = nil, 0
+	}
+	return tags, nil
+}
+
+// tagColor returns the color with the given ID, if any.
+func tagColor(ctx context.Context, st *store.Store, colorID *int) (*models.Color, error) {
+	if colorID == nil {
+		return nil, nil
+	}
+
+	color, err := st.Colors.GetByID(ctx, *colorID)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, apperr.New(apperr.InvalidColor)
+	} else if err != nil {
+		return nil, apperr.Wrap(apperr.TagFetchFailed, err)
+	}
+
+	return color, nil
+}
+
+// tagIDs returns the IDs of tags.
+func tagIDs(tags []models.Tag) []int {
+	ids := make([]int, len(tags))
+	for i, tag := range tags {
+		ids[i] = tag.ID
+	}
+	return ids
+}
+
+// withForeignTags adds to tags, the new tags of todo, the tags other members of its todo list put on it,
+// which they keep when a user sets the tags of a shared todo. The result is in the order todos list their tags.
+func withForeignTags(todo *models.Todo, user *models.User, tags []models.Tag) []models.Tag {
+	for _, tag := range todo.Tags {
+		if tag.OwnerID != user.ID {
+			tags = append(tags, tag)
+		}
+	}
+
+	slices.SortFunc(tags, func(a, b models.Tag) int {
+		return cmp.Or(strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name)), a.ID-b.ID)
+	})
+	return tags
+}
@@ -2,185 +2,509 @@ package controllers
 
 import (
 	"context"
-	"fmt"
+	"errors"
 	"net/http"
 	"strconv"
 
 	"github.com/labstack/echo/v4"
-	"github.com/marouane-ach/todo-go/db"
+	"github.com/marouane-ach/todo-go/apperr"
 	"github.com/marouane-ach/todo-go/dtos"
 	"github.com/marouane-ach/todo-go/models"
-	"github.com/marouane-ach/todo-go/utils"
+	"github.com/marouane-ach/todo-go/store"
 )
 
 // Create Todo List godoc
 // @Summary      Create a new todo list
-// @Description  Accepts `name` and `color_id` as JSON and returns the created todo list.
+// @Description  Accepts `name` and `color_id` as JSON and returns the created todo list, which starts with the default statuses
+// @Description  and goes after the other lists of the user. With `reject_duplicates`, two todos of the list can't have the same text.
+// @Description  With `X-Org-ID`, the list belongs to the organization and goes after its other lists, unless the organization reached its quota of lists.
+// @Description  `folder_id` files the list in a folder.
 // @Tags         Todo Lists
-// @Param        todo_list body dtos.TodoListDTO true "The todo list's name and color ID"
+// @Param        todo_list body dtos.TodoListDTO true "The todo list's name, color ID, duplicate detection and folder"
+// @Param        X-Org-ID header int false "ID of the organization to work in"
 // @Accept       json
 // @Produce      json
 // @Success      201  {object}	models.TodoList
 // @Failure      400  {object}  dtos.ErrorDTO
 // @Failure      401  {object}  dtos.ErrorDTO
+// @Failure      403  {object}  dtos.ErrorDTO
+// @Failure      404  {object}  dtos.ErrorDTO
+// @Failure      422  {object}  dtos.ErrorDTO
 // @Failure      500  {object}  dtos.ErrorDTO
 // @Security	 BearerAuth
 // @Router       /todolists [post]
-func CreateTodoList(c echo.Context) error {
-	ctx := context.Background()
-	db := db.GetDBIntance()
+func (ctl *Controller) CreateTodoList(c echo.Context) error {
+	ctx := c.Request().Context()
 
-	user, err := utils.ValidateToken(c, db, ctx)
+	user, _, err := ctl.authenticate(c, ctx)
 	if err != nil {
 		return err
 	}
 
 	todoListDTO := new(dtos.TodoListDTO)
-	if err = c.Bind(todoListDTO); err != nil {
+	if err = bind(c, todoListDTO); err != nil {
 		return err
 	}
 
-	color := new(models.Color)
-	err = db.NewSelect().Model(color).Where("id = ?", todoListDTO.ColorID).Scan(ctx)
-	if err != nil {
-		return c.JSON(http.StatusBadRequest, &dtos.ErrorDTO{ErrorCode: 11, Description: "Invalid color ID."})
+	_, err = ctl.app.Store.Colors.GetByID(ctx, todoListDTO.ColorID)
+	if errors.Is(err, store.ErrNotFound) {
+		return apperr.New(apperr.InvalidColor)
+	} else if err != nil {
+		return apperr.Wrap(apperr.TodoListCreateFailed, err)
+	}
+
+	todoList := &models.TodoList{
+		Name:             todoListDTO.Name,
+		ColorID:          todoListDTO.ColorID,
+		OwnerID:          user.ID,
+		OrganizationID:   orgID(user),
+		RejectDuplicates: todoListDTO.RejectDuplicates,
+	}
+	if todoListDTO.FolderID != nil {
+		todoList.FolderID = *todoListDTO.FolderID
+	}
+
+	if err = checkFolder(ctx, ctl.app.Store, user, todoList.FolderID); err != nil {
+		return err
 	}
 
-	todoList := &models.TodoList{Name: todoListDTO.Name, ColorID: todoListDTO.ColorID, OwnerID: user.ID}
-	_, err = db.NewInsert().Model(todoList).Exec(ctx)
+	err = ctl.inTxRanked(ctx, func(tx *store.Store) error {
+		if todoList.OrganizationID != 0 {
+			if err := checkTodoListQuota(ctx, tx, todoList.OrganizationID); err != nil {
+				return err
+			}
+		}
+
+		adjacent, scopeID := todoListRanks(tx, user)
+
+		var err error
+		if todoList.Rank, err = rankLast(ctx, adjacent, scopeID); err != nil {
+			return err
+		}
+
+		if err := tx.TodoLists.Create(ctx, todoList); err != nil {
+			return err
+		}
+		return createStatuses(ctx, tx, todoList.ID)
+	})
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, &dtos.ErrorDTO{ErrorCode: 12, Description: "Could not create todo list."})
+		return apperr.Wrap(apperr.TodoListCreateFailed, err)
 	}
 
+	todoList.Role = models.RoleOwner
 	return c.JSON(http.StatusCreated, todoList)
 }
 
 // Get User Todo Lists godoc
 // @Summary      Get user's todo lists.
-// @Description  Returns a JSON array of the user's todo lists along with their associated todos.
+// @Description  Returns a page of the user's todo lists, and of the lists shared with them, along with their associated todos.
+// @Description  Each list has the `Role` of the user on it: owner, admin, editor or viewer.
+// @Description  Lists are in the order of their owners by default, and their todos always are.
+// @Description  Pass the `next_cursor` of a page as `cursor` to get the next one, keeping the other parameters unchanged.
+// @Description  With `view=tree`, returns every list matching the filters arranged in the folders instead, as a `dtos.TodoListTreeDTO`.
 // @Tags         Todo Lists
+// @Param        limit          query int    false "Number of lists per page (1-100, default 50)"
+// @Param        cursor         query string false "Cursor returned as next_cursor by the previous page"
+// @Param        sort           query string false "Sort field" Enums(rank, name, created_at, updated_at) default(rank)
+// @Param        order          query string false "Sort direction" Enums(asc, desc) default(asc)
+// @Param        color_id       query int    false "Only lists of this color"
+// @Param        has_incomplete query bool   false "Only lists with (true) or without (false) incomplete todos"
+// @Param        todos          query string false "Whether to embed the todos of each list" Enums(include, omit) default(include)
+// @Param        todos_limit    query int    false "Maximum number of todos embedded in each list"
+// @Param        render         query string false "html to add the sanitized HTML rendering of the notes of the todos as NotesHTML" Enums(html)
+// @Param        view           query string false "Whether to return a page or a tree of folders" Enums(page, tree) default(page)
+// @Param        X-Org-ID header int false "ID of the organization to work in"
 // @Accept       json
 // @Produce      json
-// @Success      200  {array}	models.TodoList
+// @Success      200  {object}	dtos.PageDTO[models.TodoList]
+// @Failure      400  {object}  dtos.ErrorDTO
 // @Failure      401  {object}  dtos.ErrorDTO
+// @Failure      422  {object}  dtos.ErrorDTO
 // @Failure      500  {object}  dtos.ErrorDTO
 // @Security	 BearerAuth
 // @Router       /todolists [get]
-func GetUserTodoLists(c echo.Context) error {
-	ctx := context.Background()
-	db := db.GetDBIntance()
+func (ctl *Controller) GetUserTodoLists(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	user, _, err := ctl.authenticate(c, ctx)
+	if err != nil {
+		return err
+	}
+
+	queryDTO := new(dtos.TodoListQueryDTO)
+	if err = bind(c, queryDTO); err != nil {
+		return err
+	}
+
+	query := store.TodoListQuery{
+		UserID:         user.ID,
+		OrganizationID: orgID(user),
+		ColorID:        queryDTO.ColorID,
+		Sort:           parseSort(queryDTO.Sort, queryDTO.Order, "rank", false),
+		Limit:          queryDTO.Limit,
+		OmitTodos:      queryDTO.Todos == "omit",
+		TodosLimit:     queryDTO.TodosLimit,
+	}
+
+	if query.Limit == 0 {
+		query.Limit = defaultPageLimit
+	}
+
+	if queryDTO.HasIncomplete != "" {
+		hasIncomplete := queryDTO.HasIncomplete == "true"
+		query.HasIncomplete = &hasIncomplete
+	}
 
-	user, err := utils.ValidateToken(c, db, ctx)
+	query.After, err = decodeCursor(queryDTO.Cursor, query.Sort, timeSort(query.Sort))
 	if err != nil {
-		fmt.Println("err:")
-		fmt.Println(err)
 		return err
 	}
 
-	var todoLists []models.TodoList
-	err = db.NewSelect().
-		Model(&todoLists).
-		Where("owner_id = ?", user.ID).
-		Relation("Todos").
-		Order("created_at DESC").
-		Scan(ctx)
+	html, err := renderParam(c)
+	if err != nil {
+		return err
+	}
+
+	// Fetch one more list than asked to know whether there is a next page. Trees have every list.
+	tree := queryDTO.View == "tree"
+	limit := query.Limit
+	query.Limit++
+	if tree {
+		query.Limit, query.After = 0, nil
+	}
 
+	todoLists, err := ctl.app.Store.TodoLists.List(ctx, query)
 	if err != nil {
-		fmt.Println(err)
+		return apperr.Wrap(apperr.TodoListFetchFailed, err)
 	}
 
-	return c.JSON(http.StatusOK, todoLists)
+	memberships, err := ctl.app.Store.Memberships.ListByUser(ctx, user.ID)
+	if err != nil {
+		return apperr.Wrap(apperr.TodoListFetchFailed, err)
+	}
+
+	roles := make(map[int]string, len(memberships))
+	for _, membership := range memberships {
+		roles[membership.TodoListID] = membership.Role
+	}
+
+	for i := range todoLists {
+		todoLists[i].Role = roleOn(user, &todoLists[i], roles[todoLists[i].ID])
+
+		if html {
+			if err = renderListNotes(&todoLists[i]); err != nil {
+				return err
+			}
+		}
+	}
+
+	if tree {
+		folders, err := ctl.app.Store.Folders.List(ctx, store.FolderQuery{UserID: user.ID, OrganizationID: orgID(user)})
+		if err != nil {
+			return apperr.Wrap(apperr.FolderFetchFailed, err)
+		}
+		return c.JSON(http.StatusOK, folderTree(folders, todoLists))
+	}
+
+	return c.JSON(http.StatusOK, page(todoLists, limit, query.Sort, func(l *models.TodoList) store.Cursor {
+		return store.TodoListCursor(l, query.Sort.Field)
+	}))
 }
 
 // Get a Todo List by ID godoc
 // @Summary      Get a single todo list by ID
-// @Description  Returns a JSON object of a todo list along with its associated todos.
+// @Description  Returns a JSON object of a todo list along with its associated todos in order.
 // @Tags         Todo Lists
 // @Param        id path int true "Todo List ID"
+// @Param        render query string false "html to add the sanitized HTML rendering of the notes of the todos as NotesHTML" Enums(html)
 // @Accept       json
 // @Produce      json
-// @Success      200  {array}	models.TodoList
+// @Success      200  {object}	models.TodoList
+// @Failure      400  {object}  dtos.ErrorDTO
 // @Failure      401  {object}  dtos.ErrorDTO
+// @Failure      404  {object}  dtos.ErrorDTO
+// @Failure      422  {object}  dtos.ErrorDTO
 // @Failure      500  {object}  dtos.ErrorDTO
 // @Security	 BearerAuth
 // @Router       /todolists/{id} [get]
-func GetTodoListByID(c echo.Context) error {
-	ctx := context.Background()
-	db := db.GetDBIntance()
+func (ctl *Controller) GetTodoListByID(c echo.Context) error {
+	ctx := c.Request().Context()
 
-	user, err := utils.ValidateToken(c, db, ctx)
+	user, _, err := ctl.authenticate(c, ctx)
 	if err != nil {
 		return err
 	}
 
-	todoListID, err := strconv.Atoi(c.Param("id"))
+	html, err := renderParam(c)
 	if err != nil {
-		return c.JSON(http.StatusNotFound, &dtos.ErrorDTO{ErrorCode: 13, Description: "Todo list does not exist."})
+		return err
 	}
 
-	todoList := new(models.TodoList)
-	err = db.NewSelect().
-		Model(todoList).
-		Where("id = ?", todoListID).
-		Relation("Todos").
-		Order("created_at DESC").
-		Scan(ctx)
+	todoList, err := authorizedTodoList(ctx, ctl.app.Store, user, c.Param("id"), models.RoleViewer)
 	if err != nil {
-		return c.JSON(http.StatusNotFound, &dtos.ErrorDTO{ErrorCode: 13, Description: "Todo list does not exist."})
+		return err
 	}
 
-	if todoList.OwnerID != user.ID {
-		return c.JSON(http.StatusUnauthorized, &dtos.ErrorDTO{ErrorCode: 14, Description: "Unauthorized."})
+	if html {
+		if err = renderListNotes(todoList); err != nil {
+			return err
+		}
 	}
 
 	return c.JSON(http.StatusOK, todoList)
 }
 
-// Delete Todo List godoc
-// @Summary      Delete a todo list by ID
-// @Description  Deletes a todo list and return JSON object of deleted todo list.
+// Update Todo List godoc
+// @Summary      Update this todo list
+// @Description  Accepts `name`, `color_id` and `reject_duplicates` as a JSON object and returns the updated todo list.
+// @Description  Turning `reject_duplicates` on keeps the duplicates the list already has, but new todos, renamed ones and todos
+// @Description  moved to the list get a `409` with the `existing_id` of the todo that has their text.
+// @Description  Only the owner can move the list to another folder with `folder_id`, 0 for the root, and leaving it out keeps the folder.
 // @Tags         Todo Lists
+// @Param        todo_list body dtos.TodoListDTO true "The todo list's name, color ID, duplicate detection and folder"
 // @Param        id path int true "Todo List ID"
 // @Accept       json
 // @Produce      json
-// @Success      200  {array}	models.TodoList
+// @Success      200  {object}	models.TodoList
+// @Failure      400  {object}  dtos.ErrorDTO
 // @Failure      401  {object}  dtos.ErrorDTO
+// @Failure      403  {object}  dtos.ErrorDTO
+// @Failure      404  {object}  dtos.ErrorDTO
+// @Failure      422  {object}  dtos.ErrorDTO
 // @Failure      500  {object}  dtos.ErrorDTO
 // @Security	 BearerAuth
-// @Router       /todolists/{id} [delete]
-func DeleteTodoList(c echo.Context) error {
-	ctx := context.Background()
-	db := db.GetDBIntance()
+// @Router       /todolists/{id} [put]
+func (ctl *Controller) UpdateTodoList(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	user, _, err := ctl.authenticate(c, ctx)
+	if err != nil {
+		return err
+	}
+
+	todoListDTO := new(dtos.TodoListDTO)
+	if err = bind(c, todoListDTO); err != nil {
+		return err
+	}
+
+	todoList, err := authorizedTodoList(ctx, ctl.app.Store, user, c.Param("id"), models.RoleAdmin)
+	if err != nil {
+		return err
+	}
+
+	_, err = ctl.app.Store.Colors.GetByID(ctx, todoListDTO.ColorID)
+	if errors.Is(err, store.ErrNotFound) {
+		return apperr.New(apperr.InvalidColor)
+	} else if err != nil {
+		return apperr.Wrap(apperr.TodoListUpdateFailed, err)
+	}
+
+	if todoListDTO.FolderID != nil && *todoListDTO.FolderID != todoList.FolderID {
+		// Folders belong to the owner of the list, or to its organization.
+		if todoList.Role != models.RoleOwner {
+			return apperr.New(apperr.Forbidden).WithDescription("Only the owner of a todo list can move it to another folder.")
+		}
+
+		if err = checkFolder(ctx, ctl.app.Store, user, *todoListDTO.FolderID); err != nil {
+			return err
+		}
+		todoList.FolderID = *todoListDTO.FolderID
+	}
+
+	todoList.Name, todoList.ColorID = todoListDTO.Name, todoListDTO.ColorID
+	todoList.RejectDuplicates = todoListDTO.RejectDuplicates
+	todoList.UpdatedAt = ctl.app.Now()
+
+	if err = ctl.app.Store.TodoLists.Update(ctx, todoList); errors.Is(err, store.ErrNotFound) {
+		return apperr.New(apperr.TodoListNotFound)
+	} else if err != nil {
+		return apperr.Wrap(apperr.TodoListUpdateFailed, err)
+	}
+
+	return c.JSON(http.StatusOK, todoList)
+}
+
+// Reorder Todo List godoc
+// @Summary      Move this todo list in the user's order
+// @Description  Accepts either the `before_id` or the `after_id` of another of the user's todo lists as a JSON object,
+// @Description  puts the list right before or right after it and returns the list. Only the moved list changes.
+// @Description  Lists shared with the user keep the place their owner gives them.
+// @Tags         Todo Lists
+// @Param        placement body dtos.ReorderDTO true "The todo list to put this one before or after"
+// @Param        id path int true "Todo List ID"
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}	models.TodoList
+// @Failure      400  {object}  dtos.ErrorDTO
+// @Failure      401  {object}  dtos.ErrorDTO
+// @Failure      403  {object}  dtos.ErrorDTO
+// @Failure      404  {object}  dtos.ErrorDTO
+// @Failure      422  {object}  dtos.ErrorDTO
+// @Failure      500  {object}  dtos.ErrorDTO
+// @Security	 BearerAuth
+// @Router       /todolists/{id}/reorder [post]
+func (ctl *Controller) ReorderTodoList(c echo.Context) error {
+	ctx := c.Request().Context()
 
-	user, err := utils.ValidateToken(c, db, ctx)
+	user, _, err := ctl.authenticate(c, ctx)
 	if err != nil {
 		return err
 	}
 
-	todoListID, err := strconv.Atoi(c.Param("id"))
+	reorderDTO := new(dtos.ReorderDTO)
+	if err = bind(c, reorderDTO); err != nil {
+		return err
+	}
+
+	todoList, err := authorizedTodoList(ctx, ctl.app.Store, user, c.Param("id"), models.RoleOwner)
 	if err != nil {
-		return c.JSON(http.StatusNotFound, &dtos.ErrorDTO{ErrorCode: 13, Description: "Todo list does not exist."})
+		return err
 	}
 
-	todoList := new(models.TodoList)
-	err = db.NewSelect().
-		Model(todoList).
-		Where("id = ?", todoListID).
-		Relation("Todos").
-		Order("created_at DESC").
-		Scan(ctx)
+	p, err := parsePlacement(reorderDTO, todoList.ID)
 	if err != nil {
-		return c.JSON(http.StatusNotFound, &dtos.ErrorDTO{ErrorCode: 13, Description: "Todo list does not exist."})
+		return err
 	}
 
-	if todoList.OwnerID != user.ID {
-		return c.JSON(http.StatusUnauthorized, &dtos.ErrorDTO{ErrorCode: 14, Description: "Unauthorized."})
+	err = ctl.inTxRanked(ctx, func(tx *store.Store) error {
+		// The target is loaded in the transaction so that its rank is current.
+		target, err := authorizedTodoList(ctx, tx, user, strconv.Itoa(p.targetID), models.RoleOwner)
+		if err != nil {
+			return err
+		}
+
+		adjacent, scopeID := todoListRanks(tx, user)
+		r, err := rankNextTo(ctx, adjacent, scopeID, target.Rank, p.before)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.TodoLists.SetRank(ctx, todoList.ID, r); err != nil {
+			return err
+		}
+		todoList.Rank = r
+		return nil
+	})
+	if errors.Is(err, store.ErrNotFound) {
+		return apperr.New(apperr.TodoListNotFound)
+	} else if err != nil {
+		return apperr.Wrap(apperr.TodoListUpdateFailed, err)
+	}
+
+	return c.JSON(http.StatusOK, todoList)
+}
+
+// Delete Todo List godoc
+// @Summary      Delete a todo list by ID
+// @Description  Deletes a todo list and return JSON object of deleted todo list. Only its owner can delete it.
+// @Tags         Todo Lists
+// @Param        id path int true "Todo List ID"
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}	models.TodoList
+// @Failure      400  {object}  dtos.ErrorDTO
+// @Failure      401  {object}  dtos.ErrorDTO
+// @Failure      403  {object}  dtos.ErrorDTO
+// @Failure      404  {object}  dtos.ErrorDTO
+// @Failure      500  {object}  dtos.ErrorDTO
+// @Security	 BearerAuth
+// @Router       /todolists/{id} [delete]
+func (ctl *Controller) DeleteTodoList(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	user, _, err := ctl.authenticate(c, ctx)
+	if err != nil {
+		return err
 	}
 
-	_, err = db.NewDelete().Model(todoList).Where("id = ?", todoListID).Exec(ctx)
+	var todoList *models.TodoList
+	err = ctl.app.Store.InTx(ctx, func(tx *store.Store) error {
+		todoList, err = authorizedTodoList(ctx, tx, user, c.Param("id"), models.RoleOwner)
+		if err != nil {
+			return err
+		}
+
+		return deleteTodoList(ctx, tx, todoList.ID)
+	})
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, &dtos.ErrorDTO{ErrorCode: 14, Description: "Could not delete todo."})
+		return apperr.Wrap(apperr.TodoListDeleteFailed, err)
 	}
 
 	return c.JSON(http.StatusOK, todoList)
 }
+
+// deleteTodoList deletes the todo list with the given ID from st along with everything that belongs to it.
+func deleteTodoList(ctx context.Context, st *store.Store, todoListID int) error {
+	if err := st.Reminders.DeleteByTodoList(ctx, todoListID); err != nil {
+		return err
+	}
+
+	if err := st.ChecklistItems.DeleteByTodoList(ctx, todoListID); err != nil {
+		return err
+	}
+
+	if err := st.Recurrences.DeleteByTodoList(ctx, todoListID); err != nil {
+		return err
+	}
+
+	if err := st.Completions.DeleteByTodoList(ctx, todoListID); err != nil {
+		return err
+	}
+
+	if err := st.Comments.DeleteByTodoList(ctx, todoListID); err != nil {
+		return err
+	}
+
+	if err := st.Tags.DetachTodoList(ctx, todoListID); err != nil {
+		return err
+	}
+
+	if err := st.Todos.DeleteByTodoList(ctx, todoListID); err != nil {
+		return err
+	}
+
+	if err := st.Statuses.DeleteByTodoList(ctx, todoListID); err != nil {
+		return err
+	}
+
+	if err := st.Memberships.DeleteByTodoList(ctx, todoListID); err != nil {
+		return err
+	}
+
+	if err := st.Invitations.DeleteByTodoList(ctx, todoListID); err != nil {
+		return err
+	}
+
+	if err := st.ShareLinks.DeleteByTodoList(ctx, todoListID); err != nil {
+		return err
+	}
+
+	return st.TodoLists.Delete(ctx, todoListID)
+}
+
+// rejectDuplicate fails with a TodoDuplicate error if todoList rejects duplicates and another of its todos
+// has the text of todo.
+func rejectDuplicate(ctx context.Context, st *store.Store, todoList *models.TodoList, todo *models.Todo) error {
+	if !todoList.RejectDuplicates {
+		return nil
+	}
+
+	existing, err := st.Todos.GetByText(ctx, todoList.ID, todo.Text)
+	if errors.Is(err, store.ErrNotFound) || (err == nil && existing.ID == todo.ID) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	return apperr.New(apperr.TodoDuplicate).WithExistingID(existing.ID)
+}
+
+// renderListNotes renders the notes of the todos of todoList.
+func renderListNotes(todoList *models.TodoList) error {
+	for i := range todoList.Todos {
+		if err := renderNotes(&todoList.Todos[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
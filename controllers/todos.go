@@ -2,121 +2,862 @@ package controllers
 
 import (
 	"context"
-	"fmt"
+	"errors"
 	"net/http"
+	"slices"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/labstack/echo/v4"
-	"github.com/marouane-ach/todo-go/db"
+	"github.com/marouane-ach/todo-go/apperr"
 	"github.com/marouane-ach/todo-go/dtos"
+	"github.com/marouane-ach/todo-go/markdown"
 	"github.com/marouane-ach/todo-go/models"
-	"github.com/marouane-ach/todo-go/utils"
+	"github.com/marouane-ach/todo-go/rank"
+	"github.com/marouane-ach/todo-go/store"
 )
 
 // Create Todo godoc
 // @Summary      Create a new todo in this todo list
-// @Description  Accepts `text` as a JSON object and returns the created todo.
+// @Description  Accepts `text` and optional Markdown `notes`, `due_at`, `all_day`, `auto_complete`, `tag_ids`, `status_id`, `priority` and `assignee_id` as a JSON object
+// @Description  and returns the created todo. It goes last in the todo list, in its first status unless `status_id` is set.
+// @Description  The assignee must be the owner or a member of the todo list.
+// @Description  If the todo list rejects duplicates and one of its todos has the same text, ignoring case, the response is a `409` with its `existing_id`.
 // @Tags         Todos
 // @Param        todo body dtos.TodoDTO true "The todo list's name and color ID"
 // @Param        id path int true "Todo List ID"
+// @Param        render query string false "html to add the sanitized HTML rendering of the notes of the todos as NotesHTML" Enums(html)
 // @Accept       json
 // @Produce      json
 // @Success      201  {object}	models.Todo
+// @Failure      400  {object}  dtos.ErrorDTO
 // @Failure      401  {object}  dtos.ErrorDTO
+// @Failure      403  {object}  dtos.ErrorDTO
 // @Failure      404  {object}  dtos.ErrorDTO
+// @Failure      409  {object}  dtos.ErrorDTO
+// @Failure      422  {object}  dtos.ErrorDTO
 // @Failure      500  {object}  dtos.ErrorDTO
 // @Security	 BearerAuth
 // @Router       /todolists/{id}/todos [post]
-func CreateTodo(c echo.Context) error {
-	ctx := context.Background()
-	db := db.GetDBIntance()
+func (ctl *Controller) CreateTodo(c echo.Context) error {
+	ctx := c.Request().Context()
 
-	user, err := utils.ValidateToken(c, db, ctx)
+	user, _, err := ctl.authenticate(c, ctx)
 	if err != nil {
 		return err
 	}
 
 	todoDTO := new(dtos.TodoDTO)
-	if err = c.Bind(todoDTO); err != nil {
+	if err = bind(c, todoDTO); err != nil {
 		return err
 	}
 
-	todoListID, err := strconv.Atoi(c.Param("id"))
+	html, err := renderParam(c)
 	if err != nil {
-		return c.JSON(http.StatusNotFound, &dtos.ErrorDTO{ErrorCode: 13, Description: "Todo list does not exist."})
+		return err
 	}
 
-	todoList := new(models.TodoList)
-	err = db.NewSelect().Model(todoList).Where("id = ?", todoListID).Scan(ctx)
+	todoList, err := authorizedTodoList(ctx, ctl.app.Store, user, c.Param("id"), models.RoleEditor)
 	if err != nil {
-		return c.JSON(http.StatusNotFound, &dtos.ErrorDTO{ErrorCode: 13, Description: "Todo list does not exist."})
+		return err
+	}
+
+	var tags []models.Tag
+	if todoDTO.TagIDs != nil {
+		if tags, err = ownedTags(ctx, ctl.app.Store, user, *todoDTO.TagIDs); err != nil {
+			return err
+		}
 	}
 
-	if todoList.OwnerID != user.ID {
-		return c.JSON(http.StatusUnauthorized, &dtos.ErrorDTO{ErrorCode: 14, Description: "Unauthorized."})
+	var status *models.Status
+	if todoDTO.StatusID != nil {
+		if status, err = todoListStatus(ctx, ctl.app.Store, todoList.ID, *todoDTO.StatusID); err != nil {
+			return err
+		}
 	}
 
-	todo := &models.Todo{Text: todoDTO.Text, TodoListID: todoListID}
-	_, err = db.NewInsert().Model(todo).Exec(ctx)
+	todo := &models.Todo{
+		Text:         todoDTO.Text,
+		Notes:        todoDTO.Notes,
+		DueAt:        dueAt(todoDTO.DueAt, todoDTO.AllDay),
+		AllDay:       todoDTO.AllDay && todoDTO.DueAt != nil,
+		AutoComplete: todoDTO.AutoComplete,
+		Priority:     todoDTO.Priority,
+		TodoListID:   todoList.ID,
+	}
+	if todoDTO.AssigneeID != nil {
+		if err = checkAssignee(ctx, ctl.app.Store, todoList, *todoDTO.AssigneeID); err != nil {
+			return err
+		}
+		todo.AssigneeID = *todoDTO.AssigneeID
+	}
+
+	err = ctl.inTxRanked(ctx, func(tx *store.Store) error {
+		var err error
+		if todo.Rank, err = rankLast(ctx, tx.Todos.AdjacentRank, todoList.ID); err != nil {
+			return err
+		}
+
+		if err := rejectDuplicate(ctx, tx, todoList, todo); err != nil {
+			return err
+		}
+
+		if status != nil {
+			todo.StatusID = status.ID
+		} else if err := syncStatus(ctx, tx, todo); err != nil {
+			return err
+		}
+
+		if err := tx.Todos.Create(ctx, todo); err != nil {
+			return err
+		}
+
+		if err := tx.Tags.SetTodoTags(ctx, todo.ID, tagIDs(tags)); err != nil {
+			return err
+		}
+
+		if status == nil || !status.Terminal {
+			return nil
+		}
+		if _, err := completeTodo(ctx, tx, user, todo, ctl.app.Now()); err != nil {
+			return err
+		}
+		return tx.Todos.Update(ctx, todo)
+	})
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, &dtos.ErrorDTO{ErrorCode: 15, Description: "Could not create todo."})
+		return apperr.Wrap(apperr.TodoCreateFailed, err)
+	}
+	todo.Tags = tags
+
+	if html {
+		if err = renderNotes(todo); err != nil {
+			return err
+		}
 	}
 
 	return c.JSON(http.StatusCreated, todo)
 }
 
+// Get Todo godoc
+// @Summary      Get this todo with its comments
+// @Description  Returns the todo and the first page of its comments, oldest first. `limit` and `cursor` page through the comments
+// @Description  the same way they do for `GET /todos/{id}/comments`.
+// @Tags         Todos
+// @Param        id     path  int    true  "Todo ID"
+// @Param        limit  query int    false "Number of comments per page (1-100, default 50)"
+// @Param        cursor query string false "Cursor returned as next_cursor by the previous page of comments"
+// @Param        render query string false "html to add the sanitized HTML rendering of the notes of the todo as NotesHTML" Enums(html)
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}	dtos.TodoDetailDTO
+// @Failure      400  {object}  dtos.ErrorDTO
+// @Failure      401  {object}  dtos.ErrorDTO
+// @Failure      404  {object}  dtos.ErrorDTO
+// @Failure      422  {object}  dtos.ErrorDTO
+// @Failure      500  {object}  dtos.ErrorDTO
+// @Security	 BearerAuth
+// @Router       /todos/{id} [get]
+func (ctl *Controller) GetTodo(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	user, _, err := ctl.authenticate(c, ctx)
+	if err != nil {
+		return err
+	}
+
+	queryDTO := new(dtos.CommentQueryDTO)
+	if err = bind(c, queryDTO); err != nil {
+		return err
+	}
+
+	html, err := renderParam(c)
+	if err != nil {
+		return err
+	}
+
+	todo, err := authorizedTodo(ctx, ctl.app.Store, user, c.Param("id"), models.RoleViewer)
+	if err != nil {
+		return err
+	}
+
+	comments, err := ctl.commentPage(ctx, todo.ID, queryDTO)
+	if err != nil {
+		return err
+	}
+
+	if html {
+		if err = renderNotes(todo); err != nil {
+			return err
+		}
+	}
+
+	todo.TodoList = nil
+	return c.JSON(http.StatusOK, dtos.TodoDetailDTO{Todo: *todo, Comments: comments})
+}
+
 // Update todo godoc
 // @Summary      Update this todo
-// @Description  Accepts `text` and `completed` as a JSON object and returns the updated todo.
+// @Description  Accepts `text`, `notes`, `completed`, `due_at`, `all_day`, `auto_complete`, `tag_ids`, `status_id`, `priority` and `assignee_id` as a JSON object
+// @Description  and returns the updated todo. Leaving `notes` or `due_at` out removes them, while leaving `tag_ids` or `assignee_id` out keeps them.
+// @Description  An `assignee_id` of 0 unassigns the todo.
+// @Description  `status_id` decides `completed`: terminal statuses complete the todo. Without it, changing `completed` moves
+// @Description  the todo to the first status matching it.
+// @Description  Completing a recurring todo moves it to its next occurrence instead, with the next due date.
+// @Description  Its text and due date changes apply to the current occurrence only unless `scope` is `future`.
 // @Tags         Todos
 // @Param        todo body dtos.TodoDTO true "The todo's text and completed status"
 // @Param        id path int true "Todo ID"
+// @Param        scope query string false "Occurrences of a recurring todo the changes apply to" Enums(this, future) default(this)
+// @Param        render query string false "html to add the sanitized HTML rendering of the notes of the todos as NotesHTML" Enums(html)
 // @Accept       json
 // @Produce      json
 // @Success      200  {object}	models.Todo
+// @Failure      400  {object}  dtos.ErrorDTO
 // @Failure      401  {object}  dtos.ErrorDTO
+// @Failure      403  {object}  dtos.ErrorDTO
 // @Failure      404  {object}  dtos.ErrorDTO
+// @Failure      409  {object}  dtos.ErrorDTO
+// @Failure      422  {object}  dtos.ErrorDTO
 // @Failure      500  {object}  dtos.ErrorDTO
 // @Security	 BearerAuth
 // @Router       /todos/{id} [put]
-func UpdateTodo(c echo.Context) error {
-	ctx := context.Background()
-	db := db.GetDBIntance()
+func (ctl *Controller) UpdateTodo(c echo.Context) error {
+	ctx := c.Request().Context()
 
-	user, err := utils.ValidateToken(c, db, ctx)
+	user, _, err := ctl.authenticate(c, ctx)
 	if err != nil {
 		return err
 	}
 
 	todoDTO := new(dtos.TodoDTO)
-	if err = c.Bind(todoDTO); err != nil {
+	if err = bind(c, todoDTO); err != nil {
 		return err
 	}
 
-	todoID, err := strconv.Atoi(c.Param("id"))
+	// Bind only binds the query parameters of GET and DELETE requests.
+	scopeDTO := new(dtos.TodoScopeDTO)
+	if err = (&echo.DefaultBinder{}).BindQueryParams(c, scopeDTO); err != nil {
+		return apperr.Wrap(apperr.InvalidRequest, err)
+	}
+	if err = c.Validate(scopeDTO); err != nil {
+		return err
+	}
+
+	html, err := renderParam(c)
 	if err != nil {
-		return c.JSON(http.StatusNotFound, &dtos.ErrorDTO{ErrorCode: 16, Description: "Todo does not exist."})
+		return err
 	}
 
-	todo := new(models.Todo)
-	err = db.NewSelect().Model(todo).Relation("TodoList").Where("todo.id = ?", todoID).Scan(ctx)
+	todo, err := authorizedTodo(ctx, ctl.app.Store, user, c.Param("id"), models.RoleEditor)
 	if err != nil {
-		fmt.Println(err)
-		return c.JSON(http.StatusNotFound, &dtos.ErrorDTO{ErrorCode: 17, Description: "Todo does not exist."})
+		return err
+	}
+
+	if todoDTO.TagIDs != nil {
+		tags, err := ownedTags(ctx, ctl.app.Store, user, *todoDTO.TagIDs)
+		if err != nil {
+			return err
+		}
+		todo.Tags = withForeignTags(todo, user, tags)
+	}
+
+	if todoDTO.StatusID != nil {
+		status, err := todoListStatus(ctx, ctl.app.Store, todo.TodoListID, *todoDTO.StatusID)
+		if err != nil {
+			return err
+		}
+		todo.StatusID, todoDTO.Completed = status.ID, status.Terminal
 	}
 
-	if todo.TodoList.OwnerID != user.ID {
-		return c.JSON(http.StatusUnauthorized, &dtos.ErrorDTO{ErrorCode: 18, Description: "Unauthorized."})
+	if todoDTO.AssigneeID != nil {
+		if err = checkAssignee(ctx, ctl.app.Store, todo.TodoList, *todoDTO.AssigneeID); err != nil {
+			return err
+		}
+		todo.AssigneeID = *todoDTO.AssigneeID
 	}
 
+	due, allDay := dueAt(todoDTO.DueAt, todoDTO.AllDay), todoDTO.AllDay && todoDTO.DueAt != nil
+	dueChanged := !equalTimes(todo.DueAt, due) || todo.AllDay != allDay
+	completing := todoDTO.Completed && !todo.Completed
+	previousDue := todo.DueAt
+
 	todo.Completed = todoDTO.Completed
 	todo.Text = todoDTO.Text
+	todo.Notes = todoDTO.Notes
+	todo.DueAt, todo.AllDay = due, allDay
+	todo.AutoComplete = todoDTO.AutoComplete
+	todo.Priority = todoDTO.Priority
+	todo.UpdatedAt = ctl.app.Now()
+	if !todo.Completed {
+		todo.CompletedAt = nil
+	}
+
+	updateRecurrence := todo.Recurrence != nil && scopeDTO.Scope == "future"
+	if updateRecurrence {
+		if err = updateSeries(todo, previousDue); err != nil {
+			return err
+		}
+		todo.Recurrence.UpdatedAt = todo.UpdatedAt
+	}
+
+	err = ctl.app.Store.InTx(ctx, func(tx *store.Store) error {
+		if err := rejectDuplicate(ctx, tx, todo.TodoList, todo); err != nil {
+			return err
+		}
+
+		if updateRecurrence {
+			if err := tx.Recurrences.Update(ctx, todo.Recurrence); err != nil {
+				return err
+			}
+		}
+
+		if completing {
+			recurred, err := completeTodo(ctx, tx, user, todo, todo.UpdatedAt)
+			if err != nil {
+				return err
+			}
+			dueChanged = dueChanged || recurred
+		} else if err := syncStatus(ctx, tx, todo); err != nil {
+			return err
+		}
+
+		if err := tx.Todos.Update(ctx, todo); err != nil {
+			return err
+		}
 
-	_, err = db.NewUpdate().Model(todo).Where("id = ?", todoID).Exec(ctx)
+		if todoDTO.TagIDs != nil {
+			if err := tx.Tags.SetTodoTags(ctx, todo.ID, tagIDs(todo.Tags)); err != nil {
+				return err
+			}
+		}
+
+		if !dueChanged {
+			return nil
+		}
+		return rescheduleReminders(ctx, tx, todo, ctl.app.Now())
+	})
 	if err != nil {
-		fmt.Println(err)
-		return c.JSON(http.StatusInternalServerError, &dtos.ErrorDTO{ErrorCode: 19, Description: "We encoutered a problem while updating the todo."})
+		return apperr.Wrap(apperr.TodoUpdateFailed, err)
+	}
+
+	if html {
+		if err = renderNotes(todo); err != nil {
+			return err
+		}
 	}
 
 	return c.JSON(http.StatusOK, todo)
 }
+
+// Move Todo godoc
+// @Summary      Move this todo to another todo list
+// @Description  Accepts the `todo_list_id` of a todo list the user can edit, like the one of the todo, and returns the moved todo.
+// @Description  Its checklist, reminders, recurrence and completions move with it. It goes last in the destination, in the status of the same name
+// @Description  in the destination list if there is one, or else in the first status there matching whether it is completed.
+// @Description  It is unassigned if its assignee has no access to the destination.
+// @Tags         Todos
+// @Param        destination body dtos.MoveTodoDTO true "The todo list to move the todo to"
+// @Param        id path int true "Todo ID"
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}	models.Todo
+// @Failure      400  {object}  dtos.ErrorDTO
+// @Failure      401  {object}  dtos.ErrorDTO
+// @Failure      403  {object}  dtos.ErrorDTO
+// @Failure      404  {object}  dtos.ErrorDTO
+// @Failure      409  {object}  dtos.ErrorDTO
+// @Failure      422  {object}  dtos.ErrorDTO
+// @Failure      500  {object}  dtos.ErrorDTO
+// @Security	 BearerAuth
+// @Router       /todos/{id}/move [post]
+func (ctl *Controller) MoveTodo(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	user, _, err := ctl.authenticate(c, ctx)
+	if err != nil {
+		return err
+	}
+
+	moveDTO := new(dtos.MoveTodoDTO)
+	if err = bind(c, moveDTO); err != nil {
+		return err
+	}
+
+	todo, err := authorizedTodo(ctx, ctl.app.Store, user, c.Param("id"), models.RoleEditor)
+	if err != nil {
+		return err
+	}
+
+	todoList, err := authorizedTodoList(ctx, ctl.app.Store, user, strconv.Itoa(moveDTO.TodoListID), models.RoleEditor)
+	if err != nil {
+		return err
+	}
+
+	if todo.AssigneeID != 0 {
+		assigned, err := ctl.app.Store.HasAccess(ctx, todoList, todo.AssigneeID)
+		if err != nil {
+			return apperr.Wrap(apperr.MemberFetchFailed, err)
+		}
+		if !assigned {
+			todo.AssigneeID = 0
+		}
+	}
+
+	err = ctl.inTxRanked(ctx, func(tx *store.Store) error {
+		if err := rejectDuplicate(ctx, tx, todoList, todo); err != nil {
+			return err
+		}
+
+		statuses, err := tx.Statuses.ListByTodoList(ctx, todoList.ID)
+		if err != nil {
+			return err
+		}
+
+		// Keep the column of the todo when the destination has one of the same name.
+		status, err := tx.Statuses.GetByID(ctx, todo.StatusID)
+		if err == nil {
+			if i := slices.IndexFunc(statuses, func(s models.Status) bool { return strings.EqualFold(s.Name, status.Name) }); i >= 0 {
+				todo.StatusID = statuses[i].ID
+			}
+		} else if !errors.Is(err, store.ErrNotFound) {
+			return err
+		}
+
+		// The todo takes its new rank while still in its list, so the rank must be free in both lists.
+		last, err := tx.Todos.AdjacentRank(ctx, todo.TodoListID, "", true)
+		if err != nil {
+			return err
+		}
+		lastThere, err := tx.Todos.AdjacentRank(ctx, todoList.ID, "", true)
+		if err != nil {
+			return err
+		}
+		todo.Rank = rank.Between(max(last, lastThere), "")
+		if err := tx.Todos.SetRank(ctx, todo.ID, todo.Rank); err != nil {
+			return err
+		}
+
+		// Everything attached to the todo references the todo itself, so only the todo changes.
+		todo.TodoListID, todo.TodoList = todoList.ID, todoList
+		todo.StatusID = statusFor(statuses, todo)
+		todo.UpdatedAt = ctl.app.Now()
+
+		return tx.Todos.Update(ctx, todo)
+	})
+	if err != nil {
+		return apperr.Wrap(apperr.TodoUpdateFailed, err)
+	}
+
+	todo.TodoList = nil
+	return c.JSON(http.StatusOK, todo)
+}
+
+// Reorder Todo godoc
+// @Summary      Move this todo in the order of its todo list
+// @Description  Accepts either the `before_id` or the `after_id` of another todo of the same todo list as a JSON object,
+// @Description  puts the todo right before or right after it and returns the todo. Only the moved todo changes.
+// @Tags         Todos
+// @Param        placement body dtos.ReorderDTO true "The todo to put this one before or after"
+// @Param        id path int true "Todo ID"
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}	models.Todo
+// @Failure      400  {object}  dtos.ErrorDTO
+// @Failure      401  {object}  dtos.ErrorDTO
+// @Failure      403  {object}  dtos.ErrorDTO
+// @Failure      404  {object}  dtos.ErrorDTO
+// @Failure      422  {object}  dtos.ErrorDTO
+// @Failure      500  {object}  dtos.ErrorDTO
+// @Security	 BearerAuth
+// @Router       /todos/{id}/reorder [post]
+func (ctl *Controller) ReorderTodo(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	user, _, err := ctl.authenticate(c, ctx)
+	if err != nil {
+		return err
+	}
+
+	reorderDTO := new(dtos.ReorderDTO)
+	if err = bind(c, reorderDTO); err != nil {
+		return err
+	}
+
+	todo, err := authorizedTodo(ctx, ctl.app.Store, user, c.Param("id"), models.RoleEditor)
+	if err != nil {
+		return err
+	}
+
+	p, err := parsePlacement(reorderDTO, todo.ID)
+	if err != nil {
+		return err
+	}
+
+	err = ctl.inTxRanked(ctx, func(tx *store.Store) error {
+		// The target is loaded in the transaction so that its rank is current.
+		target, err := authorizedTodo(ctx, tx, user, strconv.Itoa(p.targetID), models.RoleViewer)
+		if err != nil {
+			return err
+		}
+
+		if target.TodoListID != todo.TodoListID {
+			return apperr.New(apperr.ValidationFailed).WithFields([]apperr.FieldError{
+				{Field: p.field, Message: "must be the ID of a todo of the same todo list"},
+			})
+		}
+
+		r, err := rankNextTo(ctx, tx.Todos.AdjacentRank, todo.TodoListID, target.Rank, p.before)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Todos.SetRank(ctx, todo.ID, r); err != nil {
+			return err
+		}
+		todo.Rank = r
+		return nil
+	})
+	if errors.Is(err, store.ErrNotFound) {
+		return apperr.New(apperr.TodoNotFound)
+	} else if err != nil {
+		return apperr.Wrap(apperr.TodoUpdateFailed, err)
+	}
+
+	todo.TodoList = nil
+	return c.JSON(http.StatusOK, todo)
+}
+
+// Delete Todo godoc
+// @Summary      Delete this todo
+// @Description  Deletes the todo along with its checklist, reminders, recurrence, completions and comments, removes its tags and returns it as a JSON object.
+// @Tags         Todos
+// @Param        id path int true "Todo ID"
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}	models.Todo
+// @Failure      400  {object}  dtos.ErrorDTO
+// @Failure      401  {object}  dtos.ErrorDTO
+// @Failure      403  {object}  dtos.ErrorDTO
+// @Failure      404  {object}  dtos.ErrorDTO
+// @Failure      500  {object}  dtos.ErrorDTO
+// @Security	 BearerAuth
+// @Router       /todos/{id} [delete]
+func (ctl *Controller) DeleteTodo(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	user, _, err := ctl.authenticate(c, ctx)
+	if err != nil {
+		return err
+	}
+
+	todo, err := authorizedTodo(ctx, ctl.app.Store, user, c.Param("id"), models.RoleEditor)
+	if err != nil {
+		return err
+	}
+
+	err = ctl.app.Store.InTx(ctx, func(tx *store.Store) error {
+		if err := tx.ChecklistItems.DeleteByTodo(ctx, todo.ID); err != nil {
+			return err
+		}
+
+		if err := tx.Reminders.DeleteByTodo(ctx, todo.ID); err != nil {
+			return err
+		}
+
+		if err := tx.Recurrences.DeleteByTodo(ctx, todo.ID); err != nil {
+			return err
+		}
+
+		if err := tx.Completions.DeleteByTodo(ctx, todo.ID); err != nil {
+			return err
+		}
+
+		if err := tx.Comments.DeleteByTodo(ctx, todo.ID); err != nil {
+			return err
+		}
+
+		if err := tx.Tags.SetTodoTags(ctx, todo.ID, nil); err != nil {
+			return err
+		}
+
+		return tx.Todos.Delete(ctx, todo.ID)
+	})
+	if errors.Is(err, store.ErrNotFound) {
+		return apperr.New(apperr.TodoNotFound)
+	} else if err != nil {
+		return apperr.Wrap(apperr.TodoDeleteFailed, err)
+	}
+
+	todo.TodoList = nil
+	return c.JSON(http.StatusOK, todo)
+}
+
+// List Todos godoc
+// @Summary      List the user's todos across all their todo lists, shared ones included
+// @Description  Returns a page of todos matching the filters. Time filters are RFC 3339 timestamps; `*_after` bounds are inclusive and `*_before` bounds exclusive.
+// @Tags         Todos
+// @Param        limit          query int      false "Number of todos per page (1-100, default 50)"
+// @Param        cursor         query string   false "Cursor returned as next_cursor by the previous page"
+// @Param        sort           query string   false "Sort field" Enums(rank, text, created_at, updated_at) default(created_at)
+// @Param        order          query string   false "Sort direction" Enums(asc, desc) default(desc)
+// @Param        completed      query bool     false "Only completed (true) or incomplete (false) todos"
+// @Param        text           query string   false "Only todos whose text contains this, ignoring case"
+// @Param        todo_list_id   query []int    false "Only todos of these todo lists" collectionFormat(multi)
+// @Param        created_after  query string   false "Only todos created at or after this time"
+// @Param        created_before query string   false "Only todos created before this time"
+// @Param        updated_after  query string   false "Only todos updated at or after this time"
+// @Param        updated_before query string   false "Only todos updated before this time"
+// @Param        due            query string   false "Only incomplete todos past their due date, todos due today or this week in the user's time zone, or todos without a due date" Enums(overdue, today, week, none)
+// @Param        tag_id         query []int    false "Only todos with these tags" collectionFormat(multi)
+// @Param        tag_match      query string   false "Whether todos need any or all of the tag_id tags" Enums(any, all) default(any)
+// @Param        status_id      query []int    false "Only todos in these statuses" collectionFormat(multi)
+// @Param        priority       query []int    false "Only todos with these priorities, from 0 (none) to 3 (high)" collectionFormat(multi)
+// @Param        assignee       query string   false "Only todos assigned to the user (me), to nobody (none) or to the user with this ID"
+// @Param        render         query string   false "html to add the sanitized HTML rendering of the notes as NotesHTML" Enums(html)
+// @Param        X-Org-ID header int false "ID of the organization to work in"
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}	dtos.PageDTO[models.Todo]
+// @Failure      400  {object}  dtos.ErrorDTO
+// @Failure      401  {object}  dtos.ErrorDTO
+// @Failure      422  {object}  dtos.ErrorDTO
+// @Failure      500  {object}  dtos.ErrorDTO
+// @Security	 BearerAuth
+// @Router       /todos [get]
+func (ctl *Controller) ListTodos(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	user, _, err := ctl.authenticate(c, ctx)
+	if err != nil {
+		return err
+	}
+
+	queryDTO := new(dtos.TodoQueryDTO)
+	if err = bind(c, queryDTO); err != nil {
+		return err
+	}
+
+	return ctl.listTodos(c, user, queryDTO, queryDTO.TodoListIDs, store.Sort{Field: "created_at", Desc: true})
+}
+
+// List Todo List Todos godoc
+// @Summary      List the todos of a todo list
+// @Description  Same as `GET /todos`, restricted to one todo list and in its order by default.
+// @Tags         Todos
+// @Param        id             path  int      true  "Todo List ID"
+// @Param        limit          query int      false "Number of todos per page (1-100, default 50)"
+// @Param        cursor         query string   false "Cursor returned as next_cursor by the previous page"
+// @Param        sort           query string   false "Sort field" Enums(rank, text, created_at, updated_at) default(rank)
+// @Param        order          query string   false "Sort direction" Enums(asc, desc) default(asc)
+// @Param        completed      query bool     false "Only completed (true) or incomplete (false) todos"
+// @Param        text           query string   false "Only todos whose text contains this, ignoring case"
+// @Param        created_after  query string   false "Only todos created at or after this time"
+// @Param        created_before query string   false "Only todos created before this time"
+// @Param        updated_after  query string   false "Only todos updated at or after this time"
+// @Param        updated_before query string   false "Only todos updated before this time"
+// @Param        due            query string   false "Only incomplete todos past their due date, todos due today or this week in the user's time zone, or todos without a due date" Enums(overdue, today, week, none)
+// @Param        tag_id         query []int    false "Only todos with these tags" collectionFormat(multi)
+// @Param        tag_match      query string   false "Whether todos need any or all of the tag_id tags" Enums(any, all) default(any)
+// @Param        status_id      query []int    false "Only todos in these statuses" collectionFormat(multi)
+// @Param        priority       query []int    false "Only todos with these priorities, from 0 (none) to 3 (high)" collectionFormat(multi)
+// @Param        assignee       query string   false "Only todos assigned to the user (me), to nobody (none) or to the user with this ID"
+// @Param        render         query string   false "html to add the sanitized HTML rendering of the notes as NotesHTML" Enums(html)
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}	dtos.PageDTO[models.Todo]
+// @Failure      400  {object}  dtos.ErrorDTO
+// @Failure      401  {object}  dtos.ErrorDTO
+// @Failure      404  {object}  dtos.ErrorDTO
+// @Failure      422  {object}  dtos.ErrorDTO
+// @Failure      500  {object}  dtos.ErrorDTO
+// @Security	 BearerAuth
+// @Router       /todolists/{id}/todos [get]
+func (ctl *Controller) ListTodoListTodos(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	user, _, err := ctl.authenticate(c, ctx)
+	if err != nil {
+		return err
+	}
+
+	queryDTO := new(dtos.TodoQueryDTO)
+	if err = bind(c, queryDTO); err != nil {
+		return err
+	}
+
+	todoList, err := authorizedTodoList(ctx, ctl.app.Store, user, c.Param("id"), models.RoleViewer)
+	if err != nil {
+		return err
+	}
+
+	return ctl.listTodos(c, user, queryDTO, []int{todoList.ID}, store.Sort{Field: "rank"})
+}
+
+// listTodos responds with the page of the user's todos described by queryDTO, in the given todo lists,
+// sorted by defaultSort unless queryDTO asks otherwise.
+func (ctl *Controller) listTodos(c echo.Context, user *models.User, queryDTO *dtos.TodoQueryDTO, todoListIDs []int, defaultSort store.Sort) error {
+	query := store.TodoQuery{
+		UserID:         user.ID,
+		OrganizationID: orgID(user),
+		TodoListIDs:    todoListIDs,
+		TextContains:   queryDTO.Text,
+		CreatedFrom:    queryDTO.CreatedAfter,
+		CreatedTo:      queryDTO.CreatedBefore,
+		UpdatedFrom:    queryDTO.UpdatedAfter,
+		UpdatedTo:      queryDTO.UpdatedBefore,
+		TagIDs:         queryDTO.TagIDs,
+		AllTags:        queryDTO.TagMatch == "all",
+		StatusIDs:      queryDTO.StatusIDs,
+		Priorities:     queryDTO.Priorities,
+		Sort:           parseSort(queryDTO.Sort, queryDTO.Order, defaultSort.Field, defaultSort.Desc),
+		Limit:          queryDTO.Limit,
+	}
+
+	if query.Limit == 0 {
+		query.Limit = defaultPageLimit
+	}
+
+	if queryDTO.Completed != "" {
+		completed := queryDTO.Completed == "true"
+		query.Completed = &completed
+	}
+
+	switch queryDTO.Assignee {
+	case "":
+	case "me":
+		query.AssigneeID = &user.ID
+	case "none":
+		unassigned := 0
+		query.AssigneeID = &unassigned
+	default:
+		assigneeID, err := strconv.Atoi(queryDTO.Assignee)
+		if err != nil || assigneeID <= 0 {
+			return apperr.New(apperr.ValidationFailed).WithFields([]apperr.FieldError{
+				{Field: "assignee", Message: "must be me, none or the ID of a user"},
+			})
+		}
+		query.AssigneeID = &assigneeID
+	}
+
+	now, loc := ctl.app.Now(), location(user)
+	switch queryDTO.Due {
+	case "none":
+		query.NoDueDate = true
+	case "overdue":
+		incomplete := false
+		query.Completed = &incomplete
+		query.Due = &store.DueRange{To: now, ToDate: dateOf(now, loc)}
+	case "today", "week":
+		from := dateOf(now, loc)
+		to := from.AddDate(0, 0, 1)
+		if queryDTO.Due == "week" {
+			// Weeks start on Monday.
+			from = from.AddDate(0, 0, -(int(from.Weekday())+6)%7)
+			to = from.AddDate(0, 0, 7)
+		}
+		query.Due = &store.DueRange{From: midnight(from, loc), To: midnight(to, loc), FromDate: from, ToDate: to}
+	}
+
+	html, err := renderParam(c)
+	if err != nil {
+		return err
+	}
+
+	query.After, err = decodeCursor(queryDTO.Cursor, query.Sort, timeSort(query.Sort))
+	if err != nil {
+		return err
+	}
+
+	// Fetch one more todo than asked to know whether there is a next page.
+	limit := query.Limit
+	query.Limit++
+
+	todos, err := ctl.app.Store.Todos.List(c.Request().Context(), query)
+	if err != nil {
+		return apperr.Wrap(apperr.TodoFetchFailed, err)
+	}
+
+	if html {
+		for i := range todos {
+			if err = renderNotes(&todos[i]); err != nil {
+				return err
+			}
+		}
+	}
+
+	return c.JSON(http.StatusOK, page(todos, limit, query.Sort, func(t *models.Todo) store.Cursor {
+		return store.TodoCursor(t, query.Sort.Field)
+	}))
+}
+
+// checkAssignee makes sure the user with the given ID can be assigned the todos of todoList. An ID of 0 unassigns them.
+func checkAssignee(ctx context.Context, st *store.Store, todoList *models.TodoList, userID int) error {
+	if userID == 0 {
+		return nil
+	}
+
+	ok, err := st.HasAccess(ctx, todoList, userID)
+	if err != nil {
+		return apperr.Wrap(apperr.MemberFetchFailed, err)
+	}
+	if !ok {
+		return apperr.New(apperr.ValidationFailed).WithFields([]apperr.FieldError{
+			{Field: "assignee_id", Message: "must be the owner or a member of the todo list"},
+		})
+	}
+	return nil
+}
+
+// dueAt returns the due date to store for the one sent by the client: the same instant in UTC,
+// or for all-day todos its date, as written, at midnight UTC.
+func dueAt(t *time.Time, allDay bool) *time.Time {
+	if t == nil {
+		return nil
+	}
+
+	due := t.UTC()
+	if allDay {
+		y, m, d := t.Date()
+		due = time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	}
+	return &due
+}
+
+// dateOf returns the date of t in loc at midnight UTC, the way the dates of all-day todos are stored.
+func dateOf(t time.Time, loc *time.Location) time.Time {
+	y, m, d := t.In(loc).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// midnight returns the time date starts in loc.
+func midnight(date time.Time, loc *time.Location) time.Time {
+	y, m, d := date.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, loc)
+}
+
+// renderParam reports whether the request asks for the notes of the todos it returns as HTML, with ?render=html.
+func renderParam(c echo.Context) (bool, error) {
+	switch c.QueryParam("render") {
+	case "":
+		return false, nil
+	case "html":
+		return true, nil
+	default:
+		return false, apperr.New(apperr.ValidationFailed).WithFields([]apperr.FieldError{
+			{Field: "render", Message: "must be one of: html"},
+		})
+	}
+}
+
+// renderNotes sets the NotesHTML of todos from their notes.
+func renderNotes(todos ...*models.Todo) error {
+	for _, todo := range todos {
+		notes, err := markdown.HTML(todo.Notes)
+		if err != nil {
+			return apperr.Wrap(apperr.Internal, err)
+		}
+		todo.NotesHTML = notes
+	}
+	return nil
+}
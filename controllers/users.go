@@ -1,64 +1,62 @@
 package controllers
 
 import (
-	"context"
-	"crypto/rand"
-	"fmt"
+	"errors"
 	"net/http"
-	"net/mail"
-	"unicode/utf8"
+	"time"
 
 	"github.com/labstack/echo/v4"
-	"github.com/marouane-ach/todo-go/db"
+	"github.com/marouane-ach/todo-go/apperr"
 	"github.com/marouane-ach/todo-go/dtos"
 	"github.com/marouane-ach/todo-go/models"
+	"github.com/marouane-ach/todo-go/notify"
+	"github.com/marouane-ach/todo-go/store"
 	"github.com/marouane-ach/todo-go/utils"
 	"golang.org/x/crypto/bcrypt"
 )
 
 // Signup godoc
 // @Summary      Create a new account
-// @Description  Accepts `email` and `password` as JSON and returns a Bearer token as a JSON string.
+// @Description  Accepts `email`, `password` and an optional `timezone` as JSON and returns a Bearer token as a JSON string.
 // @Description  The token must be placed in the Authorization header in subsequent authenticated requests.
 // @Tags         Accounts
-// @Param        user body dtos.UserDTO true "the user's email ans password"
+// @Param        user body dtos.SignupDTO true "the user's email ans password"
 // @Accept       json
 // @Produce      json
 // @Success      201  {string}	string
 // @Failure      400  {object}  dtos.ErrorDTO
 // @Failure      409  {object}  dtos.ErrorDTO
+// @Failure      422  {object}  dtos.ErrorDTO
 // @Failure      500  {object}  dtos.ErrorDTO
 // @Router       /signup [post]
-func Signup(c echo.Context) error {
-	ctx := context.Background()
-	db := db.GetDBIntance()
+func (ctl *Controller) Signup(c echo.Context) error {
+	ctx := c.Request().Context()
 
-	userDTO := new(dtos.UserDTO)
-	if err := c.Bind(userDTO); err != nil {
+	userDTO := new(dtos.SignupDTO)
+	if err := bind(c, userDTO); err != nil {
 		return err
 	}
 
-	if _, err := mail.ParseAddress(userDTO.Email); err != nil {
-		return c.JSON(http.StatusBadRequest, &dtos.ErrorDTO{ErrorCode: 1, Description: "Invalid email address."})
-	}
-
-	// The password hashing function can't accept a password longer than 72 bytes (72 = 4 (max bytes in a character) * 18)
-	if utf8.RuneCountInString(userDTO.Password) < 8 || utf8.RuneCountInString(userDTO.Password) > 18 {
-		return c.JSON(http.StatusBadRequest, &dtos.ErrorDTO{ErrorCode: 2, Description: "Password must contain 8-18 characters."})
-	}
-
 	hashedPassword := utils.HashPassword(userDTO.Password)
 
-	user := &models.User{Email: userDTO.Email, HashedPassword: hashedPassword}
-	_, err := db.NewInsert().Model(user).Exec(ctx)
+	// The user and its first token are created together so that a failed signup can be retried.
+	var token *models.Token
+	err := ctl.app.Store.InTx(ctx, func(tx *store.Store) error {
+		user := &models.User{Email: userDTO.Email, HashedPassword: hashedPassword, Timezone: userDTO.Timezone}
+		if user.Timezone == "" {
+			user.Timezone = "UTC"
+		}
+		if err := tx.Users.Create(ctx, user); errors.Is(err, store.ErrConflict) {
+			return apperr.New(apperr.EmailTaken)
+		} else if err != nil {
+			return err
+		}
+
+		token = &models.Token{Token: utils.GenerateToken(), OwnerID: user.ID}
+		return tx.Tokens.Create(ctx, token)
+	})
 	if err != nil {
-		return c.JSON(http.StatusConflict, &dtos.ErrorDTO{ErrorCode: 4, Description: "An account with this email already exists."})
-	}
-
-	token := &models.Token{Token: utils.GenerateToken(), OwnerID: user.ID}
-	_, err = db.NewInsert().Model(token).Exec(ctx)
-	if err != nil {
-		return c.JSON(http.StatusInternalServerError, &dtos.ErrorDTO{ErrorCode: 5, Description: "We encoutered a problem while creating your account."})
+		return apperr.Wrap(apperr.SignupFailed, err)
 	}
 
 	return c.JSON(http.StatusCreated, token.Token)
@@ -75,38 +73,34 @@ func Signup(c echo.Context) error {
 // @Success      200  {string}	string
 // @Failure      401  {object}  dtos.ErrorDTO
 // @Failure      404  {object}  dtos.ErrorDTO
+// @Failure      422  {object}  dtos.ErrorDTO
 // @Failure      500  {object}  dtos.ErrorDTO
 // @Router       /login [post]
-func Login(c echo.Context) error {
-	ctx := context.Background()
-	db := db.GetDBIntance()
+func (ctl *Controller) Login(c echo.Context) error {
+	ctx := c.Request().Context()
 
 	userDTO := new(dtos.UserDTO)
-	if err := c.Bind(userDTO); err != nil {
+	if err := bind(c, userDTO); err != nil {
 		return err
 	}
 
-	user := new(models.User)
-	err := db.NewSelect().Model(user).Where("email = ?", userDTO.Email).Scan(ctx)
-	if err != nil {
-		fmt.Println(err.Error())
-		return c.JSON(http.StatusNotFound, &dtos.ErrorDTO{ErrorCode: 6, Description: "An account with this email does not exist."})
+	user, err := ctl.app.Store.Users.GetByEmail(ctx, userDTO.Email)
+	if errors.Is(err, store.ErrNotFound) {
+		return apperr.New(apperr.AccountNotFound)
+	} else if err != nil {
+		return apperr.Wrap(apperr.LoginFailed, err)
 	}
 
 	if err = bcrypt.CompareHashAndPassword([]byte(user.HashedPassword), []byte(userDTO.Password)); err != nil {
-		return c.JSON(http.StatusUnauthorized, &dtos.ErrorDTO{ErrorCode: 7, Description: "Wrong password."})
+		return apperr.New(apperr.WrongPassword)
 	}
 
-	b := make([]byte, 32)
-	rand.Read(b)
-	t := fmt.Sprintf("%x", b)
-	token := &models.Token{Token: t, OwnerID: user.ID}
-	_, err = db.NewInsert().Model(token).Exec(ctx)
-	if err != nil {
-		return c.JSON(http.StatusInternalServerError, &dtos.ErrorDTO{ErrorCode: 8, Description: "We encoutered a problem while logging you in."})
+	token := &models.Token{Token: utils.GenerateToken(), OwnerID: user.ID}
+	if err = ctl.app.Store.Tokens.Create(ctx, token); err != nil {
+		return apperr.Wrap(apperr.LoginFailed, err)
 	}
 
-	return c.JSON(http.StatusOK, t)
+	return c.JSON(http.StatusOK, token.Token)
 }
 
 // Logout godoc
@@ -115,31 +109,108 @@ func Login(c echo.Context) error {
 // @Tags         Accounts
 // @Accept       json
 // @Produce      json
-// @Success      201  {string}	string
+// @Success      200  {string}	string
 // @Failure      401  {object}  dtos.ErrorDTO
 // @Failure      500  {object}  dtos.ErrorDTO
 // @Security	 BearerAuth
 // @Router       /logout [post]
-func Logout(c echo.Context) error {
-	ctx := context.Background()
-	db := db.GetDBIntance()
+func (ctl *Controller) Logout(c echo.Context) error {
+	ctx := c.Request().Context()
 
-	_, err := utils.ValidateToken(c, db, ctx)
+	_, token, err := ctl.authenticate(c, ctx)
 	if err != nil {
 		return err
 	}
 
-	headerToken := c.Request().Header.Get("Authorization")[7:]
-	token := new(models.Token)
-	err = db.NewSelect().Model(token).Where("token = ?", headerToken).Scan(ctx)
+	if err = ctl.app.Store.Tokens.Delete(ctx, token.ID); err != nil {
+		return apperr.Wrap(apperr.LogoutFailed, err)
+	}
+
+	return c.String(http.StatusOK, "")
+}
+
+// Get Profile godoc
+// @Summary      Get the current account
+// @Description  Returns the email and time zone of the current account.
+// @Tags         Accounts
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}	dtos.ProfileDTO
+// @Failure      401  {object}  dtos.ErrorDTO
+// @Failure      500  {object}  dtos.ErrorDTO
+// @Security	 BearerAuth
+// @Router       /me [get]
+func (ctl *Controller) GetProfile(c echo.Context) error {
+	user, _, err := ctl.authenticate(c, c.Request().Context())
 	if err != nil {
-		fmt.Println(err)
+		return err
 	}
 
-	_, err = db.NewDelete().Model(token).WherePK().Exec(ctx)
+	return c.JSON(http.StatusOK, profile(user))
+}
+
+// Update Profile godoc
+// @Summary      Update the current account
+// @Description  Accepts `timezone`, the IANA name of the time zone used to tell which todos are due today,
+// @Description  and `webhook_url`, where notifications of the webhook channel are posted, as JSON. Fields left out are unchanged.
+// @Description  Webhook URLs must resolve to public addresses: loopback, private and link-local ones are rejected.
+// @Tags         Accounts
+// @Param        profile body dtos.ProfileUpdateDTO true "The account's time zone and webhook URL"
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}	dtos.ProfileDTO
+// @Failure      400  {object}  dtos.ErrorDTO
+// @Failure      401  {object}  dtos.ErrorDTO
+// @Failure      422  {object}  dtos.ErrorDTO
+// @Failure      500  {object}  dtos.ErrorDTO
+// @Security	 BearerAuth
+// @Router       /me [patch]
+func (ctl *Controller) UpdateProfile(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	user, _, err := ctl.authenticate(c, ctx)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, &dtos.ErrorDTO{ErrorCode: 13, Description: "We encoutered a problem while logging you out."})
+		return err
 	}
 
-	return c.String(http.StatusOK, "")
+	profileDTO := new(dtos.ProfileUpdateDTO)
+	if err = bind(c, profileDTO); err != nil {
+		return err
+	}
+
+	if profileDTO.Timezone != "" {
+		user.Timezone = profileDTO.Timezone
+	}
+	if profileDTO.WebhookURL != nil {
+		if *profileDTO.WebhookURL != "" {
+			if err = notify.CheckWebhookURL(ctx, *profileDTO.WebhookURL); err != nil {
+				message := "must have a host that resolves"
+				if errors.Is(err, notify.ErrPrivateAddress) {
+					message = "must point to a public address"
+				}
+				return apperr.New(apperr.ValidationFailed).WithFields([]apperr.FieldError{{Field: "webhook_url", Message: message}})
+			}
+		}
+		user.WebhookURL = *profileDTO.WebhookURL
+	}
+	user.UpdatedAt = ctl.app.Now()
+
+	if err = ctl.app.Store.Users.Update(ctx, user); err != nil {
+		return apperr.Wrap(apperr.UserUpdateFailed, err)
+	}
+
+	return c.JSON(http.StatusOK, profile(user))
+}
+
+func profile(user *models.User) dtos.ProfileDTO {
+	return dtos.ProfileDTO{ID: user.ID, Email: user.Email, Timezone: user.Timezone, WebhookURL: user.WebhookURL}
+}
+
+// location returns the user's time zone, or UTC if it can't be loaded.
+func location(user *models.User) *time.Location {
+	loc, err := time.LoadLocation(user.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
 }
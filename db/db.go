@@ -3,86 +3,93 @@ package db
 import (
 	"context"
 	"database/sql"
+	"fmt"
+	"strings"
+	"time"
 
+	"github.com/marouane-ach/todo-go/db/migrations"
 	"github.com/marouane-ach/todo-go/models"
 	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/pgdialect"
 	"github.com/uptrace/bun/dialect/sqlitedialect"
+	"github.com/uptrace/bun/driver/pgdriver"
 	"github.com/uptrace/bun/driver/sqliteshim"
+	"github.com/uptrace/bun/migrate"
+	"github.com/uptrace/bun/schema"
 )
 
-var dbInstance *bun.DB
-var dbContext context.Context
+// Open returns a new database handle for the given URL.
+// URLs starting with postgres:// or postgresql:// use Postgres, anything else is treated as a SQLite DSN.
+func Open(url string) (*bun.DB, error) {
+	if strings.HasPrefix(url, "postgres://") || strings.HasPrefix(url, "postgresql://") {
+		sqldb := sql.OpenDB(pgdriver.NewConnector(pgdriver.WithDSN(url)))
+		return newDB(sqldb, pgdialect.New()), nil
+	}
 
-func GetDBIntance() *bun.DB {
-	if dbInstance == nil {
-		sqldb, err := sql.Open(sqliteshim.ShimName, "file:dev.db?cache=shared&mode=rwc")
-		if err != nil {
-			panic(err)
+	// Instances sharing the database file wait for each other's writes instead of failing right away.
+	if !strings.Contains(url, "busy_timeout") {
+		sep := "?"
+		if strings.Contains(url, "?") {
+			sep = "&"
 		}
-		dbInstance = bun.NewDB(sqldb, sqlitedialect.New())
+		url += sep + "_pragma=busy_timeout(5000)"
 	}
 
-	return dbInstance
-}
-
-func GetAppContext() context.Context {
-	if dbContext == nil {
-		dbContext = context.Background()
+	sqldb, err := sql.Open(sqliteshim.ShimName, url)
+	if err != nil {
+		return nil, err
 	}
 
-	return dbContext
+	return newDB(sqldb, sqlitedialect.New()), nil
 }
 
-func CreateDBTables() {
-	ctx := GetAppContext()
-	db := GetDBIntance()
-
-	_, err := db.NewCreateTable().Model((*models.User)(nil)).IfNotExists().Exec(ctx)
-	if err != nil {
-		panic(err)
-	}
+func newDB(sqldb *sql.DB, dialect schema.Dialect) *bun.DB {
+	db := bun.NewDB(sqldb, dialect)
+	// Join tables of many-to-many relations must be registered before they are used.
+	db.RegisterModel((*models.TodoTag)(nil))
+	return db
+}
 
-	_, err = db.NewCreateTable().Model((*models.Token)(nil)).IfNotExists().Exec(ctx)
-	if err != nil {
-		panic(err)
+// Migrate applies the migrations the database hasn't seen yet. Instances starting at the same
+// time wait for each other instead of running the same migrations twice.
+func Migrate(ctx context.Context, db *bun.DB) error {
+	migrator := migrate.NewMigrator(db, migrations.Migrations)
+	if err := migrator.Init(ctx); err != nil {
+		return err
 	}
 
-	_, err = db.NewCreateTable().Model((*models.Color)(nil)).IfNotExists().Exec(ctx)
-	if err != nil {
-		panic(err)
-	}
+	for {
+		err := migrator.Lock(ctx)
+		if err == nil {
+			break
+		}
 
-	_, err = db.NewCreateTable().Model((*models.TodoList)(nil)).IfNotExists().Exec(ctx)
-	if err != nil {
-		panic(err)
+		// Another instance holds the lock. If it crashed, the row in bun_migration_locks must be deleted by hand.
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w: %w", ctx.Err(), err)
+		case <-time.After(time.Second):
+		}
 	}
+	defer migrator.Unlock(ctx)
 
-	_, err = db.NewCreateTable().Model((*models.Todo)(nil)).IfNotExists().Exec(ctx)
-	if err != nil {
-		panic(err)
-	}
+	_, err := migrator.Migrate(ctx)
+	return err
 }
 
-func SeedColorsTable() {
-	ctx := GetAppContext()
-	db := GetDBIntance()
-
-	colors := [8]models.Color{
-		{ColorHex: "#FF6B6B", Name: "coral"},
-		{ColorHex: "#DA77F2", Name: "orchid"},
-		{ColorHex: "#9775FA", Name: "amethyst"},
-		{ColorHex: "#5C7CFA", Name: "cobalt"},
-		{ColorHex: "#66D9E8", Name: "aqua"},
-		{ColorHex: "#8CE99A", Name: "lime"},
-		{ColorHex: "#FFD43B", Name: "canary"},
-		{ColorHex: "#FF922B", Name: "tangerine"},
-	}
-
-	for _, c := range colors {
-		color := new(models.Color)
-		err := db.NewSelect().Model(color).Where("name = ?", c.Name).Scan(ctx)
+func SeedColorsTable(ctx context.Context, db *bun.DB) error {
+	for _, c := range models.Palette {
+		exists, err := db.NewSelect().Model((*models.Color)(nil)).Where("name = ?", c.Name).Exists(ctx)
 		if err != nil {
-			db.NewInsert().Model(&c).Exec(ctx)
+			return err
+		}
+
+		if !exists {
+			if _, err := db.NewInsert().Model(&c).Exec(ctx); err != nil {
+				return err
+			}
 		}
 	}
+
+	return nil
 }
@@ -0,0 +1,87 @@
+package migrations
+
+import (
+	"context"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// The tables as they were before migrations were introduced. Databases created back then
+// already have them, which is why they are created only if they don't exist.
+
+type baseModel struct {
+	ID        int       `bun:"id,pk,autoincrement"`
+	CreatedAt time.Time `bun:"created_at,nullzero,notnull,default:current_timestamp"`
+	UpdatedAt time.Time `bun:"updated_at,nullzero,notnull,default:current_timestamp"`
+}
+
+type user20261019 struct {
+	baseModel
+	bun.BaseModel `bun:"table:users"`
+
+	Email          string `bun:",unique"`
+	HashedPassword string
+}
+
+type token20261019 struct {
+	baseModel
+	bun.BaseModel `bun:"table:tokens"`
+
+	Token   string `bun:",unique"`
+	OwnerID int    `bun:",notnull"`
+}
+
+type color20261019 struct {
+	baseModel
+	bun.BaseModel `bun:"table:colors"`
+
+	Name     string `bun:",unique"`
+	ColorHex string `bun:",unique,notnull"`
+}
+
+type todoList20261019 struct {
+	baseModel
+	bun.BaseModel `bun:"table:todo_lists"`
+
+	Name    string `bun:",notnull"`
+	ColorID int    `bun:",notnull"`
+	OwnerID int    `bun:",notnull"`
+}
+
+type todo20261019 struct {
+	baseModel
+	bun.BaseModel `bun:"table:todos"`
+
+	Text       string `bun:",unique"`
+	Completed  bool   `bun:"default:false"`
+	TodoListID int    `bun:",notnull"`
+}
+
+func init() {
+	Migrations.MustRegister(func(ctx context.Context, db *bun.DB) error {
+		tables := []any{
+			(*user20261019)(nil),
+			(*token20261019)(nil),
+			(*color20261019)(nil),
+			(*todoList20261019)(nil),
+			(*todo20261019)(nil),
+		}
+
+		for _, model := range tables {
+			if _, err := db.NewCreateTable().Model(model).IfNotExists().Exec(ctx); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}, func(ctx context.Context, db *bun.DB) error {
+		return execAll(ctx, db,
+			"DROP TABLE IF EXISTS todos",
+			"DROP TABLE IF EXISTS todo_lists",
+			"DROP TABLE IF EXISTS colors",
+			"DROP TABLE IF EXISTS tokens",
+			"DROP TABLE IF EXISTS users",
+		)
+	})
+}
@@ -0,0 +1,73 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/uptrace/bun"
+)
+
+// Full-text search over todo texts and todo list names. SQLite uses FTS5 tables kept in sync
+// with their source tables by triggers, Postgres uses GIN indexes on tsvector expressions.
+
+func init() {
+	Migrations.MustRegister(func(ctx context.Context, db *bun.DB) error {
+		if !isSQLite(db) {
+			return execAll(ctx, db,
+				"CREATE INDEX IF NOT EXISTS todos_text_search_idx ON todos USING GIN (to_tsvector('simple', text))",
+				"CREATE INDEX IF NOT EXISTS todo_lists_name_search_idx ON todo_lists USING GIN (to_tsvector('simple', name))",
+			)
+		}
+
+		return execAll(ctx, db, append(
+			ftsTable("todos_fts", "todos", "text"),
+			ftsTable("todo_lists_fts", "todo_lists", "name")...,
+		)...)
+	}, func(ctx context.Context, db *bun.DB) error {
+		if !isSQLite(db) {
+			return execAll(ctx, db,
+				"DROP INDEX IF EXISTS todos_text_search_idx",
+				"DROP INDEX IF EXISTS todo_lists_name_search_idx",
+			)
+		}
+
+		return execAll(ctx, db, append(
+			dropFTSTable("todos_fts"),
+			dropFTSTable("todo_lists_fts")...,
+		)...)
+	})
+}
+
+// ftsTable returns the statements creating an external content FTS5 table indexing the
+// given columns of table, the triggers keeping it up to date and the backfill of existing rows.
+func ftsTable(name, table string, columns ...string) []string {
+	cols := strings.Join(columns, ", ")
+	newCols := "new." + strings.Join(columns, ", new.")
+	oldCols := "old." + strings.Join(columns, ", old.")
+
+	return []string{
+		fmt.Sprintf(`CREATE VIRTUAL TABLE %s USING fts5(%s, content='%s', content_rowid='id', tokenize='unicode61 remove_diacritics 2', prefix='2 3')`,
+			name, cols, table),
+		fmt.Sprintf(`CREATE TRIGGER %[1]s_ai AFTER INSERT ON %[2]s BEGIN
+	INSERT INTO %[1]s(rowid, %[3]s) VALUES (new.id, %[4]s);
+END`, name, table, cols, newCols),
+		fmt.Sprintf(`CREATE TRIGGER %[1]s_ad AFTER DELETE ON %[2]s BEGIN
+	INSERT INTO %[1]s(%[1]s, rowid, %[3]s) VALUES ('delete', old.id, %[4]s);
+END`, name, table, cols, oldCols),
+		fmt.Sprintf(`CREATE TRIGGER %[1]s_au AFTER UPDATE OF %[3]s ON %[2]s BEGIN
+	INSERT INTO %[1]s(%[1]s, rowid, %[3]s) VALUES ('delete', old.id, %[4]s);
+	INSERT INTO %[1]s(rowid, %[3]s) VALUES (new.id, %[5]s);
+END`, name, table, cols, oldCols, newCols),
+		fmt.Sprintf(`INSERT INTO %[1]s(%[1]s) VALUES ('rebuild')`, name),
+	}
+}
+
+func dropFTSTable(name string) []string {
+	return []string{
+		"DROP TRIGGER IF EXISTS " + name + "_ai",
+		"DROP TRIGGER IF EXISTS " + name + "_ad",
+		"DROP TRIGGER IF EXISTS " + name + "_au",
+		"DROP TABLE IF EXISTS " + name,
+	}
+}
@@ -0,0 +1,32 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/uptrace/bun"
+)
+
+// Optional due dates on todos and the time zone of each user, used to tell which todos are due today.
+
+func init() {
+	Migrations.MustRegister(func(ctx context.Context, db *bun.DB) error {
+		timestamp := "TIMESTAMPTZ"
+		if isSQLite(db) {
+			timestamp = "TIMESTAMP"
+		}
+
+		return execAll(ctx, db,
+			"ALTER TABLE todos ADD COLUMN due_at "+timestamp,
+			"ALTER TABLE todos ADD COLUMN all_day BOOLEAN NOT NULL DEFAULT false",
+			"CREATE INDEX todos_due_at_idx ON todos (due_at)",
+			"ALTER TABLE users ADD COLUMN timezone VARCHAR NOT NULL DEFAULT 'UTC'",
+		)
+	}, func(ctx context.Context, db *bun.DB) error {
+		return execAll(ctx, db,
+			"DROP INDEX IF EXISTS todos_due_at_idx",
+			"ALTER TABLE todos DROP COLUMN due_at",
+			"ALTER TABLE todos DROP COLUMN all_day",
+			"ALTER TABLE users DROP COLUMN timezone",
+		)
+	})
+}
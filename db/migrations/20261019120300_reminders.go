@@ -0,0 +1,65 @@
+package migrations
+
+import (
+	"context"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// Reminders fired by the scheduler, the in-app notifications they may produce and
+// the webhook URL of each user.
+
+type reminder20261019 struct {
+	baseModel
+	bun.BaseModel `bun:"table:reminders"`
+
+	TodoID        int `bun:",notnull"`
+	UserID        int `bun:",notnull"`
+	RemindAt      *time.Time
+	OffsetMinutes *int
+	Channel       string `bun:",notnull"`
+	FireAt        *time.Time
+	Status        string `bun:",notnull,default:'pending'"`
+	Attempts      int    `bun:",notnull,default:0"`
+	LastError     string `bun:",nullzero"`
+	SentAt        *time.Time
+	ClaimToken    string `bun:",nullzero"`
+	ClaimedUntil  *time.Time
+}
+
+type notification20261019 struct {
+	baseModel
+	bun.BaseModel `bun:"table:notifications"`
+
+	UserID int    `bun:",notnull"`
+	Kind   string `bun:",notnull"`
+	Title  string `bun:",notnull"`
+	Body   string
+	TodoID *int
+	ReadAt *time.Time
+}
+
+func init() {
+	Migrations.MustRegister(func(ctx context.Context, db *bun.DB) error {
+		for _, model := range []any{(*reminder20261019)(nil), (*notification20261019)(nil)} {
+			if _, err := db.NewCreateTable().Model(model).Exec(ctx); err != nil {
+				return err
+			}
+		}
+
+		return execAll(ctx, db,
+			// The scheduler looks for pending reminders by fire time.
+			"CREATE INDEX reminders_status_fire_at_idx ON reminders (status, fire_at)",
+			"CREATE INDEX reminders_todo_id_idx ON reminders (todo_id, user_id)",
+			"CREATE INDEX notifications_user_id_idx ON notifications (user_id, created_at)",
+			"ALTER TABLE users ADD COLUMN webhook_url VARCHAR",
+		)
+	}, func(ctx context.Context, db *bun.DB) error {
+		return execAll(ctx, db,
+			"ALTER TABLE users DROP COLUMN webhook_url",
+			"DROP TABLE IF EXISTS notifications",
+			"DROP TABLE IF EXISTS reminders",
+		)
+	})
+}
@@ -0,0 +1,57 @@
+package migrations
+
+import (
+	"context"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// Recurrence rules of todos and the history of their completions.
+
+type recurrence20261019 struct {
+	baseModel
+	bun.BaseModel `bun:"table:todo_recurrences"`
+
+	TodoID  int    `bun:",notnull,unique"`
+	Rule    string `bun:",notnull"`
+	Mode    string `bun:",notnull"`
+	Start   *time.Time
+	Current *time.Time
+	Text    string `bun:",notnull"`
+}
+
+type completion20261019 struct {
+	baseModel
+	bun.BaseModel `bun:"table:todo_completions"`
+
+	TodoID      int `bun:",notnull"`
+	DueAt       *time.Time
+	CompletedAt time.Time `bun:",notnull"`
+}
+
+func init() {
+	Migrations.MustRegister(func(ctx context.Context, db *bun.DB) error {
+		for _, model := range []any{(*recurrence20261019)(nil), (*completion20261019)(nil)} {
+			if _, err := db.NewCreateTable().Model(model).Exec(ctx); err != nil {
+				return err
+			}
+		}
+
+		timestamp := "TIMESTAMPTZ"
+		if isSQLite(db) {
+			timestamp = "TIMESTAMP"
+		}
+
+		return execAll(ctx, db,
+			"CREATE INDEX todo_completions_todo_id_idx ON todo_completions (todo_id, completed_at)",
+			"ALTER TABLE todos ADD COLUMN completed_at "+timestamp,
+		)
+	}, func(ctx context.Context, db *bun.DB) error {
+		return execAll(ctx, db,
+			"ALTER TABLE todos DROP COLUMN completed_at",
+			"DROP TABLE IF EXISTS todo_completions",
+			"DROP TABLE IF EXISTS todo_recurrences",
+		)
+	})
+}
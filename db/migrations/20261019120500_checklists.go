@@ -0,0 +1,41 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/uptrace/bun"
+)
+
+// Checklist items of todos and the progress kept on their todo.
+
+type checklistItem20261019 struct {
+	baseModel
+	bun.BaseModel `bun:"table:checklist_items"`
+
+	TodoID   int    `bun:",notnull"`
+	Text     string `bun:",notnull"`
+	Done     bool   `bun:",notnull,default:false"`
+	Position int    `bun:",notnull"`
+}
+
+func init() {
+	Migrations.MustRegister(func(ctx context.Context, db *bun.DB) error {
+		if _, err := db.NewCreateTable().Model((*checklistItem20261019)(nil)).Exec(ctx); err != nil {
+			return err
+		}
+
+		return execAll(ctx, db,
+			"CREATE INDEX checklist_items_todo_id_idx ON checklist_items (todo_id, position)",
+			"ALTER TABLE todos ADD COLUMN auto_complete BOOLEAN NOT NULL DEFAULT false",
+			"ALTER TABLE todos ADD COLUMN items_total BIGINT NOT NULL DEFAULT 0",
+			"ALTER TABLE todos ADD COLUMN items_done BIGINT NOT NULL DEFAULT 0",
+		)
+	}, func(ctx context.Context, db *bun.DB) error {
+		return execAll(ctx, db,
+			"ALTER TABLE todos DROP COLUMN items_done",
+			"ALTER TABLE todos DROP COLUMN items_total",
+			"ALTER TABLE todos DROP COLUMN auto_complete",
+			"DROP TABLE IF EXISTS checklist_items",
+		)
+	})
+}
@@ -0,0 +1,37 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/uptrace/bun"
+)
+
+// Markdown notes of todos, searched along with their text.
+
+func init() {
+	Migrations.MustRegister(func(ctx context.Context, db *bun.DB) error {
+		if err := execAll(ctx, db, "ALTER TABLE todos ADD COLUMN notes VARCHAR"); err != nil {
+			return err
+		}
+
+		if !isSQLite(db) {
+			return execAll(ctx, db,
+				"DROP INDEX IF EXISTS todos_text_search_idx",
+				"CREATE INDEX todos_search_idx ON todos USING GIN (to_tsvector('simple', text || ' ' || coalesce(notes, '')))",
+			)
+		}
+
+		return execAll(ctx, db, append(dropFTSTable("todos_fts"), ftsTable("todos_fts", "todos", "text", "notes")...)...)
+	}, func(ctx context.Context, db *bun.DB) error {
+		if !isSQLite(db) {
+			return execAll(ctx, db,
+				"DROP INDEX IF EXISTS todos_search_idx",
+				"CREATE INDEX todos_text_search_idx ON todos USING GIN (to_tsvector('simple', text))",
+				"ALTER TABLE todos DROP COLUMN notes",
+			)
+		}
+
+		stmts := append(dropFTSTable("todos_fts"), "ALTER TABLE todos DROP COLUMN notes")
+		return execAll(ctx, db, append(stmts, ftsTable("todos_fts", "todos", "text")...)...)
+	})
+}
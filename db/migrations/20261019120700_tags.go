@@ -0,0 +1,39 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/uptrace/bun"
+)
+
+// Tags of each user and the todos they label.
+
+type tag20261019 struct {
+	baseModel
+	bun.BaseModel `bun:"table:tags"`
+
+	Name    string `bun:",notnull"`
+	ColorID *int
+	OwnerID int `bun:",notnull"`
+}
+
+func init() {
+	Migrations.MustRegister(func(ctx context.Context, db *bun.DB) error {
+		if _, err := db.NewCreateTable().Model((*tag20261019)(nil)).Exec(ctx); err != nil {
+			return err
+		}
+
+		return execAll(ctx, db,
+			// Bun finds the join table of models.Todo.Tags by name, so no other model may be registered for it.
+			"CREATE TABLE todo_tags (todo_id BIGINT NOT NULL, tag_id BIGINT NOT NULL, PRIMARY KEY (todo_id, tag_id))",
+			"CREATE UNIQUE INDEX tags_owner_id_name_idx ON tags (owner_id, lower(name))",
+			// The primary key covers the lookups by todo, this one the counts and deletions by tag.
+			"CREATE INDEX todo_tags_tag_id_idx ON todo_tags (tag_id)",
+		)
+	}, func(ctx context.Context, db *bun.DB) error {
+		return execAll(ctx, db,
+			"DROP TABLE IF EXISTS todo_tags",
+			"DROP TABLE IF EXISTS tags",
+		)
+	})
+}
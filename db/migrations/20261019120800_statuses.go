@@ -0,0 +1,65 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/uptrace/bun"
+)
+
+// Status columns of todo lists and the status and priority of todos. Existing lists get the
+// default columns, and their todos the first column matching whether they are completed.
+
+type status20261019 struct {
+	baseModel
+	bun.BaseModel `bun:"table:statuses"`
+
+	TodoListID int    `bun:",notnull"`
+	Name       string `bun:",notnull"`
+	Position   int    `bun:",notnull"`
+	Terminal   bool   `bun:",notnull,default:false"`
+}
+
+var defaultStatuses20261019 = []struct {
+	name     string
+	terminal bool
+}{
+	{"To do", false},
+	{"In progress", false},
+	{"Blocked", false},
+	{"Done", true},
+}
+
+func init() {
+	Migrations.MustRegister(func(ctx context.Context, db *bun.DB) error {
+		if _, err := db.NewCreateTable().Model((*status20261019)(nil)).Exec(ctx); err != nil {
+			return err
+		}
+
+		for i, s := range defaultStatuses20261019 {
+			_, err := db.ExecContext(ctx, "INSERT INTO statuses (todo_list_id, name, position, terminal) SELECT id, ?, ?, ? FROM todo_lists",
+				s.name, i+1, s.terminal)
+			if err != nil {
+				return err
+			}
+		}
+
+		return execAll(ctx, db,
+			"CREATE INDEX statuses_todo_list_id_idx ON statuses (todo_list_id, position)",
+			"ALTER TABLE todos ADD COLUMN status_id BIGINT",
+			"ALTER TABLE todos ADD COLUMN priority BIGINT NOT NULL DEFAULT 0",
+			`UPDATE todos SET status_id = (
+	SELECT s.id FROM statuses AS s
+	WHERE s.todo_list_id = todos.todo_list_id AND s.terminal = coalesce(todos.completed, false)
+	ORDER BY s.position LIMIT 1
+)`,
+			"CREATE INDEX todos_status_id_idx ON todos (status_id)",
+		)
+	}, func(ctx context.Context, db *bun.DB) error {
+		return execAll(ctx, db,
+			"DROP INDEX IF EXISTS todos_status_id_idx",
+			"ALTER TABLE todos DROP COLUMN priority",
+			"ALTER TABLE todos DROP COLUMN status_id",
+			"DROP TABLE IF EXISTS statuses",
+		)
+	})
+}
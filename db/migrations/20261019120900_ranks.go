@@ -0,0 +1,46 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/uptrace/bun"
+)
+
+// Manual ordering of the todo lists of each user and of the todos of each list. Existing lists
+// keep their newest-first order and todos their creation order, with fixed-length keys that
+// the rank package can insert between.
+
+func init() {
+	Migrations.MustRegister(func(ctx context.Context, db *bun.DB) error {
+		// Ranks compare byte by byte, which Postgres only does in the C collation.
+		column, key := `VARCHAR COLLATE "C"`, "lpad(r.n::text, 8, '0') || 'V'"
+		if isSQLite(db) {
+			column, key = "VARCHAR", "printf('%08dV', r.n)"
+		}
+
+		return execAll(ctx, db,
+			"ALTER TABLE todo_lists ADD COLUMN rank "+column+" NOT NULL DEFAULT ''",
+			"ALTER TABLE todos ADD COLUMN rank "+column+" NOT NULL DEFAULT ''",
+			`UPDATE todo_lists SET rank = (
+	SELECT `+key+` FROM (
+		SELECT id, ROW_NUMBER() OVER (PARTITION BY owner_id ORDER BY created_at DESC, id DESC) AS n FROM todo_lists
+	) AS r WHERE r.id = todo_lists.id
+)`,
+			`UPDATE todos SET rank = (
+	SELECT `+key+` FROM (
+		SELECT id, ROW_NUMBER() OVER (PARTITION BY todo_list_id ORDER BY id) AS n FROM todos
+	) AS r WHERE r.id = todos.id
+)`,
+			// Two items never share a rank, so concurrent moves to the same place conflict instead.
+			"CREATE UNIQUE INDEX todo_lists_owner_id_rank_idx ON todo_lists (owner_id, rank)",
+			"CREATE UNIQUE INDEX todos_todo_list_id_rank_idx ON todos (todo_list_id, rank)",
+		)
+	}, func(ctx context.Context, db *bun.DB) error {
+		return execAll(ctx, db,
+			"DROP INDEX IF EXISTS todos_todo_list_id_rank_idx",
+			"DROP INDEX IF EXISTS todo_lists_owner_id_rank_idx",
+			"ALTER TABLE todos DROP COLUMN rank",
+			"ALTER TABLE todo_lists DROP COLUMN rank",
+		)
+	})
+}
@@ -0,0 +1,102 @@
+package migrations
+
+import (
+	"context"
+	"strings"
+
+	"github.com/uptrace/bun"
+)
+
+// Todo texts were unique across all users. They no longer are, and todo lists can reject
+// duplicates among their own todos instead.
+//
+// SQLite can't drop the UNIQUE constraint of a column, so the todos table is rebuilt without it,
+// along with its indexes and full-text search triggers.
+
+// todosColumns20261019 are the columns of the todos table at this point.
+var todosColumns20261019 = []string{
+	"id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT",
+	"created_at TIMESTAMP NOT NULL DEFAULT current_timestamp",
+	"updated_at TIMESTAMP NOT NULL DEFAULT current_timestamp",
+	"text VARCHAR",
+	"completed BOOLEAN DEFAULT false",
+	"todo_list_id INTEGER NOT NULL",
+	"due_at TIMESTAMP",
+	"all_day BOOLEAN NOT NULL DEFAULT false",
+	"completed_at TIMESTAMP",
+	"auto_complete BOOLEAN NOT NULL DEFAULT false",
+	"items_total BIGINT NOT NULL DEFAULT 0",
+	"items_done BIGINT NOT NULL DEFAULT 0",
+	"notes VARCHAR",
+	"status_id BIGINT",
+	"priority BIGINT NOT NULL DEFAULT 0",
+	"rank VARCHAR NOT NULL DEFAULT ''",
+}
+
+// rebuildTodos20261019 replaces the SQLite todos table with a new one, with or without unique texts, keeping its rows.
+func rebuildTodos20261019(ctx context.Context, db *bun.DB, uniqueText bool) error {
+	names := make([]string, len(todosColumns20261019))
+	for i, column := range todosColumns20261019 {
+		names[i], _, _ = strings.Cut(column, " ")
+	}
+	list := strings.Join(names, ", ")
+
+	columns := strings.Join(todosColumns20261019, ", ")
+	if uniqueText {
+		columns += `, UNIQUE ("text")`
+	}
+
+	stmts := []string{
+		"CREATE TABLE todos_rebuilt (" + columns + ")",
+		"INSERT INTO todos_rebuilt (" + list + ") SELECT " + list + " FROM todos",
+		// Keep the IDs of deleted todos from being reused.
+		"DELETE FROM sqlite_sequence WHERE name = 'todos_rebuilt'",
+		"INSERT INTO sqlite_sequence (name, seq) SELECT 'todos_rebuilt', seq FROM sqlite_sequence WHERE name = 'todos'",
+		// Dropping the table drops its indexes and triggers too.
+		"DROP TABLE todos",
+		"ALTER TABLE todos_rebuilt RENAME TO todos",
+		"CREATE INDEX todos_due_at_idx ON todos (due_at)",
+		"CREATE INDEX todos_status_id_idx ON todos (status_id)",
+		"CREATE UNIQUE INDEX todos_todo_list_id_rank_idx ON todos (todo_list_id, rank)",
+	}
+	stmts = append(stmts, dropFTSTable("todos_fts")...)
+	stmts = append(stmts, ftsTable("todos_fts", "todos", "text", "notes")...)
+
+	return db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		return execAll(ctx, tx, stmts...)
+	})
+}
+
+func init() {
+	Migrations.MustRegister(func(ctx context.Context, db *bun.DB) error {
+		if err := execAll(ctx, db, "ALTER TABLE todo_lists ADD COLUMN reject_duplicates BOOLEAN NOT NULL DEFAULT false"); err != nil {
+			return err
+		}
+
+		if !isSQLite(db) {
+			return execAll(ctx, db,
+				"ALTER TABLE todos DROP CONSTRAINT IF EXISTS todos_text_key",
+				"CREATE INDEX todos_todo_list_id_text_idx ON todos (todo_list_id, lower(text))",
+			)
+		}
+
+		if err := rebuildTodos20261019(ctx, db, false); err != nil {
+			return err
+		}
+		return execAll(ctx, db, "CREATE INDEX todos_todo_list_id_text_idx ON todos (todo_list_id, lower(text))")
+	}, func(ctx context.Context, db *bun.DB) error {
+		// Fails if different users, or the same one, have todos with the same text by then.
+		if !isSQLite(db) {
+			return execAll(ctx, db,
+				"DROP INDEX IF EXISTS todos_todo_list_id_text_idx",
+				"ALTER TABLE todos ADD CONSTRAINT todos_text_key UNIQUE (text)",
+				"ALTER TABLE todo_lists DROP COLUMN reject_duplicates",
+			)
+		}
+
+		if err := rebuildTodos20261019(ctx, db, true); err != nil {
+			return err
+		}
+		return execAll(ctx, db, "ALTER TABLE todo_lists DROP COLUMN reject_duplicates")
+	})
+}
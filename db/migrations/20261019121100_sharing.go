@@ -0,0 +1,53 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/uptrace/bun"
+)
+
+// Members of shared todo lists and the invitations to join them.
+
+type membership20261019 struct {
+	baseModel
+	bun.BaseModel `bun:"table:memberships"`
+
+	TodoListID int    `bun:",notnull"`
+	UserID     int    `bun:",notnull"`
+	Role       string `bun:",notnull"`
+}
+
+type invitation20261019 struct {
+	baseModel
+	bun.BaseModel `bun:"table:invitations"`
+
+	TodoListID int    `bun:",notnull"`
+	InviterID  int    `bun:",notnull"`
+	Email      string `bun:",notnull"`
+	Role       string `bun:",notnull"`
+}
+
+func init() {
+	Migrations.MustRegister(func(ctx context.Context, db *bun.DB) error {
+		if _, err := db.NewCreateTable().Model((*membership20261019)(nil)).Exec(ctx); err != nil {
+			return err
+		}
+
+		if _, err := db.NewCreateTable().Model((*invitation20261019)(nil)).Exec(ctx); err != nil {
+			return err
+		}
+
+		return execAll(ctx, db,
+			"CREATE UNIQUE INDEX memberships_todo_list_id_user_id_idx ON memberships (todo_list_id, user_id)",
+			// Lists are looked up by member on every request listing them.
+			"CREATE INDEX memberships_user_id_idx ON memberships (user_id)",
+			"CREATE UNIQUE INDEX invitations_todo_list_id_email_idx ON invitations (todo_list_id, lower(email))",
+			"CREATE INDEX invitations_email_idx ON invitations (lower(email))",
+		)
+	}, func(ctx context.Context, db *bun.DB) error {
+		return execAll(ctx, db,
+			"DROP TABLE IF EXISTS invitations",
+			"DROP TABLE IF EXISTS memberships",
+		)
+	})
+}
@@ -0,0 +1,34 @@
+package migrations
+
+import (
+	"context"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// Public read-only links to todo lists.
+
+type shareLink20261019 struct {
+	baseModel
+	bun.BaseModel `bun:"table:share_links"`
+
+	TodoListID     int    `bun:",notnull"`
+	Slug           string `bun:",unique,notnull"`
+	HashedPassword string `bun:",nullzero"`
+	ExpiresAt      *time.Time
+	Views          int `bun:",notnull,default:0"`
+	LastAccessedAt *time.Time
+}
+
+func init() {
+	Migrations.MustRegister(func(ctx context.Context, db *bun.DB) error {
+		if _, err := db.NewCreateTable().Model((*shareLink20261019)(nil)).Exec(ctx); err != nil {
+			return err
+		}
+
+		return execAll(ctx, db, "CREATE INDEX share_links_todo_list_id_idx ON share_links (todo_list_id)")
+	}, func(ctx context.Context, db *bun.DB) error {
+		return execAll(ctx, db, "DROP TABLE IF EXISTS share_links")
+	})
+}
@@ -0,0 +1,41 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/uptrace/bun"
+)
+
+// Todos can be assigned to a user with access to their todo list, and discussed in comments.
+
+type comment20261019 struct {
+	baseModel
+	bun.BaseModel `bun:"table:comments"`
+
+	TodoID   int    `bun:",notnull"`
+	AuthorID int    `bun:",notnull"`
+	Body     string `bun:",notnull"`
+}
+
+func init() {
+	Migrations.MustRegister(func(ctx context.Context, db *bun.DB) error {
+		if err := execAll(ctx, db,
+			"ALTER TABLE todos ADD COLUMN assignee_id BIGINT",
+			"CREATE INDEX todos_assignee_id_idx ON todos (assignee_id)",
+		); err != nil {
+			return err
+		}
+
+		if _, err := db.NewCreateTable().Model((*comment20261019)(nil)).Exec(ctx); err != nil {
+			return err
+		}
+
+		return execAll(ctx, db, "CREATE INDEX comments_todo_id_idx ON comments (todo_id)")
+	}, func(ctx context.Context, db *bun.DB) error {
+		return execAll(ctx, db,
+			"DROP TABLE IF EXISTS comments",
+			"DROP INDEX IF EXISTS todos_assignee_id_idx",
+			"ALTER TABLE todos DROP COLUMN assignee_id",
+		)
+	})
+}
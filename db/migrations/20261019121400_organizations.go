@@ -0,0 +1,59 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/uptrace/bun"
+)
+
+// Organizations and their members. Todo lists belong to a user or to an organization, and the lists of an
+// organization are ordered together rather than by owner.
+
+type organization20261019 struct {
+	baseModel
+	bun.BaseModel `bun:"table:organizations"`
+
+	Name         string `bun:",notnull"`
+	MaxMembers   int    `bun:",notnull,default:0"`
+	MaxTodoLists int    `bun:",notnull,default:0"`
+}
+
+type orgMembership20261019 struct {
+	baseModel
+	bun.BaseModel `bun:"table:org_memberships"`
+
+	OrganizationID int    `bun:",notnull"`
+	UserID         int    `bun:",notnull"`
+	Role           string `bun:",notnull"`
+}
+
+func init() {
+	Migrations.MustRegister(func(ctx context.Context, db *bun.DB) error {
+		if _, err := db.NewCreateTable().Model((*organization20261019)(nil)).Exec(ctx); err != nil {
+			return err
+		}
+
+		if _, err := db.NewCreateTable().Model((*orgMembership20261019)(nil)).Exec(ctx); err != nil {
+			return err
+		}
+
+		return execAll(ctx, db,
+			"CREATE UNIQUE INDEX org_memberships_organization_id_user_id_idx ON org_memberships (organization_id, user_id)",
+			"CREATE INDEX org_memberships_user_id_idx ON org_memberships (user_id)",
+			"ALTER TABLE todo_lists ADD COLUMN organization_id BIGINT",
+			"DROP INDEX IF EXISTS todo_lists_owner_id_rank_idx",
+			"CREATE UNIQUE INDEX todo_lists_owner_id_rank_idx ON todo_lists (owner_id, rank) WHERE organization_id IS NULL",
+			"CREATE UNIQUE INDEX todo_lists_organization_id_rank_idx ON todo_lists (organization_id, rank) WHERE organization_id IS NOT NULL",
+		)
+	}, func(ctx context.Context, db *bun.DB) error {
+		// Fails if lists of organizations took the ranks of lists of the same owner by then.
+		return execAll(ctx, db,
+			"DROP INDEX IF EXISTS todo_lists_organization_id_rank_idx",
+			"DROP INDEX IF EXISTS todo_lists_owner_id_rank_idx",
+			"CREATE UNIQUE INDEX todo_lists_owner_id_rank_idx ON todo_lists (owner_id, rank)",
+			"ALTER TABLE todo_lists DROP COLUMN organization_id",
+			"DROP TABLE IF EXISTS org_memberships",
+			"DROP TABLE IF EXISTS organizations",
+		)
+	})
+}
@@ -0,0 +1,40 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/uptrace/bun"
+)
+
+// Folders, optionally nested, that todo lists are filed in.
+
+type folder20261019 struct {
+	baseModel
+	bun.BaseModel `bun:"table:folders"`
+
+	Name           string `bun:",notnull"`
+	ParentID       int    `bun:",nullzero"`
+	OwnerID        int    `bun:",notnull"`
+	OrganizationID int    `bun:",nullzero"`
+}
+
+func init() {
+	Migrations.MustRegister(func(ctx context.Context, db *bun.DB) error {
+		if _, err := db.NewCreateTable().Model((*folder20261019)(nil)).Exec(ctx); err != nil {
+			return err
+		}
+
+		return execAll(ctx, db,
+			"CREATE INDEX folders_owner_id_idx ON folders (owner_id)",
+			"CREATE INDEX folders_organization_id_idx ON folders (organization_id)",
+			"ALTER TABLE todo_lists ADD COLUMN folder_id BIGINT",
+			"CREATE INDEX todo_lists_folder_id_idx ON todo_lists (folder_id)",
+		)
+	}, func(ctx context.Context, db *bun.DB) error {
+		return execAll(ctx, db,
+			"DROP INDEX IF EXISTS todo_lists_folder_id_idx",
+			"ALTER TABLE todo_lists DROP COLUMN folder_id",
+			"DROP TABLE IF EXISTS folders",
+		)
+	})
+}
@@ -0,0 +1,32 @@
+// Package migrations holds the schema changes of the database, applied in order by db.Migrate.
+//
+// Every migration lives in its own file named <timestamp>_<description>.go and registers
+// itself in an init function. Migrations must not use the types of the models package to
+// create tables, because those keep changing: they declare the shape of the tables as it was
+// when the migration was written.
+package migrations
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect"
+	"github.com/uptrace/bun/migrate"
+)
+
+var Migrations = migrate.NewMigrations()
+
+func isSQLite(db bun.IDB) bool {
+	return db.Dialect().Name() == dialect.SQLite
+}
+
+// execAll runs the statements in order.
+func execAll(ctx context.Context, db bun.IDB, statements ...string) error {
+	for _, stmt := range statements {
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("%w\n%s", err, stmt)
+		}
+	}
+	return nil
+}
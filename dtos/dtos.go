@@ -1,21 +1,390 @@
 package dtos
 
+import (
+	"time"
+
+	"github.com/marouane-ach/todo-go/models"
+)
+
 type UserDTO struct {
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	Email    string `json:"email" mod:"trim" validate:"required"`
+	Password string `json:"password" validate:"required"`
+}
+
+type SignupDTO struct {
+	Email string `json:"email" mod:"trim" validate:"required,email,max=254"`
+	// The password hashing function can't accept a password longer than 72 bytes (72 = 4 (max bytes in a character) * 18)
+	Password string `json:"password" validate:"min=8,max=18"`
+	// Timezone defaults to UTC.
+	Timezone string `json:"timezone" mod:"trim" validate:"omitempty,timezone"`
+}
+
+type ProfileDTO struct {
+	ID         int    `json:"id"`
+	Email      string `json:"email"`
+	Timezone   string `json:"timezone"`
+	WebhookURL string `json:"webhook_url,omitempty"`
+}
+
+// ProfileUpdateDTO changes the fields that are set.
+type ProfileUpdateDTO struct {
+	// Timezone is an IANA time zone name such as Europe/Paris.
+	Timezone string `json:"timezone" mod:"trim" validate:"omitempty,timezone"`
+	// WebhookURL receives the notifications of the webhook channel. An empty string removes it.
+	WebhookURL *string `json:"webhook_url" mod:"trim" validate:"omitzero,max=2048,http_url"`
 }
 
 type ErrorDTO struct {
-	ErrorCode   int    `json:"error_code"`
-	Description string `json:"description"`
+	ErrorCode   int             `json:"error_code"`
+	Description string          `json:"description"`
+	Errors      []FieldErrorDTO `json:"errors,omitempty"`
+	// ExistingID is the ID of the resource a request conflicts with, when it tells the client something.
+	ExistingID int `json:"existing_id,omitempty"`
+}
+
+// FieldErrorDTO describes why the value of one request field was rejected.
+type FieldErrorDTO struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
 }
 
 type TodoListDTO struct {
-	Name    string `json:"name"`
-	ColorID int    `json:"color_id"`
+	Name    string `json:"name" mod:"trim" validate:"required,max=100"`
+	ColorID int    `json:"color_id" validate:"required"`
+	// RejectDuplicates makes creating or renaming a todo fail when another todo of the list has the same text.
+	RejectDuplicates bool `json:"reject_duplicates"`
+	// FolderID files the list in a folder, or moves it to the root if it is 0. Leaving it out keeps the folder.
+	FolderID *int `json:"folder_id" validate:"omitempty,min=0"`
 }
 
 type TodoDTO struct {
+	Text      string `json:"text" mod:"trim" validate:"required,max=500"`
+	Completed bool   `json:"completed"`
+	// Notes is Markdown. Leaving it out removes the notes.
+	Notes string `json:"notes" validate:"max=20000"`
+	// DueAt is an RFC 3339 timestamp. Leaving it out removes the due date.
+	DueAt *time.Time `json:"due_at"`
+	// AllDay makes the todo due on the date of DueAt, as written, rather than at a given time.
+	AllDay bool `json:"all_day"`
+	// AutoComplete completes the todo once all its checklist items are done.
+	AutoComplete bool `json:"auto_complete"`
+	// TagIDs replaces the tags of the todo. Leaving it out keeps them.
+	TagIDs *[]int `json:"tag_ids" validate:"omitempty,max=100,dive,min=1"`
+	// StatusID is one of the statuses of the todo list and decides Completed. Leaving it out keeps
+	// the status, or moves the todo to the first status matching Completed when that changes.
+	StatusID *int `json:"status_id" validate:"omitempty,min=1"`
+	// Priority goes from 0 (none) to 3 (high).
+	Priority int `json:"priority" validate:"min=0,max=3"`
+	// AssigneeID assigns the todo to the owner or a member of the todo list, or unassigns it if it is 0.
+	// Leaving it out keeps the assignee.
+	AssigneeID *int `json:"assignee_id" validate:"omitempty,min=0"`
+}
+
+// TodoStatusDTO moves a todo to another column of the board of its todo list.
+type TodoStatusDTO struct {
+	StatusID int `json:"status_id" validate:"required,min=1"`
+}
+
+// MoveTodoDTO moves a todo, with its checklist, to another todo list.
+type MoveTodoDTO struct {
+	TodoListID int `json:"todo_list_id" validate:"required,min=1"`
+}
+
+// ProblemDTO is an RFC 7807 problem details object.
+type ProblemDTO struct {
+	Type       string          `json:"type"`
+	Title      string          `json:"title"`
+	Status     int             `json:"status"`
+	Detail     string          `json:"detail"`
+	Instance   string          `json:"instance"`
+	ErrorCode  int             `json:"error_code"`
+	Errors     []FieldErrorDTO `json:"errors,omitempty"`
+	ExistingID int             `json:"existing_id,omitempty"`
+}
+
+// PageDTO is one page of a listing.
+type PageDTO[T any] struct {
+	Data       []T           `json:"data"`
+	Pagination PaginationDTO `json:"pagination"`
+}
+
+type PaginationDTO struct {
+	Limit int `json:"limit"`
+	// NextCursor is passed as `cursor` to get the next page. It is empty on the last page.
+	NextCursor string `json:"next_cursor,omitempty"`
+	HasMore    bool   `json:"has_more"`
+}
+
+type TodoListQueryDTO struct {
+	Limit         int    `query:"limit" validate:"omitempty,min=1,max=100"`
+	Cursor        string `query:"cursor"`
+	Sort          string `query:"sort" validate:"omitempty,oneof=rank name created_at updated_at"`
+	Order         string `query:"order" validate:"omitempty,oneof=asc desc"`
+	ColorID       int    `query:"color_id" validate:"omitempty,min=1"`
+	HasIncomplete string `query:"has_incomplete" validate:"omitempty,oneof=true false"`
+	Todos         string `query:"todos" validate:"omitempty,oneof=include omit"`
+	TodosLimit    int    `query:"todos_limit" validate:"omitempty,min=1"`
+	// View is tree to get every list arranged in the folders instead of a page.
+	View string `query:"view" validate:"omitempty,oneof=page tree"`
+}
+
+type TodoQueryDTO struct {
+	Limit     int    `query:"limit" validate:"omitempty,min=1,max=100"`
+	Cursor    string `query:"cursor"`
+	Sort      string `query:"sort" validate:"omitempty,oneof=rank text created_at updated_at"`
+	Order     string `query:"order" validate:"omitempty,oneof=asc desc"`
+	Completed string `query:"completed" validate:"omitempty,oneof=true false"`
+	Text      string `query:"text" validate:"max=500"`
+	// TodoListIDs is ignored by GET /todolists/{id}/todos.
+	TodoListIDs   []int     `query:"todo_list_id" validate:"max=100,dive,min=1"`
+	CreatedAfter  time.Time `query:"created_after"`
+	CreatedBefore time.Time `query:"created_before"`
+	UpdatedAfter  time.Time `query:"updated_after"`
+	UpdatedBefore time.Time `query:"updated_before"`
+	Due           string    `query:"due" validate:"omitempty,oneof=overdue today week none"`
+	TagIDs        []int     `query:"tag_id" validate:"max=100,dive,min=1"`
+	// TagMatch keeps the todos with any of the tags, the default, or all of them.
+	TagMatch   string `query:"tag_match" validate:"omitempty,oneof=any all"`
+	StatusIDs  []int  `query:"status_id" validate:"max=100,dive,min=1"`
+	Priorities []int  `query:"priority" validate:"max=4,dive,min=0,max=3"`
+	// Assignee is me, none or the ID of a user.
+	Assignee string `query:"assignee" validate:"max=20"`
+}
+
+type SearchQueryDTO struct {
+	Q     string `query:"q" mod:"trim" validate:"required,max=200"`
+	Limit int    `query:"limit" validate:"omitempty,min=1,max=100"`
+}
+
+type SearchResultsDTO struct {
+	Data []SearchResultDTO `json:"data"`
+}
+
+// SearchResultDTO is a todo or a todo list matching a search.
+type SearchResultDTO struct {
+	// Type is "todo" or "todo_list".
+	Type string  `json:"type"`
+	Rank float64 `json:"rank"`
+	// Snippet is HTML: the text around the matches, escaped, with the matches wrapped in <mark>.
+	Snippet string       `json:"snippet"`
+	Todo    *models.Todo `json:"todo,omitempty"`
+	// TodoList is the list containing the todo, or the matching list itself. Its todos are not included.
+	TodoList models.TodoList `json:"todo_list"`
+}
+
+// ReminderDTO creates a reminder. Exactly one of RemindAt and OffsetMinutes must be set.
+type ReminderDTO struct {
+	// RemindAt is the RFC 3339 time the reminder fires at.
+	RemindAt *time.Time `json:"remind_at"`
+	// OffsetMinutes fires the reminder this many minutes before the due date of the todo, and follows it when it changes.
+	OffsetMinutes *int `json:"offset_minutes" validate:"omitempty,min=0,max=525600"`
+	// Channel defaults to in_app.
+	Channel string `json:"channel" validate:"omitempty,oneof=email webhook in_app"`
+}
+
+type NotificationQueryDTO struct {
+	Limit  int    `query:"limit" validate:"omitempty,min=1,max=100"`
+	Cursor string `query:"cursor"`
+	Unread string `query:"unread" validate:"omitempty,oneof=true false"`
+}
+
+// RecurrenceDTO makes a todo recurring, or changes how it recurs.
+type RecurrenceDTO struct {
+	// Rule is an RFC 5545 RRULE value such as FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR. DTSTART is the due date of the todo.
+	Rule string `json:"rule" mod:"trim" validate:"required,max=500"`
+	// Mode is fixed, the default, to follow the schedule of the rule, or after_completion to apply it from each completion.
+	Mode string `json:"mode" validate:"omitempty,oneof=fixed after_completion"`
+}
+
+// TodoScopeDTO tells which occurrences of a recurring todo an update applies to.
+type TodoScopeDTO struct {
+	// Scope is this, the default, for the current occurrence only or future for it and the next ones.
+	Scope string `query:"scope" validate:"omitempty,oneof=this future"`
+}
+
+type CompletionQueryDTO struct {
+	Limit  int    `query:"limit" validate:"omitempty,min=1,max=100"`
+	Cursor string `query:"cursor"`
+}
+
+type CommentDTO struct {
+	Body string `json:"body" mod:"trim" validate:"required,max=5000"`
+}
+
+type CommentQueryDTO struct {
+	Limit  int    `query:"limit" validate:"omitempty,min=1,max=100"`
+	Cursor string `query:"cursor"`
+}
+
+// TodoListTreeDTO is the todo lists of the user arranged in their folders. The lists outside of the folders, including
+// the lists shared with the user, are at the root.
+type TodoListTreeDTO struct {
+	Folders   []FolderNodeDTO   `json:"folders"`
+	TodoLists []models.TodoList `json:"todo_lists"`
+}
+
+// FolderNodeDTO is a folder of a TodoListTreeDTO with the folders nested in it and its todo lists.
+type FolderNodeDTO struct {
+	Folder    models.Folder     `json:"folder"`
+	Folders   []FolderNodeDTO   `json:"folders"`
+	TodoLists []models.TodoList `json:"todo_lists"`
+}
+
+// TodoDetailDTO is a todo with a page of its comments.
+type TodoDetailDTO struct {
+	Todo     models.Todo              `json:"todo"`
+	Comments *PageDTO[models.Comment] `json:"comments"`
+}
+
+type ChecklistItemDTO struct {
+	Text string `json:"text" mod:"trim" validate:"required,max=500"`
+	Done bool   `json:"done"`
+}
+
+// ChecklistOrderDTO lists the IDs of all the checklist items of a todo in their new order.
+type ChecklistOrderDTO struct {
+	IDs []int `json:"ids" validate:"required,max=1000,dive,min=1"`
+}
+
+// ReorderDTO places a todo list or a todo right before or right after another one. Exactly one of the IDs is set.
+type ReorderDTO struct {
+	BeforeID int `json:"before_id" validate:"omitempty,min=1"`
+	AfterID  int `json:"after_id" validate:"omitempty,min=1"`
+}
+
+type TagDTO struct {
+	Name string `json:"name" mod:"trim" validate:"required,max=50"`
+	// ColorID is optional. Leaving it out removes the color.
+	ColorID *int `json:"color_id"`
+}
+
+type StatusDTO struct {
+	Name string `json:"name" mod:"trim" validate:"required,max=50"`
+	// Terminal statuses complete the todos reaching them.
+	Terminal bool `json:"terminal"`
+}
+
+// StatusOrderDTO lists the IDs of all the statuses of a todo list in their new order.
+type StatusOrderDTO struct {
+	IDs []int `json:"ids" validate:"required,max=100,dive,min=1"`
+}
+
+// BoardDTO is a todo list with its todos grouped by status.
+type BoardDTO struct {
+	Columns []BoardColumnDTO `json:"columns"`
+}
+
+// BoardColumnDTO is a status of a board and its todos, highest priority first.
+type BoardColumnDTO struct {
+	Status models.Status `json:"status"`
+	Todos  []models.Todo `json:"todos"`
+}
+
+// MemberDTO is a user with access to a todo list: its owner or one of its members. It is also a member of an organization.
+type MemberDTO struct {
+	UserID int    `json:"user_id"`
+	Email  string `json:"email"`
+	// Role is owner, admin, editor or viewer on a todo list, and member or admin in an organization.
+	Role string `json:"role"`
+}
+
+// MemberRoleDTO gives a member of a todo list another role.
+type MemberRoleDTO struct {
+	Role string `json:"role" validate:"required,oneof=viewer editor admin"`
+}
+
+// InvitationDTO invites whoever has an email address to a todo list.
+type InvitationDTO struct {
+	Email string `json:"email" mod:"trim" validate:"required,email,max=254"`
+	Role  string `json:"role" validate:"required,oneof=viewer editor admin"`
+}
+
+// FolderDTO creates, renames or moves a folder.
+type FolderDTO struct {
+	Name string `json:"name" mod:"trim" validate:"required,max=100"`
+	// ParentID nests the folder in another folder, or puts it at the root if it is 0.
+	ParentID int `json:"parent_id" validate:"min=0"`
+}
+
+// FolderDeleteDTO chooses what happens to the content of a deleted folder.
+type FolderDeleteDTO struct {
+	// Cascade deletes the folders nested in the folder and the todo lists of all of them, instead of moving them to the root.
+	Cascade string `query:"cascade" validate:"omitempty,oneof=true false"`
+}
+
+// OrganizationDTO creates or renames an organization.
+type OrganizationDTO struct {
+	Name string `json:"name" mod:"trim" validate:"required,max=100"`
+}
+
+// OrgMemberDTO adds the user with an email address to an organization.
+type OrgMemberDTO struct {
+	Email string `json:"email" mod:"trim" validate:"required,email,max=254"`
+	Role  string `json:"role" validate:"required,oneof=member admin"`
+}
+
+// OrgMemberRoleDTO gives a member of an organization another role.
+type OrgMemberRoleDTO struct {
+	Role string `json:"role" validate:"required,oneof=member admin"`
+}
+
+// ShareLinkDTO creates a share link.
+type ShareLinkDTO struct {
+	// ExpiresAt is the RFC 3339 time the link stops working. Links without one never expire.
+	ExpiresAt *time.Time `json:"expires_at"`
+	// Password, when set, must be sent by the visitors of the link.
+	Password string `json:"password" validate:"omitempty,max=18"`
+}
+
+// SharedTodoListDTO is a todo list seen through a share link. It tells nothing about the owner of the list.
+type SharedTodoListDTO struct {
+	Name  string          `json:"name"`
+	Color string          `json:"color"`
+	Todos []SharedTodoDTO `json:"todos"`
+}
+
+// SharedTodoDTO is a todo of a todo list seen through a share link.
+type SharedTodoDTO struct {
+	Text      string `json:"text"`
+	Completed bool   `json:"completed"`
+	// Status is the name of the status of the todo.
+	Status      string     `json:"status"`
+	Priority    int        `json:"priority"`
+	Notes       string     `json:"notes,omitempty"`
+	NotesHTML   string     `json:"notes_html,omitempty"`
+	DueAt       *time.Time `json:"due_at"`
+	AllDay      bool       `json:"all_day"`
+	CompletedAt *time.Time `json:"completed_at"`
+	ItemsTotal  int        `json:"items_total"`
+	ItemsDone   int        `json:"items_done"`
+}
+
+type ExportQueryDTO struct {
+	// Format is json, the default, or csv.
+	Format string `query:"format" validate:"omitempty,oneof=json csv"`
+}
+
+// ExportedTodoListDTO is a todo list exported with all of its todos.
+type ExportedTodoListDTO struct {
+	Name  string            `json:"name"`
+	Color string            `json:"color"`
+	Todos []ExportedTodoDTO `json:"todos"`
+}
+
+// ExportedTodoDTO is a todo of an exported todo list.
+type ExportedTodoDTO struct {
 	Text      string `json:"text"`
 	Completed bool   `json:"completed"`
+	// Status is the name of the status of the todo.
+	Status   string `json:"status"`
+	Priority int    `json:"priority"`
+	// Tags are the names of the tags of the todo.
+	Tags []string `json:"tags"`
+	// Notes is the Markdown source of the notes.
+	Notes       string     `json:"notes"`
+	DueAt       *time.Time `json:"due_at"`
+	AllDay      bool       `json:"all_day"`
+	CompletedAt *time.Time `json:"completed_at"`
+	ItemsTotal  int        `json:"items_total"`
+	ItemsDone   int        `json:"items_done"`
 }
@@ -0,0 +1,137 @@
+// Package mailer sends the plain text emails of the application.
+package mailer
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/smtp"
+	"os"
+	"strings"
+	"time"
+)
+
+// Mail is a plain text email to a single recipient.
+type Mail struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+type Mailer interface {
+	Send(ctx context.Context, mail Mail) error
+}
+
+// SMTP sends mails through an SMTP server, authenticating with PLAIN when Username is set.
+type SMTP struct {
+	Addr     string
+	Username string
+	Password string
+	From     string
+}
+
+// Send delivers mail in one SMTP session, upgraded with STARTTLS when the server offers it.
+// The session ends as soon as ctx does, so that a hung server can't hold the caller.
+func (m *SMTP) Send(ctx context.Context, mail Mail) error {
+	host, _, err := net.SplitHostPort(m.Addr)
+	if err != nil {
+		return fmt.Errorf("mailer: %w", err)
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", m.Addr)
+	if err != nil {
+		return fmt.Errorf("mailer: %w", err)
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			return fmt.Errorf("mailer: %w", err)
+		}
+	}
+	// A cancellation has no deadline, so closing the connection is what interrupts the session.
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	if err := m.send(conn, host, mail); err != nil {
+		// The connection may reach the deadline a moment before ctx notices it.
+		if errors.Is(err, os.ErrDeadlineExceeded) {
+			return fmt.Errorf("mailer: %w", context.DeadlineExceeded)
+		}
+		if ctx.Err() != nil {
+			return fmt.Errorf("mailer: %w", ctx.Err())
+		}
+		return fmt.Errorf("mailer: %w", err)
+	}
+	return nil
+}
+
+// send runs the SMTP session on conn, the way smtp.SendMail does.
+func (m *SMTP) send(conn net.Conn, host string, mail Mail) error {
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+
+	if m.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", m.Username, m.Password, host)); err != nil {
+			return err
+		}
+	}
+
+	if err := c.Mail(m.From); err != nil {
+		return err
+	}
+	if err := c.Rcpt(mail.To); err != nil {
+		return err
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(m.message(mail)); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	return c.Quit()
+}
+
+func (m *SMTP) message(mail Mail) []byte {
+	// Header values can't span lines, or they could inject headers of their own.
+	header := strings.NewReplacer("\r", " ", "\n", " ")
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", header.Replace(m.From))
+	fmt.Fprintf(&b, "To: %s\r\n", header.Replace(mail.To))
+	fmt.Fprintf(&b, "Subject: %s\r\n", header.Replace(mail.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(mail.Body, "\n", "\r\n"))
+	return []byte(b.String())
+}
+
+// Log writes mails to a logger instead of sending them, for development.
+type Log struct {
+	Logger *slog.Logger
+}
+
+func (m *Log) Send(ctx context.Context, mail Mail) error {
+	m.Logger.InfoContext(ctx, "mail not sent, no SMTP server is configured", "to", mail.To, "subject", mail.Subject, "body", mail.Body)
+	return nil
+}
@@ -0,0 +1,121 @@
+package mailer
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// serveSMTP accepts connections on a new local listener and hands each one to session.
+func serveSMTP(t *testing.T, session func(conn net.Conn)) string {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				session(conn)
+			}()
+		}
+	}()
+
+	return l.Addr().String()
+}
+
+func TestSMTPSend(t *testing.T) {
+	t.Parallel()
+
+	data := make(chan string, 1)
+	addr := serveSMTP(t, func(conn net.Conn) {
+		r := bufio.NewReader(conn)
+		reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+
+		reply("220 localhost ready")
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+
+			switch cmd := strings.ToUpper(strings.Fields(line)[0]); cmd {
+			case "EHLO", "HELO", "MAIL", "RCPT":
+				reply("250 OK")
+			case "DATA":
+				reply("354 go ahead")
+
+				var b strings.Builder
+				for {
+					line, err := r.ReadString('\n')
+					if err != nil || line == ".\r\n" {
+						break
+					}
+					b.WriteString(line)
+				}
+				data <- b.String()
+				reply("250 queued")
+			case "QUIT":
+				reply("221 bye")
+				return
+			default:
+				reply("502 unknown command " + cmd)
+			}
+		}
+	})
+
+	m := &SMTP{Addr: addr, From: "todo@example.com"}
+	err := m.Send(context.Background(), Mail{To: "a@b.com", Subject: "Hi\r\nBcc: c@b.com", Body: "Line 1\nLine 2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := <-data
+	for _, want := range []string{"To: a@b.com\r\n", "Subject: Hi  Bcc: c@b.com\r\n", "\r\n\r\nLine 1\r\nLine 2"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("message %q does not contain %q", got, want)
+		}
+	}
+}
+
+func TestSMTPSendHungServer(t *testing.T) {
+	t.Parallel()
+
+	// The server accepts the connection but never greets the client.
+	addr := serveSMTP(t, func(conn net.Conn) { conn.Read(make([]byte, 1)) })
+	m := &SMTP{Addr: addr, From: "todo@example.com"}
+
+	t.Run("deadline", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		if err := m.Send(ctx, Mail{To: "a@b.com"}); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Send = %v, want %v", err, context.DeadlineExceeded)
+		}
+	})
+
+	t.Run("cancellation", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(50*time.Millisecond, cancel)
+
+		if err := m.Send(ctx, Mail{To: "a@b.com"}); !errors.Is(err, context.Canceled) {
+			t.Errorf("Send = %v, want %v", err, context.Canceled)
+		}
+	})
+}
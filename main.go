@@ -1,16 +1,25 @@
 package main
 
 import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	// Embed the time zone database so that user time zones resolve on hosts without one.
+	_ "time/tzdata"
+
 	"github.com/labstack/echo/v4"
+	"github.com/marouane-ach/todo-go/app"
+	"github.com/marouane-ach/todo-go/config"
 	"github.com/marouane-ach/todo-go/controllers"
-	"github.com/marouane-ach/todo-go/db"
 	_ "github.com/marouane-ach/todo-go/docs"
-	echoSwagger "github.com/swaggo/echo-swagger"
+	"github.com/marouane-ach/todo-go/scheduler"
 )
 
 // @title           Todo App Backend
 // @version         1.0
-// @description     Backend for a Todo App.
+// @description     Backend for a Todo App. Send the `X-Org-ID` header to work with the todo lists of an organization.
 // @host            localhost:1323
 // @BasePath        /
 
@@ -18,30 +27,31 @@ import (
 // @in                          header
 // @name                        Authorization
 func main() {
-	db.CreateDBTables()
-	db.SeedColorsTable()
-
-	e := echo.New()
-
-	e.GET("/swagger/*", echoSwagger.WrapHandler)
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal(err)
+	}
 
-	e.POST("/signup", controllers.Signup)
+	a, err := app.New(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
 
-	e.POST("/login", controllers.Login)
+	a.Go(scheduler.New(a).Run)
 
-	e.POST("/logout", controllers.Logout)
-
-	e.POST("/todolists", controllers.CreateTodoList)
-
-	e.GET("/todolists", controllers.GetUserTodoLists)
-
-	e.GET("/todolists/:id", controllers.GetTodoListByID)
+	e := echo.New()
+	controllers.New(a).RegisterRoutes(e)
 
-	e.DELETE("/todolists/:id", controllers.DeleteTodoList)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	e.POST("/todolists/:id/todos", controllers.CreateTodo)
+	err = a.Serve(ctx, e)
 
-	e.PUT("/todos/:id", controllers.UpdateTodo)
+	if closeErr := a.Close(); closeErr != nil {
+		a.Logger.Error("could not close the app", "error", closeErr)
+	}
 
-	e.Logger.Fatal(e.Start(":1323"))
+	if err != nil {
+		log.Fatal(err)
+	}
 }
@@ -0,0 +1,31 @@
+// Package markdown renders the Markdown notes of todos to HTML that is safe to embed in a page.
+package markdown
+
+import (
+	"bytes"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+)
+
+var (
+	// GitHub Flavored Markdown: tables, strikethrough, autolinks and task lists.
+	renderer = goldmark.New(goldmark.WithExtensions(extension.GFM))
+
+	// policy keeps the formatting a user may write and drops scripts, styles and event handlers.
+	// Links get rel="nofollow noopener" and open in a new tab.
+	policy = bluemonday.UGCPolicy().
+		AddTargetBlankToFullyQualifiedLinks(true).
+		AllowAttrs("type", "checked", "disabled").OnElements("input")
+)
+
+// HTML renders src and sanitizes the result. goldmark already leaves raw HTML out, the sanitizer
+// also covers what it lets through, such as javascript: links.
+func HTML(src string) (string, error) {
+	var b bytes.Buffer
+	if err := renderer.Convert([]byte(src), &b); err != nil {
+		return "", err
+	}
+	return policy.Sanitize(b.String()), nil
+}
@@ -18,6 +18,12 @@ type User struct {
 
 	Email          string `bun:",unique"`
 	HashedPassword string
+	// Timezone is the IANA name of the user's time zone, which tells when their days start.
+	Timezone string `bun:",nullzero,notnull,default:'UTC'"`
+	// WebhookURL receives the notifications sent through the webhook channel.
+	WebhookURL string `bun:",nullzero"`
+	// Org is the membership of the user in the organization their request is scoped to, if any.
+	Org *OrgMembership `bun:"-" json:"-"`
 }
 
 type Token struct {
@@ -37,6 +43,18 @@ type Color struct {
 	ColorHex string `bun:",unique,notnull"`
 }
 
+// Palette is the fixed set of colors a todo list can pick from.
+var Palette = []Color{
+	{ColorHex: "#FF6B6B", Name: "coral"},
+	{ColorHex: "#DA77F2", Name: "orchid"},
+	{ColorHex: "#9775FA", Name: "amethyst"},
+	{ColorHex: "#5C7CFA", Name: "cobalt"},
+	{ColorHex: "#66D9E8", Name: "aqua"},
+	{ColorHex: "#8CE99A", Name: "lime"},
+	{ColorHex: "#FFD43B", Name: "canary"},
+	{ColorHex: "#FF922B", Name: "tangerine"},
+}
+
 type TodoList struct {
 	MyBaseModel
 	bun.BaseModel `bun:"table:todo_lists"`
@@ -44,17 +62,300 @@ type TodoList struct {
 	Name    string `bun:",notnull"`
 	ColorID int    `bun:",notnull"`
 	Color   *Color `bun:"rel:belongs-to,join:color_id=id"`
-	OwnerID int    `bun:",notnull"`
-	Owner   *User  `bun:"rel:belongs-to,join:owner_id=id"`
-	Todos   []Todo `bun:"rel:has-many,join:id=todo_list_id"`
+	// OwnerID is the user who created the list. Lists with an OrganizationID belong to the organization instead.
+	OwnerID        int   `bun:",notnull"`
+	Owner          *User `bun:"rel:belongs-to,join:owner_id=id"`
+	OrganizationID int   `bun:",nullzero"`
+	// FolderID is the folder of the owner, or of the organization, the list is filed in. Lists without one are at the root.
+	FolderID int `bun:",nullzero"`
+	// Rank orders the todo lists of the owner, or of the organization. It is a key of the rank package.
+	Rank string `bun:",notnull"`
+	// RejectDuplicates keeps two todos of the list from having the same text, ignoring case.
+	RejectDuplicates bool   `bun:",notnull,default:false"`
+	Todos            []Todo `bun:"rel:has-many,join:id=todo_list_id"`
+	// Role is the role of the user the list is shown to, when they asked for it.
+	Role string `bun:"-" json:",omitempty"`
+}
+
+// Roles on a todo list, from the lowest. Viewers read the list, editors also change its todos, and admins
+// also manage the list, its statuses and its members. The owner of a list can do anything with it.
+const (
+	RoleViewer = "viewer"
+	RoleEditor = "editor"
+	RoleAdmin  = "admin"
+	RoleOwner  = "owner"
+)
+
+// Roles lists the roles from the lowest.
+var Roles = []string{RoleViewer, RoleEditor, RoleAdmin, RoleOwner}
+
+// Membership shares a todo list with a user other than its owner.
+type Membership struct {
+	MyBaseModel
+	bun.BaseModel `bun:"table:memberships"`
+
+	TodoListID int   `bun:",notnull"`
+	UserID     int   `bun:",notnull"`
+	User       *User `bun:"rel:belongs-to,join:user_id=id"`
+	// Role is any role but RoleOwner.
+	Role string `bun:",notnull"`
+}
+
+// Folder groups todo lists, and other folders when they are nested in it. Like todo lists, folders belong to a user,
+// or to an organization when OrganizationID is set.
+type Folder struct {
+	MyBaseModel
+	bun.BaseModel `bun:"table:folders"`
+
+	Name string `bun:",notnull"`
+	// ParentID is the folder this one is nested in. Folders without one are at the root.
+	ParentID       int `bun:",nullzero"`
+	OwnerID        int `bun:",notnull"`
+	OrganizationID int `bun:",nullzero"`
+}
+
+// Roles in an organization. Members can use the todo lists of the organization, and admins also manage it and its members.
+const (
+	OrgRoleMember = "member"
+	OrgRoleAdmin  = "admin"
+)
+
+// Organization groups users and the todo lists they share. MaxMembers and MaxTodoLists are its quotas, unlimited when zero.
+type Organization struct {
+	MyBaseModel
+	bun.BaseModel `bun:"table:organizations"`
+
+	Name         string `bun:",notnull"`
+	MaxMembers   int    `bun:",notnull,default:0"`
+	MaxTodoLists int    `bun:",notnull,default:0"`
+	// Role is the role of the user the organization is shown to.
+	Role string `bun:"-" json:",omitempty"`
 }
 
+// OrgMembership makes a user a member of an organization.
+type OrgMembership struct {
+	MyBaseModel
+	bun.BaseModel `bun:"table:org_memberships"`
+
+	OrganizationID int           `bun:",notnull"`
+	Organization   *Organization `bun:"rel:belongs-to,join:organization_id=id"`
+	UserID         int           `bun:",notnull"`
+	User           *User         `bun:"rel:belongs-to,join:user_id=id"`
+	// Role is one of the roles in an organization.
+	Role string `bun:",notnull"`
+}
+
+// Invitation offers a role on a todo list to whoever signs up, or already did, with Email.
+type Invitation struct {
+	MyBaseModel
+	bun.BaseModel `bun:"table:invitations"`
+
+	TodoListID int       `bun:",notnull"`
+	TodoList   *TodoList `bun:"rel:belongs-to,join:todo_list_id=id"`
+	InviterID  int       `bun:",notnull"`
+	Email      string    `bun:",notnull"`
+	// Role is any role but RoleOwner.
+	Role string `bun:",notnull"`
+}
+
+// ShareLink shows a todo list, read-only, to anyone who has its Slug, without logging in.
+type ShareLink struct {
+	MyBaseModel
+	bun.BaseModel `bun:"table:share_links"`
+
+	TodoListID int       `bun:",notnull"`
+	TodoList   *TodoList `bun:"rel:belongs-to,join:todo_list_id=id"`
+	Slug       string    `bun:",unique,notnull"`
+	// HashedPassword is empty when visitors need no password. HasPassword tells whether they do.
+	HashedPassword string `bun:",nullzero" json:"-"`
+	HasPassword    bool   `bun:"-"`
+	// ExpiresAt is nil for links that never expire.
+	ExpiresAt *time.Time
+	// Views counts the visits to the link, the last one at LastAccessedAt.
+	Views          int `bun:",notnull,default:0"`
+	LastAccessedAt *time.Time
+}
+
+// Priorities of todos, from the lowest.
+const (
+	PriorityNone = iota
+	PriorityLow
+	PriorityMedium
+	PriorityHigh
+)
+
 type Todo struct {
 	MyBaseModel
 	bun.BaseModel `bun:"table:todos"`
 
-	Text       string    `bun:",unique"`
-	Completed  bool      `bun:"default:false"`
+	Text string
+	// Completed tells whether the status of the todo is a terminal one.
+	Completed bool `bun:"default:false"`
+	// StatusID is the status of the todo among those of its todo list.
+	StatusID int `bun:",nullzero"`
+	// Priority is one of the priorities above.
+	Priority int `bun:",notnull,default:0"`
+	// AssigneeID is the user the todo is assigned to, the owner or a member of its todo list, if any.
+	AssigneeID int `bun:",nullzero"`
+	// Rank orders the todos of the todo list. It is a key of the rank package.
+	Rank string `bun:",notnull"`
+	// Notes are Markdown. NotesHTML is only set, to the sanitized HTML of the notes, when a request asks for it.
+	Notes     string `bun:",nullzero"`
+	NotesHTML string `bun:"-" json:",omitempty"`
+	// DueAt is when the todo is due, in UTC, or nil if it has no due date.
+	// The todos due on a whole day (AllDay) are due at midnight UTC of that day.
+	DueAt       *time.Time
+	AllDay      bool `bun:",notnull,default:false"`
+	CompletedAt *time.Time
+	// AutoComplete completes the todo once all its checklist items are done.
+	AutoComplete bool `bun:",notnull,default:false"`
+	// ItemsDone of the ItemsTotal checklist items of the todo are done. The store keeps them up to date.
+	ItemsTotal int         `bun:",notnull,default:0"`
+	ItemsDone  int         `bun:",notnull,default:0"`
+	TodoListID int         `bun:",notnull"`
+	TodoList   *TodoList   `bun:"rel:belongs-to,join:todo_list_id=id"`
+	Recurrence *Recurrence `bun:"rel:has-one,join:id=todo_id"`
+	Tags       []Tag       `bun:"m2m:todo_tags,join:Todo=Tag"`
+}
+
+// Status is a column of the board of a todo list. The statuses of a list are ordered by Position,
+// and reaching a Terminal one completes a todo.
+type Status struct {
+	MyBaseModel
+	bun.BaseModel `bun:"table:statuses"`
+
 	TodoListID int       `bun:",notnull"`
 	TodoList   *TodoList `bun:"rel:belongs-to,join:todo_list_id=id"`
+	Name       string    `bun:",notnull"`
+	Position   int       `bun:",notnull"`
+	Terminal   bool      `bun:",notnull,default:false"`
+}
+
+// DefaultStatuses are the statuses todo lists start with.
+var DefaultStatuses = []Status{
+	{Name: "To do"},
+	{Name: "In progress"},
+	{Name: "Blocked"},
+	{Name: "Done", Terminal: true},
+}
+
+// Tag labels todos of any todo list of its owner. Its name is unique per owner, ignoring case.
+type Tag struct {
+	MyBaseModel
+	bun.BaseModel `bun:"table:tags"`
+
+	Name    string `bun:",notnull"`
+	ColorID *int
+	Color   *Color `bun:"rel:belongs-to,join:color_id=id"`
+	OwnerID int    `bun:",notnull"`
+	// TodoCount is the number of todos with the tag, when the tag is loaded by itself.
+	TodoCount int `bun:",scanonly"`
+}
+
+// TodoTag links a todo to one of its tags.
+type TodoTag struct {
+	bun.BaseModel `bun:"table:todo_tags"`
+
+	TodoID int   `bun:",pk"`
+	Todo   *Todo `bun:"rel:belongs-to,join:todo_id=id"`
+	TagID  int   `bun:",pk"`
+	Tag    *Tag  `bun:"rel:belongs-to,join:tag_id=id"`
+}
+
+// ChecklistItem is a step of a todo. The items of a todo are ordered by Position.
+type ChecklistItem struct {
+	MyBaseModel
+	bun.BaseModel `bun:"table:checklist_items"`
+
+	TodoID   int    `bun:",notnull"`
+	Todo     *Todo  `bun:"rel:belongs-to,join:todo_id=id"`
+	Text     string `bun:",notnull"`
+	Done     bool   `bun:",notnull,default:false"`
+	Position int    `bun:",notnull"`
+}
+
+// Recurrence makes a todo come back once completed, with its next due date.
+type Recurrence struct {
+	MyBaseModel
+	bun.BaseModel `bun:"table:todo_recurrences"`
+
+	TodoID int `bun:",notnull,unique"`
+	// Rule is an RFC 5545 RRULE value such as FREQ=MONTHLY;BYDAY=1MO.
+	Rule string `bun:",notnull"`
+	// Mode is one of the modes of the recurrence package.
+	Mode string `bun:",notnull"`
+	// Start is the due date the schedule is expanded from and Current the scheduled due date of the
+	// current occurrence, which may differ from the due date of the todo if only this occurrence was moved.
+	Start   *time.Time
+	Current *time.Time
+	// Text is the text of the next occurrences, which editing only the current one doesn't change.
+	Text string `bun:",notnull"`
+}
+
+// Completion records that a todo was completed. Recurring todos keep one per completed occurrence.
+type Completion struct {
+	MyBaseModel
+	bun.BaseModel `bun:"table:todo_completions"`
+
+	TodoID      int `bun:",notnull"`
+	DueAt       *time.Time
+	CompletedAt time.Time `bun:",notnull"`
+}
+
+// Comment is a message about a todo by a user with access to its todo list.
+type Comment struct {
+	MyBaseModel
+	bun.BaseModel `bun:"table:comments"`
+
+	TodoID   int   `bun:",notnull"`
+	Todo     *Todo `bun:"rel:belongs-to,join:todo_id=id"`
+	AuthorID int   `bun:",notnull"`
+	// Body is plain text. It mentions users with @ followed by their email address.
+	Body string `bun:",notnull"`
+}
+
+// The states of a reminder. Pending reminders fire once FireAt is reached.
+const (
+	ReminderPending   = "pending"
+	ReminderSent      = "sent"
+	ReminderFailed    = "failed"
+	ReminderCancelled = "cancelled"
+)
+
+type Reminder struct {
+	MyBaseModel
+	bun.BaseModel `bun:"table:reminders"`
+
+	TodoID int   `bun:",notnull"`
+	Todo   *Todo `bun:"rel:belongs-to,join:todo_id=id"`
+	// UserID is the user the reminder is delivered to: the one who created it.
+	UserID int `bun:",notnull"`
+	// Exactly one of RemindAt and OffsetMinutes is set. OffsetMinutes is how long before
+	// the due date of the todo the reminder fires.
+	RemindAt      *time.Time
+	OffsetMinutes *int
+	// Channel names the notifier the reminder is delivered through.
+	Channel string `bun:",notnull"`
+	// FireAt is when the reminder fires next. It is nil while the todo of a relative reminder has no due date.
+	FireAt    *time.Time
+	Status    string `bun:",notnull,default:'pending'"`
+	Attempts  int    `bun:",notnull,default:0"`
+	LastError string `bun:",nullzero"`
+	SentAt    *time.Time
+	// ClaimToken identifies the scheduler delivering the reminder, until ClaimedUntil.
+	ClaimToken   string     `bun:",nullzero" json:"-"`
+	ClaimedUntil *time.Time `json:"-"`
+}
+
+type Notification struct {
+	MyBaseModel
+	bun.BaseModel `bun:"table:notifications"`
+
+	UserID int `bun:",notnull"`
+	// Kind tells what the notification is about, e.g. "reminder".
+	Kind   string `bun:",notnull"`
+	Title  string `bun:",notnull"`
+	Body   string
+	TodoID *int
+	ReadAt *time.Time
 }
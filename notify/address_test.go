@@ -0,0 +1,88 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPublicAddress(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		ip     string
+		public bool
+	}{
+		{"93.184.216.34", true},
+		{"2606:2800:220:1:248:1893:25c8:1946", true},
+		{"127.0.0.1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"100.64.0.1", false},
+		{"0.0.0.0", false},
+		{"255.255.255.255", false},
+		{"224.0.0.1", false},
+		{"::1", false},
+		{"::", false},
+		{"fd00::1", false},
+		{"fe80::1", false},
+		{"::ffff:127.0.0.1", false},
+		{"::ffff:10.0.0.1", false},
+		{"64:ff9b::a9fe:a9fe", false},
+	}
+
+	for _, tt := range tests {
+		if got := PublicAddress(netip.MustParseAddr(tt.ip)); got != tt.public {
+			t.Errorf("PublicAddress(%s) = %v, want %v", tt.ip, got, tt.public)
+		}
+	}
+}
+
+func TestCheckWebhookURL(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		url     string
+		private bool
+	}{
+		{"https://93.184.216.34/hook", false},
+		{"http://127.0.0.1:8080/hook", true},
+		{"http://169.254.169.254/latest/meta-data", true},
+		{"http://[::1]/hook", true},
+		{"http://[fd12:3456::1]/hook", true},
+	}
+
+	for _, tt := range tests {
+		err := CheckWebhookURL(context.Background(), tt.url)
+		if got := errors.Is(err, ErrPrivateAddress); got != tt.private {
+			t.Errorf("CheckWebhookURL(%s) = %v, want private %v", tt.url, err, tt.private)
+		}
+	}
+}
+
+func TestWebhookClientRefusesPrivateAddresses(t *testing.T) {
+	t.Parallel()
+
+	var called atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { called.Store(true) }))
+	defer server.Close()
+
+	res, err := NewWebhookClient(time.Second).Get(server.URL)
+	if err == nil {
+		res.Body.Close()
+	}
+
+	if !errors.Is(err, ErrPrivateAddress) {
+		t.Errorf("Get(%s) = %v, want ErrPrivateAddress", server.URL, err)
+	}
+	if called.Load() {
+		t.Error("the server was reached")
+	}
+}
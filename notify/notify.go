@@ -0,0 +1,120 @@
+// Package notify delivers notifications to users through pluggable channels.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/marouane-ach/todo-go/mailer"
+	"github.com/marouane-ach/todo-go/models"
+	"github.com/marouane-ach/todo-go/store"
+)
+
+// The channels a notification can be delivered through.
+const (
+	ChannelEmail   = "email"
+	ChannelWebhook = "webhook"
+	ChannelInApp   = "in_app"
+)
+
+// Message is a notification for one user.
+type Message struct {
+	User *models.User
+	// Kind tells what the message is about, e.g. "reminder".
+	Kind   string
+	Title  string
+	Body   string
+	TodoID int
+}
+
+// Notifier delivers messages through one channel. A nil error means the message was delivered.
+type Notifier interface {
+	Notify(ctx context.Context, msg Message) error
+}
+
+// Email sends messages to the email address of the user.
+type Email struct {
+	Mailer mailer.Mailer
+}
+
+func (n *Email) Notify(ctx context.Context, msg Message) error {
+	return n.Mailer.Send(ctx, mailer.Mail{To: msg.User.Email, Subject: msg.Title, Body: msg.Body})
+}
+
+// InApp stores messages as notifications listed by GET /notifications.
+type InApp struct {
+	Store *store.Store
+}
+
+func (n *InApp) Notify(ctx context.Context, msg Message) error {
+	notification := &models.Notification{UserID: msg.User.ID, Kind: msg.Kind, Title: msg.Title, Body: msg.Body}
+	if msg.TodoID != 0 {
+		notification.TodoID = &msg.TodoID
+	}
+	return n.Store.Notifications.Create(ctx, notification)
+}
+
+// Webhook posts messages as JSON to the webhook URL of the user. When Secret is set, the body is
+// signed with HMAC-SHA256 in the X-Todo-Signature header as "sha256=<hex>".
+type Webhook struct {
+	// Client defaults to a client from NewWebhookClient. Other clients must keep refusing private addresses.
+	Client *http.Client
+	Secret string
+}
+
+var defaultWebhookClient = NewWebhookClient(10 * time.Second)
+
+// WebhookPayload is the body of the requests sent by Webhook.
+type WebhookPayload struct {
+	Kind   string    `json:"kind"`
+	Title  string    `json:"title"`
+	Body   string    `json:"body"`
+	TodoID int       `json:"todo_id,omitempty"`
+	SentAt time.Time `json:"sent_at"`
+}
+
+func (n *Webhook) Notify(ctx context.Context, msg Message) error {
+	if msg.User.WebhookURL == "" {
+		return fmt.Errorf("notify: user %d has no webhook URL", msg.User.ID)
+	}
+
+	body, err := json.Marshal(WebhookPayload{Kind: msg.Kind, Title: msg.Title, Body: msg.Body, TodoID: msg.TodoID, SentAt: time.Now().UTC()})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, msg.User.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	if n.Secret != "" {
+		mac := hmac.New(sha256.New, []byte(n.Secret))
+		mac.Write(body)
+		req.Header.Set("X-Todo-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	client := n.Client
+	if client == nil {
+		client = defaultWebhookClient
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("notify: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("notify: webhook responded with %s", res.Status)
+	}
+	return nil
+}
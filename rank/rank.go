@@ -0,0 +1,72 @@
+// Package rank generates the keys that order todo lists and todos. Keys are compared as byte
+// strings, and there is always room for a new key between two others, so that moving an item
+// only changes its own key.
+package rank
+
+import "strings"
+
+// digits are the digits of keys in increasing order. Keys never end with the first one,
+// which is what leaves room before every key.
+const digits = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// Between returns a key that sorts after a and before b. An empty a stands for the start
+// and an empty b for the end, so Between("", "") is the first key of an empty list.
+// a must sort before b and both must have been returned by Between.
+func Between(a, b string) string {
+	// Items mostly go at either end, so keys there move by one digit rather than halving the room left.
+	if a != "" && b == "" {
+		if i := strings.IndexByte(digits, a[0]); i+1 < len(digits) {
+			return string(digits[i+1])
+		}
+	} else if a == "" && b != "" {
+		if i := strings.IndexByte(digits, b[0]); i > 1 {
+			return string(digits[i-1])
+		}
+	}
+
+	if b != "" {
+		// Keep the prefix the keys share, a being padded with the first digit.
+		n := 0
+		for n < len(b) && digitAt(a, n) == b[n] {
+			n++
+		}
+		if n > 0 {
+			return b[:n] + Between(tail(a, n), b[n:])
+		}
+	}
+
+	lo, hi := 0, len(digits)
+	if a != "" {
+		lo = strings.IndexByte(digits, a[0])
+	}
+	if b != "" {
+		hi = strings.IndexByte(digits, b[0])
+	}
+
+	if hi-lo > 1 {
+		return string(digits[(lo+hi+1)/2])
+	}
+
+	// The first digits are consecutive. A longer b starts with a shorter key after a,
+	// and otherwise the key goes after the rest of a.
+	if len(b) > 1 {
+		return b[:1]
+	}
+	return string(digits[lo]) + Between(tail(a, 1), "")
+}
+
+// digitAt returns the nth digit of key, which is the first digit past its end.
+func digitAt(key string, n int) byte {
+	if n < len(key) {
+		return key[n]
+	}
+	return digits[0]
+}
+
+// tail returns key without its first n digits.
+func tail(key string, n int) string {
+	if n < len(key) {
+		return key[n:]
+	}
+	return ""
+}
@@ -0,0 +1,114 @@
+package rank
+
+import (
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestBetween(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		a, b string
+		want string
+	}{
+		{"", "", "V"},
+		{"V", "", "W"},
+		{"", "V", "U"},
+		{"z", "", "zV"},
+		{"", "1", "0V"},
+		{"A", "C", "B"},
+		{"A", "B", "AV"},
+		{"A", "B1", "B"},
+		{"AV", "B", "AW"},
+		{"V", "V1", "V0V"},
+		{"V", "V01", "V00V"},
+		{"Az", "B", "AzV"},
+	}
+
+	for _, tt := range tests {
+		if got := Between(tt.a, tt.b); got != tt.want {
+			t.Errorf("Between(%q, %q) = %q, want %q", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+// TestBetweenOrder checks that Between returns a valid key strictly between any two valid keys.
+func TestBetweenOrder(t *testing.T) {
+	t.Parallel()
+
+	r := rand.New(rand.NewPCG(1, 2))
+	for range 100_000 {
+		a, b := randomKey(r), randomKey(r)
+		if a == b {
+			continue
+		}
+		if a > b {
+			a, b = b, a
+		}
+
+		for _, bounds := range [][2]string{{a, b}, {a, ""}, {"", b}} {
+			got := Between(bounds[0], bounds[1])
+			if !valid(got) || got <= bounds[0] || bounds[1] != "" && got >= bounds[1] {
+				t.Fatalf("Between(%q, %q) = %q", bounds[0], bounds[1], got)
+			}
+		}
+	}
+}
+
+// TestBetweenRepeated inserts keys at random places of a list, which must stay in order.
+func TestBetweenRepeated(t *testing.T) {
+	t.Parallel()
+
+	r := rand.New(rand.NewPCG(3, 4))
+	keys := []string{}
+	for range 2_000 {
+		i := r.IntN(len(keys) + 1)
+
+		a, b := "", ""
+		if i > 0 {
+			a = keys[i-1]
+		}
+		if i < len(keys) {
+			b = keys[i]
+		}
+
+		keys = slices.Insert(keys, i, Between(a, b))
+	}
+
+	if !slices.IsSorted(keys) {
+		t.Error("keys are out of order")
+	}
+	if len(slices.Compact(slices.Clone(keys))) != len(keys) {
+		t.Error("keys are not unique")
+	}
+	for _, key := range keys {
+		if !valid(key) {
+			t.Fatalf("invalid key %q", key)
+		}
+	}
+}
+
+// randomKey returns a key of up to 6 digits that doesn't end with the first one, as Between keys are.
+func randomKey(r *rand.Rand) string {
+	var sb strings.Builder
+	for range r.IntN(6) {
+		sb.WriteByte(digits[r.IntN(len(digits))])
+	}
+	sb.WriteByte(digits[1+r.IntN(len(digits)-1)])
+	return sb.String()
+}
+
+func valid(key string) bool {
+	if key == "" || key[len(key)-1] == digits[0] {
+		return false
+	}
+	for i := range len(key) {
+		if strings.IndexByte(digits, key[i]) < 0 {
+			return false
+		}
+	}
+	return true
+}
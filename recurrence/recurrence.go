@@ -0,0 +1,78 @@
+// Package recurrence computes the occurrences of recurring todos from RFC 5545 recurrence rules.
+package recurrence
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/teambition/rrule-go"
+)
+
+// The ways a recurring todo comes back once completed.
+const (
+	// ModeFixed follows the schedule of the rule whenever the todo is completed.
+	ModeFixed = "fixed"
+	// ModeAfterCompletion applies the rule from the time the todo was completed,
+	// e.g. FREQ=DAILY;INTERVAL=3 for three days after each completion.
+	ModeAfterCompletion = "after_completion"
+)
+
+// Parse checks that rule is an RRULE value, such as FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR,
+// a todo can recur by, and returns it without the optional RRULE: prefix.
+// Todos recur daily at most often; DTSTART comes from the todo.
+func Parse(rule string) (string, error) {
+	rule = strings.TrimPrefix(strings.TrimSpace(rule), "RRULE:")
+	if strings.ContainsAny(rule, "\r\n") {
+		return "", errors.New("must be a single RRULE value, without DTSTART")
+	}
+
+	opt, err := rrule.StrToROption(rule)
+	if err != nil {
+		return "", errors.New("is not a valid RRULE: " + err.Error())
+	}
+
+	if opt.Freq > rrule.DAILY {
+		return "", errors.New("must not repeat more often than daily")
+	}
+
+	return rule, nil
+}
+
+// Next returns the first occurrence of rule after after, for the series starting at start.
+// The rule is expanded in loc so that occurrences keep their local time of day across
+// daylight saving time changes. ok is false once the series has ended.
+func Next(rule string, start, after time.Time, loc *time.Location) (next time.Time, ok bool, err error) {
+	opt, err := rrule.StrToROptionInLocation(rule, loc)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+
+	opt.Dtstart = start.In(loc)
+	r, err := rrule.NewRRule(*opt)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+
+	next = r.After(after.In(loc), false)
+	if next.IsZero() {
+		return time.Time{}, false, nil
+	}
+	return next.UTC(), true, nil
+}
+
+// StartAfterCompletion returns the time an after completion series restarts from when an occurrence
+// due at due (if not nil) is completed at completedAt: the day of the completion in loc, at the
+// time of day of the occurrence. All-day occurrences stay at midnight UTC, like their due dates.
+func StartAfterCompletion(completedAt time.Time, due *time.Time, allDay bool, loc *time.Location) time.Time {
+	y, m, d := completedAt.In(loc).Date()
+	switch {
+	case allDay:
+		return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	case due != nil:
+		local := due.In(loc)
+		return time.Date(y, m, d, local.Hour(), local.Minute(), local.Second(), 0, loc).UTC()
+	default:
+		return completedAt.UTC()
+	}
+}
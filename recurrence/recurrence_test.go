@@ -0,0 +1,219 @@
+package recurrence
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		rule    string
+		want    string
+		invalid bool
+	}{
+		{rule: "FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR", want: "FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR"},
+		{rule: " RRULE:FREQ=DAILY;INTERVAL=3 ", want: "FREQ=DAILY;INTERVAL=3"},
+		{rule: "FREQ=MONTHLY;BYMONTHDAY=-1;COUNT=12", want: "FREQ=MONTHLY;BYMONTHDAY=-1;COUNT=12"},
+		{rule: "FREQ=HOURLY", invalid: true},
+		{rule: "FREQ=MINUTELY;INTERVAL=5", invalid: true},
+		{rule: "FREQ=FORTNIGHTLY", invalid: true},
+		{rule: "BYDAY=MO", invalid: true},
+		{rule: "DTSTART:20260101T090000Z\nRRULE:FREQ=DAILY", invalid: true},
+		{rule: "", invalid: true},
+	}
+
+	for _, tt := range tests {
+		got, err := Parse(tt.rule)
+		if tt.invalid {
+			if err == nil {
+				t.Errorf("Parse(%q) = %q, want an error", tt.rule, got)
+			}
+			continue
+		}
+
+		if err != nil || got != tt.want {
+			t.Errorf("Parse(%q) = %q, %v, want %q", tt.rule, got, err, tt.want)
+		}
+	}
+}
+
+func TestNext(t *testing.T) {
+	t.Parallel()
+
+	paris := mustLoad(t, "Europe/Paris")
+	losAngeles := mustLoad(t, "America/Los_Angeles")
+
+	tests := []struct {
+		name  string
+		rule  string
+		start string
+		after string
+		loc   *time.Location
+		want  string
+	}{
+		{
+			name:  "daily",
+			rule:  "FREQ=DAILY",
+			start: "2026-01-10T08:00:00Z", after: "2026-01-10T08:00:00Z",
+			loc: time.UTC, want: "2026-01-11T08:00:00Z",
+		},
+		{
+			name:  "daily across the start of summer time",
+			rule:  "FREQ=DAILY",
+			start: "2026-03-28T08:00:00Z", after: "2026-03-28T08:00:00Z",
+			loc: paris, want: "2026-03-29T07:00:00Z",
+		},
+		{
+			name:  "daily across the end of summer time",
+			rule:  "FREQ=DAILY",
+			start: "2026-10-24T07:00:00Z", after: "2026-10-24T07:00:00Z",
+			loc: paris, want: "2026-10-25T08:00:00Z",
+		},
+		{
+			name:  "weekday of the local date rather than the UTC one",
+			rule:  "FREQ=WEEKLY;BYDAY=MO",
+			start: "2026-01-05T07:00:00Z", after: "2026-01-05T07:00:00Z",
+			loc: losAngeles, want: "2026-01-06T07:00:00Z",
+		},
+		{
+			name:  "after a completion past several occurrences",
+			rule:  "FREQ=WEEKLY;BYDAY=MO,WE",
+			start: "2026-01-05T09:00:00Z", after: "2026-01-16T12:00:00Z",
+			loc: time.UTC, want: "2026-01-19T09:00:00Z",
+		},
+		{
+			name:  "skipped month",
+			rule:  "FREQ=MONTHLY;BYMONTHDAY=31",
+			start: "2026-01-31T09:00:00Z", after: "2026-01-31T09:00:00Z",
+			loc: time.UTC, want: "2026-03-31T09:00:00Z",
+		},
+		{
+			name:  "last day of the year in another time zone",
+			rule:  "FREQ=YEARLY",
+			start: "2025-12-31T23:30:00Z", after: "2025-12-31T23:30:00Z",
+			loc: losAngeles, want: "2026-12-31T23:30:00Z",
+		},
+		{
+			name:  "ended series",
+			rule:  "FREQ=DAILY;COUNT=2",
+			start: "2026-01-10T08:00:00Z", after: "2026-01-11T08:00:00Z",
+			loc: time.UTC,
+		},
+		{
+			name:  "until in the past",
+			rule:  "FREQ=DAILY;UNTIL=20260112T000000Z",
+			start: "2026-01-10T08:00:00Z", after: "2026-01-11T09:00:00Z",
+			loc: time.UTC,
+		},
+	}
+
+	for _, tt := range tests {
+		got, ok, err := Next(tt.rule, mustParse(t, tt.start), mustParse(t, tt.after), tt.loc)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+
+		if tt.want == "" {
+			if ok {
+				t.Errorf("%s: Next = %v, want the series to have ended", tt.name, got)
+			}
+			continue
+		}
+
+		if want := mustParse(t, tt.want); !ok || !got.Equal(want) || got.Location() != time.UTC {
+			t.Errorf("%s: Next = %v, %v, want %v", tt.name, got, ok, want)
+		}
+	}
+}
+
+func TestNextInvalidRule(t *testing.T) {
+	t.Parallel()
+
+	if _, _, err := Next("FREQ=NEVER", time.Now(), time.Now(), time.UTC); err == nil {
+		t.Error("Next with an invalid rule succeeded")
+	}
+}
+
+func TestStartAfterCompletion(t *testing.T) {
+	t.Parallel()
+
+	paris := mustLoad(t, "Europe/Paris")
+	losAngeles := mustLoad(t, "America/Los_Angeles")
+
+	tests := []struct {
+		name        string
+		completedAt string
+		due         string
+		allDay      bool
+		loc         *time.Location
+		want        string
+	}{
+		{
+			name:        "time of day of the due date",
+			completedAt: "2026-01-14T16:20:00Z", due: "2026-01-12T09:00:00Z",
+			loc: time.UTC, want: "2026-01-14T09:00:00Z",
+		},
+		{
+			name:        "local time of day kept across summer time",
+			completedAt: "2026-03-30T12:00:00Z", due: "2026-03-27T08:00:00Z",
+			loc: paris, want: "2026-03-30T07:00:00Z",
+		},
+		{
+			name:        "local day of a completion on the previous UTC day",
+			completedAt: "2026-01-05T03:00:00Z", due: "2026-01-02T17:00:00Z",
+			loc: losAngeles, want: "2026-01-04T17:00:00Z",
+		},
+		{
+			name:        "all day in a time zone ahead of UTC",
+			completedAt: "2026-03-29T22:30:00Z", due: "2026-03-27T00:00:00Z", allDay: true,
+			loc: paris, want: "2026-03-30T00:00:00Z",
+		},
+		{
+			name:        "all day in a time zone behind UTC",
+			completedAt: "2027-01-01T05:00:00Z", due: "2026-12-30T00:00:00Z", allDay: true,
+			loc: losAngeles, want: "2026-12-31T00:00:00Z",
+		},
+		{
+			name:        "without due date",
+			completedAt: "2026-01-14T16:20:00Z",
+			loc:         paris, want: "2026-01-14T16:20:00Z",
+		},
+	}
+
+	for _, tt := range tests {
+		var due *time.Time
+		if tt.due != "" {
+			d := mustParse(t, tt.due)
+			due = &d
+		}
+
+		got := StartAfterCompletion(mustParse(t, tt.completedAt).In(tt.loc), due, tt.allDay, tt.loc)
+		if want := mustParse(t, tt.want); !got.Equal(want) || got.Location() != time.UTC {
+			t.Errorf("%s: StartAfterCompletion = %v, want %v", tt.name, got, want)
+		}
+	}
+}
+
+func mustLoad(t *testing.T, name string) *time.Location {
+	t.Helper()
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return loc
+}
+
+func mustParse(t *testing.T, value string) time.Time {
+	t.Helper()
+
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return parsed
+}
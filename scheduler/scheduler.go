@@ -0,0 +1,199 @@
+// Package scheduler fires the reminders of todos when they are due.
+//
+// Reminders are stored in the database, so they survive restarts. Every instance sharing the
+// database may run a scheduler: a scheduler leases the reminders it is about to deliver (see
+// store.ReminderStore.Claim) and the others leave them alone until the lease expires. The lease is
+// renewed right before each delivery, which must end within half of it, so that the last reminders
+// of a batch stay reserved however long the first ones took. A reminder is marked as sent once its
+// notifier succeeded; if the instance dies in between, the reminder is delivered again when the
+// lease expires, so delivery is at least once.
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/marouane-ach/todo-go/app"
+	"github.com/marouane-ach/todo-go/models"
+	"github.com/marouane-ach/todo-go/notify"
+	"github.com/marouane-ach/todo-go/store"
+)
+
+type Scheduler struct {
+	app *app.App
+
+	// Notifiers maps the channel of a reminder to the notifier delivering it.
+	Notifiers map[string]notify.Notifier
+	// Lease is how long a claimed reminder is reserved for this scheduler. The lease is renewed
+	// before each delivery, and deliveries are cut off after half of it so that they finish
+	// before another scheduler may take over.
+	Lease time.Duration
+	// BatchSize is the number of reminders claimed at once.
+	BatchSize int
+	// MaxAttempts is the number of deliveries tried before a reminder is marked as failed.
+	MaxAttempts int
+}
+
+// New returns a scheduler delivering reminders by email, webhook and in-app notification.
+func New(a *app.App) *Scheduler {
+	return &Scheduler{
+		app: a,
+		Notifiers: map[string]notify.Notifier{
+			notify.ChannelEmail:   &notify.Email{Mailer: a.Mailer},
+			notify.ChannelWebhook: &notify.Webhook{Client: notify.NewWebhookClient(10 * time.Second), Secret: a.Config.WebhookSecret},
+			notify.ChannelInApp:   &notify.InApp{Store: a.Store},
+		},
+		Lease:       2 * time.Minute,
+		BatchSize:   50,
+		MaxAttempts: 8,
+	}
+}
+
+// Run fires the due reminders every Config.SchedulerInterval until ctx is cancelled.
+// It returns right away if the interval is zero.
+func (s *Scheduler) Run(ctx context.Context) {
+	interval := s.app.Config.SchedulerInterval
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.Tick(ctx); err != nil && ctx.Err() == nil {
+			s.app.Logger.Error("could not fire the due reminders", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Tick fires the reminders that are due now.
+func (s *Scheduler) Tick(ctx context.Context) error {
+	for ctx.Err() == nil {
+		reminders, err := s.app.Store.Reminders.Claim(ctx, s.app.Now(), s.Lease, s.BatchSize)
+		if err != nil {
+			return err
+		}
+
+		for i := range reminders {
+			s.fire(ctx, &reminders[i])
+		}
+
+		if len(reminders) < s.BatchSize {
+			return nil
+		}
+	}
+
+	return ctx.Err()
+}
+
+// fire delivers a claimed reminder and records the outcome. Reminders whose lease was lost, because the
+// ones before them in the batch took too long, are left to the scheduler that took them over.
+func (s *Scheduler) fire(ctx context.Context, reminder *models.Reminder) {
+	if err := s.app.Store.Reminders.Renew(ctx, reminder, s.app.Now(), s.Lease); errors.Is(err, store.ErrNotFound) {
+		s.app.Logger.Warn("lost the lease of a reminder before delivering it", "reminder", reminder.ID)
+		return
+	} else if err != nil {
+		s.app.Logger.Error("could not renew the lease of a reminder", "reminder", reminder.ID, "error", err)
+		return
+	}
+
+	deliverCtx, cancel := context.WithTimeout(ctx, s.Lease/2)
+	err := s.deliver(deliverCtx, reminder)
+	cancel()
+	now := s.app.Now()
+
+	switch {
+	case errors.Is(err, errCancelled):
+		reminder.Status = models.ReminderCancelled
+	case err == nil:
+		reminder.Status, reminder.SentAt, reminder.LastError = models.ReminderSent, &now, ""
+	case reminder.Attempts >= s.MaxAttempts:
+		reminder.Status, reminder.LastError = models.ReminderFailed, err.Error()
+	default:
+		retryAt := now.Add(backoff(reminder.Attempts))
+		reminder.FireAt, reminder.LastError = &retryAt, err.Error()
+	}
+
+	if err != nil && !errors.Is(err, errCancelled) {
+		s.app.Logger.Warn("could not deliver a reminder", "reminder", reminder.ID, "attempt", reminder.Attempts, "error", err)
+	}
+
+	reminder.UpdatedAt = now
+	// The outcome must be saved even if the app is shutting down, or the reminder would be delivered twice.
+	if err := s.app.Store.Reminders.Release(context.WithoutCancel(ctx), reminder); errors.Is(err, store.ErrNotFound) {
+		s.app.Logger.Warn("lost the lease of a reminder while delivering it", "reminder", reminder.ID)
+	} else if err != nil {
+		s.app.Logger.Error("could not save the outcome of a reminder", "reminder", reminder.ID, "error", err)
+	}
+}
+
+// errCancelled is returned by deliver when the reminder no longer needs to be delivered.
+var errCancelled = errors.New("scheduler: reminder cancelled")
+
+func (s *Scheduler) deliver(ctx context.Context, reminder *models.Reminder) error {
+	todo, err := s.app.Store.Todos.GetByID(ctx, reminder.TodoID)
+	if errors.Is(err, store.ErrNotFound) {
+		return errCancelled
+	} else if err != nil {
+		return err
+	}
+
+	if todo.Completed || todo.TodoList == nil {
+		return errCancelled
+	}
+
+	user, err := s.app.Store.Users.GetByID(ctx, reminder.UserID)
+	if errors.Is(err, store.ErrNotFound) {
+		return errCancelled
+	} else if err != nil {
+		return err
+	}
+
+	// The user who created the reminder stops getting it once they can't see the list.
+	if ok, err := s.app.Store.HasAccess(ctx, todo.TodoList, user.ID); err != nil {
+		return err
+	} else if !ok {
+		return errCancelled
+	}
+
+	notifier, ok := s.Notifiers[reminder.Channel]
+	if !ok {
+		return fmt.Errorf("scheduler: no notifier for channel %q", reminder.Channel)
+	}
+
+	return notifier.Notify(ctx, message(todo, user))
+}
+
+// message returns the reminder of todo for user.
+func message(todo *models.Todo, user *models.User) notify.Message {
+	body := fmt.Sprintf("In %s.", todo.TodoList.Name)
+	if todo.DueAt != nil {
+		loc, err := time.LoadLocation(user.Timezone)
+		if err != nil {
+			loc = time.UTC
+		}
+
+		if todo.AllDay {
+			body = fmt.Sprintf("Due on %s. %s", todo.DueAt.Format("Monday, January 2"), body)
+		} else {
+			body = fmt.Sprintf("Due on %s. %s", todo.DueAt.In(loc).Format("Monday, January 2 at 15:04 MST"), body)
+		}
+	}
+
+	return notify.Message{User: user, Kind: "reminder", Title: "Reminder: " + todo.Text, Body: body, TodoID: todo.ID}
+}
+
+// backoff returns how long to wait before another delivery after the given number of attempts.
+func backoff(attempts int) time.Duration {
+	d := time.Minute << min(max(attempts-1, 0), 6)
+	return min(d, time.Hour)
+}
@@ -0,0 +1,235 @@
+package scheduler_test
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/marouane-ach/todo-go/app"
+	"github.com/marouane-ach/todo-go/config"
+	"github.com/marouane-ach/todo-go/models"
+	"github.com/marouane-ach/todo-go/notify"
+	"github.com/marouane-ach/todo-go/scheduler"
+	"github.com/marouane-ach/todo-go/store"
+)
+
+// notifierFunc adapts a function to notify.Notifier.
+type notifierFunc func(ctx context.Context, msg notify.Message) error
+
+func (f notifierFunc) Notify(ctx context.Context, msg notify.Message) error { return f(ctx, msg) }
+
+// clock is a fake clock shared by the instances of a test.
+type clock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// backends return two Apps sharing their data, as two instances sharing a database would.
+var backends = map[string]func(t *testing.T, now func() time.Time) [2]*app.App{
+	"memory": func(t *testing.T, now func() time.Time) [2]*app.App {
+		st := store.NewMemory(now)
+		return [2]*app.App{app.NewWithStore(config.Default(), st), app.NewWithStore(config.Default(), st)}
+	},
+	"sqlite": func(t *testing.T, now func() time.Time) [2]*app.App {
+		cfg := config.Default()
+		cfg.DatabaseURL = "file:" + filepath.Join(t.TempDir(), "todo.db") + "?cache=shared&mode=rwc"
+
+		var apps [2]*app.App
+		for i := range apps {
+			a, err := app.New(cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			apps[i] = a
+		}
+		return apps
+	},
+}
+
+// instances returns two schedulers sharing c and the data of st, where a user owns the todo list with the given ID.
+func instances(t *testing.T, newApps func(t *testing.T, now func() time.Time) [2]*app.App, c *clock) (schedulers [2]*scheduler.Scheduler, st *store.Store, user *models.User, todoListID int) {
+	t.Helper()
+
+	apps := newApps(t, c.Now)
+	for i, a := range apps {
+		a.Now = c.Now
+		a.Logger = slog.New(slog.DiscardHandler)
+		t.Cleanup(func() { a.Close() })
+
+		schedulers[i] = scheduler.New(a)
+		schedulers[i].Lease = time.Minute
+	}
+
+	ctx := context.Background()
+	st = apps[0].Store
+
+	user = &models.User{Email: "a@b.com", Timezone: "UTC"}
+	if err := st.Users.Create(ctx, user); err != nil {
+		t.Fatal(err)
+	}
+
+	todoList := &models.TodoList{Name: "Home", ColorID: 1, OwnerID: user.ID}
+	if err := st.TodoLists.Create(ctx, todoList); err != nil {
+		t.Fatal(err)
+	}
+
+	return schedulers, st, user, todoList.ID
+}
+
+// addReminder adds a todo with the given text to the todo list, with a reminder for the user due now.
+func addReminder(t *testing.T, st *store.Store, now time.Time, text string, todoListID, userID int) *models.Todo {
+	t.Helper()
+
+	ctx := context.Background()
+
+	// The todos of a list need distinct ranks, and any distinct keys do here.
+	todo := &models.Todo{Text: text, TodoListID: todoListID, Rank: text}
+	if err := st.Todos.Create(ctx, todo); err != nil {
+		t.Fatal(err)
+	}
+
+	reminder := &models.Reminder{TodoID: todo.ID, UserID: userID, RemindAt: &now, FireAt: &now, Channel: notify.ChannelInApp, Status: models.ReminderPending}
+	if err := st.Reminders.Create(ctx, reminder); err != nil {
+		t.Fatal(err)
+	}
+
+	return todo
+}
+
+func TestTickWithSlowDeliveries(t *testing.T) {
+	t.Parallel()
+
+	for name, newApps := range backends {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			c := &clock{now: time.Date(2026, time.January, 2, 3, 4, 5, 0, time.UTC)}
+			schedulers, st, user, todoListID := instances(t, newApps, c)
+			a, b := schedulers[0], schedulers[1]
+
+			const count = 5
+			for i := range count {
+				addReminder(t, st, c.Now(), fmt.Sprint("Todo ", i+1), todoListID, user.ID)
+			}
+
+			var mu sync.Mutex
+			delivered := map[int]int{}
+			record := func(msg notify.Message) {
+				mu.Lock()
+				defer mu.Unlock()
+				delivered[msg.TodoID]++
+			}
+
+			b.Notifiers[notify.ChannelInApp] = notifierFunc(func(ctx context.Context, msg notify.Message) error {
+				record(msg)
+				return nil
+			})
+
+			// Every delivery of a takes 40% of the lease, which is within the limit, so its batch outlasts
+			// the lease and the other instance ticks in the meantime.
+			a.Notifiers[notify.ChannelInApp] = notifierFunc(func(ctx context.Context, msg notify.Message) error {
+				record(msg)
+				c.Advance(a.Lease * 4 / 10)
+				return b.Tick(ctx)
+			})
+
+			if err := a.Tick(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+
+			for id := 1; id <= count; id++ {
+				if delivered[id] != 1 {
+					t.Errorf("reminder of todo %d delivered %d times, want once", id, delivered[id])
+				}
+			}
+		})
+	}
+}
+
+func TestTickCancelsReminders(t *testing.T) {
+	t.Parallel()
+
+	for name, newApps := range backends {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+			c := &clock{now: time.Date(2026, time.January, 2, 3, 4, 5, 0, time.UTC)}
+			schedulers, st, user, todoListID := instances(t, newApps, c)
+			s := schedulers[0]
+
+			stranger := &models.User{Email: "c@b.com", Timezone: "UTC"}
+			if err := st.Users.Create(ctx, stranger); err != nil {
+				t.Fatal(err)
+			}
+
+			completed := addReminder(t, st, c.Now(), "Water plants", todoListID, user.ID)
+			completed.Completed = true
+			if err := st.Todos.Update(ctx, completed); err != nil {
+				t.Fatal(err)
+			}
+			// The user who created this one can't see the list.
+			addReminder(t, st, c.Now(), "Pay rent", todoListID, stranger.ID)
+			addReminder(t, st, c.Now(), "Call mom", todoListID, user.ID)
+
+			// In an organization's list, only the members of the organization get theirs, even the creator of the list.
+			org := &models.Organization{Name: "Acme"}
+			if err := st.Organizations.Create(ctx, org); err != nil {
+				t.Fatal(err)
+			}
+			if err := st.OrgMemberships.Create(ctx, &models.OrgMembership{OrganizationID: org.ID, UserID: stranger.ID, Role: models.OrgRoleMember}); err != nil {
+				t.Fatal(err)
+			}
+			orgList := &models.TodoList{Name: "Roadmap", ColorID: 1, OwnerID: user.ID, OrganizationID: org.ID}
+			if err := st.TodoLists.Create(ctx, orgList); err != nil {
+				t.Fatal(err)
+			}
+			addReminder(t, st, c.Now(), "Ship it", orgList.ID, user.ID)
+			addReminder(t, st, c.Now(), "Plan the launch", orgList.ID, stranger.ID)
+
+			var delivered []int
+			s.Notifiers[notify.ChannelInApp] = notifierFunc(func(ctx context.Context, msg notify.Message) error {
+				delivered = append(delivered, msg.TodoID)
+				return nil
+			})
+
+			if err := s.Tick(ctx); err != nil {
+				t.Fatal(err)
+			}
+
+			slices.Sort(delivered)
+			if !slices.Equal(delivered, []int{3, 5}) {
+				t.Errorf("delivered the reminders of todos %v, want [3 5]", delivered)
+			}
+
+			for todoID, want := range map[int]string{
+				1: models.ReminderCancelled, 2: models.ReminderCancelled, 3: models.ReminderSent, 4: models.ReminderCancelled, 5: models.ReminderSent,
+			} {
+				reminders, err := st.Reminders.ListByTodo(ctx, todoID)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(reminders) != 1 || reminders[0].Status != want {
+					t.Errorf("reminders of todo %d = %+v, want one %s", todoID, reminders, want)
+				}
+			}
+		})
+	}
+}
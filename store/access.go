@@ -0,0 +1,31 @@
+package store
+
+import (
+	"context"
+	"errors"
+
+	"github.com/marouane-ach/todo-go/models"
+)
+
+// HasAccess reports whether the user with the given ID can see todoList: its owner and its members can, or,
+// for the lists of an organization, the members of the organization.
+// Controllers and the scheduler both ask it, so that whoever can read a list is decided in one place.
+func (s *Store) HasAccess(ctx context.Context, todoList *models.TodoList, userID int) (bool, error) {
+	if todoList.OrganizationID != 0 {
+		_, err := s.OrgMemberships.Get(ctx, todoList.OrganizationID, userID)
+		if errors.Is(err, ErrNotFound) {
+			return false, nil
+		}
+		return err == nil, err
+	}
+
+	if todoList.OwnerID == userID {
+		return true, nil
+	}
+
+	_, err := s.Memberships.Get(ctx, todoList.ID, userID)
+	if errors.Is(err, ErrNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
@@ -0,0 +1,1210 @@
+package store
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/marouane-ach/todo-go/models"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect"
+	"github.com/uptrace/bun/driver/pgdriver"
+)
+
+// NewBun returns a Store backed by db.
+func NewBun(db bun.IDB) *Store {
+	return &Store{
+		Users:          &bunUserStore{db: db},
+		Tokens:         &bunTokenStore{db: db},
+		Colors:         &bunColorStore{db: db},
+		TodoLists:      &bunTodoListStore{db: db},
+		Folders:        &bunFolderStore{db: db},
+		Organizations:  &bunOrganizationStore{db: db},
+		OrgMemberships: &bunOrgMembershipStore{db: db},
+		Memberships:    &bunMembershipStore{db: db},
+		Invitations:    &bunInvitationStore{db: db},
+		ShareLinks:     &bunShareLinkStore{db: db},
+		Todos:          &bunTodoStore{db: db},
+		Statuses:       &bunStatusStore{db: db},
+		Tags:           &bunTagStore{db: db},
+		ChecklistItems: &bunChecklistItemStore{db: db},
+		Recurrences:    &bunRecurrenceStore{db: db},
+		Completions:    &bunCompletionStore{db: db},
+		Comments:       &bunCommentStore{db: db},
+		Reminders:      &bunReminderStore{db: db},
+		Notifications:  &bunNotificationStore{db: db},
+		Search:         &bunSearchStore{db: db},
+		inTx:           bunInTx(db),
+	}
+}
+
+// bunError translates driver errors into the errors declared by this package.
+func bunError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+
+	var pgErr pgdriver.Error
+	if errors.As(err, &pgErr) && pgErr.Field('C') == "23505" {
+		return ErrConflict
+	}
+
+	if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+		return ErrConflict
+	}
+
+	return err
+}
+
+// checkAffected returns ErrNotFound if a write did not touch any row.
+func checkAffected(res sql.Result, err error) error {
+	if err != nil {
+		return bunError(err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+type bunUserStore struct {
+	db bun.IDB
+}
+
+func (s *bunUserStore) Create(ctx context.Context, user *models.User) error {
+	_, err := s.db.NewInsert().Model(user).Exec(ctx)
+	return bunError(err)
+}
+
+func (s *bunUserStore) GetByID(ctx context.Context, id int) (*models.User, error) {
+	user := new(models.User)
+	err := s.db.NewSelect().Model(user).Where("id = ?", id).Scan(ctx)
+	return user, bunError(err)
+}
+
+func (s *bunUserStore) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	user := new(models.User)
+	err := s.db.NewSelect().Model(user).Where("email = ?", email).Scan(ctx)
+	return user, bunError(err)
+}
+
+func (s *bunUserStore) Update(ctx context.Context, user *models.User) error {
+	return checkAffected(s.db.NewUpdate().Model(user).WherePK().Exec(ctx))
+}
+
+type bunTokenStore struct {
+	db bun.IDB
+}
+
+func (s *bunTokenStore) Create(ctx context.Context, token *models.Token) error {
+	_, err := s.db.NewInsert().Model(token).Exec(ctx)
+	return bunError(err)
+}
+
+func (s *bunTokenStore) Get(ctx context.Context, t string) (*models.Token, error) {
+	token := new(models.Token)
+	err := s.db.NewSelect().Model(token).Where("token = ?", t).Scan(ctx)
+	return token, bunError(err)
+}
+
+func (s *bunTokenStore) Delete(ctx context.Context, id int) error {
+	return checkAffected(s.db.NewDelete().Model((*models.Token)(nil)).Where("id = ?", id).Exec(ctx))
+}
+
+type bunColorStore struct {
+	db bun.IDB
+}
+
+func (s *bunColorStore) GetByID(ctx context.Context, id int) (*models.Color, error) {
+	color := new(models.Color)
+	err := s.db.NewSelect().Model(color).Where("id = ?", id).Scan(ctx)
+	return color, bunError(err)
+}
+
+type bunTodoListStore struct {
+	db bun.IDB
+}
+
+func (s *bunTodoListStore) Create(ctx context.Context, todoList *models.TodoList) error {
+	_, err := s.db.NewInsert().Model(todoList).Exec(ctx)
+	return bunError(err)
+}
+
+func (s *bunTodoListStore) GetByID(ctx context.Context, id int) (*models.TodoList, error) {
+	todoList := new(models.TodoList)
+	err := s.db.NewSelect().
+		Model(todoList).
+		Where("id = ?", id).
+		Relation("Todos", orderTodos).
+		Relation("Todos.Tags", orderTags).
+		Scan(ctx)
+	return todoList, bunError(err)
+}
+
+func (s *bunTodoListStore) List(ctx context.Context, q TodoListQuery) ([]models.TodoList, error) {
+	var todoLists []models.TodoList
+	accessible, args := accessibleBy("todo_list", q.UserID, q.OrganizationID)
+	query := s.db.NewSelect().
+		Model(&todoLists).
+		Where(accessible, args...)
+
+	if q.ColorID != 0 {
+		query = query.Where("todo_list.color_id = ?", q.ColorID)
+	}
+
+	if len(q.FolderIDs) > 0 {
+		query = query.Where("todo_list.folder_id IN (?)", bun.In(q.FolderIDs))
+	}
+
+	if q.HasIncomplete != nil {
+		incomplete := s.db.NewSelect().
+			Model((*models.Todo)(nil)).
+			ColumnExpr("1").
+			Where("todo.todo_list_id = todo_list.id").
+			Where("todo.completed = ?", false)
+		if *q.HasIncomplete {
+			query = query.Where("EXISTS (?)", incomplete)
+		} else {
+			query = query.Where("NOT EXISTS (?)", incomplete)
+		}
+	}
+
+	query = applySort(s.db, query, "todo_list", q.Sort, todoListSortKey(&models.TodoList{}, q.Sort.Field), q.After)
+
+	if q.Limit > 0 {
+		query = query.Limit(q.Limit)
+	}
+
+	if !q.OmitTodos {
+		query = query.Relation("Todos", func(sq *bun.SelectQuery) *bun.SelectQuery {
+			if q.TodosLimit > 0 {
+				// Keep the first TodosLimit todos of each list.
+				ranked := s.db.NewSelect().
+					Model((*models.Todo)(nil)).
+					Column("id").
+					ColumnExpr("ROW_NUMBER() OVER (PARTITION BY todo_list_id ORDER BY rank, id) AS n")
+				sq = sq.Where("todo.id IN (SELECT id FROM (?) AS ranked WHERE n <= ?)", ranked, q.TodosLimit)
+			}
+			return orderTodos(sq)
+		}).Relation("Todos.Tags", orderTags)
+	}
+
+	err := query.Scan(ctx)
+	return todoLists, bunError(err)
+}
+
+func (s *bunTodoListStore) Update(ctx context.Context, todoList *models.TodoList) error {
+	return checkAffected(s.db.NewUpdate().Model(todoList).ExcludeColumn("rank").WherePK().Exec(ctx))
+}
+
+func (s *bunTodoListStore) AdjacentRank(ctx context.Context, ownerID int, rank string, before bool) (string, error) {
+	q := s.db.NewSelect().Model((*models.TodoList)(nil)).Where("owner_id = ? AND organization_id IS NULL", ownerID)
+	return adjacentRank(ctx, q, rank, before)
+}
+
+func (s *bunTodoListStore) AdjacentOrganizationRank(ctx context.Context, organizationID int, rank string, before bool) (string, error) {
+	q := s.db.NewSelect().Model((*models.TodoList)(nil)).Where("organization_id = ?", organizationID)
+	return adjacentRank(ctx, q, rank, before)
+}
+
+func (s *bunTodoListStore) CountByOrganization(ctx context.Context, organizationID int) (int, error) {
+	n, err := s.db.NewSelect().Model((*models.TodoList)(nil)).Where("organization_id = ?", organizationID).Count(ctx)
+	return n, bunError(err)
+}
+
+func (s *bunTodoListStore) SetRank(ctx context.Context, id int, rank string) error {
+	return checkAffected(s.db.NewUpdate().Model((*models.TodoList)(nil)).Set("rank = ?", rank).Where("id = ?", id).Exec(ctx))
+}
+
+// orderTodos sorts the todos of a relation by rank.
+func orderTodos(q *bun.SelectQuery) *bun.SelectQuery {
+	return q.Order("todo.rank", "todo.id")
+}
+
+// adjacentRank returns the rank of the row of q right before rank, or right after it if before is false,
+// or "" if there is none. An empty rank is past both ends.
+func adjacentRank(ctx context.Context, q *bun.SelectQuery, rank string, before bool) (string, error) {
+	q = q.Column("rank").Limit(1)
+	switch {
+	case before && rank != "":
+		q = q.Where("rank < ?", rank).OrderExpr("rank DESC")
+	case before:
+		q = q.OrderExpr("rank DESC")
+	case rank != "":
+		q = q.Where("rank > ?", rank).OrderExpr("rank ASC")
+	default:
+		q = q.OrderExpr("rank ASC")
+	}
+
+	var adjacent string
+	err := q.Scan(ctx, &adjacent)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	return adjacent, bunError(err)
+}
+
+func (s *bunTodoListStore) Delete(ctx context.Context, id int) error {
+	return checkAffected(s.db.NewDelete().Model((*models.TodoList)(nil)).Where("id = ?", id).Exec(ctx))
+}
+
+func (s *bunTodoListStore) ClearFolder(ctx context.Context, folderID int) error {
+	_, err := s.db.NewUpdate().Model((*models.TodoList)(nil)).Set("folder_id = NULL").Where("folder_id = ?", folderID).Exec(ctx)
+	return bunError(err)
+}
+
+type bunFolderStore struct {
+	db bun.IDB
+}
+
+func (s *bunFolderStore) Create(ctx context.Context, folder *models.Folder) error {
+	_, err := s.db.NewInsert().Model(folder).Returning("*").Exec(ctx)
+	return bunError(err)
+}
+
+func (s *bunFolderStore) GetByID(ctx context.Context, id int) (*models.Folder, error) {
+	folder := new(models.Folder)
+	err := s.db.NewSelect().Model(folder).Where("id = ?", id).Scan(ctx)
+	return folder, bunError(err)
+}
+
+func (s *bunFolderStore) List(ctx context.Context, q FolderQuery) ([]models.Folder, error) {
+	folders := []models.Folder{}
+	query := s.db.NewSelect().Model(&folders).OrderExpr("name, id")
+	if q.OrganizationID != 0 {
+		query = query.Where("organization_id = ?", q.OrganizationID)
+	} else {
+		query = query.Where("organization_id IS NULL AND owner_id = ?", q.UserID)
+	}
+
+	err := query.Scan(ctx)
+	return folders, bunError(err)
+}
+
+func (s *bunFolderStore) Update(ctx context.Context, folder *models.Folder) error {
+	return checkAffected(s.db.NewUpdate().Model(folder).WherePK().Exec(ctx))
+}
+
+func (s *bunFolderStore) ClearParent(ctx context.Context, parentID int) error {
+	_, err := s.db.NewUpdate().Model((*models.Folder)(nil)).Set("parent_id = NULL").Where("parent_id = ?", parentID).Exec(ctx)
+	return bunError(err)
+}
+
+func (s *bunFolderStore) Delete(ctx context.Context, id int) error {
+	return checkAffected(s.db.NewDelete().Model((*models.Folder)(nil)).Where("id = ?", id).Exec(ctx))
+}
+
+func (s *bunFolderStore) DeleteByOrganization(ctx context.Context, organizationID int) error {
+	_, err := s.db.NewDelete().Model((*models.Folder)(nil)).Where("organization_id = ?", organizationID).Exec(ctx)
+	return bunError(err)
+}
+
+type bunOrganizationStore struct {
+	db bun.IDB
+}
+
+func (s *bunOrganizationStore) Create(ctx context.Context, organization *models.Organization) error {
+	_, err := s.db.NewInsert().Model(organization).Returning("*").Exec(ctx)
+	return bunError(err)
+}
+
+func (s *bunOrganizationStore) GetByID(ctx context.Context, id int) (*models.Organization, error) {
+	organization := new(models.Organization)
+	err := s.db.NewSelect().Model(organization).Where("id = ?", id).Scan(ctx)
+	return organization, bunError(err)
+}
+
+func (s *bunOrganizationStore) Update(ctx context.Context, organization *models.Organization) error {
+	return checkAffected(s.db.NewUpdate().Model(organization).WherePK().Exec(ctx))
+}
+
+func (s *bunOrganizationStore) Delete(ctx context.Context, id int) error {
+	return checkAffected(s.db.NewDelete().Model((*models.Organization)(nil)).Where("id = ?", id).Exec(ctx))
+}
+
+type bunOrgMembershipStore struct {
+	db bun.IDB
+}
+
+func (s *bunOrgMembershipStore) Create(ctx context.Context, membership *models.OrgMembership) error {
+	_, err := s.db.NewInsert().Model(membership).Returning("*").Exec(ctx)
+	return bunError(err)
+}
+
+func (s *bunOrgMembershipStore) Get(ctx context.Context, organizationID, userID int) (*models.OrgMembership, error) {
+	membership := new(models.OrgMembership)
+	err := s.db.NewSelect().Model(membership).Where("organization_id = ? AND user_id = ?", organizationID, userID).Scan(ctx)
+	return membership, bunError(err)
+}
+
+func (s *bunOrgMembershipStore) ListByOrganization(ctx context.Context, organizationID int) ([]models.OrgMembership, error) {
+	memberships := []models.OrgMembership{}
+	err := s.db.NewSelect().
+		Model(&memberships).
+		Relation("User").
+		Where("org_membership.organization_id = ?", organizationID).
+		Order("org_membership.id").
+		Scan(ctx)
+	return memberships, bunError(err)
+}
+
+func (s *bunOrgMembershipStore) ListByUser(ctx context.Context, userID int) ([]models.OrgMembership, error) {
+	memberships := []models.OrgMembership{}
+	err := s.db.NewSelect().
+		Model(&memberships).
+		Relation("Organization").
+		Where("org_membership.user_id = ?", userID).
+		Order("org_membership.id").
+		Scan(ctx)
+	return memberships, bunError(err)
+}
+
+func (s *bunOrgMembershipStore) Update(ctx context.Context, membership *models.OrgMembership) error {
+	return checkAffected(s.db.NewUpdate().Model(membership).WherePK().Exec(ctx))
+}
+
+func (s *bunOrgMembershipStore) Delete(ctx context.Context, id int) error {
+	return checkAffected(s.db.NewDelete().Model((*models.OrgMembership)(nil)).Where("id = ?", id).Exec(ctx))
+}
+
+func (s *bunOrgMembershipStore) DeleteByOrganization(ctx context.Context, organizationID int) error {
+	_, err := s.db.NewDelete().Model((*models.OrgMembership)(nil)).Where("organization_id = ?", organizationID).Exec(ctx)
+	return bunError(err)
+}
+
+type bunMembershipStore struct {
+	db bun.IDB
+}
+
+func (s *bunMembershipStore) Create(ctx context.Context, membership *models.Membership) error {
+	_, err := s.db.NewInsert().Model(membership).Returning("*").Exec(ctx)
+	return bunError(err)
+}
+
+func (s *bunMembershipStore) Get(ctx context.Context, todoListID, userID int) (*models.Membership, error) {
+	membership := new(models.Membership)
+	err := s.db.NewSelect().Model(membership).Where("todo_list_id = ? AND user_id = ?", todoListID, userID).Scan(ctx)
+	return membership, bunError(err)
+}
+
+func (s *bunMembershipStore) ListByTodoList(ctx context.Context, todoListID int) ([]models.Membership, error) {
+	memberships := []models.Membership{}
+	err := s.db.NewSelect().
+		Model(&memberships).
+		Relation("User").
+		Where("membership.todo_list_id = ?", todoListID).
+		Order("membership.id").
+		Scan(ctx)
+	return memberships, bunError(err)
+}
+
+func (s *bunMembershipStore) ListByUser(ctx context.Context, userID int) ([]models.Membership, error) {
+	memberships := []models.Membership{}
+	err := s.db.NewSelect().Model(&memberships).Where("user_id = ?", userID).Order("id").Scan(ctx)
+	return memberships, bunError(err)
+}
+
+func (s *bunMembershipStore) Update(ctx context.Context, membership *models.Membership) error {
+	return checkAffected(s.db.NewUpdate().Model(membership).WherePK().Exec(ctx))
+}
+
+func (s *bunMembershipStore) Delete(ctx context.Context, id int) error {
+	return checkAffected(s.db.NewDelete().Model((*models.Membership)(nil)).Where("id = ?", id).Exec(ctx))
+}
+
+func (s *bunMembershipStore) DeleteByTodoList(ctx context.Context, todoListID int) error {
+	_, err := s.db.NewDelete().Model((*models.Membership)(nil)).Where("todo_list_id = ?", todoListID).Exec(ctx)
+	return bunError(err)
+}
+
+func (s *bunMembershipStore) DeleteInOrganization(ctx context.Context, organizationID, userID int) error {
+	todoLists := s.db.NewSelect().Model((*models.TodoList)(nil)).Column("id").Where("organization_id = ?", organizationID)
+	_, err := s.db.NewDelete().
+		Model((*models.Membership)(nil)).
+		Where("user_id = ? AND todo_list_id IN (?)", userID, todoLists).
+		Exec(ctx)
+	return bunError(err)
+}
+
+type bunInvitationStore struct {
+	db bun.IDB
+}
+
+func (s *bunInvitationStore) Create(ctx context.Context, invitation *models.Invitation) error {
+	_, err := s.db.NewInsert().Model(invitation).Returning("*").Exec(ctx)
+	return bunError(err)
+}
+
+func (s *bunInvitationStore) GetByID(ctx context.Context, id int) (*models.Invitation, error) {
+	invitation := new(models.Invitation)
+	err := s.db.NewSelect().Model(invitation).Relation("TodoList").Where("invitation.id = ?", id).Scan(ctx)
+	return invitation, bunError(err)
+}
+
+func (s *bunInvitationStore) ListByTodoList(ctx context.Context, todoListID int) ([]models.Invitation, error) {
+	invitations := []models.Invitation{}
+	err := s.db.NewSelect().Model(&invitations).Where("todo_list_id = ?", todoListID).Order("id").Scan(ctx)
+	return invitations, bunError(err)
+}
+
+func (s *bunInvitationStore) ListByEmail(ctx context.Context, email string) ([]models.Invitation, error) {
+	invitations := []models.Invitation{}
+	err := s.db.NewSelect().
+		Model(&invitations).
+		Relation("TodoList").
+		Where("lower(invitation.email) = lower(?)", email).
+		Order("invitation.id").
+		Scan(ctx)
+	return invitations, bunError(err)
+}
+
+func (s *bunInvitationStore) Delete(ctx context.Context, id int) error {
+	return checkAffected(s.db.NewDelete().Model((*models.Invitation)(nil)).Where("id = ?", id).Exec(ctx))
+}
+
+func (s *bunInvitationStore) DeleteByTodoList(ctx context.Context, todoListID int) error {
+	_, err := s.db.NewDelete().Model((*models.Invitation)(nil)).Where("todo_list_id = ?", todoListID).Exec(ctx)
+	return bunError(err)
+}
+
+type bunShareLinkStore struct {
+	db bun.IDB
+}
+
+func (s *bunShareLinkStore) Create(ctx context.Context, link *models.ShareLink) error {
+	_, err := s.db.NewInsert().Model(link).Returning("*").Exec(ctx)
+	return bunError(err)
+}
+
+func (s *bunShareLinkStore) GetByID(ctx context.Context, id int) (*models.ShareLink, error) {
+	link := new(models.ShareLink)
+	err := s.db.NewSelect().Model(link).Relation("TodoList").Where("share_link.id = ?", id).Scan(ctx)
+	return link, bunError(err)
+}
+
+func (s *bunShareLinkStore) GetBySlug(ctx context.Context, slug string) (*models.ShareLink, error) {
+	link := new(models.ShareLink)
+	err := s.db.NewSelect().Model(link).Where("slug = ?", slug).Scan(ctx)
+	return link, bunError(err)
+}
+
+func (s *bunShareLinkStore) ListByTodoList(ctx context.Context, todoListID int) ([]models.ShareLink, error) {
+	links := []models.ShareLink{}
+	err := s.db.NewSelect().Model(&links).Where("todo_list_id = ?", todoListID).Order("id").Scan(ctx)
+	return links, bunError(err)
+}
+
+func (s *bunShareLinkStore) RecordView(ctx context.Context, id int, at time.Time) error {
+	return checkAffected(s.db.NewUpdate().
+		Model((*models.ShareLink)(nil)).
+		Set("views = views + 1").
+		Set("last_accessed_at = ?", at).
+		Where("id = ?", id).
+		Exec(ctx))
+}
+
+func (s *bunShareLinkStore) Delete(ctx context.Context, id int) error {
+	return checkAffected(s.db.NewDelete().Model((*models.ShareLink)(nil)).Where("id = ?", id).Exec(ctx))
+}
+
+func (s *bunShareLinkStore) DeleteByTodoList(ctx context.Context, todoListID int) error {
+	_, err := s.db.NewDelete().Model((*models.ShareLink)(nil)).Where("todo_list_id = ?", todoListID).Exec(ctx)
+	return bunError(err)
+}
+
+type bunTodoStore struct {
+	db bun.IDB
+}
+
+func (s *bunTodoStore) Create(ctx context.Context, todo *models.Todo) error {
+	_, err := s.db.NewInsert().Model(todo).Exec(ctx)
+	return bunError(err)
+}
+
+func (s *bunTodoStore) GetByID(ctx context.Context, id int) (*models.Todo, error) {
+	todo := new(models.Todo)
+	err := s.db.NewSelect().
+		Model(todo).
+		Relation("TodoList").
+		Relation("Recurrence").
+		Relation("Tags", orderTags).
+		Where("todo.id = ?", id).
+		Scan(ctx)
+	return todo, bunError(err)
+}
+
+func (s *bunTodoStore) GetByText(ctx context.Context, todoListID int, text string) (*models.Todo, error) {
+	todo := new(models.Todo)
+	err := s.db.NewSelect().
+		Model(todo).
+		Where("todo_list_id = ? AND lower(text) = lower(?)", todoListID, text).
+		Order("id").
+		Limit(1).
+		Scan(ctx)
+	return todo, bunError(err)
+}
+
+func (s *bunTodoStore) Update(ctx context.Context, todo *models.Todo) error {
+	return checkAffected(s.db.NewUpdate().Model(todo).ExcludeColumn("items_total", "items_done", "rank").WherePK().Exec(ctx))
+}
+
+func (s *bunTodoStore) UpdateProgress(ctx context.Context, todo *models.Todo) error {
+	items := func(done bool) *bun.SelectQuery {
+		q := s.db.NewSelect().Model((*models.ChecklistItem)(nil)).ColumnExpr("count(*)").Where("todo_id = ?", todo.ID)
+		if done {
+			q = q.Where("done = ?", true)
+		}
+		return q
+	}
+
+	_, err := s.db.NewUpdate().
+		Model(todo).
+		Set("items_total = (?)", items(false)).
+		Set("items_done = (?)", items(true)).
+		WherePK().
+		Returning("items_total, items_done").
+		Exec(ctx)
+	return bunError(err)
+}
+
+func (s *bunTodoStore) Delete(ctx context.Context, id int) error {
+	return checkAffected(s.db.NewDelete().Model((*models.Todo)(nil)).Where("id = ?", id).Exec(ctx))
+}
+
+func (s *bunTodoStore) DeleteByTodoList(ctx context.Context, todoListID int) error {
+	_, err := s.db.NewDelete().Model((*models.Todo)(nil)).Where("todo_list_id = ?", todoListID).Exec(ctx)
+	return bunError(err)
+}
+
+func (s *bunTodoStore) List(ctx context.Context, q TodoQuery) ([]models.Todo, error) {
+	var todos []models.Todo
+	accessible, args := accessibleBy("tl", q.UserID, q.OrganizationID)
+	query := s.db.NewSelect().
+		Model(&todos).
+		Relation("Recurrence").
+		Relation("Tags", orderTags).
+		Join("JOIN todo_lists AS tl ON tl.id = todo.todo_list_id").
+		Where(accessible, args...)
+
+	if len(q.TodoListIDs) > 0 {
+		query = query.Where("todo.todo_list_id IN (?)", bun.In(q.TodoListIDs))
+	}
+
+	if q.Completed != nil {
+		query = query.Where("todo.completed = ?", *q.Completed)
+	}
+
+	if q.TextContains != "" {
+		query = query.Where(`lower(todo.text) LIKE lower(?) ESCAPE '\'`, "%"+escapeLike(q.TextContains)+"%")
+	}
+
+	query = whereTimeRange(s.db, query, "todo.created_at", q.CreatedFrom, q.CreatedTo)
+	query = whereTimeRange(s.db, query, "todo.updated_at", q.UpdatedFrom, q.UpdatedTo)
+
+	if q.Due != nil {
+		query = query.WhereGroup(" AND ", func(sq *bun.SelectQuery) *bun.SelectQuery {
+			sq = sq.WhereGroup(" OR ", func(sq *bun.SelectQuery) *bun.SelectQuery {
+				sq = sq.Where("todo.due_at IS NOT NULL AND todo.all_day = ?", false)
+				return whereTimeRange(s.db, sq, "todo.due_at", q.Due.From, q.Due.To)
+			})
+			return sq.WhereGroup(" OR ", func(sq *bun.SelectQuery) *bun.SelectQuery {
+				sq = sq.Where("todo.due_at IS NOT NULL AND todo.all_day = ?", true)
+				return whereTimeRange(s.db, sq, "todo.due_at", q.Due.FromDate, q.Due.ToDate)
+			})
+		})
+	}
+
+	if q.NoDueDate {
+		query = query.Where("todo.due_at IS NULL")
+	}
+
+	if len(q.StatusIDs) > 0 {
+		query = query.Where("todo.status_id IN (?)", bun.In(q.StatusIDs))
+	}
+
+	if len(q.Priorities) > 0 {
+		query = query.Where("todo.priority IN (?)", bun.In(q.Priorities))
+	}
+
+	if q.AssigneeID != nil && *q.AssigneeID == 0 {
+		query = query.Where("todo.assignee_id IS NULL")
+	} else if q.AssigneeID != nil {
+		query = query.Where("todo.assignee_id = ?", *q.AssigneeID)
+	}
+
+	if len(q.TagIDs) > 0 {
+		tagIDs := slices.Compact(slices.Sorted(slices.Values(q.TagIDs)))
+		tagged := s.db.NewSelect().
+			Model((*models.TodoTag)(nil)).
+			Column("todo_id").
+			Where("tag_id IN (?)", bun.In(tagIDs))
+		if q.AllTags {
+			tagged = tagged.Group("todo_id").Having("count(*) = ?", len(tagIDs))
+		}
+		query = query.Where("todo.id IN (?)", tagged)
+	}
+
+	query = applySort(s.db, query, "todo", q.Sort, todoSortKey(&models.Todo{}, q.Sort.Field), q.After)
+
+	if q.Limit > 0 {
+		query = query.Limit(q.Limit)
+	}
+
+	err := query.Scan(ctx)
+	return todos, bunError(err)
+}
+
+func (s *bunTodoStore) AdjacentRank(ctx context.Context, todoListID int, rank string, before bool) (string, error) {
+	q := s.db.NewSelect().Model((*models.Todo)(nil)).Where("todo_list_id = ?", todoListID)
+	return adjacentRank(ctx, q, rank, before)
+}
+
+func (s *bunTodoStore) SetRank(ctx context.Context, id int, rank string) error {
+	return checkAffected(s.db.NewUpdate().Model((*models.Todo)(nil)).Set("rank = ?", rank).Where("id = ?", id).Exec(ctx))
+}
+
+func (s *bunTodoStore) MoveStatus(ctx context.Context, from, to int) error {
+	_, err := s.db.NewUpdate().Model((*models.Todo)(nil)).Set("status_id = ?", to).Where("status_id = ?", from).Exec(ctx)
+	return bunError(err)
+}
+
+func (s *bunTodoStore) Unassign(ctx context.Context, todoListID, userID int) error {
+	_, err := s.db.NewUpdate().
+		Model((*models.Todo)(nil)).
+		Set("assignee_id = NULL").
+		Where("todo_list_id = ? AND assignee_id = ?", todoListID, userID).
+		Exec(ctx)
+	return bunError(err)
+}
+
+func (s *bunTodoStore) UnassignInOrganization(ctx context.Context, organizationID, userID int) error {
+	todoLists := s.db.NewSelect().Model((*models.TodoList)(nil)).Column("id").Where("organization_id = ?", organizationID)
+	_, err := s.db.NewUpdate().
+		Model((*models.Todo)(nil)).
+		Set("assignee_id = NULL").
+		Where("assignee_id = ? AND todo_list_id IN (?)", userID, todoLists).
+		Exec(ctx)
+	return bunError(err)
+}
+
+func (s *bunTodoStore) CompleteStatus(ctx context.Context, statusID int, completedAt *time.Time) error {
+	_, err := s.db.NewUpdate().
+		Model((*models.Todo)(nil)).
+		Set("completed = ?", completedAt != nil).
+		Set("completed_at = ?", completedAt).
+		Where("status_id = ? AND completed = ?", statusID, completedAt == nil).
+		Exec(ctx)
+	return bunError(err)
+}
+
+type bunStatusStore struct {
+	db bun.IDB
+}
+
+func (s *bunStatusStore) Create(ctx context.Context, status *models.Status) error {
+	last := s.db.NewSelect().
+		Model((*models.Status)(nil)).
+		ColumnExpr("coalesce(max(position), 0) + 1").
+		Where("todo_list_id = ?", status.TodoListID)
+
+	_, err := s.db.NewInsert().Model(status).Value("position", "(?)", last).Returning("*").Exec(ctx)
+	return bunError(err)
+}
+
+func (s *bunStatusStore) GetByID(ctx context.Context, id int) (*models.Status, error) {
+	status := new(models.Status)
+	err := s.db.NewSelect().Model(status).Relation("TodoList").Where("status.id = ?", id).Scan(ctx)
+	return status, bunError(err)
+}
+
+func (s *bunStatusStore) ListByTodoList(ctx context.Context, todoListID int) ([]models.Status, error) {
+	statuses := []models.Status{}
+	err := s.db.NewSelect().Model(&statuses).Where("todo_list_id = ?", todoListID).Order("position", "id").Scan(ctx)
+	return statuses, bunError(err)
+}
+
+func (s *bunStatusStore) Update(ctx context.Context, status *models.Status) error {
+	return checkAffected(s.db.NewUpdate().Model(status).WherePK().Exec(ctx))
+}
+
+func (s *bunStatusStore) Reorder(ctx context.Context, todoListID int, ids []int) error {
+	for i, id := range ids {
+		_, err := s.db.NewUpdate().
+			Model((*models.Status)(nil)).
+			Set("position = ?", i+1).
+			Where("id = ? AND todo_list_id = ?", id, todoListID).
+			Exec(ctx)
+		if err != nil {
+			return bunError(err)
+		}
+	}
+	return nil
+}
+
+func (s *bunStatusStore) Delete(ctx context.Context, id int) error {
+	return checkAffected(s.db.NewDelete().Model((*models.Status)(nil)).Where("id = ?", id).Exec(ctx))
+}
+
+func (s *bunStatusStore) DeleteByTodoList(ctx context.Context, todoListID int) error {
+	_, err := s.db.NewDelete().Model((*models.Status)(nil)).Where("todo_list_id = ?", todoListID).Exec(ctx)
+	return bunError(err)
+}
+
+// orderTags sorts the tags of a relation by name.
+func orderTags(q *bun.SelectQuery) *bun.SelectQuery {
+	return q.OrderExpr("lower(tag.name)").Order("tag.id")
+}
+
+type bunTagStore struct {
+	db bun.IDB
+}
+
+func (s *bunTagStore) Create(ctx context.Context, tag *models.Tag) error {
+	_, err := s.db.NewInsert().Model(tag).Exec(ctx)
+	return bunError(err)
+}
+
+func (s *bunTagStore) GetByID(ctx context.Context, id int) (*models.Tag, error) {
+	tags, err := s.list(ctx, func(q *bun.SelectQuery) *bun.SelectQuery { return q.Where("tag.id = ?", id) })
+	if err != nil {
+		return nil, err
+	}
+	if len(tags) == 0 {
+		return nil, ErrNotFound
+	}
+	return &tags[0], nil
+}
+
+func (s *bunTagStore) List(ctx context.Context, q TagQuery) ([]models.Tag, error) {
+	if q.IDs != nil && len(q.IDs) == 0 {
+		return []models.Tag{}, nil
+	}
+
+	return s.list(ctx, func(sq *bun.SelectQuery) *bun.SelectQuery {
+		sq = sq.Where("tag.owner_id = ?", q.OwnerID)
+		if q.IDs != nil {
+			sq = sq.Where("tag.id IN (?)", bun.In(q.IDs))
+		}
+		return sq
+	})
+}
+
+// list returns the tags selected by where with their colors and usage counts.
+func (s *bunTagStore) list(ctx context.Context, where func(*bun.SelectQuery) *bun.SelectQuery) ([]models.Tag, error) {
+	count := s.db.NewSelect().
+		Model((*models.TodoTag)(nil)).
+		ColumnExpr("count(*)").
+		Where("todo_tag.tag_id = tag.id")
+
+	tags := []models.Tag{}
+	query := s.db.NewSelect().
+		Model(&tags).
+		ColumnExpr("tag.*").
+		ColumnExpr("(?) AS todo_count", count).
+		Relation("Color")
+
+	err := orderTags(where(query)).Scan(ctx)
+	return tags, bunError(err)
+}
+
+func (s *bunTagStore) Update(ctx context.Context, tag *models.Tag) error {
+	return checkAffected(s.db.NewUpdate().Model(tag).WherePK().Exec(ctx))
+}
+
+func (s *bunTagStore) Delete(ctx context.Context, id int) error {
+	if _, err := s.db.NewDelete().Model((*models.TodoTag)(nil)).Where("tag_id = ?", id).Exec(ctx); err != nil {
+		return bunError(err)
+	}
+	return checkAffected(s.db.NewDelete().Model((*models.Tag)(nil)).Where("id = ?", id).Exec(ctx))
+}
+
+func (s *bunTagStore) Attach(ctx context.Context, todoID, tagID int) error {
+	_, err := s.db.NewInsert().Model(&models.TodoTag{TodoID: todoID, TagID: tagID}).On("CONFLICT DO NOTHING").Exec(ctx)
+	return bunError(err)
+}
+
+func (s *bunTagStore) Detach(ctx context.Context, todoID, tagID int) error {
+	_, err := s.db.NewDelete().Model((*models.TodoTag)(nil)).Where("todo_id = ? AND tag_id = ?", todoID, tagID).Exec(ctx)
+	return bunError(err)
+}
+
+func (s *bunTagStore) SetTodoTags(ctx context.Context, todoID int, tagIDs []int) error {
+	if _, err := s.db.NewDelete().Model((*models.TodoTag)(nil)).Where("todo_id = ?", todoID).Exec(ctx); err != nil {
+		return bunError(err)
+	}
+
+	for _, tagID := range tagIDs {
+		if err := s.Attach(ctx, todoID, tagID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *bunTagStore) DetachTodoList(ctx context.Context, todoListID int) error {
+	todos := s.db.NewSelect().Model((*models.Todo)(nil)).Column("id").Where("todo_list_id = ?", todoListID)
+	_, err := s.db.NewDelete().Model((*models.TodoTag)(nil)).Where("todo_id IN (?)", todos).Exec(ctx)
+	return bunError(err)
+}
+
+type bunChecklistItemStore struct {
+	db bun.IDB
+}
+
+func (s *bunChecklistItemStore) Create(ctx context.Context, item *models.ChecklistItem) error {
+	last := s.db.NewSelect().
+		Model((*models.ChecklistItem)(nil)).
+		ColumnExpr("coalesce(max(position), 0) + 1").
+		Where("todo_id = ?", item.TodoID)
+
+	_, err := s.db.NewInsert().Model(item).Value("position", "(?)", last).Returning("*").Exec(ctx)
+	return bunError(err)
+}
+
+func (s *bunChecklistItemStore) GetByID(ctx context.Context, id int) (*models.ChecklistItem, error) {
+	item := new(models.ChecklistItem)
+	err := s.db.NewSelect().Model(item).Relation("Todo").Relation("Todo.TodoList").Where("checklist_item.id = ?", id).Scan(ctx)
+	return item, bunError(err)
+}
+
+func (s *bunChecklistItemStore) ListByTodo(ctx context.Context, todoID int) ([]models.ChecklistItem, error) {
+	items := []models.ChecklistItem{}
+	err := s.db.NewSelect().Model(&items).Where("todo_id = ?", todoID).Order("position", "id").Scan(ctx)
+	return items, bunError(err)
+}
+
+func (s *bunChecklistItemStore) Update(ctx context.Context, item *models.ChecklistItem) error {
+	return checkAffected(s.db.NewUpdate().Model(item).WherePK().Exec(ctx))
+}
+
+func (s *bunChecklistItemStore) Reorder(ctx context.Context, todoID int, ids []int) error {
+	for i, id := range ids {
+		_, err := s.db.NewUpdate().
+			Model((*models.ChecklistItem)(nil)).
+			Set("position = ?", i+1).
+			Where("id = ? AND todo_id = ?", id, todoID).
+			Exec(ctx)
+		if err != nil {
+			return bunError(err)
+		}
+	}
+	return nil
+}
+
+func (s *bunChecklistItemStore) UncheckAll(ctx context.Context, todoID int) error {
+	_, err := s.db.NewUpdate().Model((*models.ChecklistItem)(nil)).Set("done = ?", false).Where("todo_id = ?", todoID).Exec(ctx)
+	return bunError(err)
+}
+
+func (s *bunChecklistItemStore) Delete(ctx context.Context, id int) error {
+	return checkAffected(s.db.NewDelete().Model((*models.ChecklistItem)(nil)).Where("id = ?", id).Exec(ctx))
+}
+
+func (s *bunChecklistItemStore) DeleteByTodo(ctx context.Context, todoID int) error {
+	_, err := s.db.NewDelete().Model((*models.ChecklistItem)(nil)).Where("todo_id = ?", todoID).Exec(ctx)
+	return bunError(err)
+}
+
+func (s *bunChecklistItemStore) DeleteByTodoList(ctx context.Context, todoListID int) error {
+	todos := s.db.NewSelect().Model((*models.Todo)(nil)).Column("id").Where("todo_list_id = ?", todoListID)
+	_, err := s.db.NewDelete().Model((*models.ChecklistItem)(nil)).Where("todo_id IN (?)", todos).Exec(ctx)
+	return bunError(err)
+}
+
+type bunRecurrenceStore struct {
+	db bun.IDB
+}
+
+func (s *bunRecurrenceStore) Create(ctx context.Context, recurrence *models.Recurrence) error {
+	_, err := s.db.NewInsert().Model(recurrence).Exec(ctx)
+	return bunError(err)
+}
+
+func (s *bunRecurrenceStore) GetByTodo(ctx context.Context, todoID int) (*models.Recurrence, error) {
+	recurrence := new(models.Recurrence)
+	err := s.db.NewSelect().Model(recurrence).Where("todo_id = ?", todoID).Scan(ctx)
+	return recurrence, bunError(err)
+}
+
+func (s *bunRecurrenceStore) Update(ctx context.Context, recurrence *models.Recurrence) error {
+	return checkAffected(s.db.NewUpdate().Model(recurrence).WherePK().Exec(ctx))
+}
+
+func (s *bunRecurrenceStore) Delete(ctx context.Context, id int) error {
+	return checkAffected(s.db.NewDelete().Model((*models.Recurrence)(nil)).Where("id = ?", id).Exec(ctx))
+}
+
+func (s *bunRecurrenceStore) DeleteByTodo(ctx context.Context, todoID int) error {
+	_, err := s.db.NewDelete().Model((*models.Recurrence)(nil)).Where("todo_id = ?", todoID).Exec(ctx)
+	return bunError(err)
+}
+
+func (s *bunRecurrenceStore) DeleteByTodoList(ctx context.Context, todoListID int) error {
+	todos := s.db.NewSelect().Model((*models.Todo)(nil)).Column("id").Where("todo_list_id = ?", todoListID)
+	_, err := s.db.NewDelete().Model((*models.Recurrence)(nil)).Where("todo_id IN (?)", todos).Exec(ctx)
+	return bunError(err)
+}
+
+type bunCompletionStore struct {
+	db bun.IDB
+}
+
+func (s *bunCompletionStore) Create(ctx context.Context, completion *models.Completion) error {
+	_, err := s.db.NewInsert().Model(completion).Exec(ctx)
+	return bunError(err)
+}
+
+func (s *bunCompletionStore) List(ctx context.Context, q CompletionQuery) ([]models.Completion, error) {
+	var completions []models.Completion
+	query := s.db.NewSelect().
+		Model(&completions).
+		Where("completion.todo_id = ?", q.TodoID)
+
+	query = applySort(s.db, query, "completion", CompletionSort, time.Time{}, q.After)
+
+	if q.Limit > 0 {
+		query = query.Limit(q.Limit)
+	}
+
+	err := query.Scan(ctx)
+	return completions, bunError(err)
+}
+
+func (s *bunCompletionStore) DeleteByTodo(ctx context.Context, todoID int) error {
+	_, err := s.db.NewDelete().Model((*models.Completion)(nil)).Where("todo_id = ?", todoID).Exec(ctx)
+	return bunError(err)
+}
+
+func (s *bunCompletionStore) DeleteByTodoList(ctx context.Context, todoListID int) error {
+	todos := s.db.NewSelect().Model((*models.Todo)(nil)).Column("id").Where("todo_list_id = ?", todoListID)
+	_, err := s.db.NewDelete().Model((*models.Completion)(nil)).Where("todo_id IN (?)", todos).Exec(ctx)
+	return bunError(err)
+}
+
+type bunCommentStore struct {
+	db bun.IDB
+}
+
+func (s *bunCommentStore) Create(ctx context.Context, comment *models.Comment) error {
+	_, err := s.db.NewInsert().Model(comment).Returning("*").Exec(ctx)
+	return bunError(err)
+}
+
+func (s *bunCommentStore) GetByID(ctx context.Context, id int) (*models.Comment, error) {
+	comment := new(models.Comment)
+	err := s.db.NewSelect().
+		Model(comment).
+		Relation("Todo").
+		Relation("Todo.TodoList").
+		Where("comment.id = ?", id).
+		Scan(ctx)
+	return comment, bunError(err)
+}
+
+func (s *bunCommentStore) List(ctx context.Context, q CommentQuery) ([]models.Comment, error) {
+	var comments []models.Comment
+	query := s.db.NewSelect().
+		Model(&comments).
+		Where("comment.todo_id = ?", q.TodoID)
+
+	query = applySort(s.db, query, "comment", CommentSort, time.Time{}, q.After)
+
+	if q.Limit > 0 {
+		query = query.Limit(q.Limit)
+	}
+
+	err := query.Scan(ctx)
+	return comments, bunError(err)
+}
+
+func (s *bunCommentStore) Update(ctx context.Context, comment *models.Comment) error {
+	return checkAffected(s.db.NewUpdate().Model(comment).WherePK().Exec(ctx))
+}
+
+func (s *bunCommentStore) Delete(ctx context.Context, id int) error {
+	return checkAffected(s.db.NewDelete().Model((*models.Comment)(nil)).Where("id = ?", id).Exec(ctx))
+}
+
+func (s *bunCommentStore) DeleteByTodo(ctx context.Context, todoID int) error {
+	_, err := s.db.NewDelete().Model((*models.Comment)(nil)).Where("todo_id = ?", todoID).Exec(ctx)
+	return bunError(err)
+}
+
+func (s *bunCommentStore) DeleteByTodoList(ctx context.Context, todoListID int) error {
+	todos := s.db.NewSelect().Model((*models.Todo)(nil)).Column("id").Where("todo_list_id = ?", todoListID)
+	_, err := s.db.NewDelete().Model((*models.Comment)(nil)).Where("todo_id IN (?)", todos).Exec(ctx)
+	return bunError(err)
+}
+
+type bunReminderStore struct {
+	db bun.IDB
+}
+
+func (s *bunReminderStore) Create(ctx context.Context, reminder *models.Reminder) error {
+	_, err := s.db.NewInsert().Model(reminder).Exec(ctx)
+	return bunError(err)
+}
+
+func (s *bunReminderStore) GetByID(ctx context.Context, id int) (*models.Reminder, error) {
+	reminder := new(models.Reminder)
+	err := s.db.NewSelect().
+		Model(reminder).
+		Relation("Todo").
+		Relation("Todo.TodoList").
+		Where("reminder.id = ?", id).
+		Scan(ctx)
+	return reminder, bunError(err)
+}
+
+func (s *bunReminderStore) ListByTodo(ctx context.Context, todoID int) ([]models.Reminder, error) {
+	reminders := []models.Reminder{}
+	err := s.db.NewSelect().Model(&reminders).Where("todo_id = ?", todoID).Order("id").Scan(ctx)
+	return reminders, bunError(err)
+}
+
+func (s *bunReminderStore) Update(ctx context.Context, reminder *models.Reminder) error {
+	// The lease belongs to the scheduler, which may hold it right now.
+	return checkAffected(s.db.NewUpdate().
+		Model(reminder).
+		ExcludeColumn("claim_token", "claimed_until").
+		WherePK().
+		Exec(ctx))
+}
+
+func (s *bunReminderStore) Delete(ctx context.Context, id int) error {
+	return checkAffected(s.db.NewDelete().Model((*models.Reminder)(nil)).Where("id = ?", id).Exec(ctx))
+}
+
+func (s *bunReminderStore) DeleteByTodo(ctx context.Context, todoID int) error {
+	_, err := s.db.NewDelete().Model((*models.Reminder)(nil)).Where("todo_id = ?", todoID).Exec(ctx)
+	return bunError(err)
+}
+
+func (s *bunReminderStore) DeleteByTodoList(ctx context.Context, todoListID int) error {
+	todos := s.db.NewSelect().Model((*models.Todo)(nil)).Column("id").Where("todo_list_id = ?", todoListID)
+	_, err := s.db.NewDelete().Model((*models.Reminder)(nil)).Where("todo_id IN (?)", todos).Exec(ctx)
+	return bunError(err)
+}
+
+func (s *bunReminderStore) Claim(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]models.Reminder, error) {
+	due := s.db.NewSelect().
+		Model((*models.Reminder)(nil)).
+		Column("id").
+		Where("status = ?", models.ReminderPending).
+		Where(orderedExpr(s.db, "fire_at", now)+" <= "+orderedExpr(s.db, "?", now), now).
+		WhereGroup(" AND ", func(q *bun.SelectQuery) *bun.SelectQuery {
+			return q.Where("claimed_until IS NULL").
+				WhereOr(orderedExpr(s.db, "claimed_until", now)+" <= "+orderedExpr(s.db, "?", now), now)
+		}).
+		Order("fire_at").
+		Limit(limit)
+
+	// Concurrent schedulers skip the rows another one is claiming instead of waiting for it.
+	// SQLite has a single writer, so the update below is atomic on its own.
+	if s.db.Dialect().Name() == dialect.PG {
+		due = due.For("UPDATE SKIP LOCKED")
+	}
+
+	token := rand.Text()
+	_, err := s.db.NewUpdate().
+		Model((*models.Reminder)(nil)).
+		Set("claim_token = ?", token).
+		Set("claimed_until = ?", now.Add(lease)).
+		Set("attempts = attempts + 1").
+		Where("id IN (?)", due).
+		Exec(ctx)
+	if err != nil {
+		return nil, bunError(err)
+	}
+
+	reminders := []models.Reminder{}
+	err = s.db.NewSelect().Model(&reminders).Where("claim_token = ?", token).Order("fire_at", "id").Scan(ctx)
+	return reminders, bunError(err)
+}
+
+func (s *bunReminderStore) Renew(ctx context.Context, reminder *models.Reminder, now time.Time, lease time.Duration) error {
+	until := now.Add(lease)
+	err := checkAffected(s.db.NewUpdate().
+		Model((*models.Reminder)(nil)).
+		Set("claimed_until = ?", until).
+		Where("id = ?", reminder.ID).
+		Where("claim_token = ?", reminder.ClaimToken).
+		Exec(ctx))
+	if err == nil {
+		reminder.ClaimedUntil = &until
+	}
+	return err
+}
+
+func (s *bunReminderStore) Release(ctx context.Context, reminder *models.Reminder) error {
+	err := checkAffected(s.db.NewUpdate().
+		Model((*models.Reminder)(nil)).
+		Set("status = ?", reminder.Status).
+		Set("fire_at = ?", reminder.FireAt).
+		Set("last_error = ?", reminder.LastError).
+		Set("sent_at = ?", reminder.SentAt).
+		Set("updated_at = ?", reminder.UpdatedAt).
+		Set("claim_token = NULL").
+		Set("claimed_until = NULL").
+		Where("id = ?", reminder.ID).
+		Where("claim_token = ?", reminder.ClaimToken).
+		Exec(ctx))
+	if err == nil {
+		reminder.ClaimToken, reminder.ClaimedUntil = "", nil
+	}
+	return err
+}
+
+type bunNotificationStore struct {
+	db bun.IDB
+}
+
+func (s *bunNotificationStore) Create(ctx context.Context, notification *models.Notification) error {
+	_, err := s.db.NewInsert().Model(notification).Exec(ctx)
+	return bunError(err)
+}
+
+func (s *bunNotificationStore) GetByID(ctx context.Context, id int) (*models.Notification, error) {
+	notification := new(models.Notification)
+	err := s.db.NewSelect().Model(notification).Where("id = ?", id).Scan(ctx)
+	return notification, bunError(err)
+}
+
+func (s *bunNotificationStore) List(ctx context.Context, q NotificationQuery) ([]models.Notification, error) {
+	var notifications []models.Notification
+	query := s.db.NewSelect().
+		Model(&notifications).
+		Where("notification.user_id = ?", q.UserID)
+
+	if q.Unread {
+		query = query.Where("notification.read_at IS NULL")
+	}
+
+	query = applySort(s.db, query, "notification", NotificationSort, time.Time{}, q.After)
+
+	if q.Limit > 0 {
+		query = query.Limit(q.Limit)
+	}
+
+	err := query.Scan(ctx)
+	return notifications, bunError(err)
+}
+
+func (s *bunNotificationStore) Update(ctx context.Context, notification *models.Notification) error {
+	return checkAffected(s.db.NewUpdate().Model(notification).WherePK().Exec(ctx))
+}
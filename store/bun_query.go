@@ -0,0 +1,66 @@
+package store
+
+import (
+	"strings"
+	"time"
+
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect"
+)
+
+// orderedExpr returns an SQL expression of column that compares in the same order as its Go value.
+// SQLite keeps timestamps as text in two formats (CURRENT_TIMESTAMP's and bun's), so they are
+// normalized with strftime before being compared. The same is done to cursor values.
+func orderedExpr(db bun.IDB, column string, value any) string {
+	if _, ok := value.(time.Time); ok && db.Dialect().Name() == dialect.SQLite {
+		return "strftime('%Y-%m-%d %H:%M:%f', " + column + ")"
+	}
+	return column
+}
+
+// applySort orders q by the column of sort and the ID, and skips the rows up to and including after.
+// zero is a value of the Go type of the sort column.
+func applySort(db bun.IDB, q *bun.SelectQuery, alias string, sort Sort, zero any, after *Cursor) *bun.SelectQuery {
+	column := orderedExpr(db, alias+"."+sort.Field, zero)
+	id := alias + ".id"
+
+	direction, cmp := "ASC", ">"
+	if sort.Desc {
+		direction, cmp = "DESC", "<"
+	}
+
+	if after != nil {
+		value := orderedExpr(db, "?", zero)
+		q = q.Where("("+column+" "+cmp+" "+value+" OR ("+column+" = "+value+" AND "+id+" "+cmp+" ?))",
+			after.Value, after.Value, after.ID)
+	}
+
+	return q.OrderExpr(column + " " + direction).OrderExpr(id + " " + direction)
+}
+
+// escapeLike escapes the LIKE wildcards in s. The pattern must be used with ESCAPE '\'.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+// whereTimeRange keeps the rows whose column is within [from, to). Zero bounds are ignored.
+func whereTimeRange(db bun.IDB, q *bun.SelectQuery, column string, from, to time.Time) *bun.SelectQuery {
+	if !from.IsZero() {
+		q = q.Where(orderedExpr(db, column, from)+" >= "+orderedExpr(db, "?", from), from)
+	}
+	if !to.IsZero() {
+		q = q.Where(orderedExpr(db, column, to)+" < "+orderedExpr(db, "?", to), to)
+	}
+	return q
+}
+
+// accessibleBy returns the condition keeping the todo lists aliased as alias that a user can see, and its arguments.
+// In an organization, these are all the lists of the organization, and outside of organizations, when organizationID
+// is zero, the other lists the user owns or is a member of.
+func accessibleBy(alias string, userID, organizationID int) (string, []any) {
+	if organizationID != 0 {
+		return alias + ".organization_id = ?", []any{organizationID}
+	}
+	return "(" + alias + ".organization_id IS NULL AND (" + alias + ".owner_id = ? OR " + alias +
+		".id IN (SELECT todo_list_id FROM memberships WHERE user_id = ?)))", []any{userID, userID}
+}
@@ -0,0 +1,170 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/marouane-ach/todo-go/models"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect"
+)
+
+// searchTarget is a table the search looks into. from must alias the todo lists as tl
+// so that the hits can be restricted to one owner. column is the text Postgres searches,
+// which must match the expression of its index.
+type searchTarget struct {
+	fts    string
+	from   string
+	id     string
+	column string
+}
+
+var (
+	todoSearchTarget     = searchTarget{"todos_fts", "todos AS t JOIN todo_lists AS tl ON tl.id = t.todo_list_id", "t.id", "t.text || ' ' || coalesce(t.notes, '')"}
+	todoListSearchTarget = searchTarget{"todo_lists_fts", "todo_lists AS tl", "tl.id", "tl.name"}
+)
+
+type searchRow struct {
+	ID      int     `bun:"id"`
+	Score   float64 `bun:"score"`
+	Snippet string  `bun:"snippet"`
+}
+
+type bunSearchStore struct {
+	db bun.IDB
+}
+
+func (s *bunSearchStore) Search(ctx context.Context, q SearchQuery) ([]SearchHit, error) {
+	if len(q.Terms) == 0 {
+		return []SearchHit{}, nil
+	}
+
+	todoRows, err := s.rows(ctx, todoSearchTarget, q)
+	if err != nil {
+		return nil, err
+	}
+
+	todoListRows, err := s.rows(ctx, todoListSearchTarget, q)
+	if err != nil {
+		return nil, err
+	}
+
+	var todos []models.Todo
+	if len(todoRows) > 0 {
+		err = s.db.NewSelect().
+			Model(&todos).
+			Relation("TodoList").
+			Where("todo.id IN (?)", bun.In(rowIDs(todoRows))).
+			Scan(ctx)
+		if err != nil {
+			return nil, bunError(err)
+		}
+	}
+
+	var todoLists []models.TodoList
+	if len(todoListRows) > 0 {
+		err = s.db.NewSelect().
+			Model(&todoLists).
+			Where("id IN (?)", bun.In(rowIDs(todoListRows))).
+			Scan(ctx)
+		if err != nil {
+			return nil, bunError(err)
+		}
+	}
+
+	hits := make([]SearchHit, 0, len(todoRows)+len(todoListRows))
+	for _, r := range todoRows {
+		// Skip the rows deleted between the two queries.
+		i := slices.IndexFunc(todos, func(t models.Todo) bool { return t.ID == r.ID })
+		if i >= 0 && todos[i].TodoList != nil {
+			todo := todos[i]
+			hits = append(hits, SearchHit{Todo: &todo, TodoList: *todo.TodoList, Rank: r.Score, Snippet: r.Snippet})
+		}
+	}
+	for _, r := range todoListRows {
+		i := slices.IndexFunc(todoLists, func(l models.TodoList) bool { return l.ID == r.ID })
+		if i >= 0 {
+			hits = append(hits, SearchHit{TodoList: todoLists[i], Rank: r.Score, Snippet: r.Snippet})
+		}
+	}
+
+	return sortHits(hits, q.Limit), nil
+}
+
+// rows returns the IDs, scores and snippets of the best matches in target.
+func (s *bunSearchStore) rows(ctx context.Context, target searchTarget, q SearchQuery) ([]searchRow, error) {
+	var (
+		query string
+		args  []any
+	)
+
+	accessible, accessibleArgs := accessibleBy("tl", q.UserID, q.OrganizationID)
+	if s.db.Dialect().Name() == dialect.SQLite {
+		// bm25 is lower for better matches. The snippet comes from the column matching best.
+		query = fmt.Sprintf(`SELECT %[3]s AS id, -bm25(%[1]s) AS score, snippet(%[1]s, -1, ?, ?, '…', 16) AS snippet
+			FROM %[1]s, %[2]s
+			WHERE %[1]s.rowid = %[3]s AND %[1]s MATCH ? AND %[4]s
+			ORDER BY score DESC, %[3]s`, target.fts, target.from, target.id, accessible)
+		args = append([]any{HighlightStart, HighlightEnd, ftsMatch(q.Terms)}, accessibleArgs...)
+	} else {
+		query = fmt.Sprintf(`SELECT %[2]s AS id, ts_rank(to_tsvector('simple', %[3]s), query) AS score,
+				ts_headline('simple', %[3]s, query, ?) AS snippet
+			FROM %[1]s, to_tsquery('simple', ?) AS query
+			WHERE to_tsvector('simple', %[3]s) @@ query AND %[4]s
+			ORDER BY score DESC, %[2]s`, target.from, target.id, target.column, accessible)
+		args = append([]any{
+			"StartSel=" + HighlightStart + ", StopSel=" + HighlightEnd + ", MaxWords=16, MinWords=8",
+			tsQuery(q.Terms),
+		}, accessibleArgs...)
+	}
+
+	if q.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, q.Limit)
+	}
+
+	var rows []searchRow
+	if err := s.db.NewRaw(query, args...).Scan(ctx, &rows); err != nil {
+		return nil, bunError(err)
+	}
+	return rows, nil
+}
+
+func rowIDs(rows []searchRow) []int {
+	ids := make([]int, len(rows))
+	for i, r := range rows {
+		ids[i] = r.ID
+	}
+	return ids
+}
+
+// ftsMatch returns the FTS5 query matching every term. Each term is quoted so that words such as
+// AND or NEAR aren't read as operators.
+func ftsMatch(terms []SearchTerm) string {
+	parts := make([]string, len(terms))
+	for i, t := range terms {
+		parts[i] = `"` + strings.ReplaceAll(strings.Join(t.Words, " "), `"`, `""`) + `"`
+		if t.Prefix {
+			parts[i] += " *"
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// tsQuery returns the Postgres tsquery matching every term.
+func tsQuery(terms []SearchTerm) string {
+	parts := make([]string, len(terms))
+	for i, t := range terms {
+		lexemes := make([]string, len(t.Words))
+		for j, w := range t.Words {
+			lexemes[j] = "'" + strings.ReplaceAll(w, "'", "''") + "'"
+		}
+		if t.Prefix {
+			lexemes[len(lexemes)-1] += ":*"
+		}
+		parts[i] = "(" + strings.Join(lexemes, " <-> ") + ")"
+	}
+	return strings.Join(parts, " & ")
+}
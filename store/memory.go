@@ -0,0 +1,2289 @@
+package store
+
+import (
+	"context"
+	"crypto/rand"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/marouane-ach/todo-go/models"
+)
+
+// NewMemory returns a Store that keeps everything in maps. The color palette is seeded
+// the same way the database is. It is meant for tests and is safe for concurrent use.
+// Creation times are read from now, time.Now if nil, so that tests can control the clock.
+func NewMemory(now func() time.Time) *Store {
+	if now == nil {
+		now = time.Now
+	}
+
+	m := &memory{
+		now:           now,
+		ids:           map[string]int{},
+		users:         map[int]models.User{},
+		tokens:        map[int]models.Token{},
+		colors:        map[int]models.Color{},
+		todoLists:     map[int]models.TodoList{},
+		folders:       map[int]models.Folder{},
+		organizations: map[int]models.Organization{},
+		orgMembers:    map[int]models.OrgMembership{},
+		memberships:   map[int]models.Membership{},
+		invitations:   map[int]models.Invitation{},
+		shareLinks:    map[int]models.ShareLink{},
+		todos:         map[int]models.Todo{},
+		statuses:      map[int]models.Status{},
+		tags:          map[int]models.Tag{},
+		todoTags:      map[todoTag]struct{}{},
+		items:         map[int]models.ChecklistItem{},
+		recurrences:   map[int]models.Recurrence{},
+		completions:   map[int]models.Completion{},
+		comments:      map[int]models.Comment{},
+		reminders:     map[int]models.Reminder{},
+		notifications: map[int]models.Notification{},
+	}
+
+	for _, c := range models.Palette {
+		c.MyBaseModel = m.newBase("colors")
+		m.colors[c.ID] = c
+	}
+
+	st := &Store{
+		Users:          &memUserStore{m},
+		Tokens:         &memTokenStore{m},
+		Colors:         &memColorStore{m},
+		TodoLists:      &memTodoListStore{m},
+		Folders:        &memFolderStore{m},
+		Organizations:  &memOrganizationStore{m},
+		OrgMemberships: &memOrgMembershipStore{m},
+		Memberships:    &memMembershipStore{m},
+		Invitations:    &memInvitationStore{m},
+		ShareLinks:     &memShareLinkStore{m},
+		Todos:          &memTodoStore{m},
+		Statuses:       &memStatusStore{m},
+		Tags:           &memTagStore{m},
+		ChecklistItems: &memChecklistItemStore{m},
+		Recurrences:    &memRecurrenceStore{m},
+		Completions:    &memCompletionStore{m},
+		Comments:       &memCommentStore{m},
+		Reminders:      &memReminderStore{m},
+		Notifications:  &memNotificationStore{m},
+		Search:         &memSearchStore{m},
+	}
+	st.inTx = m.inTx(st)
+	return st
+}
+
+type memory struct {
+	// txMu serializes transactions. Writes made outside of a transaction while one is
+	// rolling back are lost, which is fine for the tests this store is meant for.
+	txMu sync.Mutex
+	mu   sync.Mutex
+	// now returns the current time, which records are created at.
+	now func() time.Time
+
+	ids           map[string]int
+	users         map[int]models.User
+	tokens        map[int]models.Token
+	colors        map[int]models.Color
+	todoLists     map[int]models.TodoList
+	folders       map[int]models.Folder
+	organizations map[int]models.Organization
+	orgMembers    map[int]models.OrgMembership
+	memberships   map[int]models.Membership
+	invitations   map[int]models.Invitation
+	shareLinks    map[int]models.ShareLink
+	todos         map[int]models.Todo
+	statuses      map[int]models.Status
+	tags          map[int]models.Tag
+	todoTags      map[todoTag]struct{}
+	items         map[int]models.ChecklistItem
+	recurrences   map[int]models.Recurrence
+	completions   map[int]models.Completion
+	comments      map[int]models.Comment
+	reminders     map[int]models.Reminder
+	notifications map[int]models.Notification
+}
+
+// inTx runs fn against a copy of st and restores a snapshot of the data if fn fails.
+func (m *memory) inTx(st *Store) func(context.Context, func(*Store) error) error {
+	return func(ctx context.Context, fn func(*Store) error) error {
+		m.txMu.Lock()
+		defer m.txMu.Unlock()
+
+		m.mu.Lock()
+		snapshot := m.clone()
+		m.mu.Unlock()
+
+		tx := *st
+		tx.inTx = func(ctx context.Context, fn func(*Store) error) error { return fn(&tx) }
+
+		if err := fn(&tx); err != nil {
+			m.mu.Lock()
+			m.restore(snapshot)
+			m.mu.Unlock()
+			return err
+		}
+
+		return nil
+	}
+}
+
+// clone returns a deep copy of the data. The caller must hold m.mu.
+func (m *memory) clone() *memory {
+	return &memory{
+		ids:           maps.Clone(m.ids),
+		users:         maps.Clone(m.users),
+		tokens:        maps.Clone(m.tokens),
+		colors:        maps.Clone(m.colors),
+		todoLists:     maps.Clone(m.todoLists),
+		folders:       maps.Clone(m.folders),
+		organizations: maps.Clone(m.organizations),
+		orgMembers:    maps.Clone(m.orgMembers),
+		memberships:   maps.Clone(m.memberships),
+		invitations:   maps.Clone(m.invitations),
+		shareLinks:    maps.Clone(m.shareLinks),
+		todos:         maps.Clone(m.todos),
+		statuses:      maps.Clone(m.statuses),
+		tags:          maps.Clone(m.tags),
+		todoTags:      maps.Clone(m.todoTags),
+		items:         maps.Clone(m.items),
+		recurrences:   maps.Clone(m.recurrences),
+		completions:   maps.Clone(m.completions),
+		comments:      maps.Clone(m.comments),
+		reminders:     maps.Clone(m.reminders),
+		notifications: maps.Clone(m.notifications),
+	}
+}
+
+// restore replaces the data with a snapshot taken by clone. The caller must hold m.mu.
+func (m *memory) restore(snapshot *memory) {
+	m.ids = snapshot.ids
+	m.users = snapshot.users
+	m.tokens = snapshot.tokens
+	m.colors = snapshot.colors
+	m.todoLists = snapshot.todoLists
+	m.folders = snapshot.folders
+	m.organizations = snapshot.organizations
+	m.orgMembers = snapshot.orgMembers
+	m.memberships = snapshot.memberships
+	m.invitations = snapshot.invitations
+	m.shareLinks = snapshot.shareLinks
+	m.todos = snapshot.todos
+	m.statuses = snapshot.statuses
+	m.tags = snapshot.tags
+	m.todoTags = snapshot.todoTags
+	m.items = snapshot.items
+	m.recurrences = snapshot.recurrences
+	m.completions = snapshot.completions
+	m.comments = snapshot.comments
+	m.reminders = snapshot.reminders
+	m.notifications = snapshot.notifications
+}
+
+// newBase allocates the next ID of table. The caller must hold m.mu.
+func (m *memory) newBase(table string) models.MyBaseModel {
+	m.ids[table]++
+	now := m.now().UTC()
+	return models.MyBaseModel{ID: m.ids[table], CreatedAt: now, UpdatedAt: now}
+}
+
+// recurrenceOf returns the recurrence of a todo, or nil. The caller must hold m.mu.
+func (m *memory) recurrenceOf(todoID int) *models.Recurrence {
+	for _, r := range m.recurrences {
+		if r.TodoID == todoID {
+			return &r
+		}
+	}
+	return nil
+}
+
+// todoTag is the key of a link between a todo and one of its tags.
+type todoTag struct{ todoID, tagID int }
+
+// tagsOf returns the tags of a todo ordered by name. The caller must hold m.mu.
+func (m *memory) tagsOf(todoID int) []models.Tag {
+	var tags []models.Tag
+	for link := range m.todoTags {
+		if tag, ok := m.tags[link.tagID]; ok && link.todoID == todoID {
+			tags = append(tags, tag)
+		}
+	}
+
+	slices.SortFunc(tags, compareTags)
+	return tags
+}
+
+// compareTags orders tags by name, ignoring case, then by ID.
+func compareTags(a, b models.Tag) int {
+	if cmp := strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name)); cmp != 0 {
+		return cmp
+	}
+	return a.ID - b.ID
+}
+
+// todosOf returns the todos of a todo list ordered by rank along with their tags. The caller must hold m.mu.
+func (m *memory) todosOf(todoListID int) []models.Todo {
+	todos := []models.Todo{}
+	for _, t := range m.todos {
+		if t.TodoListID == todoListID {
+			t.Tags = m.tagsOf(t.ID)
+			todos = append(todos, t)
+		}
+	}
+
+	sort.Slice(todos, func(i, j int) bool {
+		if todos[i].Rank != todos[j].Rank {
+			return todos[i].Rank < todos[j].Rank
+		}
+		return todos[i].ID < todos[j].ID
+	})
+	return todos
+}
+
+// nearestRank returns the rank among ranks right before rank, or right after it if before is false,
+// or "" if there is none. An empty rank is past both ends.
+func nearestRank(ranks []string, rank string, before bool) string {
+	adjacent := ""
+	for _, r := range ranks {
+		if before && (rank == "" || r < rank) && r > adjacent {
+			adjacent = r
+		} else if !before && (rank == "" || r > rank) && (adjacent == "" || r < adjacent) {
+			adjacent = r
+		}
+	}
+	return adjacent
+}
+
+type memUserStore struct{ m *memory }
+
+func (s *memUserStore) Create(ctx context.Context, user *models.User) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	for _, u := range s.m.users {
+		if u.Email == user.Email {
+			return ErrConflict
+		}
+	}
+
+	user.MyBaseModel = s.m.newBase("users")
+	s.m.users[user.ID] = *user
+	return nil
+}
+
+func (s *memUserStore) GetByID(ctx context.Context, id int) (*models.User, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	user, ok := s.m.users[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	return &user, nil
+}
+
+func (s *memUserStore) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	for _, u := range s.m.users {
+		if u.Email == email {
+			return &u, nil
+		}
+	}
+
+	return nil, ErrNotFound
+}
+
+func (s *memUserStore) Update(ctx context.Context, user *models.User) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	if _, ok := s.m.users[user.ID]; !ok {
+		return ErrNotFound
+	}
+
+	for _, u := range s.m.users {
+		if u.ID != user.ID && u.Email == user.Email {
+			return ErrConflict
+		}
+	}
+
+	s.m.users[user.ID] = *user
+	return nil
+}
+
+type memTokenStore struct{ m *memory }
+
+func (s *memTokenStore) Create(ctx context.Context, token *models.Token) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	for _, t := range s.m.tokens {
+		if t.Token == token.Token {
+			return ErrConflict
+		}
+	}
+
+	token.MyBaseModel = s.m.newBase("tokens")
+	stored := *token
+	stored.Owner = nil
+	s.m.tokens[token.ID] = stored
+	return nil
+}
+
+func (s *memTokenStore) Get(ctx context.Context, token string) (*models.Token, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	for _, t := range s.m.tokens {
+		if t.Token == token {
+			return &t, nil
+		}
+	}
+
+	return nil, ErrNotFound
+}
+
+func (s *memTokenStore) Delete(ctx context.Context, id int) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	if _, ok := s.m.tokens[id]; !ok {
+		return ErrNotFound
+	}
+
+	delete(s.m.tokens, id)
+	return nil
+}
+
+type memColorStore struct{ m *memory }
+
+func (s *memColorStore) GetByID(ctx context.Context, id int) (*models.Color, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	color, ok := s.m.colors[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	return &color, nil
+}
+
+type memTodoListStore struct{ m *memory }
+
+func (s *memTodoListStore) Create(ctx context.Context, todoList *models.TodoList) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	if s.rankTaken(*todoList, todoList.Rank) {
+		return ErrConflict
+	}
+
+	todoList.MyBaseModel = s.m.newBase("todo_lists")
+	stored := *todoList
+	stored.Color, stored.Owner, stored.Todos = nil, nil, nil
+	s.m.todoLists[todoList.ID] = stored
+	return nil
+}
+
+func (s *memTodoListStore) GetByID(ctx context.Context, id int) (*models.TodoList, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	todoList, ok := s.m.todoLists[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	todoList.Todos = s.m.todosOf(id)
+	return &todoList, nil
+}
+
+func (s *memTodoListStore) List(ctx context.Context, q TodoListQuery) ([]models.TodoList, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	todoLists := []models.TodoList{}
+	for _, l := range s.m.todoLists {
+		if !s.m.accessible(l, q.UserID, q.OrganizationID) || (q.ColorID != 0 && l.ColorID != q.ColorID) {
+			continue
+		}
+
+		if len(q.FolderIDs) > 0 && !slices.Contains(q.FolderIDs, l.FolderID) {
+			continue
+		}
+
+		if !afterCursor(todoListSortKey(&l, q.Sort.Field), l.ID, q.Sort, q.After) {
+			continue
+		}
+
+		todos := s.m.todosOf(l.ID)
+		if q.HasIncomplete != nil {
+			incomplete := slices.ContainsFunc(todos, func(t models.Todo) bool { return !t.Completed })
+			if incomplete != *q.HasIncomplete {
+				continue
+			}
+		}
+
+		if !q.OmitTodos {
+			if q.TodosLimit > 0 && len(todos) > q.TodosLimit {
+				todos = todos[:q.TodosLimit]
+			}
+			l.Todos = todos
+		}
+
+		todoLists = append(todoLists, l)
+	}
+
+	slices.SortFunc(todoLists, func(a, b models.TodoList) int {
+		cmp := compareKeys(todoListSortKey(&a, q.Sort.Field), todoListSortKey(&b, q.Sort.Field))
+		if cmp == 0 {
+			cmp = a.ID - b.ID
+		}
+		if q.Sort.Desc {
+			return -cmp
+		}
+		return cmp
+	})
+
+	if q.Limit > 0 && len(todoLists) > q.Limit {
+		todoLists = todoLists[:q.Limit]
+	}
+
+	return todoLists, nil
+}
+
+func (s *memTodoListStore) Update(ctx context.Context, todoList *models.TodoList) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	stored, ok := s.m.todoLists[todoList.ID]
+	if !ok {
+		return ErrNotFound
+	}
+
+	rank := stored.Rank
+	stored = *todoList
+	stored.Color, stored.Owner, stored.Todos = nil, nil, nil
+	stored.Rank = rank
+	s.m.todoLists[todoList.ID] = stored
+	return nil
+}
+
+func (s *memTodoListStore) AdjacentRank(ctx context.Context, ownerID int, rank string, before bool) (string, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	var ranks []string
+	for _, l := range s.m.todoLists {
+		if l.OwnerID == ownerID && l.OrganizationID == 0 {
+			ranks = append(ranks, l.Rank)
+		}
+	}
+	return nearestRank(ranks, rank, before), nil
+}
+
+func (s *memTodoListStore) AdjacentOrganizationRank(ctx context.Context, organizationID int, rank string, before bool) (string, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	var ranks []string
+	for _, l := range s.m.todoLists {
+		if l.OrganizationID == organizationID {
+			ranks = append(ranks, l.Rank)
+		}
+	}
+	return nearestRank(ranks, rank, before), nil
+}
+
+func (s *memTodoListStore) SetRank(ctx context.Context, id int, rank string) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	todoList, ok := s.m.todoLists[id]
+	if !ok {
+		return ErrNotFound
+	}
+
+	if s.rankTaken(todoList, rank) {
+		return ErrConflict
+	}
+
+	todoList.Rank = rank
+	s.m.todoLists[id] = todoList
+	return nil
+}
+
+// rankTaken reports whether another todo list ordered along with todoList has the rank. The caller must hold m.mu.
+func (s *memTodoListStore) rankTaken(todoList models.TodoList, rank string) bool {
+	for _, l := range s.m.todoLists {
+		if l.ID != todoList.ID && l.OwnerID == todoList.OwnerID && l.OrganizationID == 0 && todoList.OrganizationID == 0 && l.Rank == rank {
+			return true
+		}
+		if l.ID != todoList.ID && l.OrganizationID != 0 && l.OrganizationID == todoList.OrganizationID && l.Rank == rank {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *memTodoListStore) CountByOrganization(ctx context.Context, organizationID int) (int, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	n := 0
+	for _, l := range s.m.todoLists {
+		if l.OrganizationID == organizationID {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (s *memTodoListStore) Delete(ctx context.Context, id int) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	if _, ok := s.m.todoLists[id]; !ok {
+		return ErrNotFound
+	}
+
+	delete(s.m.todoLists, id)
+	return nil
+}
+
+// accessible reports whether a user sees a todo list in an organization, or outside of organizations when organizationID
+// is zero, like accessibleBy. The caller must hold m.mu.
+func (m *memory) accessible(todoList models.TodoList, userID, organizationID int) bool {
+	if todoList.OrganizationID != organizationID {
+		return false
+	}
+
+	if organizationID != 0 || todoList.OwnerID == userID {
+		return true
+	}
+
+	for _, ms := range m.memberships {
+		if ms.TodoListID == todoList.ID && ms.UserID == userID {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *memTodoListStore) ClearFolder(ctx context.Context, folderID int) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	for id, l := range s.m.todoLists {
+		if l.FolderID == folderID {
+			l.FolderID = 0
+			s.m.todoLists[id] = l
+		}
+	}
+	return nil
+}
+
+type memFolderStore struct{ m *memory }
+
+func (s *memFolderStore) Create(ctx context.Context, folder *models.Folder) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	folder.MyBaseModel = s.m.newBase("folders")
+	s.m.folders[folder.ID] = *folder
+	return nil
+}
+
+func (s *memFolderStore) GetByID(ctx context.Context, id int) (*models.Folder, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	folder, ok := s.m.folders[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &folder, nil
+}
+
+func (s *memFolderStore) List(ctx context.Context, q FolderQuery) ([]models.Folder, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	folders := []models.Folder{}
+	for _, f := range s.m.folders {
+		if f.OrganizationID == q.OrganizationID && (q.OrganizationID != 0 || f.OwnerID == q.UserID) {
+			folders = append(folders, f)
+		}
+	}
+
+	slices.SortFunc(folders, func(a, b models.Folder) int {
+		if cmp := strings.Compare(a.Name, b.Name); cmp != 0 {
+			return cmp
+		}
+		return a.ID - b.ID
+	})
+	return folders, nil
+}
+
+func (s *memFolderStore) Update(ctx context.Context, folder *models.Folder) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	if _, ok := s.m.folders[folder.ID]; !ok {
+		return ErrNotFound
+	}
+
+	s.m.folders[folder.ID] = *folder
+	return nil
+}
+
+func (s *memFolderStore) ClearParent(ctx context.Context, parentID int) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	for id, f := range s.m.folders {
+		if f.ParentID == parentID {
+			f.ParentID = 0
+			s.m.folders[id] = f
+		}
+	}
+	return nil
+}
+
+func (s *memFolderStore) Delete(ctx context.Context, id int) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	if _, ok := s.m.folders[id]; !ok {
+		return ErrNotFound
+	}
+
+	delete(s.m.folders, id)
+	return nil
+}
+
+func (s *memFolderStore) DeleteByOrganization(ctx context.Context, organizationID int) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	maps.DeleteFunc(s.m.folders, func(_ int, f models.Folder) bool { return f.OrganizationID == organizationID })
+	return nil
+}
+
+type memOrganizationStore struct{ m *memory }
+
+func (s *memOrganizationStore) Create(ctx context.Context, organization *models.Organization) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	organization.MyBaseModel = s.m.newBase("organizations")
+	s.m.organizations[organization.ID] = *organization
+	return nil
+}
+
+func (s *memOrganizationStore) GetByID(ctx context.Context, id int) (*models.Organization, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	organization, ok := s.m.organizations[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &organization, nil
+}
+
+func (s *memOrganizationStore) Update(ctx context.Context, organization *models.Organization) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	if _, ok := s.m.organizations[organization.ID]; !ok {
+		return ErrNotFound
+	}
+
+	s.m.organizations[organization.ID] = *organization
+	return nil
+}
+
+func (s *memOrganizationStore) Delete(ctx context.Context, id int) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	if _, ok := s.m.organizations[id]; !ok {
+		return ErrNotFound
+	}
+
+	delete(s.m.organizations, id)
+	return nil
+}
+
+type memOrgMembershipStore struct{ m *memory }
+
+func (s *memOrgMembershipStore) Create(ctx context.Context, membership *models.OrgMembership) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	for _, ms := range s.m.orgMembers {
+		if ms.OrganizationID == membership.OrganizationID && ms.UserID == membership.UserID {
+			return ErrConflict
+		}
+	}
+
+	membership.MyBaseModel = s.m.newBase("org_memberships")
+	stored := *membership
+	stored.Organization, stored.User = nil, nil
+	s.m.orgMembers[membership.ID] = stored
+	return nil
+}
+
+func (s *memOrgMembershipStore) Get(ctx context.Context, organizationID, userID int) (*models.OrgMembership, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	for _, ms := range s.m.orgMembers {
+		if ms.OrganizationID == organizationID && ms.UserID == userID {
+			return &ms, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (s *memOrgMembershipStore) ListByOrganization(ctx context.Context, organizationID int) ([]models.OrgMembership, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	memberships := []models.OrgMembership{}
+	for _, ms := range s.m.orgMembers {
+		if ms.OrganizationID == organizationID {
+			if u, ok := s.m.users[ms.UserID]; ok {
+				ms.User = &u
+			}
+			memberships = append(memberships, ms)
+		}
+	}
+
+	slices.SortFunc(memberships, func(a, b models.OrgMembership) int { return a.ID - b.ID })
+	return memberships, nil
+}
+
+func (s *memOrgMembershipStore) ListByUser(ctx context.Context, userID int) ([]models.OrgMembership, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	memberships := []models.OrgMembership{}
+	for _, ms := range s.m.orgMembers {
+		if ms.UserID == userID {
+			if o, ok := s.m.organizations[ms.OrganizationID]; ok {
+				ms.Organization = &o
+			}
+			memberships = append(memberships, ms)
+		}
+	}
+
+	slices.SortFunc(memberships, func(a, b models.OrgMembership) int { return a.ID - b.ID })
+	return memberships, nil
+}
+
+func (s *memOrgMembershipStore) Update(ctx context.Context, membership *models.OrgMembership) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	if _, ok := s.m.orgMembers[membership.ID]; !ok {
+		return ErrNotFound
+	}
+
+	stored := *membership
+	stored.Organization, stored.User = nil, nil
+	s.m.orgMembers[membership.ID] = stored
+	return nil
+}
+
+func (s *memOrgMembershipStore) Delete(ctx context.Context, id int) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	if _, ok := s.m.orgMembers[id]; !ok {
+		return ErrNotFound
+	}
+
+	delete(s.m.orgMembers, id)
+	return nil
+}
+
+func (s *memOrgMembershipStore) DeleteByOrganization(ctx context.Context, organizationID int) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	maps.DeleteFunc(s.m.orgMembers, func(_ int, ms models.OrgMembership) bool { return ms.OrganizationID == organizationID })
+	return nil
+}
+
+type memMembershipStore struct{ m *memory }
+
+func (s *memMembershipStore) Create(ctx context.Context, membership *models.Membership) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	for _, ms := range s.m.memberships {
+		if ms.TodoListID == membership.TodoListID && ms.UserID == membership.UserID {
+			return ErrConflict
+		}
+	}
+
+	membership.MyBaseModel = s.m.newBase("memberships")
+	stored := *membership
+	stored.User = nil
+	s.m.memberships[membership.ID] = stored
+	return nil
+}
+
+func (s *memMembershipStore) Get(ctx context.Context, todoListID, userID int) (*models.Membership, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	for _, ms := range s.m.memberships {
+		if ms.TodoListID == todoListID && ms.UserID == userID {
+			return &ms, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (s *memMembershipStore) ListByTodoList(ctx context.Context, todoListID int) ([]models.Membership, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	memberships := []models.Membership{}
+	for _, ms := range s.m.memberships {
+		if ms.TodoListID == todoListID {
+			if u, ok := s.m.users[ms.UserID]; ok {
+				ms.User = &u
+			}
+			memberships = append(memberships, ms)
+		}
+	}
+
+	slices.SortFunc(memberships, func(a, b models.Membership) int { return a.ID - b.ID })
+	return memberships, nil
+}
+
+func (s *memMembershipStore) ListByUser(ctx context.Context, userID int) ([]models.Membership, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	memberships := []models.Membership{}
+	for _, ms := range s.m.memberships {
+		if ms.UserID == userID {
+			memberships = append(memberships, ms)
+		}
+	}
+
+	slices.SortFunc(memberships, func(a, b models.Membership) int { return a.ID - b.ID })
+	return memberships, nil
+}
+
+func (s *memMembershipStore) Update(ctx context.Context, membership *models.Membership) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	if _, ok := s.m.memberships[membership.ID]; !ok {
+		return ErrNotFound
+	}
+
+	stored := *membership
+	stored.User = nil
+	s.m.memberships[membership.ID] = stored
+	return nil
+}
+
+func (s *memMembershipStore) Delete(ctx context.Context, id int) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	if _, ok := s.m.memberships[id]; !ok {
+		return ErrNotFound
+	}
+
+	delete(s.m.memberships, id)
+	return nil
+}
+
+func (s *memMembershipStore) DeleteByTodoList(ctx context.Context, todoListID int) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	maps.DeleteFunc(s.m.memberships, func(_ int, ms models.Membership) bool { return ms.TodoListID == todoListID })
+	return nil
+}
+
+func (s *memMembershipStore) DeleteInOrganization(ctx context.Context, organizationID, userID int) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	maps.DeleteFunc(s.m.memberships, func(_ int, ms models.Membership) bool {
+		l, ok := s.m.todoLists[ms.TodoListID]
+		return ok && ms.UserID == userID && l.OrganizationID == organizationID
+	})
+	return nil
+}
+
+type memInvitationStore struct{ m *memory }
+
+func (s *memInvitationStore) Create(ctx context.Context, invitation *models.Invitation) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	for _, inv := range s.m.invitations {
+		if inv.TodoListID == invitation.TodoListID && strings.EqualFold(inv.Email, invitation.Email) {
+			return ErrConflict
+		}
+	}
+
+	invitation.MyBaseModel = s.m.newBase("invitations")
+	stored := *invitation
+	stored.TodoList = nil
+	s.m.invitations[invitation.ID] = stored
+	return nil
+}
+
+// withTodoList returns invitation along with its todo list. The caller must hold m.mu.
+func (s *memInvitationStore) withTodoList(invitation models.Invitation) models.Invitation {
+	if l, ok := s.m.todoLists[invitation.TodoListID]; ok {
+		invitation.TodoList = &l
+	}
+	return invitation
+}
+
+func (s *memInvitationStore) GetByID(ctx context.Context, id int) (*models.Invitation, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	invitation, ok := s.m.invitations[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	invitation = s.withTodoList(invitation)
+	return &invitation, nil
+}
+
+func (s *memInvitationStore) ListByTodoList(ctx context.Context, todoListID int) ([]models.Invitation, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	invitations := []models.Invitation{}
+	for _, inv := range s.m.invitations {
+		if inv.TodoListID == todoListID {
+			invitations = append(invitations, inv)
+		}
+	}
+
+	slices.SortFunc(invitations, func(a, b models.Invitation) int { return a.ID - b.ID })
+	return invitations, nil
+}
+
+func (s *memInvitationStore) ListByEmail(ctx context.Context, email string) ([]models.Invitation, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	invitations := []models.Invitation{}
+	for _, inv := range s.m.invitations {
+		if strings.EqualFold(inv.Email, email) {
+			invitations = append(invitations, s.withTodoList(inv))
+		}
+	}
+
+	slices.SortFunc(invitations, func(a, b models.Invitation) int { return a.ID - b.ID })
+	return invitations, nil
+}
+
+func (s *memInvitationStore) Delete(ctx context.Context, id int) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	if _, ok := s.m.invitations[id]; !ok {
+		return ErrNotFound
+	}
+
+	delete(s.m.invitations, id)
+	return nil
+}
+
+func (s *memInvitationStore) DeleteByTodoList(ctx context.Context, todoListID int) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	maps.DeleteFunc(s.m.invitations, func(_ int, inv models.Invitation) bool { return inv.TodoListID == todoListID })
+	return nil
+}
+
+type memShareLinkStore struct{ m *memory }
+
+func (s *memShareLinkStore) Create(ctx context.Context, link *models.ShareLink) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	for _, l := range s.m.shareLinks {
+		if l.Slug == link.Slug {
+			return ErrConflict
+		}
+	}
+
+	link.MyBaseModel = s.m.newBase("share_links")
+	stored := *link
+	stored.TodoList = nil
+	s.m.shareLinks[link.ID] = stored
+	return nil
+}
+
+func (s *memShareLinkStore) GetByID(ctx context.Context, id int) (*models.ShareLink, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	link, ok := s.m.shareLinks[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	if l, ok := s.m.todoLists[link.TodoListID]; ok {
+		link.TodoList = &l
+	}
+	return &link, nil
+}
+
+func (s *memShareLinkStore) GetBySlug(ctx context.Context, slug string) (*models.ShareLink, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	for _, l := range s.m.shareLinks {
+		if l.Slug == slug {
+			return &l, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (s *memShareLinkStore) ListByTodoList(ctx context.Context, todoListID int) ([]models.ShareLink, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	links := []models.ShareLink{}
+	for _, l := range s.m.shareLinks {
+		if l.TodoListID == todoListID {
+			links = append(links, l)
+		}
+	}
+
+	slices.SortFunc(links, func(a, b models.ShareLink) int { return a.ID - b.ID })
+	return links, nil
+}
+
+func (s *memShareLinkStore) RecordView(ctx context.Context, id int, at time.Time) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	link, ok := s.m.shareLinks[id]
+	if !ok {
+		return ErrNotFound
+	}
+
+	link.Views++
+	link.LastAccessedAt = &at
+	s.m.shareLinks[id] = link
+	return nil
+}
+
+func (s *memShareLinkStore) Delete(ctx context.Context, id int) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	if _, ok := s.m.shareLinks[id]; !ok {
+		return ErrNotFound
+	}
+
+	delete(s.m.shareLinks, id)
+	return nil
+}
+
+func (s *memShareLinkStore) DeleteByTodoList(ctx context.Context, todoListID int) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	maps.DeleteFunc(s.m.shareLinks, func(_ int, l models.ShareLink) bool { return l.TodoListID == todoListID })
+	return nil
+}
+
+type memTodoStore struct{ m *memory }
+
+func (s *memTodoStore) Create(ctx context.Context, todo *models.Todo) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	for _, t := range s.m.todos {
+		if t.TodoListID == todo.TodoListID && t.Rank == todo.Rank {
+			return ErrConflict
+		}
+	}
+
+	todo.MyBaseModel = s.m.newBase("todos")
+	stored := *todo
+	stored.TodoList, stored.Recurrence, stored.Tags = nil, nil, nil
+	s.m.todos[todo.ID] = stored
+	return nil
+}
+
+func (s *memTodoStore) GetByID(ctx context.Context, id int) (*models.Todo, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	todo, ok := s.m.todos[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	if todoList, ok := s.m.todoLists[todo.TodoListID]; ok {
+		todo.TodoList = &todoList
+	}
+	todo.Recurrence = s.m.recurrenceOf(todo.ID)
+	todo.Tags = s.m.tagsOf(todo.ID)
+
+	return &todo, nil
+}
+
+func (s *memTodoStore) GetByText(ctx context.Context, todoListID int, text string) (*models.Todo, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	var found *models.Todo
+	for _, t := range s.m.todos {
+		if t.TodoListID == todoListID && strings.EqualFold(t.Text, text) && (found == nil || t.ID < found.ID) {
+			found = &t
+		}
+	}
+	if found == nil {
+		return nil, ErrNotFound
+	}
+	return found, nil
+}
+
+func (s *memTodoStore) Update(ctx context.Context, todo *models.Todo) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	if _, ok := s.m.todos[todo.ID]; !ok {
+		return ErrNotFound
+	}
+
+	for _, t := range s.m.todos {
+		if t.ID != todo.ID && t.TodoListID == todo.TodoListID && t.Rank == s.m.todos[todo.ID].Rank {
+			return ErrConflict
+		}
+	}
+
+	stored := *todo
+	stored.TodoList, stored.Recurrence, stored.Tags = nil, nil, nil
+	stored.ItemsTotal, stored.ItemsDone = s.m.todos[todo.ID].ItemsTotal, s.m.todos[todo.ID].ItemsDone
+	stored.Rank = s.m.todos[todo.ID].Rank
+	s.m.todos[todo.ID] = stored
+	return nil
+}
+
+func (s *memTodoStore) UpdateProgress(ctx context.Context, todo *models.Todo) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	stored, ok := s.m.todos[todo.ID]
+	if !ok {
+		return ErrNotFound
+	}
+
+	stored.ItemsTotal, stored.ItemsDone = 0, 0
+	for _, item := range s.m.items {
+		if item.TodoID == todo.ID {
+			stored.ItemsTotal++
+			if item.Done {
+				stored.ItemsDone++
+			}
+		}
+	}
+
+	s.m.todos[todo.ID] = stored
+	todo.ItemsTotal, todo.ItemsDone = stored.ItemsTotal, stored.ItemsDone
+	return nil
+}
+
+func (s *memTodoStore) Delete(ctx context.Context, id int) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	if _, ok := s.m.todos[id]; !ok {
+		return ErrNotFound
+	}
+
+	delete(s.m.todos, id)
+	return nil
+}
+
+func (s *memTodoStore) DeleteByTodoList(ctx context.Context, todoListID int) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	maps.DeleteFunc(s.m.todos, func(_ int, t models.Todo) bool { return t.TodoListID == todoListID })
+	return nil
+}
+
+func (s *memTodoStore) List(ctx context.Context, q TodoQuery) ([]models.Todo, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	todos := []models.Todo{}
+	for _, t := range s.m.todos {
+		if l, ok := s.m.todoLists[t.TodoListID]; !ok || !s.m.accessible(l, q.UserID, q.OrganizationID) {
+			continue
+		}
+
+		if len(q.TodoListIDs) > 0 && !slices.Contains(q.TodoListIDs, t.TodoListID) {
+			continue
+		}
+
+		if q.Completed != nil && t.Completed != *q.Completed {
+			continue
+		}
+
+		if q.TextContains != "" && !strings.Contains(strings.ToLower(t.Text), strings.ToLower(q.TextContains)) {
+			continue
+		}
+
+		if !inTimeRange(t.CreatedAt, q.CreatedFrom, q.CreatedTo) || !inTimeRange(t.UpdatedAt, q.UpdatedFrom, q.UpdatedTo) {
+			continue
+		}
+
+		if (q.Due != nil && !q.Due.contains(&t)) || (q.NoDueDate && t.DueAt != nil) {
+			continue
+		}
+
+		if len(q.StatusIDs) > 0 && !slices.Contains(q.StatusIDs, t.StatusID) {
+			continue
+		}
+
+		if len(q.Priorities) > 0 && !slices.Contains(q.Priorities, t.Priority) {
+			continue
+		}
+
+		if q.AssigneeID != nil && t.AssigneeID != *q.AssigneeID {
+			continue
+		}
+
+		if len(q.TagIDs) > 0 && !s.m.hasTags(t.ID, q.TagIDs, q.AllTags) {
+			continue
+		}
+
+		if !afterCursor(todoSortKey(&t, q.Sort.Field), t.ID, q.Sort, q.After) {
+			continue
+		}
+
+		t.Recurrence = s.m.recurrenceOf(t.ID)
+		t.Tags = s.m.tagsOf(t.ID)
+		todos = append(todos, t)
+	}
+
+	slices.SortFunc(todos, func(a, b models.Todo) int {
+		cmp := compareKeys(todoSortKey(&a, q.Sort.Field), todoSortKey(&b, q.Sort.Field))
+		if cmp == 0 {
+			cmp = a.ID - b.ID
+		}
+		if q.Sort.Desc {
+			return -cmp
+		}
+		return cmp
+	})
+
+	if q.Limit > 0 && len(todos) > q.Limit {
+		todos = todos[:q.Limit]
+	}
+
+	return todos, nil
+}
+
+func (s *memTodoStore) AdjacentRank(ctx context.Context, todoListID int, rank string, before bool) (string, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	var ranks []string
+	for _, t := range s.m.todos {
+		if t.TodoListID == todoListID {
+			ranks = append(ranks, t.Rank)
+		}
+	}
+	return nearestRank(ranks, rank, before), nil
+}
+
+func (s *memTodoStore) SetRank(ctx context.Context, id int, rank string) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	todo, ok := s.m.todos[id]
+	if !ok {
+		return ErrNotFound
+	}
+
+	for _, t := range s.m.todos {
+		if t.ID != id && t.TodoListID == todo.TodoListID && t.Rank == rank {
+			return ErrConflict
+		}
+	}
+
+	todo.Rank = rank
+	s.m.todos[id] = todo
+	return nil
+}
+
+func (s *memTodoStore) MoveStatus(ctx context.Context, from, to int) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	for id, t := range s.m.todos {
+		if t.StatusID == from {
+			t.StatusID = to
+			s.m.todos[id] = t
+		}
+	}
+	return nil
+}
+
+func (s *memTodoStore) Unassign(ctx context.Context, todoListID, userID int) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	for id, t := range s.m.todos {
+		if t.TodoListID == todoListID && t.AssigneeID == userID {
+			t.AssigneeID = 0
+			s.m.todos[id] = t
+		}
+	}
+	return nil
+}
+
+func (s *memTodoStore) UnassignInOrganization(ctx context.Context, organizationID, userID int) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	for id, t := range s.m.todos {
+		if l, ok := s.m.todoLists[t.TodoListID]; ok && l.OrganizationID == organizationID && t.AssigneeID == userID {
+			t.AssigneeID = 0
+			s.m.todos[id] = t
+		}
+	}
+	return nil
+}
+
+func (s *memTodoStore) CompleteStatus(ctx context.Context, statusID int, completedAt *time.Time) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	for id, t := range s.m.todos {
+		if t.StatusID == statusID && t.Completed != (completedAt != nil) {
+			t.Completed, t.CompletedAt = completedAt != nil, completedAt
+			s.m.todos[id] = t
+		}
+	}
+	return nil
+}
+
+type memStatusStore struct{ m *memory }
+
+func (s *memStatusStore) Create(ctx context.Context, status *models.Status) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	status.MyBaseModel = s.m.newBase("statuses")
+	status.Position = 1
+	for _, st := range s.m.statuses {
+		if st.TodoListID == status.TodoListID && st.Position >= status.Position {
+			status.Position = st.Position + 1
+		}
+	}
+
+	stored := *status
+	stored.TodoList = nil
+	s.m.statuses[status.ID] = stored
+	return nil
+}
+
+func (s *memStatusStore) GetByID(ctx context.Context, id int) (*models.Status, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	status, ok := s.m.statuses[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	if l, ok := s.m.todoLists[status.TodoListID]; ok {
+		status.TodoList = &l
+	}
+
+	return &status, nil
+}
+
+func (s *memStatusStore) ListByTodoList(ctx context.Context, todoListID int) ([]models.Status, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	statuses := []models.Status{}
+	for _, st := range s.m.statuses {
+		if st.TodoListID == todoListID {
+			statuses = append(statuses, st)
+		}
+	}
+
+	slices.SortFunc(statuses, func(a, b models.Status) int {
+		if a.Position != b.Position {
+			return a.Position - b.Position
+		}
+		return a.ID - b.ID
+	})
+	return statuses, nil
+}
+
+func (s *memStatusStore) Update(ctx context.Context, status *models.Status) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	if _, ok := s.m.statuses[status.ID]; !ok {
+		return ErrNotFound
+	}
+
+	stored := *status
+	stored.TodoList = nil
+	s.m.statuses[status.ID] = stored
+	return nil
+}
+
+func (s *memStatusStore) Reorder(ctx context.Context, todoListID int, ids []int) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	for i, id := range ids {
+		if status, ok := s.m.statuses[id]; ok && status.TodoListID == todoListID {
+			status.Position = i + 1
+			s.m.statuses[id] = status
+		}
+	}
+	return nil
+}
+
+func (s *memStatusStore) Delete(ctx context.Context, id int) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	if _, ok := s.m.statuses[id]; !ok {
+		return ErrNotFound
+	}
+
+	delete(s.m.statuses, id)
+	return nil
+}
+
+func (s *memStatusStore) DeleteByTodoList(ctx context.Context, todoListID int) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	maps.DeleteFunc(s.m.statuses, func(_ int, st models.Status) bool { return st.TodoListID == todoListID })
+	return nil
+}
+
+// hasTags reports whether a todo has any of tagIDs, or all of them if all is set. The caller must hold m.mu.
+func (m *memory) hasTags(todoID int, tagIDs []int, all bool) bool {
+	for _, tagID := range tagIDs {
+		_, ok := m.todoTags[todoTag{todoID, tagID}]
+		if ok != all {
+			return ok
+		}
+	}
+	return all
+}
+
+type memTagStore struct{ m *memory }
+
+// nameTaken reports whether the owner of tag has another tag with its name. The caller must hold m.mu.
+func (s *memTagStore) nameTaken(tag *models.Tag) bool {
+	for _, t := range s.m.tags {
+		if t.ID != tag.ID && t.OwnerID == tag.OwnerID && strings.EqualFold(t.Name, tag.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+// withCount returns tag along with its color and the number of todos it labels. The caller must hold m.mu.
+func (s *memTagStore) withCount(tag models.Tag) models.Tag {
+	if tag.ColorID != nil {
+		if color, ok := s.m.colors[*tag.ColorID]; ok {
+			tag.Color = &color
+		}
+	}
+
+	for link := range s.m.todoTags {
+		if link.tagID == tag.ID {
+			tag.TodoCount++
+		}
+	}
+	return tag
+}
+
+func (s *memTagStore) Create(ctx context.Context, tag *models.Tag) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	if s.nameTaken(tag) {
+		return ErrConflict
+	}
+
+	tag.MyBaseModel = s.m.newBase("tags")
+	stored := *tag
+	stored.Color, stored.TodoCount = nil, 0
+	s.m.tags[tag.ID] = stored
+	return nil
+}
+
+func (s *memTagStore) GetByID(ctx context.Context, id int) (*models.Tag, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	tag, ok := s.m.tags[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	tag = s.withCount(tag)
+	return &tag, nil
+}
+
+func (s *memTagStore) List(ctx context.Context, q TagQuery) ([]models.Tag, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	tags := []models.Tag{}
+	for _, t := range s.m.tags {
+		if t.OwnerID != q.OwnerID || (q.IDs != nil && !slices.Contains(q.IDs, t.ID)) {
+			continue
+		}
+		tags = append(tags, s.withCount(t))
+	}
+
+	slices.SortFunc(tags, compareTags)
+	return tags, nil
+}
+
+func (s *memTagStore) Update(ctx context.Context, tag *models.Tag) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	if _, ok := s.m.tags[tag.ID]; !ok {
+		return ErrNotFound
+	}
+
+	if s.nameTaken(tag) {
+		return ErrConflict
+	}
+
+	stored := *tag
+	stored.Color, stored.TodoCount = nil, 0
+	s.m.tags[tag.ID] = stored
+	return nil
+}
+
+func (s *memTagStore) Delete(ctx context.Context, id int) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	if _, ok := s.m.tags[id]; !ok {
+		return ErrNotFound
+	}
+
+	maps.DeleteFunc(s.m.todoTags, func(link todoTag, _ struct{}) bool { return link.tagID == id })
+	delete(s.m.tags, id)
+	return nil
+}
+
+func (s *memTagStore) Attach(ctx context.Context, todoID, tagID int) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	s.m.todoTags[todoTag{todoID, tagID}] = struct{}{}
+	return nil
+}
+
+func (s *memTagStore) Detach(ctx context.Context, todoID, tagID int) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	delete(s.m.todoTags, todoTag{todoID, tagID})
+	return nil
+}
+
+func (s *memTagStore) SetTodoTags(ctx context.Context, todoID int, tagIDs []int) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	maps.DeleteFunc(s.m.todoTags, func(link todoTag, _ struct{}) bool { return link.todoID == todoID })
+	for _, tagID := range tagIDs {
+		s.m.todoTags[todoTag{todoID, tagID}] = struct{}{}
+	}
+	return nil
+}
+
+func (s *memTagStore) DetachTodoList(ctx context.Context, todoListID int) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	maps.DeleteFunc(s.m.todoTags, func(link todoTag, _ struct{}) bool {
+		return s.m.todos[link.todoID].TodoListID == todoListID
+	})
+	return nil
+}
+
+type memSearchStore struct{ m *memory }
+
+func (s *memSearchStore) Search(ctx context.Context, q SearchQuery) ([]SearchHit, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	hits := []SearchHit{}
+	if len(q.Terms) == 0 {
+		return hits, nil
+	}
+
+	for _, l := range s.m.todoLists {
+		if !s.m.accessible(l, q.UserID, q.OrganizationID) {
+			continue
+		}
+
+		if snippet, score, ok := matchText(l.Name, q.Terms); ok {
+			hits = append(hits, SearchHit{TodoList: l, Rank: score, Snippet: snippet})
+		}
+	}
+
+	for _, t := range s.m.todos {
+		l, ok := s.m.todoLists[t.TodoListID]
+		if !ok || !s.m.accessible(l, q.UserID, q.OrganizationID) {
+			continue
+		}
+
+		if snippet, score, ok := matchText(strings.TrimSpace(t.Text+"\n"+t.Notes), q.Terms); ok {
+			t.TodoList = &l
+			hits = append(hits, SearchHit{Todo: &t, TodoList: l, Rank: score, Snippet: snippet})
+		}
+	}
+
+	// Break ties by ID like the database does.
+	slices.SortFunc(hits, func(a, b SearchHit) int {
+		if a.Todo != nil && b.Todo != nil {
+			return a.Todo.ID - b.Todo.ID
+		}
+		return a.TodoList.ID - b.TodoList.ID
+	})
+
+	return sortHits(hits, q.Limit), nil
+}
+
+type memChecklistItemStore struct{ m *memory }
+
+func (s *memChecklistItemStore) Create(ctx context.Context, item *models.ChecklistItem) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	item.MyBaseModel = s.m.newBase("checklist_items")
+	item.Position = 1
+	for _, i := range s.m.items {
+		if i.TodoID == item.TodoID && i.Position >= item.Position {
+			item.Position = i.Position + 1
+		}
+	}
+
+	stored := *item
+	stored.Todo = nil
+	s.m.items[item.ID] = stored
+	return nil
+}
+
+func (s *memChecklistItemStore) GetByID(ctx context.Context, id int) (*models.ChecklistItem, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	item, ok := s.m.items[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	if t, ok := s.m.todos[item.TodoID]; ok {
+		if l, ok := s.m.todoLists[t.TodoListID]; ok {
+			t.TodoList = &l
+		}
+		item.Todo = &t
+	}
+
+	return &item, nil
+}
+
+func (s *memChecklistItemStore) ListByTodo(ctx context.Context, todoID int) ([]models.ChecklistItem, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	items := []models.ChecklistItem{}
+	for _, i := range s.m.items {
+		if i.TodoID == todoID {
+			items = append(items, i)
+		}
+	}
+
+	slices.SortFunc(items, func(a, b models.ChecklistItem) int {
+		if a.Position != b.Position {
+			return a.Position - b.Position
+		}
+		return a.ID - b.ID
+	})
+	return items, nil
+}
+
+func (s *memChecklistItemStore) Update(ctx context.Context, item *models.ChecklistItem) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	if _, ok := s.m.items[item.ID]; !ok {
+		return ErrNotFound
+	}
+
+	stored := *item
+	stored.Todo = nil
+	s.m.items[item.ID] = stored
+	return nil
+}
+
+func (s *memChecklistItemStore) Reorder(ctx context.Context, todoID int, ids []int) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	for i, id := range ids {
+		if item, ok := s.m.items[id]; ok && item.TodoID == todoID {
+			item.Position = i + 1
+			s.m.items[id] = item
+		}
+	}
+	return nil
+}
+
+func (s *memChecklistItemStore) UncheckAll(ctx context.Context, todoID int) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	for id, item := range s.m.items {
+		if item.TodoID == todoID {
+			item.Done = false
+			s.m.items[id] = item
+		}
+	}
+	return nil
+}
+
+func (s *memChecklistItemStore) Delete(ctx context.Context, id int) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	if _, ok := s.m.items[id]; !ok {
+		return ErrNotFound
+	}
+
+	delete(s.m.items, id)
+	return nil
+}
+
+func (s *memChecklistItemStore) DeleteByTodo(ctx context.Context, todoID int) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	maps.DeleteFunc(s.m.items, func(_ int, i models.ChecklistItem) bool { return i.TodoID == todoID })
+	return nil
+}
+
+func (s *memChecklistItemStore) DeleteByTodoList(ctx context.Context, todoListID int) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	maps.DeleteFunc(s.m.items, func(_ int, i models.ChecklistItem) bool {
+		t, ok := s.m.todos[i.TodoID]
+		return ok && t.TodoListID == todoListID
+	})
+	return nil
+}
+
+type memRecurrenceStore struct{ m *memory }
+
+func (s *memRecurrenceStore) Create(ctx context.Context, recurrence *models.Recurrence) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	if s.m.recurrenceOf(recurrence.TodoID) != nil {
+		return ErrConflict
+	}
+
+	recurrence.MyBaseModel = s.m.newBase("todo_recurrences")
+	s.m.recurrences[recurrence.ID] = *recurrence
+	return nil
+}
+
+func (s *memRecurrenceStore) GetByTodo(ctx context.Context, todoID int) (*models.Recurrence, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	r := s.m.recurrenceOf(todoID)
+	if r == nil {
+		return nil, ErrNotFound
+	}
+
+	return r, nil
+}
+
+func (s *memRecurrenceStore) Update(ctx context.Context, recurrence *models.Recurrence) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	if _, ok := s.m.recurrences[recurrence.ID]; !ok {
+		return ErrNotFound
+	}
+
+	s.m.recurrences[recurrence.ID] = *recurrence
+	return nil
+}
+
+func (s *memRecurrenceStore) Delete(ctx context.Context, id int) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	if _, ok := s.m.recurrences[id]; !ok {
+		return ErrNotFound
+	}
+
+	delete(s.m.recurrences, id)
+	return nil
+}
+
+func (s *memRecurrenceStore) DeleteByTodo(ctx context.Context, todoID int) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	maps.DeleteFunc(s.m.recurrences, func(_ int, r models.Recurrence) bool { return r.TodoID == todoID })
+	return nil
+}
+
+func (s *memRecurrenceStore) DeleteByTodoList(ctx context.Context, todoListID int) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	maps.DeleteFunc(s.m.recurrences, func(_ int, r models.Recurrence) bool {
+		t, ok := s.m.todos[r.TodoID]
+		return ok && t.TodoListID == todoListID
+	})
+	return nil
+}
+
+type memCompletionStore struct{ m *memory }
+
+func (s *memCompletionStore) Create(ctx context.Context, completion *models.Completion) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	completion.MyBaseModel = s.m.newBase("todo_completions")
+	s.m.completions[completion.ID] = *completion
+	return nil
+}
+
+func (s *memCompletionStore) List(ctx context.Context, q CompletionQuery) ([]models.Completion, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	completions := []models.Completion{}
+	for _, c := range s.m.completions {
+		if c.TodoID != q.TodoID || !afterCursor(c.CompletedAt, c.ID, CompletionSort, q.After) {
+			continue
+		}
+
+		completions = append(completions, c)
+	}
+
+	slices.SortFunc(completions, func(a, b models.Completion) int {
+		if c := b.CompletedAt.Compare(a.CompletedAt); c != 0 {
+			return c
+		}
+		return b.ID - a.ID
+	})
+
+	if q.Limit > 0 && len(completions) > q.Limit {
+		completions = completions[:q.Limit]
+	}
+
+	return completions, nil
+}
+
+func (s *memCompletionStore) DeleteByTodo(ctx context.Context, todoID int) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	maps.DeleteFunc(s.m.completions, func(_ int, c models.Completion) bool { return c.TodoID == todoID })
+	return nil
+}
+
+func (s *memCompletionStore) DeleteByTodoList(ctx context.Context, todoListID int) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	maps.DeleteFunc(s.m.completions, func(_ int, c models.Completion) bool {
+		t, ok := s.m.todos[c.TodoID]
+		return ok && t.TodoListID == todoListID
+	})
+	return nil
+}
+
+type memCommentStore struct{ m *memory }
+
+func (s *memCommentStore) Create(ctx context.Context, comment *models.Comment) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	comment.MyBaseModel = s.m.newBase("comments")
+	stored := *comment
+	stored.Todo = nil
+	s.m.comments[comment.ID] = stored
+	return nil
+}
+
+func (s *memCommentStore) GetByID(ctx context.Context, id int) (*models.Comment, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	c, ok := s.m.comments[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	if t, ok := s.m.todos[c.TodoID]; ok {
+		if l, ok := s.m.todoLists[t.TodoListID]; ok {
+			t.TodoList = &l
+		}
+		c.Todo = &t
+	}
+
+	return &c, nil
+}
+
+func (s *memCommentStore) List(ctx context.Context, q CommentQuery) ([]models.Comment, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	comments := []models.Comment{}
+	for _, c := range s.m.comments {
+		if c.TodoID != q.TodoID || !afterCursor(c.CreatedAt, c.ID, CommentSort, q.After) {
+			continue
+		}
+
+		comments = append(comments, c)
+	}
+
+	slices.SortFunc(comments, func(a, b models.Comment) int {
+		if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+			return c
+		}
+		return a.ID - b.ID
+	})
+
+	if q.Limit > 0 && len(comments) > q.Limit {
+		comments = comments[:q.Limit]
+	}
+
+	return comments, nil
+}
+
+func (s *memCommentStore) Update(ctx context.Context, comment *models.Comment) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	if _, ok := s.m.comments[comment.ID]; !ok {
+		return ErrNotFound
+	}
+
+	stored := *comment
+	stored.Todo = nil
+	s.m.comments[comment.ID] = stored
+	return nil
+}
+
+func (s *memCommentStore) Delete(ctx context.Context, id int) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	if _, ok := s.m.comments[id]; !ok {
+		return ErrNotFound
+	}
+
+	delete(s.m.comments, id)
+	return nil
+}
+
+func (s *memCommentStore) DeleteByTodo(ctx context.Context, todoID int) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	maps.DeleteFunc(s.m.comments, func(_ int, c models.Comment) bool { return c.TodoID == todoID })
+	return nil
+}
+
+func (s *memCommentStore) DeleteByTodoList(ctx context.Context, todoListID int) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	maps.DeleteFunc(s.m.comments, func(_ int, c models.Comment) bool {
+		t, ok := s.m.todos[c.TodoID]
+		return ok && t.TodoListID == todoListID
+	})
+	return nil
+}
+
+type memReminderStore struct{ m *memory }
+
+func (s *memReminderStore) Create(ctx context.Context, reminder *models.Reminder) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	reminder.MyBaseModel = s.m.newBase("reminders")
+	if reminder.Status == "" {
+		reminder.Status = models.ReminderPending
+	}
+
+	stored := *reminder
+	stored.Todo = nil
+	s.m.reminders[reminder.ID] = stored
+	return nil
+}
+
+func (s *memReminderStore) GetByID(ctx context.Context, id int) (*models.Reminder, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	r, ok := s.m.reminders[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	if t, ok := s.m.todos[r.TodoID]; ok {
+		if l, ok := s.m.todoLists[t.TodoListID]; ok {
+			t.TodoList = &l
+		}
+		r.Todo = &t
+	}
+
+	return &r, nil
+}
+
+func (s *memReminderStore) ListByTodo(ctx context.Context, todoID int) ([]models.Reminder, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	reminders := []models.Reminder{}
+	for _, r := range s.m.reminders {
+		if r.TodoID == todoID {
+			reminders = append(reminders, r)
+		}
+	}
+
+	slices.SortFunc(reminders, func(a, b models.Reminder) int { return a.ID - b.ID })
+	return reminders, nil
+}
+
+func (s *memReminderStore) Update(ctx context.Context, reminder *models.Reminder) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	current, ok := s.m.reminders[reminder.ID]
+	if !ok {
+		return ErrNotFound
+	}
+
+	stored := *reminder
+	stored.Todo = nil
+	stored.ClaimToken, stored.ClaimedUntil = current.ClaimToken, current.ClaimedUntil
+	s.m.reminders[reminder.ID] = stored
+	return nil
+}
+
+func (s *memReminderStore) Delete(ctx context.Context, id int) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	if _, ok := s.m.reminders[id]; !ok {
+		return ErrNotFound
+	}
+
+	delete(s.m.reminders, id)
+	return nil
+}
+
+func (s *memReminderStore) DeleteByTodo(ctx context.Context, todoID int) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	maps.DeleteFunc(s.m.reminders, func(_ int, r models.Reminder) bool { return r.TodoID == todoID })
+	return nil
+}
+
+func (s *memReminderStore) DeleteByTodoList(ctx context.Context, todoListID int) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	maps.DeleteFunc(s.m.reminders, func(_ int, r models.Reminder) bool {
+		t, ok := s.m.todos[r.TodoID]
+		return ok && t.TodoListID == todoListID
+	})
+	return nil
+}
+
+func (s *memReminderStore) Claim(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]models.Reminder, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	reminders := []models.Reminder{}
+	for _, r := range s.m.reminders {
+		if r.Status == models.ReminderPending && r.FireAt != nil && !r.FireAt.After(now) &&
+			(r.ClaimedUntil == nil || !r.ClaimedUntil.After(now)) {
+			reminders = append(reminders, r)
+		}
+	}
+
+	slices.SortFunc(reminders, func(a, b models.Reminder) int {
+		if c := a.FireAt.Compare(*b.FireAt); c != 0 {
+			return c
+		}
+		return a.ID - b.ID
+	})
+
+	if len(reminders) > limit {
+		reminders = reminders[:limit]
+	}
+
+	token, until := rand.Text(), now.Add(lease)
+	for i := range reminders {
+		reminders[i].ClaimToken, reminders[i].ClaimedUntil = token, &until
+		reminders[i].Attempts++
+		s.m.reminders[reminders[i].ID] = reminders[i]
+	}
+
+	return reminders, nil
+}
+
+func (s *memReminderStore) Renew(ctx context.Context, reminder *models.Reminder, now time.Time, lease time.Duration) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	stored, ok := s.m.reminders[reminder.ID]
+	if !ok || stored.ClaimToken != reminder.ClaimToken {
+		return ErrNotFound
+	}
+
+	until := now.Add(lease)
+	stored.ClaimedUntil = &until
+	s.m.reminders[reminder.ID] = stored
+
+	reminder.ClaimedUntil = &until
+	return nil
+}
+
+func (s *memReminderStore) Release(ctx context.Context, reminder *models.Reminder) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	stored, ok := s.m.reminders[reminder.ID]
+	if !ok || stored.ClaimToken != reminder.ClaimToken {
+		return ErrNotFound
+	}
+
+	stored.Status, stored.FireAt, stored.LastError, stored.SentAt = reminder.Status, reminder.FireAt, reminder.LastError, reminder.SentAt
+	stored.UpdatedAt = reminder.UpdatedAt
+	stored.ClaimToken, stored.ClaimedUntil = "", nil
+	s.m.reminders[reminder.ID] = stored
+
+	reminder.ClaimToken, reminder.ClaimedUntil = "", nil
+	return nil
+}
+
+type memNotificationStore struct{ m *memory }
+
+func (s *memNotificationStore) Create(ctx context.Context, notification *models.Notification) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	notification.MyBaseModel = s.m.newBase("notifications")
+	s.m.notifications[notification.ID] = *notification
+	return nil
+}
+
+func (s *memNotificationStore) GetByID(ctx context.Context, id int) (*models.Notification, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	n, ok := s.m.notifications[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	return &n, nil
+}
+
+func (s *memNotificationStore) List(ctx context.Context, q NotificationQuery) ([]models.Notification, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	notifications := []models.Notification{}
+	for _, n := range s.m.notifications {
+		if n.UserID != q.UserID || (q.Unread && n.ReadAt != nil) {
+			continue
+		}
+
+		if !afterCursor(n.CreatedAt, n.ID, NotificationSort, q.After) {
+			continue
+		}
+
+		notifications = append(notifications, n)
+	}
+
+	slices.SortFunc(notifications, func(a, b models.Notification) int {
+		if c := b.CreatedAt.Compare(a.CreatedAt); c != 0 {
+			return c
+		}
+		return b.ID - a.ID
+	})
+
+	if q.Limit > 0 && len(notifications) > q.Limit {
+		notifications = notifications[:q.Limit]
+	}
+
+	return notifications, nil
+}
+
+func (s *memNotificationStore) Update(ctx context.Context, notification *models.Notification) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	if _, ok := s.m.notifications[notification.ID]; !ok {
+		return ErrNotFound
+	}
+
+	s.m.notifications[notification.ID] = *notification
+	return nil
+}
@@ -0,0 +1,50 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/marouane-ach/todo-go/models"
+)
+
+func TestMemoryClock(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, time.January, 2, 3, 4, 5, 0, time.FixedZone("CET", 3600))
+	st := NewMemory(func() time.Time { return now })
+
+	user := &models.User{Email: "a@b.com"}
+	if err := st.Users.Create(context.Background(), user); err != nil {
+		t.Fatal(err)
+	}
+
+	if !user.CreatedAt.Equal(now) || user.CreatedAt.Location() != time.UTC || !user.UpdatedAt.Equal(now) {
+		t.Errorf("created at %v, updated at %v, want %v in UTC", user.CreatedAt, user.UpdatedAt, now)
+	}
+}
+
+func TestMemoryIsolation(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	a, b := NewMemory(nil), NewMemory(nil)
+
+	for _, st := range []*Store{a, b} {
+		user := &models.User{Email: "a@b.com"}
+		if err := st.Users.Create(ctx, user); err != nil {
+			t.Fatal(err)
+		}
+		if user.ID != 1 {
+			t.Errorf("ID = %d, want 1", user.ID)
+		}
+	}
+
+	if err := a.Users.Create(ctx, &models.User{Email: "a@b.com"}); !errors.Is(err, ErrConflict) {
+		t.Errorf("Create with a taken email = %v, want %v", err, ErrConflict)
+	}
+	if _, err := b.Users.GetByID(ctx, 2); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetByID(2) = %v, want %v", err, ErrNotFound)
+	}
+}
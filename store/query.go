@@ -0,0 +1,225 @@
+package store
+
+import (
+	"time"
+
+	"github.com/marouane-ach/todo-go/models"
+)
+
+// Sort orders a listing by one field. Ties are always broken by ID in the same direction.
+type Sort struct {
+	Field string
+	Desc  bool
+}
+
+// Cursor marks where a page starts: the sort key and the ID of the last row of the previous page.
+// Value is a string or a time.Time depending on the sort field.
+type Cursor struct {
+	Value any
+	ID    int
+}
+
+// TodoListSortFields are the fields todo lists can be sorted by.
+var TodoListSortFields = []string{"rank", "name", "created_at", "updated_at"}
+
+// TodoListQuery selects a page of the todo lists a user owns or is a member of.
+type TodoListQuery struct {
+	UserID int
+	// OrganizationID scopes the query to the lists of an organization, which the user must be a member of,
+	// or to the lists outside of organizations when it is zero.
+	OrganizationID int
+	// ColorID keeps the lists of this color when it is not zero.
+	ColorID int
+	// HasIncomplete keeps the lists with (true) or without (false) incomplete todos when it is not nil.
+	HasIncomplete *bool
+	// FolderIDs keeps the lists filed in these folders when it is not empty.
+	FolderIDs []int
+	// Sort.Field must be one of TodoListSortFields.
+	Sort  Sort
+	After *Cursor
+	// Limit caps the number of lists returned when it is not zero.
+	Limit int
+	// OmitTodos leaves the Todos of every list empty.
+	OmitTodos bool
+	// TodosLimit caps the number of todos embedded in each list when it is not zero.
+	TodosLimit int
+}
+
+// TodoListCursor returns the cursor pointing right after todoList in a listing sorted by field.
+func TodoListCursor(todoList *models.TodoList, field string) Cursor {
+	return Cursor{Value: todoListSortKey(todoList, field), ID: todoList.ID}
+}
+
+func todoListSortKey(todoList *models.TodoList, field string) any {
+	switch field {
+	case "rank":
+		return todoList.Rank
+	case "name":
+		return todoList.Name
+	case "updated_at":
+		return todoList.UpdatedAt
+	default:
+		return todoList.CreatedAt
+	}
+}
+
+// compareKeys orders two sort keys of the same type.
+func compareKeys(a, b any) int {
+	switch a := a.(type) {
+	case string:
+		b := b.(string)
+		if a < b {
+			return -1
+		} else if a > b {
+			return 1
+		}
+		return 0
+	case time.Time:
+		return a.Compare(b.(time.Time))
+	}
+
+	return 0
+}
+
+// afterCursor reports whether a row with the given key and ID comes after the cursor in sort order.
+func afterCursor(key any, id int, sort Sort, after *Cursor) bool {
+	if after == nil {
+		return true
+	}
+
+	cmp := compareKeys(key, after.Value)
+	if cmp == 0 {
+		cmp = id - after.ID
+	}
+
+	if sort.Desc {
+		return cmp < 0
+	}
+	return cmp > 0
+}
+
+// TodoSortFields are the fields todos can be sorted by.
+var TodoSortFields = []string{"rank", "text", "created_at", "updated_at"}
+
+// TodoQuery selects a page of the todos in the todo lists a user owns or is a member of.
+type TodoQuery struct {
+	UserID int
+	// OrganizationID scopes the query like TodoListQuery.OrganizationID does.
+	OrganizationID int
+	// TodoListIDs keeps the todos of these lists when it is not empty.
+	TodoListIDs []int
+	// Completed keeps the completed (true) or incomplete (false) todos when it is not nil.
+	Completed *bool
+	// TextContains keeps the todos whose text contains it, ignoring case.
+	TextContains string
+	// The time ranges include their start and exclude their end. Zero bounds are ignored.
+	CreatedFrom, CreatedTo time.Time
+	UpdatedFrom, UpdatedTo time.Time
+	// Due keeps the todos due within it when it is not nil.
+	Due *DueRange
+	// NoDueDate keeps the todos without a due date.
+	NoDueDate bool
+	// TagIDs keeps the todos with any of these tags, or all of them if AllTags is set, when it is not empty.
+	TagIDs  []int
+	AllTags bool
+	// StatusIDs and Priorities keep the todos in these statuses and with these priorities when they are not empty.
+	StatusIDs  []int
+	Priorities []int
+	// AssigneeID keeps the todos assigned to this user when it is not nil, or the unassigned todos when it points to 0.
+	AssigneeID *int
+	// Sort.Field must be one of TodoSortFields.
+	Sort  Sort
+	After *Cursor
+	// Limit caps the number of todos returned when it is not zero.
+	Limit int
+}
+
+// DueRange selects todos by due date. Todos due at a given time are compared to [From, To),
+// while todos due on a whole day are compared to [FromDate, ToDate), two midnights UTC,
+// because they are due on the same day wherever the user is. Zero bounds are ignored.
+type DueRange struct {
+	From, To         time.Time
+	FromDate, ToDate time.Time
+}
+
+// contains reports whether todo is due within r.
+func (r *DueRange) contains(todo *models.Todo) bool {
+	if todo.DueAt == nil {
+		return false
+	}
+	if todo.AllDay {
+		return inTimeRange(*todo.DueAt, r.FromDate, r.ToDate)
+	}
+	return inTimeRange(*todo.DueAt, r.From, r.To)
+}
+
+// TodoCursor returns the cursor pointing right after todo in a listing sorted by field.
+func TodoCursor(todo *models.Todo, field string) Cursor {
+	return Cursor{Value: todoSortKey(todo, field), ID: todo.ID}
+}
+
+func todoSortKey(todo *models.Todo, field string) any {
+	switch field {
+	case "rank":
+		return todo.Rank
+	case "text":
+		return todo.Text
+	case "updated_at":
+		return todo.UpdatedAt
+	default:
+		return todo.CreatedAt
+	}
+}
+
+// inTimeRange reports whether t is within [from, to), ignoring zero bounds.
+func inTimeRange(t, from, to time.Time) bool {
+	return (from.IsZero() || !t.Before(from)) && (to.IsZero() || t.Before(to))
+}
+
+// NotificationSort is the order of the notifications.
+var NotificationSort = Sort{Field: "created_at", Desc: true}
+
+// NotificationQuery selects a page of the notifications of one user.
+type NotificationQuery struct {
+	UserID int
+	// Unread keeps the notifications that weren't read yet.
+	Unread bool
+	After  *Cursor
+	// Limit caps the number of notifications returned when it is not zero.
+	Limit int
+}
+
+// CompletionSort is the order of the completions.
+var CompletionSort = Sort{Field: "completed_at", Desc: true}
+
+// CompletionQuery selects a page of the completions of one todo.
+type CompletionQuery struct {
+	TodoID int
+	After  *Cursor
+	// Limit caps the number of completions returned when it is not zero.
+	Limit int
+}
+
+// CommentSort is the order of the comments.
+var CommentSort = Sort{Field: "created_at"}
+
+// CommentQuery selects a page of the comments of one todo.
+type CommentQuery struct {
+	TodoID int
+	After  *Cursor
+	// Limit caps the number of comments returned when it is not zero.
+	Limit int
+}
+
+// FolderQuery selects the folders of a user outside of organizations, or of an organization when OrganizationID is not zero.
+type FolderQuery struct {
+	UserID         int
+	OrganizationID int
+}
+
+// TagQuery selects the tags of one owner.
+type TagQuery struct {
+	OwnerID int
+	// IDs keeps these tags when it is not nil.
+	IDs []int
+}
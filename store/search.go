@@ -0,0 +1,173 @@
+package store
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/marouane-ach/todo-go/models"
+)
+
+// The matched words of a snippet are wrapped in these private use characters, which can't be
+// typed in a todo, so that callers can highlight them however they like.
+const (
+	HighlightStart = "\uE000"
+	HighlightEnd   = "\uE001"
+)
+
+// SearchTerm is one word or quoted phrase of a search. Every term must match.
+type SearchTerm struct {
+	// Words are matched next to each other, in order, ignoring case.
+	Words []string
+	// Prefix lets the last word match any word it starts.
+	Prefix bool
+}
+
+// SearchQuery selects the todos and todo lists matching every term among the todo lists a user owns or is a member of.
+type SearchQuery struct {
+	UserID int
+	// OrganizationID scopes the query like TodoListQuery.OrganizationID does.
+	OrganizationID int
+	Terms          []SearchTerm
+	// Limit caps the number of hits returned when it is not zero.
+	Limit int
+}
+
+// SearchHit is a todo or, when Todo is nil, a todo list matching a search.
+type SearchHit struct {
+	Todo *models.Todo
+	// TodoList is the list the todo belongs to, or the matching list itself. Its Todos are not loaded.
+	TodoList models.TodoList
+	// Rank orders the hits, the higher the better. It only compares hits of the same search.
+	Rank float64
+	// Snippet is the part of the text, notes or name around the matches, which are
+	// wrapped in HighlightStart and HighlightEnd.
+	Snippet string
+}
+
+// ParseSearch splits a search string into terms. Text between double quotes is a phrase
+// and a word (or closing quote) followed by * is a prefix. Punctuation separates words the way
+// the full-text indexes do, so "e-mail" is the phrase "e mail".
+func ParseSearch(s string) []SearchTerm {
+	var terms []SearchTerm
+	add := func(text string, prefix bool) {
+		if words := searchWords(text); len(words) > 0 {
+			terms = append(terms, SearchTerm{Words: words, Prefix: prefix})
+		}
+	}
+
+	for s != "" {
+		var text string
+		if s[0] == '"' {
+			end := strings.IndexByte(s[1:], '"')
+			if end < 0 {
+				// An unterminated quote runs to the end of the search.
+				text, s = s[1:], ""
+			} else {
+				text, s = s[1:end+1], s[end+2:]
+			}
+		} else {
+			end := strings.IndexFunc(s, func(r rune) bool { return unicode.IsSpace(r) || r == '"' })
+			if end < 0 {
+				end = len(s)
+			}
+			text, s = s[:end], s[end:]
+		}
+
+		prefix := strings.HasSuffix(text, "*") || strings.HasPrefix(s, "*")
+		s = strings.TrimLeftFunc(strings.TrimPrefix(s, "*"), unicode.IsSpace)
+		add(text, prefix)
+	}
+
+	return terms
+}
+
+// searchWords returns the lower-cased runs of letters and digits of s.
+func searchWords(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// sortHits orders hits best first, todo lists before todos when they rank the same, and keeps the first limit.
+func sortHits(hits []SearchHit, limit int) []SearchHit {
+	slices.SortStableFunc(hits, func(a, b SearchHit) int {
+		if c := cmp.Compare(b.Rank, a.Rank); c != 0 {
+			return c
+		}
+		if (a.Todo == nil) != (b.Todo == nil) {
+			if a.Todo == nil {
+				return -1
+			}
+			return 1
+		}
+		return 0
+	})
+
+	if limit > 0 && len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits
+}
+
+// matchText matches terms against text the way the full-text indexes do, for the memory store.
+// It returns text with every matched word highlighted and the share of the words of text that matched.
+func matchText(text string, terms []SearchTerm) (snippet string, score float64, ok bool) {
+	type span struct{ start, end int }
+
+	var (
+		spans []span
+		words []string
+		start = -1
+	)
+	for i, r := range text + " " {
+		isWord := unicode.IsLetter(r) || unicode.IsDigit(r)
+		if isWord && start < 0 {
+			start = i
+		} else if !isWord && start >= 0 {
+			spans = append(spans, span{start, i})
+			words = append(words, strings.ToLower(text[start:i]))
+			start = -1
+		}
+	}
+
+	matched := make([]bool, len(words))
+	for _, t := range terms {
+		found := false
+		for i := 0; i+len(t.Words) <= len(words); i++ {
+			if termAt(t, words[i:]) {
+				found = true
+				for j := range t.Words {
+					matched[i+j] = true
+				}
+			}
+		}
+		if !found {
+			return "", 0, false
+		}
+	}
+
+	var b strings.Builder
+	last, count := 0, 0
+	for i, sp := range spans {
+		if matched[i] {
+			b.WriteString(text[last:sp.start] + HighlightStart + text[sp.start:sp.end] + HighlightEnd)
+			last = sp.end
+			count++
+		}
+	}
+	b.WriteString(text[last:])
+
+	return b.String(), float64(count) / float64(len(words)), true
+}
+
+// termAt reports whether the words starting at the beginning of words match t.
+func termAt(t SearchTerm, words []string) bool {
+	for j, w := range t.Words {
+		if words[j] != w && !(t.Prefix && j == len(t.Words)-1 && strings.HasPrefix(words[j], w)) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,115 @@
+package store
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseSearch(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		search string
+		want   []SearchTerm
+	}{
+		{"", nil},
+		{"   ", nil},
+		{`""`, nil},
+		{"*", nil},
+		{"buy milk", []SearchTerm{{Words: []string{"buy"}}, {Words: []string{"milk"}}}},
+		{"  Buy\tMILK ", []SearchTerm{{Words: []string{"buy"}}, {Words: []string{"milk"}}}},
+		{`"buy milk"`, []SearchTerm{{Words: []string{"buy", "milk"}}}},
+		{`"buy milk"*`, []SearchTerm{{Words: []string{"buy", "milk"}, Prefix: true}}},
+		{`"buy mil*"`, []SearchTerm{{Words: []string{"buy", "mil"}, Prefix: true}}},
+		{"mil*", []SearchTerm{{Words: []string{"mil"}, Prefix: true}}},
+		{"mil *", []SearchTerm{{Words: []string{"mil"}}}},
+		{`"unterminated phrase`, []SearchTerm{{Words: []string{"unterminated", "phrase"}}}},
+		{`buy"milk"`, []SearchTerm{{Words: []string{"buy"}}, {Words: []string{"milk"}}}},
+		{"e-mail", []SearchTerm{{Words: []string{"e", "mail"}}}},
+		{"Émile ÇA", []SearchTerm{{Words: []string{"émile"}}, {Words: []string{"ça"}}}},
+		{"AND OR NOT", []SearchTerm{{Words: []string{"and"}}, {Words: []string{"or"}}, {Words: []string{"not"}}}},
+		{"NEAR(a b)", []SearchTerm{{Words: []string{"near", "a"}}, {Words: []string{"b"}}}},
+		{"text:milk -bread ^x", []SearchTerm{{Words: []string{"text", "milk"}}, {Words: []string{"bread"}}, {Words: []string{"x"}}}},
+		{"a & !b | c:*", []SearchTerm{{Words: []string{"a"}}, {Words: []string{"b"}}, {Words: []string{"c"}, Prefix: true}}},
+		{`'; DROP TABLE todos; --`, []SearchTerm{{Words: []string{"drop"}}, {Words: []string{"table"}}, {Words: []string{"todos"}}}},
+	}
+
+	for _, tt := range tests {
+		if got := ParseSearch(tt.search); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseSearch(%q) = %v, want %v", tt.search, got, tt.want)
+		}
+	}
+}
+
+func TestFTSMatch(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		terms []SearchTerm
+		want  string
+	}{
+		{[]SearchTerm{{Words: []string{"milk"}}}, `"milk"`},
+		{[]SearchTerm{{Words: []string{"buy", "milk"}}, {Words: []string{"mil"}, Prefix: true}}, `"buy milk" "mil" *`},
+		{[]SearchTerm{{Words: []string{"near"}}, {Words: []string{"and"}}, {Words: []string{"not"}}}, `"near" "and" "not"`},
+		{[]SearchTerm{{Words: []string{`a"b`}}}, `"a""b"`},
+	}
+
+	for _, tt := range tests {
+		if got := ftsMatch(tt.terms); got != tt.want {
+			t.Errorf("ftsMatch(%v) = %s, want %s", tt.terms, got, tt.want)
+		}
+	}
+}
+
+func TestTSQuery(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		terms []SearchTerm
+		want  string
+	}{
+		{[]SearchTerm{{Words: []string{"milk"}}}, `('milk')`},
+		{[]SearchTerm{{Words: []string{"buy", "milk"}}, {Words: []string{"mil"}, Prefix: true}}, `('buy' <-> 'milk') & ('mil':*)`},
+		{[]SearchTerm{{Words: []string{"buy", "mil"}, Prefix: true}}, `('buy' <-> 'mil':*)`},
+		{[]SearchTerm{{Words: []string{"and"}}, {Words: []string{"or"}}}, `('and') & ('or')`},
+		{[]SearchTerm{{Words: []string{"o'neil"}}}, `('o''neil')`},
+	}
+
+	for _, tt := range tests {
+		if got := tsQuery(tt.terms); got != tt.want {
+			t.Errorf("tsQuery(%v) = %s, want %s", tt.terms, got, tt.want)
+		}
+	}
+}
+
+func TestMatchText(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		text    string
+		search  string
+		snippet string
+		score   float64
+		ok      bool
+	}{
+		{"Buy the milk", "milk", "Buy the " + highlighted("milk"), 1.0 / 3, true},
+		{"Buy the milk", "MIL*", "Buy the " + highlighted("milk"), 1.0 / 3, true},
+		{"Buy the milk", "mil", "", 0, false},
+		{"Buy the milk", `"buy milk"`, "", 0, false},
+		{"Buy the milk", `"the milk" buy`, highlighted("Buy") + " " + highlighted("the") + " " + highlighted("milk"), 1, true},
+		{"Send an e-mail", "e-mail", "Send an " + highlighted("e") + "-" + highlighted("mail"), 0.5, true},
+		{"Buy the milk", "milk bread", "", 0, false},
+	}
+
+	for _, tt := range tests {
+		snippet, score, ok := matchText(tt.text, ParseSearch(tt.search))
+		if snippet != tt.snippet || score != tt.score || ok != tt.ok {
+			t.Errorf("matchText(%q, %q) = %q, %v, %v, want %q, %v, %v", tt.text, tt.search, snippet, score, ok, tt.snippet, tt.score, tt.ok)
+		}
+	}
+}
+
+// highlighted returns word wrapped the way matchText highlights it.
+func highlighted(word string) string {
+	return HighlightStart + word + HighlightEnd
+}
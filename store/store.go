@@ -0,0 +1,328 @@
+// Package store sits between the controllers and the database.
+// Controllers only talk to the interfaces declared here, which have a bun implementation
+// used in production and an in-memory implementation that needs no database at all.
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/marouane-ach/todo-go/models"
+)
+
+var (
+	// ErrNotFound is returned when the requested record does not exist.
+	ErrNotFound = errors.New("store: record not found")
+	// ErrConflict is returned when a write violates a uniqueness constraint.
+	ErrConflict = errors.New("store: record already exists")
+)
+
+type UserStore interface {
+	// Create inserts the user and fills its ID. It returns ErrConflict if the email is taken.
+	Create(ctx context.Context, user *models.User) error
+	GetByID(ctx context.Context, id int) (*models.User, error)
+	GetByEmail(ctx context.Context, email string) (*models.User, error)
+	Update(ctx context.Context, user *models.User) error
+}
+
+type TokenStore interface {
+	Create(ctx context.Context, token *models.Token) error
+	Get(ctx context.Context, token string) (*models.Token, error)
+	Delete(ctx context.Context, id int) error
+}
+
+type ColorStore interface {
+	GetByID(ctx context.Context, id int) (*models.Color, error)
+}
+
+type TodoListStore interface {
+	Create(ctx context.Context, todoList *models.TodoList) error
+	// GetByID returns the todo list along with its todos and their tags.
+	GetByID(ctx context.Context, id int) (*models.TodoList, error)
+	// List returns the todo lists selected by q along with their todos and their tags.
+	List(ctx context.Context, q TodoListQuery) ([]models.TodoList, error)
+	// Update saves the todo list, except for its rank.
+	Update(ctx context.Context, todoList *models.TodoList) error
+	// AdjacentRank returns the rank of the owner's todo list outside of organizations right before rank, or right after it
+	// if before is false, or "" if there is none. An empty rank is past both ends.
+	AdjacentRank(ctx context.Context, ownerID int, rank string, before bool) (string, error)
+	// AdjacentOrganizationRank is AdjacentRank for the todo lists of an organization.
+	AdjacentOrganizationRank(ctx context.Context, organizationID int, rank string, before bool) (string, error)
+	// SetRank moves the todo list to rank. It returns ErrConflict if another list of the owner, or of the organization
+	// of the list, has that rank.
+	SetRank(ctx context.Context, id int, rank string) error
+	// CountByOrganization returns the number of todo lists of an organization.
+	CountByOrganization(ctx context.Context, organizationID int) (int, error)
+	// ClearFolder moves the todo lists of a folder to the root.
+	ClearFolder(ctx context.Context, folderID int) error
+	Delete(ctx context.Context, id int) error
+}
+
+type FolderStore interface {
+	Create(ctx context.Context, folder *models.Folder) error
+	GetByID(ctx context.Context, id int) (*models.Folder, error)
+	// List returns the folders selected by q, by name.
+	List(ctx context.Context, q FolderQuery) ([]models.Folder, error)
+	Update(ctx context.Context, folder *models.Folder) error
+	// ClearParent moves the folders nested in a folder to the root.
+	ClearParent(ctx context.Context, parentID int) error
+	Delete(ctx context.Context, id int) error
+	// DeleteByOrganization deletes the folders of an organization.
+	DeleteByOrganization(ctx context.Context, organizationID int) error
+}
+
+type OrganizationStore interface {
+	Create(ctx context.Context, organization *models.Organization) error
+	GetByID(ctx context.Context, id int) (*models.Organization, error)
+	Update(ctx context.Context, organization *models.Organization) error
+	Delete(ctx context.Context, id int) error
+}
+
+type OrgMembershipStore interface {
+	// Create returns ErrConflict if the user already is a member of the organization.
+	Create(ctx context.Context, membership *models.OrgMembership) error
+	// Get returns the membership of a user in an organization.
+	Get(ctx context.Context, organizationID, userID int) (*models.OrgMembership, error)
+	// ListByOrganization returns the memberships of an organization along with their users, oldest first.
+	ListByOrganization(ctx context.Context, organizationID int) ([]models.OrgMembership, error)
+	// ListByUser returns the memberships of a user along with their organizations, oldest first.
+	ListByUser(ctx context.Context, userID int) ([]models.OrgMembership, error)
+	Update(ctx context.Context, membership *models.OrgMembership) error
+	Delete(ctx context.Context, id int) error
+	// DeleteByOrganization deletes the memberships of an organization.
+	DeleteByOrganization(ctx context.Context, organizationID int) error
+}
+
+type MembershipStore interface {
+	// Create returns ErrConflict if the user already is a member of the todo list.
+	Create(ctx context.Context, membership *models.Membership) error
+	// Get returns the membership of a user in a todo list.
+	Get(ctx context.Context, todoListID, userID int) (*models.Membership, error)
+	// ListByTodoList returns the memberships of a todo list along with their users, oldest first.
+	ListByTodoList(ctx context.Context, todoListID int) ([]models.Membership, error)
+	// ListByUser returns the memberships of a user.
+	ListByUser(ctx context.Context, userID int) ([]models.Membership, error)
+	Update(ctx context.Context, membership *models.Membership) error
+	Delete(ctx context.Context, id int) error
+	// DeleteByTodoList deletes the memberships of a todo list.
+	DeleteByTodoList(ctx context.Context, todoListID int) error
+	// DeleteInOrganization deletes the memberships of a user in the todo lists of an organization.
+	DeleteInOrganization(ctx context.Context, organizationID, userID int) error
+}
+
+type InvitationStore interface {
+	// Create returns ErrConflict if the email address is already invited to the todo list, ignoring case.
+	Create(ctx context.Context, invitation *models.Invitation) error
+	// GetByID returns the invitation along with its todo list, without the todos.
+	GetByID(ctx context.Context, id int) (*models.Invitation, error)
+	// ListByTodoList returns the invitations to a todo list, oldest first.
+	ListByTodoList(ctx context.Context, todoListID int) ([]models.Invitation, error)
+	// ListByEmail returns the invitations sent to an email address, ignoring case, along with their todo lists
+	// without the todos, oldest first.
+	ListByEmail(ctx context.Context, email string) ([]models.Invitation, error)
+	Delete(ctx context.Context, id int) error
+	// DeleteByTodoList deletes the invitations to a todo list.
+	DeleteByTodoList(ctx context.Context, todoListID int) error
+}
+
+type ShareLinkStore interface {
+	// Create returns ErrConflict if another link has the same slug.
+	Create(ctx context.Context, link *models.ShareLink) error
+	// GetByID returns the link along with its todo list, without the todos.
+	GetByID(ctx context.Context, id int) (*models.ShareLink, error)
+	GetBySlug(ctx context.Context, slug string) (*models.ShareLink, error)
+	// ListByTodoList returns the links of a todo list, oldest first.
+	ListByTodoList(ctx context.Context, todoListID int) ([]models.ShareLink, error)
+	// RecordView counts a visit to the link at the given time.
+	RecordView(ctx context.Context, id int, at time.Time) error
+	Delete(ctx context.Context, id int) error
+	// DeleteByTodoList deletes the links of a todo list.
+	DeleteByTodoList(ctx context.Context, todoListID int) error
+}
+
+type TodoStore interface {
+	// Create returns ErrConflict if another todo of the todo list has the same rank.
+	Create(ctx context.Context, todo *models.Todo) error
+	// GetByID returns the todo along with the todo list it belongs to, its recurrence and its tags.
+	GetByID(ctx context.Context, id int) (*models.Todo, error)
+	// GetByText returns a todo of the todo list whose text is text, ignoring case.
+	GetByText(ctx context.Context, todoListID int, text string) (*models.Todo, error)
+	// Update saves the todo, except for its checklist progress and its rank.
+	Update(ctx context.Context, todo *models.Todo) error
+	// UpdateProgress counts the checklist items of todo again and sets its ItemsTotal and ItemsDone.
+	UpdateProgress(ctx context.Context, todo *models.Todo) error
+	// List returns the todos selected by q along with their recurrences and tags.
+	List(ctx context.Context, q TodoQuery) ([]models.Todo, error)
+	Delete(ctx context.Context, id int) error
+	// DeleteByTodoList deletes every todo of a todo list.
+	DeleteByTodoList(ctx context.Context, todoListID int) error
+	// AdjacentRank returns the rank of the todo of the todo list right before rank, or right after it if before
+	// is false, or "" if there is none. An empty rank is past both ends.
+	AdjacentRank(ctx context.Context, todoListID int, rank string, before bool) (string, error)
+	// SetRank moves the todo to rank. It returns ErrConflict if another todo of its todo list has that rank.
+	SetRank(ctx context.Context, id int, rank string) error
+	// MoveStatus moves the todos in status from to status to.
+	MoveStatus(ctx context.Context, from, to int) error
+	// Unassign unassigns the todos of a todo list assigned to a user.
+	Unassign(ctx context.Context, todoListID, userID int) error
+	// UnassignInOrganization unassigns the todos of the todo lists of an organization assigned to a user.
+	UnassignInOrganization(ctx context.Context, organizationID, userID int) error
+	// CompleteStatus marks the todos in a status that aren't completed yet as completed at completedAt,
+	// or the completed ones as incomplete if completedAt is nil.
+	CompleteStatus(ctx context.Context, statusID int, completedAt *time.Time) error
+}
+
+type StatusStore interface {
+	// Create adds the status after the last status of its todo list.
+	Create(ctx context.Context, status *models.Status) error
+	// GetByID returns the status along with its todo list.
+	GetByID(ctx context.Context, id int) (*models.Status, error)
+	// ListByTodoList returns the statuses of a todo list in order.
+	ListByTodoList(ctx context.Context, todoListID int) ([]models.Status, error)
+	Update(ctx context.Context, status *models.Status) error
+	// Reorder moves the statuses of a todo list to the order of ids, which must list all of them.
+	Reorder(ctx context.Context, todoListID int, ids []int) error
+	Delete(ctx context.Context, id int) error
+	// DeleteByTodoList deletes every status of a todo list.
+	DeleteByTodoList(ctx context.Context, todoListID int) error
+}
+
+type TagStore interface {
+	// Create returns ErrConflict if the owner already has a tag with the same name, ignoring case.
+	Create(ctx context.Context, tag *models.Tag) error
+	// GetByID returns the tag along with its color and the number of todos it labels.
+	GetByID(ctx context.Context, id int) (*models.Tag, error)
+	// List returns the tags selected by q ordered by name, with their colors and the number of todos they label.
+	List(ctx context.Context, q TagQuery) ([]models.Tag, error)
+	// Update returns ErrConflict if the owner already has another tag with the new name.
+	Update(ctx context.Context, tag *models.Tag) error
+	// Delete deletes the tag and detaches it from its todos.
+	Delete(ctx context.Context, id int) error
+	// Attach labels a todo with a tag. Attaching a tag twice does nothing.
+	Attach(ctx context.Context, todoID, tagID int) error
+	// Detach removes a tag from a todo. Detaching a tag the todo doesn't have does nothing.
+	Detach(ctx context.Context, todoID, tagID int) error
+	// SetTodoTags replaces the tags of a todo.
+	SetTodoTags(ctx context.Context, todoID int, tagIDs []int) error
+	// DetachTodoList removes the tags of every todo of a todo list.
+	DetachTodoList(ctx context.Context, todoListID int) error
+}
+
+type ChecklistItemStore interface {
+	// Create adds the item after the last item of its todo.
+	Create(ctx context.Context, item *models.ChecklistItem) error
+	// GetByID returns the item along with its todo and the todo list of the todo.
+	GetByID(ctx context.Context, id int) (*models.ChecklistItem, error)
+	// ListByTodo returns the items of a todo in order.
+	ListByTodo(ctx context.Context, todoID int) ([]models.ChecklistItem, error)
+	Update(ctx context.Context, item *models.ChecklistItem) error
+	// Reorder moves the items of a todo to the order of ids, which must list all of them.
+	Reorder(ctx context.Context, todoID int, ids []int) error
+	// UncheckAll marks every item of a todo as not done.
+	UncheckAll(ctx context.Context, todoID int) error
+	Delete(ctx context.Context, id int) error
+	// DeleteByTodo deletes the items of a todo.
+	DeleteByTodo(ctx context.Context, todoID int) error
+	// DeleteByTodoList deletes the items of every todo of a todo list.
+	DeleteByTodoList(ctx context.Context, todoListID int) error
+}
+
+type RecurrenceStore interface {
+	Create(ctx context.Context, recurrence *models.Recurrence) error
+	// GetByTodo returns the recurrence of a todo.
+	GetByTodo(ctx context.Context, todoID int) (*models.Recurrence, error)
+	Update(ctx context.Context, recurrence *models.Recurrence) error
+	Delete(ctx context.Context, id int) error
+	// DeleteByTodo deletes the recurrence of a todo, if any.
+	DeleteByTodo(ctx context.Context, todoID int) error
+	// DeleteByTodoList deletes the recurrences of every todo of a todo list.
+	DeleteByTodoList(ctx context.Context, todoListID int) error
+}
+
+type CompletionStore interface {
+	Create(ctx context.Context, completion *models.Completion) error
+	// List returns the completions selected by q, latest first.
+	List(ctx context.Context, q CompletionQuery) ([]models.Completion, error)
+	// DeleteByTodo deletes the completions of a todo.
+	DeleteByTodo(ctx context.Context, todoID int) error
+	// DeleteByTodoList deletes the completions of every todo of a todo list.
+	DeleteByTodoList(ctx context.Context, todoListID int) error
+}
+
+type CommentStore interface {
+	Create(ctx context.Context, comment *models.Comment) error
+	// GetByID returns the comment along with its todo and the todo list of the todo.
+	GetByID(ctx context.Context, id int) (*models.Comment, error)
+	// List returns the comments selected by q, oldest first.
+	List(ctx context.Context, q CommentQuery) ([]models.Comment, error)
+	Update(ctx context.Context, comment *models.Comment) error
+	Delete(ctx context.Context, id int) error
+	// DeleteByTodo deletes the comments of a todo.
+	DeleteByTodo(ctx context.Context, todoID int) error
+	// DeleteByTodoList deletes the comments of every todo of a todo list.
+	DeleteByTodoList(ctx context.Context, todoListID int) error
+}
+
+type ReminderStore interface {
+	Create(ctx context.Context, reminder *models.Reminder) error
+	// GetByID returns the reminder along with its todo and the todo's list.
+	GetByID(ctx context.Context, id int) (*models.Reminder, error)
+	// ListByTodo returns the reminders of a todo ordered by ID.
+	ListByTodo(ctx context.Context, todoID int) ([]models.Reminder, error)
+	Update(ctx context.Context, reminder *models.Reminder) error
+	Delete(ctx context.Context, id int) error
+	// DeleteByTodo deletes the reminders of a todo.
+	DeleteByTodo(ctx context.Context, todoID int) error
+	// DeleteByTodoList deletes the reminders of every todo of a todo list.
+	DeleteByTodoList(ctx context.Context, todoListID int) error
+	// Claim leases up to limit pending reminders whose FireAt is at or before now to the caller
+	// until now+lease, counting one more attempt for each. Reminders leased to someone else are skipped
+	// until their lease expires, so that a reminder is only delivered by one scheduler at a time.
+	Claim(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]models.Reminder, error)
+	// Renew extends the lease of a claimed reminder until now+lease.
+	// It returns ErrNotFound if the lease was lost to another scheduler in the meantime.
+	Renew(ctx context.Context, reminder *models.Reminder, now time.Time, lease time.Duration) error
+	// Release saves the status, FireAt, LastError and SentAt of a claimed reminder and ends its lease.
+	// It returns ErrNotFound if the lease was lost to another scheduler in the meantime.
+	Release(ctx context.Context, reminder *models.Reminder) error
+}
+
+type NotificationStore interface {
+	Create(ctx context.Context, notification *models.Notification) error
+	GetByID(ctx context.Context, id int) (*models.Notification, error)
+	// List returns the notifications selected by q, newest first.
+	List(ctx context.Context, q NotificationQuery) ([]models.Notification, error)
+	Update(ctx context.Context, notification *models.Notification) error
+}
+
+type SearchStore interface {
+	// Search returns the todos and todo lists matching q, best first.
+	Search(ctx context.Context, q SearchQuery) ([]SearchHit, error)
+}
+
+// Store groups every store the application uses.
+type Store struct {
+	Users          UserStore
+	Tokens         TokenStore
+	Colors         ColorStore
+	TodoLists      TodoListStore
+	Folders        FolderStore
+	Organizations  OrganizationStore
+	OrgMemberships OrgMembershipStore
+	Memberships    MembershipStore
+	Invitations    InvitationStore
+	ShareLinks     ShareLinkStore
+	Todos          TodoStore
+	Statuses       StatusStore
+	Tags           TagStore
+	ChecklistItems ChecklistItemStore
+	Recurrences    RecurrenceStore
+	Completions    CompletionStore
+	Comments       CommentStore
+	Reminders      ReminderStore
+	Notifications  NotificationStore
+	Search         SearchStore
+
+	inTx func(ctx context.Context, fn func(tx *Store) error) error
+}
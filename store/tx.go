@@ -0,0 +1,76 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"math/rand/v2"
+	"strings"
+	"time"
+
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect"
+	"github.com/uptrace/bun/driver/pgdriver"
+)
+
+// maxTxAttempts is how many times InTx runs a transaction that keeps failing with a retryable error.
+const maxTxAttempts = 5
+
+// InTx runs fn as a single unit of work. fn receives a Store bound to the transaction
+// and must only use that one. If fn returns an error every write it made is rolled back.
+// Transactions aborted because the database was busy or could not serialize them are retried,
+// so fn may run more than once. Calling InTx on a Store that is already in a transaction
+// runs fn inside the existing one.
+func (s *Store) InTx(ctx context.Context, fn func(tx *Store) error) error {
+	return s.inTx(ctx, fn)
+}
+
+func bunInTx(db bun.IDB) func(context.Context, func(*Store) error) error {
+	return func(ctx context.Context, fn func(*Store) error) error {
+		bunDB, ok := db.(*bun.DB)
+		if !ok {
+			return fn(NewBun(db))
+		}
+
+		var opts *sql.TxOptions
+		if bunDB.Dialect().Name() == dialect.PG {
+			opts = &sql.TxOptions{Isolation: sql.LevelSerializable}
+		}
+
+		var err error
+		for attempt := 1; ; attempt++ {
+			err = bunDB.RunInTx(ctx, opts, func(ctx context.Context, tx bun.Tx) error {
+				return fn(NewBun(tx))
+			})
+
+			if err == nil || !isRetryable(err) || attempt == maxTxAttempts {
+				return err
+			}
+
+			// Back off exponentially with some jitter so competing transactions don't collide again.
+			backoff := time.Duration(1<<attempt)*5*time.Millisecond + rand.N(5*time.Millisecond)
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(backoff):
+			}
+		}
+	}
+}
+
+// isRetryable reports whether err means the transaction lost a race and can safely be run again.
+func isRetryable(err error) bool {
+	var pgErr pgdriver.Error
+	if errors.As(err, &pgErr) {
+		switch pgErr.Field('C') {
+		case "40001", "40P01": // serialization_failure, deadlock_detected
+			return true
+		}
+		return false
+	}
+
+	msg := err.Error()
+	return strings.Contains(msg, "SQLITE_BUSY") ||
+		strings.Contains(msg, "database is locked") ||
+		strings.Contains(msg, "database table is locked")
+}
@@ -0,0 +1,79 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/marouane-ach/todo-go/db"
+	"github.com/marouane-ach/todo-go/models"
+)
+
+// newSQLite returns a Store on a new, migrated SQLite database.
+func newSQLite(t *testing.T) *Store {
+	t.Helper()
+
+	bunDB, err := db.Open("file:" + filepath.Join(t.TempDir(), "todo.db") + "?cache=shared&mode=rwc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { bunDB.Close() })
+
+	if err := db.Migrate(context.Background(), bunDB); err != nil {
+		t.Fatal(err)
+	}
+	return NewBun(bunDB)
+}
+
+func TestInTx(t *testing.T) {
+	t.Parallel()
+
+	stores := map[string]func(t *testing.T) *Store{
+		"memory": func(t *testing.T) *Store { return NewMemory(nil) },
+		"sqlite": newSQLite,
+	}
+
+	for name, newStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+			st := newStore(t)
+
+			errFail := errors.New("fail")
+			err := st.InTx(ctx, func(tx *Store) error {
+				if err := tx.Users.Create(ctx, &models.User{Email: "a@b.com"}); err != nil {
+					return err
+				}
+
+				// Nested calls join the transaction, so this write is rolled back along with the first one.
+				if err := tx.InTx(ctx, func(tx *Store) error {
+					return tx.Users.Create(ctx, &models.User{Email: "c@b.com"})
+				}); err != nil {
+					return err
+				}
+
+				return errFail
+			})
+			if !errors.Is(err, errFail) {
+				t.Fatalf("InTx = %v, want %v", err, errFail)
+			}
+
+			for _, email := range []string{"a@b.com", "c@b.com"} {
+				if _, err := st.Users.GetByEmail(ctx, email); !errors.Is(err, ErrNotFound) {
+					t.Errorf("GetByEmail(%q) after rollback = %v, want %v", email, err, ErrNotFound)
+				}
+			}
+
+			if err := st.InTx(ctx, func(tx *Store) error {
+				return tx.Users.Create(ctx, &models.User{Email: "a@b.com"})
+			}); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := st.Users.GetByEmail(ctx, "a@b.com"); err != nil {
+				t.Errorf("GetByEmail after commit = %v", err)
+			}
+		})
+	}
+}
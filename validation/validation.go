@@ -0,0 +1,147 @@
+// Package validation checks request DTOs against the rules declared in their struct tags.
+//
+// Two tags are read:
+//   - `mod:"trim"` strips leading and trailing whitespace from a string field before validation.
+//   - `validate:"..."` holds go-playground/validator rules, e.g. `validate:"required,max=100"`.
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/marouane-ach/todo-go/apperr"
+)
+
+// Validator implements echo.Validator.
+type Validator struct {
+	validate *validator.Validate
+}
+
+func New() *Validator {
+	validate := validator.New(validator.WithRequiredStructEnabled())
+
+	// Report fields under their JSON or query parameter names, which is what the client sent.
+	validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		for _, tag := range []string{"json", "query"} {
+			name, _, _ := strings.Cut(field.Tag.Get(tag), ",")
+			if name == "-" {
+				return ""
+			}
+			if name != "" {
+				return name
+			}
+		}
+		return field.Name
+	})
+
+	return &Validator{validate: validate}
+}
+
+// Validate normalizes i and checks it. Failures are returned as an apperr.ValidationFailed
+// error listing every invalid field.
+func (v *Validator) Validate(i any) error {
+	trim(reflect.ValueOf(i))
+
+	err := v.validate.Struct(i)
+
+	var validationErrors validator.ValidationErrors
+	if errors.As(err, &validationErrors) {
+		fields := make([]apperr.FieldError, 0, len(validationErrors))
+		for _, fe := range validationErrors {
+			fields = append(fields, apperr.FieldError{Field: fieldPath(fe), Message: message(fe)})
+		}
+		return apperr.New(apperr.ValidationFailed).WithFields(fields)
+	}
+
+	return err
+}
+
+// trim applies the `mod:"trim"` tag to the string fields of v, descending into nested structs and slices.
+func trim(v reflect.Value) {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			trim(v.Index(i))
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Field(i)
+			if !field.CanSet() {
+				continue
+			}
+
+			if v.Type().Field(i).Tag.Get("mod") != "trim" {
+				trim(field)
+				continue
+			}
+
+			if field.Kind() == reflect.Pointer && !field.IsNil() {
+				field = field.Elem()
+			}
+			if field.Kind() == reflect.String {
+				field.SetString(strings.TrimSpace(field.String()))
+			}
+		}
+	}
+}
+
+// fieldPath returns the JSON path of the field without the name of the top-level struct.
+func fieldPath(fe validator.FieldError) string {
+	_, path, found := strings.Cut(fe.Namespace(), ".")
+	if !found {
+		return fe.Field()
+	}
+	return path
+}
+
+// message describes a failed rule in plain words.
+func message(fe validator.FieldError) string {
+	unit := ""
+	switch fe.Kind() {
+	case reflect.String:
+		unit = " characters"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		unit = " items"
+	}
+
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "email":
+		return "must be a valid email address"
+	case "http_url":
+		return "must be an http or https URL"
+	case "timezone":
+		return "must be an IANA time zone such as Europe/Paris"
+	case "min", "gte":
+		if unit != "" {
+			return fmt.Sprintf("must contain at least %s%s", fe.Param(), unit)
+		}
+		return fmt.Sprintf("must be at least %s", fe.Param())
+	case "max", "lte":
+		if unit != "" {
+			return fmt.Sprintf("must contain at most %s%s", fe.Param(), unit)
+		}
+		return fmt.Sprintf("must be at most %s", fe.Param())
+	case "len":
+		return fmt.Sprintf("must contain exactly %s%s", fe.Param(), unit)
+	case "gt":
+		return fmt.Sprintf("must be greater than %s", fe.Param())
+	case "lt":
+		return fmt.Sprintf("must be less than %s", fe.Param())
+	case "oneof":
+		return fmt.Sprintf("must be one of: %s", strings.ReplaceAll(fe.Param(), " ", ", "))
+	}
+
+	return fmt.Sprintf("does not satisfy the %q rule", fe.Tag())
+}
@@ -0,0 +1,49 @@
+package validation
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/marouane-ach/todo-go/apperr"
+)
+
+type item struct {
+	Title string `json:"title" mod:"trim" validate:"required,max=5"`
+}
+
+type payload struct {
+	Name  *string `json:"name" mod:"trim" validate:"omitnil,min=2"`
+	Items []item  `json:"items" validate:"max=3,dive"`
+	Note  string  `json:"-" mod:"trim"`
+}
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+
+	name := "  Al  "
+	p := &payload{Name: &name, Items: []item{{Title: " ok "}, {Title: "   "}, {Title: "too long"}}, Note: " kept "}
+
+	err := New().Validate(p)
+
+	var appErr *apperr.Error
+	if !errors.As(err, &appErr) || appErr.Code != apperr.ValidationFailed {
+		t.Fatalf("Validate = %v, want %d", err, apperr.ValidationFailed)
+	}
+
+	want := []apperr.FieldError{
+		{Field: "items[1].title", Message: "is required"},
+		{Field: "items[2].title", Message: "must contain at most 5 characters"},
+	}
+	if !reflect.DeepEqual(appErr.Fields, want) {
+		t.Errorf("fields = %+v, want %+v", appErr.Fields, want)
+	}
+
+	if *p.Name != "Al" || p.Items[0].Title != "ok" || p.Note != "kept" {
+		t.Errorf("trimmed to %q, %q and %q", *p.Name, p.Items[0].Title, p.Note)
+	}
+
+	if err := New().Validate(&payload{Items: []item{{Title: "ok"}}}); err != nil {
+		t.Errorf("Validate of a valid payload = %v", err)
+	}
+}